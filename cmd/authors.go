@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newAuthorsCommand creates the authors command and its subcommands
+func newAuthorsCommand(opts *cliOptions) *cobra.Command {
+	authors := &cobra.Command{
+		Use:   "authors",
+		Short: "List, show and merge authors",
+	}
+	authors.AddCommand(
+		newAuthorsListCommand(opts),
+		newAuthorsGetCommand(opts),
+		newAuthorsMergeCommand(opts),
+	)
+	return authors
+}
+
+func newAuthorsListCommand(opts *cliOptions) *cobra.Command {
+	var limit, offset int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List authors by name",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				authors, err := c.ListAuthors(cmd.Context(), limit, offset)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), opts.output, authors)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum number of authors (default: all)")
+	cmd.Flags().IntVar(&offset, "offset", 0, "number of authors skipped")
+	return cmd
+}
+
+func newAuthorsGetCommand(opts *cliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Show an author; IDs of merged authors show the surviving one",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				author, err := c.GetAuthor(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), opts.output, author)
+			})
+		},
+	}
+}
+
+func newAuthorsMergeCommand(opts *cliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "merge SURVIVOR_ID DUPLICATE_ID...",
+		Short: "Move the books of duplicate authors to the surviving one and delete the duplicates",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				author, err := c.MergeAuthors(cmd.Context(), args[0], args[1:])
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), opts.output, author)
+			})
+		},
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+)
+
+// newBooksCommand creates the books command and its subcommands
+func newBooksCommand(opts *cliOptions) *cobra.Command {
+	books := &cobra.Command{
+		Use:   "books",
+		Short: "List, show, create, update and delete books",
+	}
+	books.AddCommand(
+		newBooksListCommand(opts),
+		newBooksGetCommand(opts),
+		newBooksCreateCommand(opts),
+		newBooksUpdateCommand(opts),
+		newBooksDeleteCommand(opts),
+	)
+	return books
+}
+
+// withCatalog opens the catalog of opts for the duration of run
+func withCatalog(opts *cliOptions, run func(c catalog) error) error {
+	c, err := openCatalog(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return run(c)
+}
+
+func newBooksListCommand(opts *cliOptions) *cobra.Command {
+	var search repository.BookSearch
+	var offset int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List books by name, optionally filtered",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				books, err := c.ListBooks(cmd.Context(), search, offset)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), opts.output, books)
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&search.Query, "query", "q", "", "text matched against the name and description")
+	flags.StringVar(&search.Author, "author", "", "text matched against the author name")
+	flags.StringVar(&search.Publisher, "publisher", "", "text matched against the publisher")
+	flags.Float64Var(&search.MinPrice, "min-price", 0, "minimum price")
+	flags.Float64Var(&search.MaxPrice, "max-price", 0, "maximum price")
+	flags.IntVar(&search.Limit, "limit", 0, "maximum number of books (default: all)")
+	flags.IntVar(&offset, "offset", 0, "number of books skipped")
+	return cmd
+}
+
+func newBooksGetCommand(opts *cliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Show a book",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				book, err := c.GetBook(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), opts.output, book)
+			})
+		},
+	}
+}
+
+// bookFlags registers the flags setting the fields of book
+func bookFlags(flags *pflag.FlagSet, book *models.Book) {
+	flags.StringVar(&book.Name, "name", "", "name of the book")
+	flags.StringVar(&book.Author.Name, "author", "", "name of the author")
+	flags.StringVar(&book.Author.ID, "author-id", "", "ID of the author")
+	flags.StringVar(&book.Author.Bio, "author-bio", "", "biography of the author")
+	flags.StringVar(&book.Publisher, "publisher", "", "publisher")
+	flags.UintVar(&book.PublishedYear, "year", 0, "year of publication")
+	flags.StringVar(&book.Description, "description", "", "description")
+	flags.Float64Var(&book.Price, "price", 0, "price")
+	flags.IntVar(&book.Pages, "pages", 0, "number of pages")
+}
+
+func newBooksCreateCommand(opts *cliOptions) *cobra.Command {
+	var book models.Book
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a book",
+		Long: `Create a book. Its author is the one with --author-id, or else a new author
+named --author (or the existing one with that name, when the server matches
+authors by name).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				if book.Author.ID != "" && book.Author.Name == "" {
+					// The author's name is required even when its ID is given
+					author, err := c.GetAuthor(cmd.Context(), book.Author.ID)
+					if err != nil {
+						return err
+					}
+					book.Author = *author
+				}
+				if err := c.CreateBook(cmd.Context(), &book); err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), opts.output, &book)
+			})
+		},
+	}
+
+	bookFlags(cmd.Flags(), &book)
+	_ = cmd.MarkFlagRequired("name")
+	cmd.MarkFlagsOneRequired("author", "author-id")
+	return cmd
+}
+
+func newBooksUpdateCommand(opts *cliOptions) *cobra.Command {
+	var book models.Book
+	cmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Update the given fields of a book",
+		Long: `Update the fields of a book given as flags; the others are left as they are.
+--author and --author-bio rename and describe the book's author, or the
+author with --author-id, which becomes the book's author.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				flags := cmd.Flags()
+				if flags.Changed("author") || flags.Changed("author-bio") || flags.Changed("author-id") {
+					// The author is saved whole, so start from the stored one
+					authorID := book.Author.ID
+					if authorID == "" {
+						current, err := c.GetBook(cmd.Context(), args[0])
+						if err != nil {
+							return err
+						}
+						authorID = current.AuthorID
+					}
+					author, err := c.GetAuthor(cmd.Context(), authorID)
+					if err != nil {
+						return err
+					}
+					if flags.Changed("author") {
+						author.Name = book.Author.Name
+					}
+					if flags.Changed("author-bio") {
+						author.Bio = book.Author.Bio
+					}
+					book.Author = *author
+				}
+
+				updated, err := c.UpdateBook(cmd.Context(), args[0], &book)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), opts.output, updated)
+			})
+		},
+	}
+
+	bookFlags(cmd.Flags(), &book)
+	return cmd
+}
+
+func newBooksDeleteCommand(opts *cliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID...",
+		Short: "Delete books",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCatalog(opts, func(c catalog) error {
+				for _, id := range args {
+					if err := c.DeleteBook(cmd.Context(), id); err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.ErrOrStderr(), "Deleted book %s\n", id)
+				}
+				return nil
+			})
+		},
+	}
+}
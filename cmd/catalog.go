@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// catalog is what the catalog commands work on: the database through the
+// services, or a server through its REST API
+type catalog interface {
+	// ListBooks searches books, skipping the first offset matches; a zero
+	// limit lists every match
+	ListBooks(ctx context.Context, search repository.BookSearch, offset int) ([]models.Book, error)
+	GetBook(ctx context.Context, id string) (*models.Book, error)
+	CreateBook(ctx context.Context, book *models.Book) error
+	// UpdateBook updates a book and returns it as updated
+	UpdateBook(ctx context.Context, id string, book *models.Book) (*models.Book, error)
+	DeleteBook(ctx context.Context, id string) error
+	BatchBooks(ctx context.Context, mode models.BatchMode, ops []models.BatchOperation) ([]models.BatchResult, error)
+
+	// ListAuthors lists authors by name; a zero limit lists all of them
+	ListAuthors(ctx context.Context, limit int, offset int) ([]models.Author, error)
+	GetAuthor(ctx context.Context, id string) (*models.Author, error)
+	MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, error)
+
+	Close() error
+}
+
+// openCatalog opens the catalog selected by the options
+func openCatalog(opts *cliOptions) (catalog, error) {
+	if opts.url != "" {
+		return &remoteCatalog{
+			base:   strings.TrimSuffix(opts.url, "/") + "/v2",
+			token:  opts.token,
+			tenant: opts.tenant,
+			actor:  opts.actor,
+			client: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	}
+	return openLocalCatalog(opts)
+}
+
+// cliLogger only reports warnings and errors, on stderr, so that they do
+// not mix with the results
+func cliLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+}
+
+// localCatalog works on the database through the services, like the
+// server's handlers do
+type localCatalog struct {
+	server *Server
+	tenant string
+	actor  string
+}
+
+// openLocalCatalog connects to the database and wires the services
+func openLocalCatalog(opts *cliOptions) (*localCatalog, error) {
+	server, err := newServerFromEnv(cliLogger())
+	if err != nil {
+		return nil, err
+	}
+
+	// The middlewares configure the tenants the routes depend on
+	for _, setup := range []func() error{server.SetupDB, server.SetupCache, server.SetupMiddlewares, server.SetupRoutes} {
+		if err := setup(); err != nil {
+			if server.DB != nil {
+				if sqlDB, dbErr := server.DB.DB(); dbErr == nil {
+					sqlDB.Close()
+				}
+			}
+			return nil, err
+		}
+	}
+
+	return &localCatalog{server: server, tenant: opts.tenant, actor: opts.actor}, nil
+}
+
+// scope returns ctx scoped to the tenant and carrying the actor
+func (c *localCatalog) scope(ctx context.Context) context.Context {
+	ctx = tenant.WithID(ctx, c.tenant)
+	return audit.WithActor(ctx, audit.Actor{ID: c.actor})
+}
+
+// Sizes of the pages lists are fetched in
+const (
+	bookPage   = 1000
+	authorPage = 100
+)
+
+func (c *localCatalog) ListBooks(ctx context.Context, search repository.BookSearch, offset int) ([]models.Book, error) {
+	ctx = c.scope(ctx)
+	return collect(search.Limit, offset, bookPage, bookKey, func(size int, after *pagination.Key) ([]models.Book, error) {
+		search.Limit, search.After = size, after
+		return c.server.bookService.SearchBooks(ctx, search)
+	})
+}
+
+func (c *localCatalog) GetBook(ctx context.Context, id string) (*models.Book, error) {
+	return c.server.bookService.GetBookByID(c.scope(ctx), id)
+}
+
+func (c *localCatalog) CreateBook(ctx context.Context, book *models.Book) error {
+	return c.server.bookService.CreateBook(c.scope(ctx), book)
+}
+
+func (c *localCatalog) UpdateBook(ctx context.Context, id string, book *models.Book) (*models.Book, error) {
+	ctx = c.scope(ctx)
+	if err := c.server.bookService.UpdateBook(ctx, id, book); err != nil {
+		return nil, err
+	}
+	return c.server.bookService.GetBookByID(ctx, id)
+}
+
+func (c *localCatalog) DeleteBook(ctx context.Context, id string) error {
+	return c.server.bookService.DeleteBook(c.scope(ctx), id)
+}
+
+func (c *localCatalog) BatchBooks(ctx context.Context, mode models.BatchMode, ops []models.BatchOperation) ([]models.BatchResult, error) {
+	return c.server.bookService.BatchBooks(c.scope(ctx), mode, ops)
+}
+
+func (c *localCatalog) ListAuthors(ctx context.Context, limit int, offset int) ([]models.Author, error) {
+	ctx = c.scope(ctx)
+	return collect(limit, offset, authorPage, authorKey, func(size int, after *pagination.Key) ([]models.Author, error) {
+		return c.server.authorService.ListAuthors(ctx, size, after)
+	})
+}
+
+func (c *localCatalog) GetAuthor(ctx context.Context, id string) (*models.Author, error) {
+	return c.server.authorService.GetAuthorByID(c.scope(ctx), id)
+}
+
+func (c *localCatalog) MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, error) {
+	return c.server.bookService.MergeAuthors(c.scope(ctx), survivorID, duplicateIDs)
+}
+
+func (c *localCatalog) Close() error {
+	sqlDB, err := c.server.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to access database pool: %w", err)
+	}
+	return sqlDB.Close()
+}
+
+var _ catalog = (*localCatalog)(nil)
+
+// collect fetches up to limit items after the first offset ones, a page at
+// a time; a zero limit fetches every item. Each page is fetched after the
+// key of the last item of the previous one, as lists are paginated.
+func collect[T any](limit, offset, pageSize int, key func(T) pagination.Key, fetch func(size int, after *pagination.Key) ([]T, error)) ([]T, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset cannot be negative")
+	}
+
+	items := []T{}
+	var after *pagination.Key
+	for {
+		size := pageSize
+		if limit > 0 {
+			size = min(size, offset+limit-len(items))
+		}
+		page, err := fetch(size, after)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		if len(page) > 0 {
+			last := key(page[len(page)-1])
+			after = &last
+		}
+		if len(page) < size || (limit > 0 && len(items) >= offset+limit) {
+			return items[min(offset, len(items)):], nil
+		}
+	}
+}
+
+// bookKey is the position of a book in lists ordered by name
+func bookKey(book models.Book) pagination.Key {
+	return pagination.Key{Name: book.Name, ID: book.ID}
+}
+
+// authorKey is the position of an author in lists ordered by name
+func authorKey(author models.Author) pagination.Key {
+	return pagination.Key{Name: author.Name, ID: author.ID}
+}
+
+// errorsOf reports the failed operations of a batch, if any
+func errorsOf(results []models.BatchResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.Status == models.BatchStatusFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d operations failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/fixtures"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// Output formats of the catalog commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// render writes v in format. JSON and YAML use the field names of the REST
+// API; tables show the main fields of books, authors and batch results.
+func render(w io.Writer, format string, v any) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case outputYAML:
+		return writeYAML(w, v)
+	default:
+		return writeTable(w, v)
+	}
+}
+
+// writeYAML writes v as YAML with the keys, in the order, of its JSON
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	// JSON is YAML; decoding it into a node keeps the order of the keys
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return encoder.Close()
+}
+
+// blockStyle drops the flow style of the JSON a node was decoded from
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// writeTable writes the main fields of v as aligned columns
+func writeTable(w io.Writer, v any) error {
+	var header []string
+	var rows [][]string
+
+	switch v := v.(type) {
+	case *models.Book:
+		return writeTable(w, []models.Book{*v})
+	case []models.Book:
+		header = []string{"ID", "NAME", "AUTHOR", "PUBLISHER", "YEAR", "PRICE", "PAGES"}
+		for _, book := range v {
+			rows = append(rows, []string{
+				book.ID, book.Name, book.Author.Name, book.Publisher,
+				optional(int(book.PublishedYear)), strconv.FormatFloat(book.Price, 'f', 2, 64), optional(book.Pages),
+			})
+		}
+	case *models.Author:
+		return writeTable(w, []models.Author{*v})
+	case []models.Author:
+		header = []string{"ID", "NAME", "BIO"}
+		for _, author := range v {
+			rows = append(rows, []string{author.ID, author.Name, author.Bio})
+		}
+	case []models.BatchResult:
+		header = []string{"INDEX", "OP", "ID", "STATUS", "ERROR"}
+		for _, result := range v {
+			rows = append(rows, []string{strconv.Itoa(result.Index), string(result.Op), result.ID, string(result.Status), result.Error})
+		}
+	case *fixtures.Result:
+		header = []string{"SET", "TENANT", "RECORDS", "WRITTEN", "UNCHANGED"}
+		for _, records := range []struct {
+			name   string
+			counts fixtures.Counts
+		}{{"authors", v.Authors}, {"books", v.Books}} {
+			rows = append(rows, []string{
+				v.Set, v.Tenant, records.name, strconv.Itoa(records.counts.Written), strconv.Itoa(records.counts.Unchanged),
+			})
+		}
+	case *userToken:
+		expires := "never"
+		if v.ExpiresAt != nil {
+			expires = v.ExpiresAt.Format(time.RFC3339)
+		}
+		header = []string{"USER", "TENANT", "EXPIRES", "TOKEN"}
+		rows = append(rows, []string{v.User, v.Tenant, expires, v.Token})
+	default:
+		return fmt.Errorf("cannot show %T as a table", v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// optional formats n, leaving zero blank
+func optional(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
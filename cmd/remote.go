@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/middleware"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// remotePage is the largest page the v2 API and GraphQL serve
+const remotePage = 100
+
+// remoteCatalog works on a server through the v2 REST API. Authors are
+// listed through GraphQL, which is the only API listing them.
+type remoteCatalog struct {
+	// base is the URL of the v2 API, e.g. http://localhost:8080/api/v2
+	base   string
+	token  string
+	tenant string
+	actor  string
+	client *http.Client
+}
+
+// apiError is a failed response of the v2 API
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (%d %s)", e.Message, e.Status, e.Code)
+}
+
+// newRequest creates a request to the server with the CLI's tenant, actor
+// and token, whose body, unless nil, is encoded as JSON
+func (c *remoteCatalog) newRequest(ctx context.Context, method, target string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(tenant.DefaultHeader, c.tenant)
+	req.Header.Set(middleware.ActorHeader, c.actor)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// do sends a request to the v2 API and decodes the data of its response
+// into out, also for failed responses carrying data
+func (c *remoteCatalog) do(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := c.newRequest(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return decodeResponse(resp, out)
+}
+
+// decodeResponse decodes the data of a v2 API response into out and
+// returns its error, if it failed
+func decodeResponse(resp *http.Response, out any) error {
+	var envelope struct {
+		Data  json.RawMessage `json:"data"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode %s response of %s %s: %w", resp.Status, resp.Request.Method, resp.Request.URL.Path, err)
+	}
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &apiError{Status: resp.StatusCode, Code: http.StatusText(resp.StatusCode)}
+		if envelope.Error != nil {
+			apiErr.Code, apiErr.Message = envelope.Error.Code, envelope.Error.Message
+		}
+		return apiErr
+	}
+	return nil
+}
+
+func (c *remoteCatalog) ListBooks(ctx context.Context, search repository.BookSearch, offset int) ([]models.Book, error) {
+	return collect(search.Limit, offset, remotePage, bookKey, func(size int, after *pagination.Key) ([]models.Book, error) {
+		query := url.Values{"limit": {strconv.Itoa(size)}}
+		if after != nil {
+			query.Set("cursor", pagination.EncodeCursor(*after))
+		}
+		for key, value := range map[string]string{"q": search.Query, "author": search.Author, "publisher": search.Publisher} {
+			if value != "" {
+				query.Set(key, value)
+			}
+		}
+		if search.MinPrice > 0 {
+			query.Set("min_price", strconv.FormatFloat(search.MinPrice, 'f', -1, 64))
+		}
+		if search.MaxPrice > 0 {
+			query.Set("max_price", strconv.FormatFloat(search.MaxPrice, 'f', -1, 64))
+		}
+
+		books := []models.Book{}
+		err := c.do(ctx, http.MethodGet, "/books", query, nil, &books)
+		return books, err
+	})
+}
+
+func (c *remoteCatalog) GetBook(ctx context.Context, id string) (*models.Book, error) {
+	var book models.Book
+	if err := c.do(ctx, http.MethodGet, "/books/"+url.PathEscape(id), nil, nil, &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+func (c *remoteCatalog) CreateBook(ctx context.Context, book *models.Book) error {
+	return c.do(ctx, http.MethodPost, "/books", nil, bookBody(book), book)
+}
+
+func (c *remoteCatalog) UpdateBook(ctx context.Context, id string, book *models.Book) (*models.Book, error) {
+	var updated models.Book
+	if err := c.do(ctx, http.MethodPut, "/books/"+url.PathEscape(id), nil, bookBody(book), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *remoteCatalog) DeleteBook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/books/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *remoteCatalog) BatchBooks(ctx context.Context, mode models.BatchMode, ops []models.BatchOperation) ([]models.BatchResult, error) {
+	operations := make([]map[string]any, len(ops))
+	for i, op := range ops {
+		operations[i] = map[string]any{"op": op.Op}
+		if op.ID != "" {
+			operations[i]["id"] = op.ID
+		}
+		if op.Book != nil {
+			operations[i]["book"] = bookBody(op.Book)
+		}
+	}
+
+	var results []models.BatchResult
+	err := c.do(ctx, http.MethodPost, "/books/batch", nil, map[string]any{"mode": mode, "operations": operations}, &results)
+	return results, err
+}
+
+// graphQLAuthor is an author as GraphQL names its fields
+type graphQLAuthor struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Bio       string    `json:"bio"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// authorsQuery lists a page of authors
+const authorsQuery = `query($first: Int, $after: String) {
+  authors(first: $first, after: $after) { nodes { id name bio createdAt updatedAt } }
+}`
+
+func (c *remoteCatalog) ListAuthors(ctx context.Context, limit int, offset int) ([]models.Author, error) {
+	return collect(limit, offset, remotePage, authorKey, func(size int, after *pagination.Key) ([]models.Author, error) {
+		variables := map[string]any{"first": size}
+		if after != nil {
+			variables["after"] = pagination.EncodeCursor(*after)
+		}
+
+		var response struct {
+			Data struct {
+				Authors struct {
+					Nodes []graphQLAuthor `json:"nodes"`
+				} `json:"authors"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if err := c.graphQL(ctx, authorsQuery, variables, &response); err != nil {
+			return nil, err
+		}
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("failed to list authors: %s", response.Errors[0].Message)
+		}
+
+		authors := make([]models.Author, len(response.Data.Authors.Nodes))
+		for i, node := range response.Data.Authors.Nodes {
+			authors[i] = models.Author{ID: node.ID, Name: node.Name, Bio: node.Bio, CreatedAt: node.CreatedAt, UpdatedAt: node.UpdatedAt}
+		}
+		return authors, nil
+	})
+}
+
+// graphQL sends a GraphQL query and decodes its response, which is not in
+// the REST envelope, into out
+func (c *remoteCatalog) graphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	req, err := c.newRequest(ctx, http.MethodPost, c.base+"/graphql", map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer resp.Body.Close()
+	// Requests rejected before reaching GraphQL, e.g. for their tenant,
+	// fail like REST requests
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeResponse(resp, nil)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s GraphQL response: %w", resp.Status, err)
+	}
+	return nil
+}
+
+func (c *remoteCatalog) GetAuthor(ctx context.Context, id string) (*models.Author, error) {
+	var author models.Author
+	if err := c.do(ctx, http.MethodGet, "/authors/"+url.PathEscape(id), nil, nil, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+func (c *remoteCatalog) MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, error) {
+	var author models.Author
+	body := map[string]any{"author_ids": duplicateIDs}
+	if err := c.do(ctx, http.MethodPost, "/authors/"+url.PathEscape(survivorID)+"/merge", nil, body, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+func (c *remoteCatalog) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+var _ catalog = (*remoteCatalog)(nil)
+
+// bookBody is the request body writing the fields of book that are set
+func bookBody(book *models.Book) map[string]any {
+	body := map[string]any{}
+	if book.ID != "" {
+		body["id"] = book.ID
+	}
+	if book.Name != "" {
+		body["name"] = book.Name
+	}
+	author := map[string]any{}
+	for key, value := range map[string]string{"id": book.Author.ID, "name": book.Author.Name, "bio": book.Author.Bio} {
+		if value != "" {
+			author[key] = value
+		}
+	}
+	if len(author) > 0 {
+		body["author"] = author
+	}
+	if book.Publisher != "" {
+		body["publisher"] = book.Publisher
+	}
+	if book.PublishedYear != 0 {
+		body["published_year"] = book.PublishedYear
+	}
+	if book.Description != "" {
+		body["description"] = book.Description
+	}
+	if book.Price != 0 {
+		body["price"] = book.Price
+	}
+	if book.Pages != 0 {
+		body["pages"] = book.Pages
+	}
+	return body
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/dtg-lucifer/go-bookstore/pkg/utils"
+)
+
+// cliOptions are the flags shared by the commands working on the catalog
+type cliOptions struct {
+	// output is the format of results: table, json or yaml
+	output string
+	// url is the REST API of a server to work through; empty works on the
+	// database directly
+	url string
+	// token is sent as a bearer token to the server
+	token string
+	// tenant is the store whose catalog is used
+	tenant string
+	// actor is recorded as the author of changes in the audit log
+	actor string
+}
+
+// newRootCommand creates the bookstore command and its subcommands
+func newRootCommand(envErr error) *cobra.Command {
+	opts := &cliOptions{}
+
+	root := &cobra.Command{
+		Use:   "bookstore",
+		Short: "Book Store API server and admin client",
+		Long: `bookstore runs the Book Store API servers and administers their catalog.
+
+Catalog commands work on the database configured by the DB_* variables, or,
+with --url, on a running server through its REST API.`,
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(outputFormats, opts.output) {
+				return fmt.Errorf("invalid output %q: must be one of %v", opts.output, outputFormats)
+			}
+			if !tenant.ValidID(opts.tenant) {
+				return fmt.Errorf("invalid tenant %q", opts.tenant)
+			}
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVarP(&opts.output, "output", "o", outputTable, "output format: table, json or yaml")
+	flags.StringVar(&opts.url, "url", utils.GetEnv("BOOKSTORE_URL", ""), "base URL of a server's REST API, e.g. http://localhost:8080/api (default: the database)")
+	flags.StringVar(&opts.token, "token", utils.GetEnv("BOOKSTORE_TOKEN", ""), "bearer token sent to the server")
+	flags.StringVar(&opts.tenant, "tenant", utils.GetEnv("BOOKSTORE_TENANT", tenant.DefaultID), "tenant whose catalog is used")
+	flags.StringVar(&opts.actor, "actor", utils.GetEnv("BOOKSTORE_ACTOR", "cli"), "actor recorded in the audit log")
+	_ = root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(
+		newServeCommand(envErr),
+		newMigrateCommand(),
+		newBooksCommand(opts),
+		newAuthorsCommand(opts),
+		newImportCommand(opts),
+		newExportCommand(opts),
+		newSeedCommand(opts),
+		newUsersCommand(opts),
+	)
+	return root
+}
+
+// newMigrateCommand creates the command migrating the database schema
+func newMigrateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the database schema and exit",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server, err := newServerFromEnv(cliLogger())
+			if err != nil {
+				return err
+			}
+			// Connecting migrates the database
+			if err := server.SetupDB(); err != nil {
+				return err
+			}
+			if sqlDB, err := server.DB.DB(); err == nil {
+				sqlDB.Close()
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Database migrated")
+			return nil
+		},
+	}
+}
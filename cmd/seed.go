@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/fixtures"
+)
+
+func newSeedCommand(opts *cliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "seed [small|medium|large]",
+		Short: "Load a demo data set of authors and books",
+		Long: `Load a data set of authors and books into the tenant's catalog:
+
+  small   20 classic novels by 10 authors (the default)
+  medium  2,000 synthetic books by about 600 authors
+  large   100,000 synthetic books by about 25,000 authors
+
+Data sets are the same on every run. Seeding again only writes the records
+of the set that were changed or deleted since, and leaves other records as
+they are. Seeded records are not written to the audit log, and no events are
+published for them. seed works on the database directly and does not take --url.`,
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: fixtures.Names(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.url != "" {
+				return errors.New("seed works on the database and does not take --url")
+			}
+			name := "small"
+			if len(args) == 1 {
+				name = args[0]
+			}
+			set, err := fixtures.Get(name)
+			if err != nil {
+				return err
+			}
+
+			c, err := openLocalCatalog(opts)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			server := c.server
+			loader := fixtures.NewLoader(server.bookRepo, server.authorRepo, server.txManager, fixtures.LoaderOptions{
+				Progress: func(loaded int, total int) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "\rLoaded %d of %d books", loaded, total)
+					if loaded == total {
+						fmt.Fprintln(cmd.ErrOrStderr())
+					}
+				},
+			})
+			result, err := loader.Load(c.scope(cmd.Context()), set)
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), opts.output, result)
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/utils"
+)
+
+// newServeCommand creates the command running the API servers
+func newServeCommand(envErr error) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run the REST, GraphQL and gRPC servers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(envErr)
+		},
+	}
+}
+
+// serve sets up every part of the server and runs it until SIGINT/SIGTERM
+func serve(envErr error) error {
+	// Initialize logger
+	logConfig, err := logging.ConfigFromEnv()
+	if err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		return err
+	}
+	logger, logCloser, err := logging.New(logConfig)
+	if err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		return err
+	}
+	defer logCloser.Close()
+
+	if envErr != nil {
+		logger.Warn("Failed to load .env file", "error", envErr)
+		// Continue execution as default values will be used
+	}
+
+	// Create the server
+	server, err := newServerFromEnv(logger)
+	if err != nil {
+		logger.Error("Failed to create server", "error", err)
+		return err
+	}
+
+	steps := []struct {
+		name  string
+		setup func() error
+	}{
+		{"tracing", server.SetupTracing},
+		{"database", server.SetupDB},
+		{"cache", server.SetupCache},
+		{"event relay", server.SetupEvents},
+		{"middlewares", server.SetupMiddlewares},
+		{"routes", server.SetupRoutes},
+		{"gRPC server", server.SetupGRPC},
+		{"metrics", server.SetupMetrics},
+	}
+	for _, step := range steps {
+		if err := step.setup(); err != nil {
+			logger.Error("Failed to set up "+step.name, "error", err)
+			return fmt.Errorf("failed to set up %s: %w", step.name, err)
+		}
+	}
+
+	// Shut down gracefully on SIGINT/SIGTERM
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Error("Failed to shut down server", "error", err)
+		}
+	}()
+
+	// Start the server
+	logger.Info("Starting server", "address", server.Addr)
+	if err := server.Start(); err != nil {
+		logger.Error("Server failed", "error", err)
+		return err
+	}
+
+	// Start only returns without an error once shutdown has begun
+	<-shutdownDone
+	return nil
+}
+
+// newServerFromEnv creates a Server configured by the ADDR, PORT and API_*
+// environment variables
+func newServerFromEnv(logger *slog.Logger) (*Server, error) {
+	return NewServer(
+		utils.GetEnv("ADDR", "127.0.0.1"),
+		utils.GetEnv("PORT", "8080"),
+		utils.GetEnv("API_PREFIX", "/api"),
+		strings.Split(utils.GetEnv("API_VERSIONS", "v1,v2"), ","),
+		utils.GetEnv("API_DEFAULT_VERSION", "v1"),
+		logger,
+	)
+}
@@ -1,94 +1,394 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/cache"
 	"github.com/dtg-lucifer/go-bookstore/pkg/config"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/graph"
+	"github.com/dtg-lucifer/go-bookstore/pkg/grpcapi"
 	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/metrics"
+	"github.com/dtg-lucifer/go-bookstore/pkg/middleware"
+	"github.com/dtg-lucifer/go-bookstore/pkg/openapi"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
 	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
 	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/storage"
+	"github.com/dtg-lucifer/go-bookstore/pkg/stream"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tracing"
 	"github.com/dtg-lucifer/go-bookstore/pkg/utils"
+	"github.com/dtg-lucifer/go-bookstore/pkg/webhooks"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 type Server struct {
-	App        *fiber.App
-	Router     fiber.Router
-	DB         *gorm.DB
-	ApiVersion string
-	Addr       string
+	App     *fiber.App
+	DB      *gorm.DB
+	Addr    string
+	Metrics *metrics.Metrics
+	Logger  *slog.Logger
+
+	// ApiPrefix is the path the versions of the REST API are mounted under
+	ApiPrefix string
+	// Versions are the mounted versions of the REST API
+	Versions []handlers.Version
+	// DefaultVersion serves requests that do not ask for a version
+	DefaultVersion handlers.Version
+
+	// shutdownTracing flushes pending spans
+	shutdownTracing func(context.Context) error
+
+	// bookCache caches catalog reads; nil when caching is disabled
+	bookCache *cache.Cache
+	redis     *redis.Client
+
+	// Repositories of the catalog, which the seed command writes to
+	bookRepo   repository.BookRepository
+	authorRepo repository.AuthorRepository
+	txManager  *database.TxManager
+
+	// blobs keeps book covers and ebook files
+	blobs storage.BlobStore
+	// fileMaxSize is the largest ebook file accepted, in bytes
+	fileMaxSize int64
+
+	// Services shared by the REST and gRPC APIs
+	bookService   service.BookService
+	authorService service.AuthorService
+
+	// grpcServer serves the gRPC API; nil when it is disabled
+	grpcServer *grpc.Server
 
 	// Handlers
-	bookHandler   *handlers.BookHandler
-	healthHandler *handlers.HealthHandler
+	bookHandler    *handlers.BookHandler
+	coverHandler   *handlers.CoverHandler
+	fileHandler    *handlers.FileHandler
+	authorHandler  *handlers.AuthorHandler
+	healthHandler  *handlers.HealthHandler
+	webhookHandler *handlers.WebhookHandler
+	streamHandler  *handlers.StreamHandler
+	auditHandler   *handlers.AuditHandler
+	tenantHandler  *handlers.TenantHandler
+	graphqlHandler *handlers.GraphQLHandler
+	docsHandler    *handlers.DocsHandler
+
+	// validation configures the validation of requests against the API
+	// contract of their version; nil when it is disabled
+	validation *middleware.ValidationConfig
+	// documents are the API contracts of the mounted versions, by name
+	documents map[string]*openapi.Document
+	// idempotency configures the replay of retried POST requests
+	idempotency middleware.IdempotencyConfig
+	// tenants configures how the tenant of requests is resolved
+	tenants middleware.TenantConfig
+	// rateLimit enforces the tenants' rate limits across versions
+	rateLimit fiber.Handler
+	// adminToken protects the administration routes; empty disables them
+	adminToken string
+	// actors identifies the callers recorded in the audit log
+	actors audit.ActorResolver
 }
 
-func NewServer(ip string, port string, version string) (*Server, error) {
-	utils.Logger.Info("Initializing the Server")
+// maxBodySize bounds the request bodies read into memory, which must fit
+// cover uploads. Ebook files, of up to FILE_MAX_SIZE_MB, are streamed
+// instead (see isFileUpload).
+const maxBodySize = 16 << 20
+
+// fileUploadPath matches the path of the ebook file upload route below the
+// API prefix, with or without a version
+var fileUploadPath = regexp.MustCompile(`^(?:[^/]+/)?books/[^/]+/files/[^/]+/?$`)
+
+func NewServer(ip string, port string, prefix string, versions []string, defaultVersion string, logger *slog.Logger) (*Server, error) {
+	logger.Info("Initializing the Server")
 
 	addr := fmt.Sprintf("%s:%s", ip, port)
+	fileMaxSizeMB, err := strconv.Atoi(utils.GetEnv("FILE_MAX_SIZE_MB", "100"))
+	if err != nil || fileMaxSizeMB < 1 || fileMaxSizeMB > 2048 {
+		return nil, fmt.Errorf("FILE_MAX_SIZE_MB must be between 1 and 2048")
+	}
+	fileMaxSize := int64(fileMaxSizeMB) << 20
+	// Request bodies are streamed so that ebook files need not fit in
+	// memory; the BodyLimit middleware reads the others
 	app := fiber.New(fiber.Config{
-		AppName: "Book Store API",
+		AppName:                      "Book Store API",
+		ErrorHandler:                 handlers.ErrorHandler,
+		BodyLimit:                    maxBodySize,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 
-	if version == "" {
-		return nil, fmt.Errorf("API version cannot be empty")
+	if prefix == "" {
+		return nil, fmt.Errorf("API prefix cannot be empty")
+	}
+
+	known := handlers.Versions()
+	var mounted []handlers.Version
+	for _, name := range versions {
+		name = strings.TrimSpace(name)
+		version, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown API version %q", name)
+		}
+		mounted = append(mounted, version)
+	}
+	if len(mounted) == 0 {
+		return nil, fmt.Errorf("at least one API version must be mounted")
 	}
 
-	router := app.Group(version)
+	defaultIndex := slices.IndexFunc(mounted, func(version handlers.Version) bool {
+		return version.Name == defaultVersion
+	})
+	if defaultIndex < 0 {
+		return nil, fmt.Errorf("default API version %q is not mounted", defaultVersion)
+	}
 
 	return &Server{
-		App:        app,
-		Router:     router,
-		Addr:       addr,
-		ApiVersion: version,
-		DB:         nil,
+		App:            app,
+		Addr:           addr,
+		ApiPrefix:      strings.TrimSuffix(prefix, "/"),
+		Versions:       mounted,
+		DefaultVersion: mounted[defaultIndex],
+		DB:             nil,
+		Metrics:        metrics.New(),
+		Logger:         logger,
+		fileMaxSize:    fileMaxSize,
 	}, nil
 }
 
-func (s *Server) SetupDB() error {
-	utils.Logger.Info("Connecting to the Database")
-
-	db_user := utils.GetEnv("DB_USER", "demo")
-	db_pass := utils.GetEnv("DB_PASS", "password")
-	db_addr := utils.GetEnv("DB_ADDR", "127.0.0.1")
-	db_port := utils.GetEnv("DB_PORT", "3306")
-	db_name := utils.GetEnv("DB_NAME", "book_store")
-
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		db_user,
-		db_pass,
-		db_addr,
-		db_port,
-		db_name,
+func (s *Server) SetupTracing() error {
+	s.Logger.Info("Setting up Tracing")
+
+	shutdown, err := tracing.Setup(
+		context.Background(),
+		utils.GetEnv("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
+		utils.GetEnv("OTEL_SERVICE_NAME", "go-bookstore"),
 	)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	s.shutdownTracing = shutdown
+	return nil
+}
 
+func (s *Server) SetupDB() error {
+	s.Logger.Info("Connecting to the Database")
+
+	cfg, err := database.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	slowQuery, err := time.ParseDuration(utils.GetEnv("LOG_SLOW_QUERY", "200ms"))
+	if err != nil {
+		return fmt.Errorf("invalid LOG_SLOW_QUERY: %w", err)
+	}
+
+	// The database may still be starting, e.g. under docker-compose
+	ctx := context.Background()
+	var db *gorm.DB
+	err = database.Connect(ctx, s.Logger, "primary", cfg.RetryTimeout, func(ctx context.Context) error {
+		opened, err := gorm.Open(mysql.Open(cfg.DSN(cfg.Primary)), &gorm.Config{
+			Logger: logging.NewGORMLogger(s.Logger, slowQuery),
+		})
+		if err != nil {
+			// A failed ping leaves the pool open
+			if opened != nil {
+				if sqlDB, dbErr := opened.DB(); dbErr == nil {
+					sqlDB.Close()
+				}
+			}
+			return err
+		}
+		db = opened
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	utils.Logger.Info("Migrating the Database")
-	config.MigrateDB(db)
+	replicas := make([]*sql.DB, len(cfg.Replicas))
+	for i, addr := range cfg.Replicas {
+		err := database.Connect(ctx, s.Logger, "replica "+addr, cfg.RetryTimeout, func(ctx context.Context) error {
+			replica, err := database.OpenReplica(ctx, cfg.DSN(addr))
+			replicas[i] = replica
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to connect to read replica: %w", err)
+		}
+	}
+
+	if err := db.Use(s.Metrics.GORMPlugin()); err != nil {
+		return fmt.Errorf("failed to register database metrics: %w", err)
+	}
+
+	if err := db.Use(tracing.NewGORMPlugin()); err != nil {
+		return fmt.Errorf("failed to register database tracing: %w", err)
+	}
+
+	if err := db.Use(tenant.NewGORMPlugin()); err != nil {
+		return fmt.Errorf("failed to register tenant scoping: %w", err)
+	}
+
+	if err := db.Use(database.NewReplicaRouter(replicas...)); err != nil {
+		return fmt.Errorf("failed to register read replica routing: %w", err)
+	}
+
+	if err := db.Use(database.NewQueryTimeout(cfg.QueryTimeout)); err != nil {
+		return fmt.Errorf("failed to register query timeouts: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to access database pool: %w", err)
+	}
+	cfg.Pool.Apply(sqlDB)
+	if err := s.Metrics.RegisterDB(sqlDB, cfg.Name); err != nil {
+		return fmt.Errorf("failed to register database pool metrics: %w", err)
+	}
+	for i, replica := range replicas {
+		cfg.Pool.Apply(replica)
+		if err := s.Metrics.RegisterDB(replica, fmt.Sprintf("%s_replica_%d", cfg.Name, i+1)); err != nil {
+			return fmt.Errorf("failed to register replica pool metrics: %w", err)
+		}
+	}
+
+	s.Logger.Info("Migrating the Database")
+	if err := config.MigrateDB(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
 
 	s.DB = db
 
 	return nil
 }
 
+func (s *Server) SetupCache() error {
+	s.Logger.Info("Setting up the Cache")
+
+	ttl, err := time.ParseDuration(utils.GetEnv("CACHE_TTL", "5m"))
+	if err != nil {
+		return fmt.Errorf("invalid CACHE_TTL: %w", err)
+	}
+
+	var store cache.Store
+	switch backend := utils.GetEnv("CACHE_BACKEND", "memory"); backend {
+	case "none":
+		return nil
+	case "memory":
+		size, err := strconv.Atoi(utils.GetEnv("CACHE_SIZE", "10000"))
+		if err != nil {
+			return fmt.Errorf("invalid CACHE_SIZE: %w", err)
+		}
+		store = cache.NewLRU(size)
+	case "redis":
+		db, err := strconv.Atoi(utils.GetEnv("REDIS_DB", "0"))
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_DB: %w", err)
+		}
+		s.redis = redis.NewClient(&redis.Options{
+			Addr:     utils.GetEnv("REDIS_ADDR", "127.0.0.1:6379"),
+			Password: utils.GetEnv("REDIS_PASSWORD", ""),
+			DB:       db,
+		})
+		if err := s.redis.Ping(context.Background()).Err(); err != nil {
+			return fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		store = cache.NewRedisStore(s.redis, "bookstore:")
+	default:
+		return fmt.Errorf("unknown cache backend %q", backend)
+	}
+
+	s.bookCache = cache.New("books", store, ttl, s.Logger, s.Metrics)
+	return nil
+}
+
+func (s *Server) SetupEvents() error {
+	s.Logger.Info("Setting up the Event Relay")
+
+	var sinks []events.Sink
+	for _, name := range strings.Split(utils.GetEnv("EVENT_SINKS", "log"), ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "log":
+			sinks = append(sinks, events.NewLogSink(s.Logger))
+		case "webhook":
+			url := utils.GetEnv("EVENT_WEBHOOK_URL", "")
+			if url == "" {
+				return fmt.Errorf("EVENT_WEBHOOK_URL is required for the webhook event sink")
+			}
+			sinks = append(sinks, events.NewWebhookSink(url))
+		default:
+			return fmt.Errorf("unknown event sink %q", name)
+		}
+	}
+
+	interval, err := time.ParseDuration(utils.GetEnv("OUTBOX_POLL_INTERVAL", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid OUTBOX_POLL_INTERVAL: %w", err)
+	}
+
+	// The relay and the dispatcher work for every tenant
+	ctx := tenant.WithAllTenants(context.Background())
+
+	// Webhook subscriptions always receive events
+	webhookConfig := webhooks.DefaultConfig()
+	webhookConfig.AllowPrivateNetworks = utils.GetEnv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false") == "true"
+	dispatcher := webhooks.NewDispatcher(impl.NewWebhookRepository(s.DB), webhookConfig, s.Logger)
+	sinks = append(sinks, dispatcher)
+	go dispatcher.Run(ctx)
+
+	relay := events.NewRelay(impl.NewOutboxRepository(s.DB), interval, s.Logger, sinks...)
+	go relay.Run(ctx)
+
+	return nil
+}
+
 func (s *Server) SetupMiddlewares() error {
-	utils.Logger.Info("Setting up Middlewares")
+	s.Logger.Info("Setting up Middlewares")
 
-	writer, err := utils.NewEventLogger()
+	logBodySize, err := strconv.Atoi(utils.GetEnv("LOG_MAX_BODY_SIZE", "2048"))
 	if err != nil {
-		return fmt.Errorf("failed to create event logger: %w", err)
+		return fmt.Errorf("invalid LOG_MAX_BODY_SIZE: %w", err)
+	}
+
+	s.adminToken = utils.GetEnv("ADMIN_TOKEN", "")
+	trustedProxies, err := audit.ParseTrustedProxies(utils.GetEnv("ACTOR_TRUSTED_PROXIES", ""))
+	if err != nil {
+		return fmt.Errorf("invalid ACTOR_TRUSTED_PROXIES: %w", err)
+	}
+	// Callers are identified by the tokens that also name their tenant
+	s.actors = audit.ActorResolver{
+		Secret:         []byte(utils.GetEnv("TENANT_JWT_SECRET", "")),
+		Claim:          utils.GetEnv("ACTOR_JWT_CLAIM", "sub"),
+		AdminToken:     s.adminToken,
+		TrustedProxies: trustedProxies,
 	}
 
 	s.App.Use(recover.New())
@@ -97,40 +397,440 @@ func (s *Server) SetupMiddlewares() error {
 		AllowOrigins:     "http://localhost:3000",
 	}))
 	s.App.Use(requestid.New())
-	s.App.Use(logger.New(logger.Config{
-		Format:   "${green}[${time} - ${latency}]${reset} ${blue}[${ip}:${port}]${reset} ${blue}${locals:requestid}${reset} ${status} - [${method}] - ${yellow}${path}${reset} - ${blue}${queryParams} ${reqHeaders} ${body} - ${resBody}${reset}\n",
-		TimeZone: "Asia/Kolkata",
+	s.App.Use(middleware.Tracing())
+	s.App.Use(middleware.Actor(s.actors))
+	s.App.Use(middleware.ReadYourWrites())
+	s.App.Use(middleware.RequestLogger(s.Logger, middleware.RequestLoggerConfig{
+		LogBodies:   utils.GetEnv("LOG_REQUEST_BODIES", "false") == "true",
+		MaxBodySize: logBodySize,
+	}))
+
+	versions := map[string]handlers.Version{}
+	for _, version := range s.Versions {
+		versions[version.Name] = version
+	}
+	s.App.Use(middleware.VersionNegotiation(middleware.VersionConfig{
+		Prefix:   s.ApiPrefix,
+		Versions: versions,
+		Default:  s.DefaultVersion,
 	}))
-	s.App.Use(logger.New(logger.Config{
-		Format:   "${green}[${time} - ${latency}]${reset} ${blue}[${ip}:${port}]${reset} ${blue}${locals:requestid}${reset} ${status} - [${method}] - ${yellow}${path}${reset} - ${blue}${queryParams} ${reqHeaders} ${body} - ${resBody}${reset}\n",
-		TimeZone: "Asia/Kolkata",
-		Output:   writer,
+	s.App.Use(middleware.BodyLimit(middleware.BodyLimitConfig{
+		Limit:  maxBodySize,
+		Stream: s.isFileUpload,
 	}))
+
+	idempotencyTTL, err := time.ParseDuration(utils.GetEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil {
+		return fmt.Errorf("invalid IDEMPOTENCY_TTL: %w", err)
+	}
+	if idempotencyTTL <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_TTL must be positive")
+	}
+	idempotencyLease, err := time.ParseDuration(utils.GetEnv("IDEMPOTENCY_LEASE", "1m"))
+	if err != nil {
+		return fmt.Errorf("invalid IDEMPOTENCY_LEASE: %w", err)
+	}
+	if idempotencyLease <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_LEASE must be positive")
+	}
+	s.idempotency = middleware.IdempotencyConfig{
+		Repository: impl.NewIdempotencyRepository(s.DB),
+		TTL:        idempotencyTTL,
+		Lease:      idempotencyLease,
+		Logger:     s.Logger,
+	}
+
+	resolver := tenant.Resolver{
+		Header:  utils.GetEnv("TENANT_HEADER", tenant.DefaultHeader),
+		Domain:  utils.GetEnv("TENANT_DOMAIN", ""),
+		Secret:  []byte(utils.GetEnv("TENANT_JWT_SECRET", "")),
+		Claim:   utils.GetEnv("TENANT_JWT_CLAIM", "tenant_id"),
+		Default: tenant.DefaultID,
+	}
+	// An empty TENANT_DEFAULT requires every request to name its tenant
+	if value, ok := os.LookupEnv("TENANT_DEFAULT"); ok {
+		resolver.Default = value
+	}
+	for _, source := range strings.Split(utils.GetEnv("TENANT_SOURCES", tenant.SourceHeader), ",") {
+		switch source = strings.TrimSpace(source); source {
+		case "":
+			continue
+		case tenant.SourceHeader:
+		case tenant.SourceSubdomain:
+			if resolver.Domain == "" {
+				return fmt.Errorf("TENANT_DOMAIN is required to resolve tenants from subdomains")
+			}
+		case tenant.SourceJWT:
+			if len(resolver.Secret) == 0 {
+				return fmt.Errorf("TENANT_JWT_SECRET is required to resolve tenants from tokens")
+			}
+		default:
+			return fmt.Errorf("unknown tenant source %q", source)
+		}
+		resolver.Sources = append(resolver.Sources, source)
+	}
+	s.tenants = middleware.TenantConfig{
+		Resolver: resolver,
+		Tenants:  impl.NewTenantRepository(s.DB),
+	}
+	s.rateLimit = middleware.TenantRateLimit()
+
+	// Requests are validated by each version's route group
+	switch mode := utils.GetEnv("REQUEST_VALIDATION", "on"); mode {
+	case "off":
+	case "on", "strict":
+		s.validation = &middleware.ValidationConfig{
+			Strict:            mode == "strict",
+			ValidateResponses: utils.GetEnv("RESPONSE_VALIDATION", "false") == "true",
+			Logger:            s.Logger,
+		}
+	default:
+		return fmt.Errorf("invalid REQUEST_VALIDATION %q: must be off, on or strict", mode)
+	}
 	return nil
 }
 
+// isFileUpload reports whether the request uploads an ebook file, whose
+// body is streamed to blob storage and bounded by FILE_MAX_SIZE_MB rather
+// than read into memory
+func (s *Server) isFileUpload(ctx *fiber.Ctx) bool {
+	if ctx.Method() != fiber.MethodPut {
+		return false
+	}
+	path, ok := strings.CutPrefix(ctx.Path(), s.ApiPrefix+"/")
+	return ok && fileUploadPath.MatchString(path)
+}
+
 func (s *Server) SetupRoutes() error {
-	utils.Logger.Info("Setting up Routes")
+	s.Logger.Info("Setting up Routes")
 
 	// Initialize repositories
-	bookRepo := impl.NewBookRepository(s.DB)
+	s.bookRepo = impl.NewBookRepository(s.DB)
+	if s.bookCache != nil {
+		s.bookRepo = impl.NewCachedBookRepository(s.bookRepo, s.bookCache)
+	}
+	webhookRepo := impl.NewWebhookRepository(s.DB)
+	auditRepo := impl.NewAuditRepository(s.DB)
+	s.authorRepo = impl.NewAuthorRepository(s.DB)
+	s.txManager = database.NewTxManager(s.DB, s.Logger)
+
+	// Live stream of book changes
+	hub := stream.NewHub(1000, 64)
 
 	// Initialize services
-	bookService := service.NewBookService(bookRepo)
+	var bookOptions service.BookServiceOptions
+	switch matching := utils.GetEnv("AUTHOR_MATCHING", "off"); matching {
+	case "off":
+	case "name":
+		bookOptions.MatchAuthorsByName = true
+	default:
+		return fmt.Errorf("invalid AUTHOR_MATCHING %q: must be off or name", matching)
+	}
+	coverOptions, err := coverOptionsFromEnv()
+	if err != nil {
+		return err
+	}
+	if s.blobs, err = newBlobStore(); err != nil {
+		return err
+	}
+	fileOptions, err := s.fileOptionsFromEnv()
+	if err != nil {
+		return err
+	}
+	coverService := service.NewCoverService(impl.NewCoverRepository(s.DB), s.bookRepo, s.blobs, s.Logger, coverOptions)
+	fileService := service.NewFileService(impl.NewFileRepository(s.DB), s.bookRepo, s.tenants.Tenants, s.blobs, s.Logger, fileOptions)
+	s.bookService = service.NewBookService(s.bookRepo, s.authorRepo, s.txManager, s.Logger, bookOptions, hub, s.Metrics, coverService, fileService)
+	s.authorService = service.NewAuthorService(s.authorRepo)
+	webhookService := service.NewWebhookService(webhookRepo, s.Logger)
+	auditService := service.NewAuditService(auditRepo, s.bookService, s.Logger)
+	tenantService := service.NewTenantService(s.tenants.Tenants, s.Logger)
+
+	maxAge, err := time.ParseDuration(utils.GetEnv("HTTP_CACHE_MAX_AGE", "0s"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CACHE_MAX_AGE: %w", err)
+	}
+	coverMaxAge, err := time.ParseDuration(utils.GetEnv("COVER_CACHE_MAX_AGE", "1h"))
+	if err != nil {
+		return fmt.Errorf("invalid COVER_CACHE_MAX_AGE: %w", err)
+	}
 
 	// Initialize handlers
-	s.bookHandler = handlers.NewBookHandler(bookService)
+	s.bookHandler = handlers.NewBookHandler(s.bookService, maxAge)
+	s.coverHandler = handlers.NewCoverHandler(coverService, coverOptions.MaxSize, coverMaxAge)
+	s.fileHandler = handlers.NewFileHandler(fileService)
+	s.authorHandler = handlers.NewAuthorHandler(s.authorService, s.bookService)
 	s.healthHandler = handlers.NewHealthHandler()
+	s.webhookHandler = handlers.NewWebhookHandler(webhookService)
+	s.streamHandler = handlers.NewStreamHandler(hub, 15*time.Second)
+	s.auditHandler = handlers.NewAuditHandler(auditService)
+	s.tenantHandler = handlers.NewTenantHandler(tenantService)
+
+	maxDepth, err := strconv.Atoi(utils.GetEnv("GRAPHQL_MAX_DEPTH", strconv.Itoa(graph.DefaultLimits().MaxDepth)))
+	if err != nil {
+		return fmt.Errorf("invalid GRAPHQL_MAX_DEPTH: %w", err)
+	}
+	maxComplexity, err := strconv.Atoi(utils.GetEnv("GRAPHQL_MAX_COMPLEXITY", strconv.Itoa(graph.DefaultLimits().MaxComplexity)))
+	if err != nil {
+		return fmt.Errorf("invalid GRAPHQL_MAX_COMPLEXITY: %w", err)
+	}
+	s.graphqlHandler = handlers.NewGraphQLHandler(graph.NewServer(s.bookService, s.authorService, graph.Limits{
+		MaxDepth:      maxDepth,
+		MaxComplexity: maxComplexity,
+	}, s.Logger))
+
+	// Every version serves the same services under its own path
+	s.documents = map[string]*openapi.Document{}
+	for _, version := range s.Versions {
+		if err := s.mountVersion(version); err != nil {
+			return err
+		}
+	}
+
+	// API documentation
+	s.docsHandler = handlers.NewDocsHandler(s.documents, s.DefaultVersion.Name)
+	s.App.Get("/openapi.json", s.docsHandler.Spec)
+	s.App.Get("/openapi/:version.json", s.docsHandler.Spec)
+	s.App.Get("/docs", s.docsHandler.UI)
+	s.App.Get("/docs/swagger-ui/:version/:name", s.docsHandler.Asset)
+
+	return nil
+}
+
+// coverOptionsFromEnv returns the limits and renditions of covers set by
+// the COVER_* environment variables
+func coverOptionsFromEnv() (service.CoverServiceOptions, error) {
+	options := service.DefaultCoverServiceOptions()
+
+	maxSizeMB, err := strconv.Atoi(utils.GetEnv("COVER_MAX_SIZE_MB", "5"))
+	if err != nil {
+		return options, fmt.Errorf("invalid COVER_MAX_SIZE_MB: %w", err)
+	}
+	// The rest of the body is left for the multipart framing
+	if maxSizeMB < 1 || maxSizeMB >= maxBodySize>>20 {
+		return options, fmt.Errorf("COVER_MAX_SIZE_MB must be between 1 and %d", maxBodySize>>20-1)
+	}
+	options.MaxSize = int64(maxSizeMB) << 20
+
+	options.Widths = nil
+	for _, field := range strings.Split(utils.GetEnv("COVER_WIDTHS", "160,320,640"), ",") {
+		width, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || width < 16 || width > 4096 {
+			return options, fmt.Errorf("invalid COVER_WIDTHS: %q is not a width from 16 to 4096", field)
+		}
+		options.Widths = append(options.Widths, width)
+	}
+	return options, nil
+}
+
+// fileOptionsFromEnv returns the limits of ebook files and their download
+// links set by the FILE_* and DOWNLOAD_* environment variables
+func (s *Server) fileOptionsFromEnv() (service.FileServiceOptions, error) {
+	options := service.DefaultFileServiceOptions()
+	options.MaxSize = s.fileMaxSize
+
+	var err error
+	if options.LinkTTL, err = time.ParseDuration(utils.GetEnv("DOWNLOAD_LINK_TTL", "15m")); err != nil || options.LinkTTL < time.Second {
+		return options, fmt.Errorf("DOWNLOAD_LINK_TTL must be a duration of at least 1s")
+	}
+	if options.MaxLinkTTL, err = time.ParseDuration(utils.GetEnv("DOWNLOAD_LINK_MAX_TTL", "24h")); err != nil || options.MaxLinkTTL < options.LinkTTL {
+		return options, fmt.Errorf("DOWNLOAD_LINK_MAX_TTL must be a duration of at least DOWNLOAD_LINK_TTL")
+	}
+	if options.DownloadLimit, err = strconv.Atoi(utils.GetEnv("DOWNLOAD_LIMIT", "5")); err != nil || options.DownloadLimit < 0 {
+		return options, fmt.Errorf("DOWNLOAD_LIMIT must be a number of downloads, or 0 for no limit")
+	}
+
+	key := utils.GetEnv("DOWNLOAD_SIGNING_KEY", "")
+	switch {
+	case key == "":
+		// Links then only work until the process restarts, on this instance
+		s.Logger.Info("DOWNLOAD_SIGNING_KEY is not set; download links are signed with a random key")
+		options.SigningKey = make([]byte, 32)
+		if _, err := rand.Read(options.SigningKey); err != nil {
+			return options, fmt.Errorf("failed to generate a download signing key: %w", err)
+		}
+	case len(key) < 32:
+		return options, fmt.Errorf("DOWNLOAD_SIGNING_KEY must be at least 32 characters")
+	default:
+		options.SigningKey = []byte(key)
+	}
+	return options, nil
+}
+
+// newBlobStore creates the blob store selected by BLOB_STORE
+func newBlobStore() (storage.BlobStore, error) {
+	switch backend := utils.GetEnv("BLOB_STORE", "file"); backend {
+	case "file":
+		return storage.NewFileStore(utils.GetEnv("BLOB_DIR", "data/blobs")), nil
+	case "s3":
+		bucket := utils.GetEnv("S3_BUCKET", "")
+		if bucket == "" {
+			return nil, fmt.Errorf("S3_BUCKET is required for the s3 blob store")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return storage.NewS3Store(ctx, storage.S3Config{
+			Endpoint:  utils.GetEnv("S3_ENDPOINT", "s3.amazonaws.com"),
+			Bucket:    bucket,
+			Region:    utils.GetEnv("S3_REGION", "us-east-1"),
+			AccessKey: utils.GetEnv("S3_ACCESS_KEY", ""),
+			SecretKey: utils.GetEnv("S3_SECRET_KEY", ""),
+			UseSSL:    utils.GetEnv("S3_USE_SSL", "true") == "true",
+			PathStyle: utils.GetEnv("S3_PATH_STYLE", "false") == "true",
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", backend)
+	}
+}
+
+// mountVersion registers the routes of a version of the REST API under
+// ApiPrefix/<version> and checks that its OpenAPI document describes them
+func (s *Server) mountVersion(version handlers.Version) error {
+	base := s.ApiPrefix + "/" + version.Name
+	document := handlers.APIDocument(base, version)
+	s.documents[version.Name] = document
+
+	// Track every route registered on the version's router
+	router := s.Metrics.Router(s.App.Group(base, handlers.UseVersion(version)))
+	if s.validation != nil {
+		config := *s.validation
+		config.Document = document
+		config.Prefix = base
+		router.Use(middleware.Validation(config))
+	}
 
 	// Health routes
-	s.Router.Get("/health", s.healthHandler.HealthCheck)
+	router.Get("/health", s.healthHandler.HealthCheck)
+
+	// Tenant administration routes
+	admin := router.Group("/admin", middleware.AdminToken(s.adminToken), middleware.Idempotency(s.idempotency))
+	admin.Get("/tenants", s.tenantHandler.GetAllTenants)
+	admin.Post("/tenants", s.tenantHandler.CreateTenant)
+	admin.Get("/tenants/:id", s.tenantHandler.GetTenantById).Name(version.Name + "." + handlers.RouteTenant)
+	admin.Put("/tenants/:id", s.tenantHandler.UpdateTenant)
+	admin.Delete("/tenants/:id", s.tenantHandler.DeleteTenant)
+
+	// Download links carry their tenant, and are checked by the handler
+	router.Get("/downloads/:token", s.fileHandler.Download).Name(version.Name + "." + handlers.RouteDownload)
+
+	// Every route below is scoped to the tenant of the request. Callers are
+	// authenticated before idempotency, which replays stored responses.
+	router.Use(middleware.Tenant(s.tenants), s.rateLimit)
+	router.Use("/books/:id/files/:format/links", middleware.AdminToken(s.adminToken))
+	router.Use("/webhooks", middleware.AdminToken(s.adminToken))
+	router.Use(middleware.Idempotency(s.idempotency))
+	router.Get("/tenant", s.tenantHandler.GetCurrentTenant)
 
 	// Book routes
-	s.Router.Get("/books", s.bookHandler.GetAllBooks)
-	s.Router.Get("/books/:id", s.bookHandler.GetBookById)
-	s.Router.Post("/books/create", s.bookHandler.CreateBook)
-	s.Router.Put("/books/:id", s.bookHandler.UpdateBook)
-	s.Router.Delete("/books/:id", s.bookHandler.DeleteBook)
+	if version.Paginated {
+		router.Get("/books", s.bookHandler.ListBooks)
+	} else {
+		router.Get("/books", s.bookHandler.GetAllBooks)
+	}
+	router.Get("/books/stream", s.streamHandler.StreamBooks)
+	router.Get("/books/:id", s.bookHandler.GetBookById).Name(version.Name + "." + handlers.RouteBook)
+	router.Post("/books", s.bookHandler.CreateBook)
+	if version.Legacy {
+		router.Post("/books/create", middleware.Deprecated(middleware.DeprecationConfig{
+			Since:     time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+			Sunset:    time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+			Successor: base + "/books",
+			Logger:    s.Logger,
+		}), s.bookHandler.CreateBook)
+	}
+	router.Post("/books/batch", s.bookHandler.BatchBooks)
+	router.Put("/books/:id", s.bookHandler.UpdateBook)
+	router.Delete("/books/:id", s.bookHandler.DeleteBook)
+	router.Get("/books/:id/history", s.auditHandler.GetBookHistory)
+	router.Post("/books/:id/revert", s.auditHandler.RevertBook)
+	router.Put("/books/:id/cover", s.coverHandler.UploadCover)
+	router.Get("/books/:id/cover", s.coverHandler.GetCover)
+	router.Delete("/books/:id/cover", s.coverHandler.DeleteCover)
+	router.Get("/books/:id/files", s.fileHandler.ListFiles)
+	router.Put("/books/:id/files/:format", s.fileHandler.UploadFile)
+	router.Delete("/books/:id/files/:format", s.fileHandler.DeleteFile)
+	router.Post("/books/:id/files/:format/links", s.fileHandler.CreateLink)
+
+	// Author routes
+	router.Get("/authors/duplicates", s.authorHandler.GetDuplicates)
+	router.Get("/authors/:id", s.authorHandler.GetAuthorById)
+	router.Post("/authors/:id/merge", s.authorHandler.MergeAuthors)
+
+	// GraphQL
+	router.Post("/graphql", s.graphqlHandler.Query)
+
+	// Audit routes
+	router.Get("/audit", s.auditHandler.GetAuditEntries)
+
+	// Webhook routes, which administer the tenant's subscriptions
+	webhookRoutes := router.Group("/webhooks")
+	webhookRoutes.Get("", s.webhookHandler.GetAllWebhooks)
+	webhookRoutes.Post("", s.webhookHandler.CreateWebhook)
+	webhookRoutes.Get("/:id", s.webhookHandler.GetWebhookById).Name(version.Name + "." + handlers.RouteWebhook)
+	webhookRoutes.Put("/:id", s.webhookHandler.UpdateWebhook)
+	webhookRoutes.Delete("/:id", s.webhookHandler.DeleteWebhook)
+	webhookRoutes.Get("/:id/deliveries", s.webhookHandler.GetDeliveries)
+	webhookRoutes.Post("/:id/deliveries/:deliveryId/replay", s.webhookHandler.ReplayDelivery)
+
+	// The API documentation must describe every route above
+	if err := document.Check(s.App.GetRoutes(true), base); err != nil {
+		return fmt.Errorf("failed to document API %s: %w", version.Name, err)
+	}
+	return nil
+}
+
+func (s *Server) SetupGRPC() error {
+	s.Logger.Info("Setting up the gRPC Server")
+
+	addr := utils.GetEnv("GRPC_ADDR", "127.0.0.1:9091")
+	if addr == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.grpcServer = grpcapi.NewServer(s.bookService, s.authorService, grpcapi.Tenants{
+		Resolver:   s.tenants.Resolver,
+		Repository: s.tenants.Tenants,
+	}, s.actors, s.Logger)
+
+	go func() {
+		s.Logger.Info("Starting gRPC server", "address", addr)
+		if err := s.grpcServer.Serve(listener); err != nil {
+			s.Logger.Error("gRPC server failed", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) SetupMetrics() error {
+	s.Logger.Info("Setting up Metrics")
+
+	addr := utils.GetEnv("METRICS_ADDR", "127.0.0.1:9090")
+	token := utils.GetEnv("METRICS_TOKEN", "")
+
+	// Serve on a separate admin port when one is configured
+	if addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.RequireToken(token, s.Metrics.Handler()))
+
+		go func() {
+			s.Logger.Info("Starting metrics server", "address", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				s.Logger.Error("Metrics server failed", "error", err)
+			}
+		}()
+		return nil
+	}
+
+	// Otherwise share the API port, which requires a token
+	if token == "" {
+		return fmt.Errorf("METRICS_TOKEN is required to serve metrics on the API port")
+	}
+	s.App.Get("/metrics", adaptor.HTTPHandler(metrics.RequireToken(token, s.Metrics.Handler())))
 
 	return nil
 }
@@ -138,3 +838,40 @@ func (s *Server) SetupRoutes() error {
 func (s *Server) Start() error {
 	return s.App.Listen(s.Addr)
 }
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Logger.Info("Shutting down the Server")
+
+	if err := s.App.ShutdownWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	if s.grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			s.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			// Open streams did not finish in time
+			s.grpcServer.Stop()
+		}
+	}
+
+	if s.redis != nil {
+		if err := s.redis.Close(); err != nil {
+			return fmt.Errorf("failed to close redis: %w", err)
+		}
+	}
+
+	if s.shutdownTracing != nil {
+		if err := s.shutdownTracing(ctx); err != nil {
+			return fmt.Errorf("failed to flush traces: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/openapi"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+)
+
+// newTestServer sets up a server with every route, on a SQLite database
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	t.Setenv("METRICS_ADDR", "")
+	t.Setenv("BLOB_DIR", t.TempDir())
+
+	s, err := NewServer("127.0.0.1", "0", "/api", []string{"v1", "v2"}, "v1", logging.Discard())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	s.DB = repotest.SQLite(t)
+	for _, setup := range []func() error{s.SetupCache, s.SetupMiddlewares, s.SetupRoutes} {
+		if err := setup(); err != nil {
+			t.Fatalf("setup error = %v", err)
+		}
+	}
+	return s
+}
+
+func TestRoutesMatchAPIDocuments(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, version := range s.Versions {
+		document, ok := s.documents[version.Name]
+		if !ok {
+			t.Fatalf("no API document for version %s", version.Name)
+		}
+		if err := document.Check(s.App.GetRoutes(true), s.ApiPrefix+"/"+version.Name); err != nil {
+			t.Errorf("version %s: %v", version.Name, err)
+		}
+	}
+}
+
+func TestDocsServesSwaggerUI(t *testing.T) {
+	s := newTestServer(t)
+	api := apitest.New(t, s.App)
+
+	page := api.Get("/docs").ExpectStatus(http.StatusOK)
+	if strings.Contains(string(page.Body), "https://") {
+		t.Errorf("docs page loads assets from another origin:\n%s", page.Body)
+	}
+
+	for _, asset := range []string{"swagger-ui.css", "swagger-ui-bundle.js", "swagger-ui-standalone-preset.js"} {
+		path := "/docs/swagger-ui/" + openapi.SwaggerUIVersion + "/" + asset
+		if !strings.Contains(string(page.Body), path) {
+			t.Errorf("docs page does not load %s", path)
+		}
+		response := api.Get(path).ExpectStatus(http.StatusOK)
+		if len(response.Body) == 0 {
+			t.Errorf("%s is empty", path)
+		}
+	}
+	api.Get("/docs/swagger-ui/" + openapi.SwaggerUIVersion + "/LICENSE").ExpectStatus(http.StatusNotFound)
+	api.Get("/docs/swagger-ui/4.0.0/swagger-ui.css").ExpectStatus(http.StatusNotFound)
+}
+
+func TestRoutesAuthenticateBeforeReplaying(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	s := newTestServer(t)
+	api := apitest.New(t, s.App).WithHeader("Idempotency-Key", "key")
+
+	// Admin requests are stored for the admin only
+	admin := api.WithHeader("Authorization", "Bearer secret")
+	webhook := map[string]any{"url": "https://example.com/hooks", "events": []string{"book.created"}}
+	admin.Post("/api/v1/webhooks", webhook).ExpectStatus(http.StatusCreated)
+	admin.Post("/api/v1/webhooks", webhook).ExpectStatus(http.StatusCreated)
+
+	api.Post("/api/v1/webhooks", webhook).ExpectStatus(http.StatusUnauthorized)
+	api.Post("/api/v1/books/1/files/epub/links", map[string]any{"user_id": "alice", "purchase_id": "p1"}).ExpectStatus(http.StatusUnauthorized)
+}
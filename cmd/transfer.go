@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+)
+
+func newExportCommand(opts *cliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [FILE]",
+		Short: "Export every book, with its author, as JSON or YAML",
+		Long: `Export every book of the tenant, with its author, to FILE or stdout.
+The format is given by the extension of FILE (.json, .yaml or .yml), or
+else by --output, which defaults to JSON for exports.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := opts.output
+			if len(args) == 1 {
+				format = formatOf(args[0], format)
+			}
+			if format == outputTable {
+				format = outputJSON
+			}
+
+			return withCatalog(opts, func(c catalog) error {
+				books, err := c.ListBooks(cmd.Context(), repository.BookSearch{}, 0)
+				if err != nil {
+					return err
+				}
+
+				if len(args) == 0 {
+					return render(cmd.OutOrStdout(), format, books)
+				}
+				file, err := os.Create(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to create export file: %w", err)
+				}
+				if err := render(file, format, books); err != nil {
+					file.Close()
+					return err
+				}
+				if err := file.Close(); err != nil {
+					return fmt.Errorf("failed to write export file: %w", err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d books to %s\n", len(books), args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func newImportCommand(opts *cliOptions) *cobra.Command {
+	var mode string
+	var newIDs bool
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Create the books of a JSON or YAML file, such as an export",
+		Long: `Create the books listed in FILE, or stdin with -, as a JSON or YAML array.
+Books and authors keep the IDs they have in the file, which restores an
+export; --new-ids gives them new IDs instead, e.g. to copy the catalog of a
+tenant into another one of the same database. Books are created in batches
+of up to 1000; with --mode atomic each batch is applied all or nothing.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if mode != string(models.BatchModeAtomic) && mode != string(models.BatchModeBestEffort) {
+				return fmt.Errorf("invalid mode %q: must be atomic or best_effort", mode)
+			}
+			books, err := readBooks(cmd.InOrStdin(), args[0])
+			if err != nil {
+				return err
+			}
+			if newIDs {
+				renumber(books)
+			}
+
+			return withCatalog(opts, func(c catalog) error {
+				var results []models.BatchResult
+				var batchErr error
+				for start := 0; start < len(books) && batchErr == nil; start += service.MaxBatchSize {
+					end := min(start+service.MaxBatchSize, len(books))
+					ops := make([]models.BatchOperation, 0, end-start)
+					for i := start; i < end; i++ {
+						ops = append(ops, models.BatchOperation{Op: models.BatchOpCreate, Book: &books[i]})
+					}
+
+					batch, err := c.BatchBooks(cmd.Context(), models.BatchMode(mode), ops)
+					for i := range batch {
+						batch[i].Index += start
+					}
+					results = append(results, batch...)
+					batchErr = err
+				}
+
+				if err := render(cmd.OutOrStdout(), opts.output, results); err != nil {
+					return err
+				}
+				if batchErr != nil {
+					return batchErr
+				}
+				return errorsOf(results)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&mode, "mode", string(models.BatchModeAtomic), "atomic or best_effort")
+	cmd.Flags().BoolVar(&newIDs, "new-ids", false, "give the books and their authors new IDs")
+	_ = cmd.RegisterFlagCompletionFunc("mode", cobra.FixedCompletions(
+		[]string{string(models.BatchModeAtomic), string(models.BatchModeBestEffort)}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+// readBooks reads the books of a JSON or YAML file, or of stdin for "-"
+func readBooks(stdin io.Reader, path string) ([]models.Book, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	// JSON is YAML; going through JSON applies the field names of the API
+	var decoded any
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to parse import file: %w", err)
+	}
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse import file: %w", err)
+	}
+	var books []models.Book
+	if err := json.Unmarshal(encoded, &books); err != nil {
+		return nil, fmt.Errorf("import file must be an array of books: %w", err)
+	}
+	if len(books) == 0 {
+		return nil, errors.New("import file has no books")
+	}
+	return books, nil
+}
+
+// renumber clears the IDs of books and gives each of their authors a new
+// one, so books sharing an author still do
+func renumber(books []models.Book) {
+	authorIDs := map[string]string{}
+	for i := range books {
+		books[i].ID = ""
+		books[i].AuthorID = ""
+		if id := books[i].Author.ID; id != "" {
+			if _, ok := authorIDs[id]; !ok {
+				authorIDs[id] = uuid.NewString()
+			}
+			books[i].Author.ID = authorIDs[id]
+		}
+	}
+}
+
+// formatOf returns the output format of a file's extension, or fallback
+func formatOf(path string, fallback string) string {
+	switch filepath.Ext(path) {
+	case ".json":
+		return outputJSON
+	case ".yaml", ".yml":
+		return outputYAML
+	}
+	return fallback
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// runCommand runs the bookstore command with args and returns its output
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	root := newRootCommand(nil)
+	root.SetArgs(args)
+	root.SetOut(&out)
+	root.SetErr(&out)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestUsersCreateIssuesTokens(t *testing.T) {
+	t.Setenv("TENANT_JWT_SECRET", "secret")
+
+	output, err := runCommand(t, "users", "create", "alice", "--tenant", "acme", "--expires", "1h", "-o", "json")
+	if err != nil {
+		t.Fatalf("users create error = %v: %s", err, output)
+	}
+	var user userToken
+	if err := json.Unmarshal([]byte(output), &user); err != nil {
+		t.Fatal(err)
+	}
+	if user.User != "alice" || user.Tenant != "acme" || user.ExpiresAt == nil || time.Until(*user.ExpiresAt) > time.Hour {
+		t.Errorf("users create = %+v, want alice of acme for an hour", user)
+	}
+
+	// The token identifies the user and names the tenant, as the server checks them
+	resolver := audit.ActorResolver{Secret: []byte("secret"), Claim: "sub"}
+	if actor := resolver.Resolve(audit.ActorRequest{Authorization: "Bearer " + user.Token}); actor != "alice" {
+		t.Errorf("token resolves to actor %q, want alice", actor)
+	}
+	if id, err := tenant.VerifyClaim(user.Token, []byte("secret"), "tenant_id", time.Now()); err != nil || id != "acme" {
+		t.Errorf("token names tenant %q, %v, want acme", id, err)
+	}
+	if _, err := tenant.VerifyClaim(user.Token, []byte("secret"), "sub", time.Now().Add(2*time.Hour)); err == nil {
+		t.Error("token is still valid after it expired")
+	}
+
+	// Tokens of --expires 0 do not expire
+	output, err = runCommand(t, "users", "create", "bob", "--expires", "0", "-o", "json")
+	if err != nil {
+		t.Fatalf("users create error = %v: %s", err, output)
+	}
+	if err := json.Unmarshal([]byte(output), &user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tenant.VerifyClaim(user.Token, []byte("secret"), "sub", time.Now().AddDate(100, 0, 0)); err != nil || user.ExpiresAt != nil {
+		t.Errorf("token of --expires 0 = %+v, %v, want one that does not expire", user, err)
+	}
+}
+
+func TestUsersCreateErrors(t *testing.T) {
+	t.Setenv("TENANT_JWT_SECRET", "secret")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"empty name", []string{"users", "create", " "}},
+		{"negative expiry", []string{"users", "create", "alice", "--expires", "-1h"}},
+		{"remote", []string{"users", "create", "alice", "--url", "http://localhost:8080/api"}},
+		{"invalid tenant", []string{"users", "create", "alice", "--tenant", "Not A Tenant"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := runCommand(t, tt.args...); err == nil {
+				t.Errorf("users create %v succeeded", tt.args)
+			}
+		})
+	}
+
+	t.Setenv("TENANT_JWT_SECRET", "")
+	if _, err := runCommand(t, "users", "create", "alice"); err == nil {
+		t.Error("users create succeeded without TENANT_JWT_SECRET")
+	}
+}
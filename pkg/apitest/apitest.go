@@ -0,0 +1,170 @@
+// Package apitest sends requests to a fiber.App in memory, through
+// fiber.App.Test, for handler tests
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// timeout is how long a request may take, in milliseconds
+const timeout = 5000
+
+// Client sends requests to an app, failing its test when one cannot be sent
+type Client struct {
+	t       *testing.T
+	app     *fiber.App
+	headers map[string]string
+}
+
+// New creates a Client of app
+func New(t *testing.T, app *fiber.App) *Client {
+	return &Client{t: t, app: app, headers: map[string]string{}}
+}
+
+// WithHeader returns a copy of the client that sets a header on every request,
+// e.g. the tenant or the X-Actor
+func (c *Client) WithHeader(key, value string) *Client {
+	headers := maps.Clone(c.headers)
+	headers[key] = value
+	return &Client{t: c.t, app: c.app, headers: headers}
+}
+
+// Do sends a request whose body, unless nil, is encoded as JSON
+func (c *Client) Do(method, path string, body any) *Response {
+	c.t.Helper()
+
+	if body == nil {
+		return c.send(method, path, "", nil)
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		c.t.Fatalf("failed to encode %s %s body: %v", method, path, err)
+	}
+	return c.send(method, path, fiber.MIMEApplicationJSON, bytes.NewReader(encoded))
+}
+
+// Upload sends a request whose body is a multipart form holding content as
+// the file of field, named filename
+func (c *Client) Upload(method, path, field, filename string, content []byte) *Response {
+	c.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, filename)
+	if err == nil {
+		_, err = part.Write(content)
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		c.t.Fatalf("failed to encode %s %s form: %v", method, path, err)
+	}
+	return c.send(method, path, form.FormDataContentType(), &body)
+}
+
+// Send sends a request whose body is content as is, e.g. an ebook file
+func (c *Client) Send(method, path, contentType string, content []byte) *Response {
+	c.t.Helper()
+	return c.send(method, path, contentType, bytes.NewReader(content))
+}
+
+// send sends a request and reads its response
+func (c *Client) send(method, path, contentType string, body io.Reader) *Response {
+	c.t.Helper()
+
+	req := httptest.NewRequest(method, path, body)
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.app.Test(req, timeout)
+	if err != nil {
+		c.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("failed to read %s %s response: %v", method, path, err)
+	}
+	return &Response{t: c.t, request: method + " " + path, Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// Get sends a GET request
+func (c *Client) Get(path string) *Response {
+	c.t.Helper()
+	return c.Do(http.MethodGet, path, nil)
+}
+
+// Post sends a POST request with a JSON body
+func (c *Client) Post(path string, body any) *Response {
+	c.t.Helper()
+	return c.Do(http.MethodPost, path, body)
+}
+
+// Put sends a PUT request with a JSON body
+func (c *Client) Put(path string, body any) *Response {
+	c.t.Helper()
+	return c.Do(http.MethodPut, path, body)
+}
+
+// Delete sends a DELETE request
+func (c *Client) Delete(path string) *Response {
+	c.t.Helper()
+	return c.Do(http.MethodDelete, path, nil)
+}
+
+// Response is a response read in full
+type Response struct {
+	t       *testing.T
+	request string
+
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// ExpectStatus fails the test unless the response has the given status
+func (r *Response) ExpectStatus(status int) *Response {
+	r.t.Helper()
+	if r.Status != status {
+		r.t.Fatalf("%s: got status %d, want %d; body: %s", r.request, r.Status, status, r.Body)
+	}
+	return r
+}
+
+// Decode decodes the JSON body into v
+func (r *Response) Decode(v any) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("%s: failed to decode body %s: %v", r.request, r.Body, err)
+	}
+}
+
+// Data decodes the data of the response envelope, in either version, into v
+func (r *Response) Data(v any) {
+	r.t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	r.Decode(&envelope)
+	if len(envelope.Data) == 0 {
+		r.t.Fatalf("%s: response has no data; body: %s", r.request, r.Body)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		r.t.Fatalf("%s: failed to decode data %s: %v", r.request, envelope.Data, err)
+	}
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionRevert = "revert"
+	// ActionMerge removes an author merged into another one
+	ActionMerge = "merge"
+)
+
+// Entity types recorded in the audit log
+const (
+	EntityBook   = "book"
+	EntityAuthor = "author"
+)
+
+// AnonymousActor is recorded when a change is made without a known caller
+const AnonymousActor = "anonymous"
+
+// Actor identifies who made a change and in which request
+type Actor struct {
+	ID        string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or the anonymous actor
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	if actor.ID == "" {
+		actor.ID = AnonymousActor
+	}
+	return actor
+}
+
+// IdentifiedActor returns the ID of the actor stored in ctx, and false when
+// the caller was not identified
+func IdentifiedActor(ctx context.Context) (string, bool) {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor.ID, actor.ID != ""
+}
+
+// ignoredFields are bookkeeping fields that are not reported in diffs
+var ignoredFields = map[string]bool{
+	"author":     true,
+	"books":      true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+}
+
+// Diff compares the JSON representation of two snapshots and returns the
+// top-level fields that differ. A nil before or after (creation or deletion)
+// reports every field as changed.
+func Diff(before any, after any) (map[string]events.FieldChange, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]events.FieldChange{}
+	for key, value := range afterFields {
+		if ignoredFields[key] {
+			continue
+		}
+		if old, ok := beforeFields[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = events.FieldChange{Old: beforeFields[key], New: value}
+		}
+	}
+	for key, old := range beforeFields {
+		if ignoredFields[key] {
+			continue
+		}
+		if _, ok := afterFields[key]; !ok {
+			changes[key] = events.FieldChange{Old: old, New: nil}
+		}
+	}
+
+	return changes, nil
+}
+
+// toFields decodes a value's JSON object form into a map
+func toFields(v any) (map[string]any, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return map[string]any{}, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,141 @@
+package audit_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+func TestDiff(t *testing.T) {
+	before := &models.Book{
+		ID:        "dune",
+		Name:      "Dune",
+		AuthorID:  "herbert",
+		Author:    models.Author{ID: "herbert", Name: "Frank Herbert"},
+		Publisher: "Chilton",
+		Price:     10,
+		UpdatedAt: time.Now(),
+	}
+	after := *before
+	after.Price = 12.5
+	after.Publisher = "Ace"
+	after.Author.Name = "F. Herbert"
+	after.UpdatedAt = before.UpdatedAt.Add(time.Hour)
+
+	changes, err := audit.Diff(before, &after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The author and timestamps are bookkeeping and not reported
+	want := map[string]events.FieldChange{
+		"price":     {Old: 10.0, New: 12.5},
+		"publisher": {Old: "Chilton", New: "Ace"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff() = %v, want %v", changes, want)
+	}
+
+	if changes, err := audit.Diff(before, before); err != nil || len(changes) != 0 {
+		t.Errorf("Diff() of equal snapshots = %v, %v, want no changes", changes, err)
+	}
+
+	// Creations and deletions report every field
+	created, err := audit.Diff(nil, before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := created["name"]; change.Old != nil || change.New != "Dune" {
+		t.Errorf("Diff() of a creation reports name as %v, want nil to Dune", change)
+	}
+	var none *models.Book
+	deleted, err := audit.Diff(before, none)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := deleted["price"]; change.Old != 10.0 || change.New != nil {
+		t.Errorf("Diff() of a deletion reports price as %v, want 10 to nil", change)
+	}
+	if len(created) != len(deleted) || len(created) == 0 {
+		t.Errorf("Diff() reports %d fields for a creation and %d for a deletion, want all of them", len(created), len(deleted))
+	}
+	for _, ignored := range []string{"author", "created_at", "updated_at", "deleted_at"} {
+		if _, ok := created[ignored]; ok {
+			t.Errorf("Diff() reports %s", ignored)
+		}
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	if actor := audit.ActorFromContext(t.Context()); actor.ID != audit.AnonymousActor {
+		t.Errorf("ActorFromContext() without an actor = %+v, want anonymous", actor)
+	}
+	if id, ok := audit.IdentifiedActor(t.Context()); ok || id != "" {
+		t.Errorf("IdentifiedActor() without an actor = %q, %v", id, ok)
+	}
+
+	ctx := audit.WithActor(t.Context(), audit.Actor{ID: "alice", RequestID: "req-1"})
+	if actor := audit.ActorFromContext(ctx); actor.ID != "alice" || actor.RequestID != "req-1" {
+		t.Errorf("ActorFromContext() = %+v, want alice in req-1", actor)
+	}
+	if id, ok := audit.IdentifiedActor(ctx); !ok || id != "alice" {
+		t.Errorf("IdentifiedActor() = %q, %v, want alice", id, ok)
+	}
+}
+
+// signToken returns an HS256 JWT of claims signed with secret
+func signToken(secret string, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestActorResolver(t *testing.T) {
+	proxies, err := audit.ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := audit.ActorResolver{
+		Secret:         []byte("secret"),
+		Claim:          "sub",
+		AdminToken:     "admin-token",
+		TrustedProxies: proxies,
+	}
+	proxy := netip.MustParseAddr("10.1.2.3")
+	client := netip.MustParseAddr("203.0.113.7")
+
+	tests := []struct {
+		name string
+		req  audit.ActorRequest
+		want string
+	}{
+		{"admin token", audit.ActorRequest{Authorization: "Bearer admin-token"}, audit.AdminActor},
+		{"signed token", audit.ActorRequest{Authorization: "Bearer " + signToken("secret", `{"sub":"alice"}`)}, "alice"},
+		{"token over the header", audit.ActorRequest{Authorization: "Bearer " + signToken("secret", `{"sub":"alice"}`), Header: "bob", Peer: proxy}, "alice"},
+		{"token of another secret", audit.ActorRequest{Authorization: "Bearer " + signToken("other", `{"sub":"alice"}`)}, ""},
+		{"expired token", audit.ActorRequest{Authorization: "Bearer " + signToken("secret", `{"sub":"alice","exp":1}`)}, ""},
+		{"header from a trusted proxy", audit.ActorRequest{Header: " bob ", Peer: proxy}, "bob"},
+		{"header from a mapped trusted proxy", audit.ActorRequest{Header: "bob", Peer: netip.MustParseAddr("::ffff:192.168.1.1")}, "bob"},
+		{"header from a client", audit.ActorRequest{Header: "bob", Peer: client}, ""},
+		{"invalid token and header from a client", audit.ActorRequest{Authorization: "Bearer nope", Header: "bob", Peer: client}, ""},
+		{"nothing", audit.ActorRequest{}, ""},
+	}
+	for _, tt := range tests {
+		if got := resolver.Resolve(tt.req); got != tt.want {
+			t.Errorf("%s: Resolve() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := audit.ParseTrustedProxies("10.0.0.0/8,nope"); err == nil {
+		t.Error("ParseTrustedProxies() accepted an invalid entry")
+	}
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// AdminActor is recorded for changes made with the administration token
+const AdminActor = "admin"
+
+// ActorResolver identifies the caller of a request from its verified
+// credentials. The actor header a client sends is only believed from a
+// trusted proxy, which authenticates callers itself.
+type ActorResolver struct {
+	// Secret verifies HS256 bearer tokens, whose Claim names the caller;
+	// nil to ignore them
+	Secret []byte
+	Claim  string
+	// AdminToken is the bearer token of administrators; empty for none
+	AdminToken string
+	// TrustedProxies are the networks of the proxies whose actor header is
+	// believed; empty to never believe it
+	TrustedProxies []netip.Prefix
+}
+
+// ActorRequest is the part of a request an ActorResolver reads
+type ActorRequest struct {
+	// Authorization is the Authorization header
+	Authorization string
+	// Header is the actor header
+	Header string
+	// Peer is the address the request came from
+	Peer netip.Addr
+}
+
+// Resolve returns the caller of req, or "" when it is not known
+func (r ActorResolver) Resolve(req ActorRequest) string {
+	if token, ok := strings.CutPrefix(req.Authorization, "Bearer "); ok {
+		if r.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(r.AdminToken)) == 1 {
+			return AdminActor
+		}
+		if len(r.Secret) > 0 {
+			if id, err := tenant.VerifyClaim(token, r.Secret, r.Claim, time.Now()); err == nil && id != "" {
+				return id
+			}
+		}
+	}
+
+	if req.Header != "" && r.trusts(req.Peer) {
+		return strings.TrimSpace(req.Header)
+	}
+	return ""
+}
+
+// trusts reports whether addr is one of the trusted proxies
+func (r ActorResolver) trusts(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range r.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and
+// CIDR networks
+func ParseTrustedProxies(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(field); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", field, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
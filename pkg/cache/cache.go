@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Store is a byte-oriented cache. Every entry can carry tags, and
+// invalidating a tag removes every entry stored with it.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+// Recorder is told about the outcome of every cache lookup
+type Recorder interface {
+	CacheHit(cache string)
+	CacheMiss(cache string)
+	CacheError(cache string)
+}
+
+// LoadFunc loads a value on a cache miss and returns the tags to store it with
+type LoadFunc func(ctx context.Context) (value []byte, tags []string, err error)
+
+// Cache reads through a Store. Concurrent misses of the same key share a
+// single load, and a load that raced with an invalidation through this Cache
+// is not stored, so stale values cannot outlive the write that replaced them.
+// The guard is in-process: with a shared Store, a load that races with an
+// invalidation made by another instance may store a stale value, which then
+// lives until it expires.
+//
+// Loads run detached from the caller's cancellation, since their result is
+// shared with every caller waiting for the same key; a canceled caller stops
+// waiting for it.
+//
+// Cache failures are logged and treated as misses; they never fail a read.
+type Cache struct {
+	name     string
+	store    Store
+	ttl      time.Duration
+	logger   *slog.Logger
+	recorder Recorder
+
+	group singleflight.Group
+	// mu orders stores of loaded values against invalidations
+	mu         sync.RWMutex
+	generation atomic.Uint64
+}
+
+// New creates a Cache named name (used in metrics and logs) keeping entries
+// in store for ttl. recorder may be nil.
+func New(name string, store Store, ttl time.Duration, logger *slog.Logger, recorder Recorder) *Cache {
+	return &Cache{
+		name:     name,
+		store:    store,
+		ttl:      ttl,
+		logger:   logger,
+		recorder: recorder,
+	}
+}
+
+// GetOrLoad returns the value cached under key, calling load on a miss
+func (c *Cache) GetOrLoad(ctx context.Context, key string, load LoadFunc) ([]byte, error) {
+	value, ok, err := c.store.Get(ctx, key)
+	switch {
+	case err != nil:
+		c.record(Recorder.CacheError)
+		c.logger.WarnContext(ctx, "Cache lookup failed", "cache", c.name, "key", key, "error", err)
+	case ok:
+		c.record(Recorder.CacheHit)
+		return value, nil
+	default:
+		c.record(Recorder.CacheMiss)
+	}
+
+	// Loads started before an invalidation are not shared with later callers
+	generation := c.generation.Load()
+	flightKey := key + "@" + strconv.FormatUint(generation, 10)
+
+	// The load outlives a canceled caller, as later callers share it
+	detached := context.WithoutCancel(ctx)
+	results := c.group.DoChan(flightKey, func() (any, error) {
+		value, tags, err := load(detached)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if c.generation.Load() == generation {
+			if err := c.store.Set(detached, key, value, c.ttl, tags...); err != nil {
+				c.record(Recorder.CacheError)
+				c.logger.WarnContext(ctx, "Cache store failed", "cache", c.name, "key", key, "error", err)
+			}
+		}
+		return value, nil
+	})
+
+	var result singleflight.Result
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Val.([]byte), nil
+}
+
+// Invalidate removes every entry carrying one of tags
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	c.generation.Add(1)
+	c.mu.Unlock()
+
+	if err := c.store.InvalidateTags(ctx, tags...); err != nil {
+		c.record(Recorder.CacheError)
+		c.logger.ErrorContext(ctx, "Cache invalidation failed", "cache", c.name, "tags", tags, "error", err)
+	}
+}
+
+// record reports a lookup outcome to the recorder, if any
+func (c *Cache) record(outcome func(Recorder, string)) {
+	if c.recorder != nil {
+		outcome(c.recorder, c.name)
+	}
+}
+
+// Tag builds a tag from a kind and an ID, e.g. Tag("book", id)
+func Tag(kind string, id string) string {
+	return fmt.Sprintf("%s:%s", kind, id)
+}
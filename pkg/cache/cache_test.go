@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+)
+
+func TestGetOrLoadOutlivesCanceledCaller(t *testing.T) {
+	store := NewLRU(10)
+	c := New("test", store, time.Minute, logging.Discard(), nil)
+
+	release := make(chan struct{})
+	loaded := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "key", func(ctx context.Context) ([]byte, []string, error) {
+			<-release
+			loaded <- ctx.Err()
+			return []byte("value"), nil, nil
+		})
+		done <- err
+	}()
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetOrLoad() of a canceled caller = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	if err := <-loaded; err != nil {
+		t.Fatalf("load saw a canceled context: %v", err)
+	}
+
+	// The load goes on and fills the cache for later callers
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, ok, _ := store.Get(context.Background(), "key")
+		if ok {
+			if string(value) != "value" {
+				t.Fatalf("cached value = %q, want %q", value, "value")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("load was not stored after its caller was canceled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
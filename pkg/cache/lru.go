@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process Store holding at most a fixed number of entries.
+// The least recently used entry is evicted first; expired entries are
+// dropped when they are read.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	tags     map[string]map[string]struct{}
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	tags      []string
+}
+
+// NewLRU creates an LRU store holding up to capacity entries
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+		tags:     map[string]map[string]struct{}{},
+		now:      time.Now,
+	}
+}
+
+// Get implements Store
+func (l *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !l.now().Before(entry.expiresAt) {
+		l.remove(element)
+		return nil, false, nil
+	}
+
+	l.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set implements Store
+func (l *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		l.remove(element)
+	}
+
+	entry := &lruEntry{
+		key:       key,
+		value:     value,
+		expiresAt: l.now().Add(ttl),
+		tags:      tags,
+	}
+	l.entries[key] = l.order.PushFront(entry)
+	for _, tag := range tags {
+		if l.tags[tag] == nil {
+			l.tags[tag] = map[string]struct{}{}
+		}
+		l.tags[tag][key] = struct{}{}
+	}
+
+	for l.capacity > 0 && l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+
+	return nil
+}
+
+// InvalidateTags implements Store
+func (l *LRU) InvalidateTags(ctx context.Context, tags ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range l.tags[tag] {
+			if element, ok := l.entries[key]; ok {
+				l.remove(element)
+			}
+		}
+		delete(l.tags, tag)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet dropped
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// remove drops an entry and its tag references; l.mu must be held
+func (l *LRU) remove(element *list.Element) {
+	entry := l.order.Remove(element).(*lruEntry)
+	delete(l.entries, entry.key)
+	for _, tag := range entry.tags {
+		delete(l.tags[tag], entry.key)
+		if len(l.tags[tag]) == 0 {
+			delete(l.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store shared by every instance of the service.
+// Each tag is a Redis set holding the keys stored with it.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore namespacing its keys with prefix
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Get implements Store
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	return value, true, nil
+}
+
+// Set implements Store. Tag sets expire together with the newest entry
+// added to them.
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.prefix+key, value, ttl)
+		for _, tag := range tags {
+			pipe.SAdd(ctx, s.tagKey(tag), key)
+			pipe.Expire(ctx, s.tagKey(tag), ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// InvalidateTags implements Store
+func (s *RedisStore) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := s.client.SMembers(ctx, s.tagKey(tag)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read cache tag: %w", err)
+		}
+
+		toDelete := []string{s.tagKey(tag)}
+		for _, key := range keys {
+			toDelete = append(toDelete, s.prefix+key)
+		}
+		if err := s.client.Del(ctx, toDelete...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cache tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// tagKey is the Redis key of the set holding the keys stored with tag
+func (s *RedisStore) tagKey(tag string) string {
+	return s.prefix + "tag:" + tag
+}
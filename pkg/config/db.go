@@ -1,18 +1,98 @@
 package config
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/similarity"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
 	"gorm.io/gorm"
 )
 
+// tenantModels are the models owned by a tenant
+var tenantModels = []any{
+	&models.Book{},
+	&models.Author{},
+	&models.AuthorRedirect{},
+	&models.OutboxEvent{},
+	&models.WebhookSubscription{},
+	&models.AuditEntry{},
+}
+
 func MigrateDB(db *gorm.DB) error {
+	// Migrations span every tenant
+	db = db.WithContext(tenant.WithAllTenants(context.Background()))
+
 	err := db.AutoMigrate(
+		&models.Tenant{},
 		&models.Book{},
 		&models.Author{},
+		&models.AuthorRedirect{},
+		&models.OutboxEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.AuditEntry{},
+		&models.IdempotencyRecord{},
+		&models.BookCover{},
+		&models.BookFile{},
+		&models.FileDownload{},
+		&models.FileDownloadLink{},
 	)
 	if err != nil {
 		return err
 	}
 
+	if err := backfillTenants(db); err != nil {
+		return err
+	}
+
+	return backfillNormalizedNames(db)
+}
+
+// backfillTenants gives the rows created before tenancy to the default
+// tenant, and creates it on the first migration
+func backfillTenants(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.Tenant{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count tenants: %w", err)
+	}
+	if count == 0 {
+		if err := db.Create(&models.Tenant{ID: tenant.DefaultID, Name: "Default", Currency: "USD", Active: true}).Error; err != nil {
+			return fmt.Errorf("failed to create the default tenant: %w", err)
+		}
+	}
+
+	for _, model := range tenantModels {
+		if err := db.Model(model).Where("tenant_id = ''").UpdateColumn("tenant_id", tenant.DefaultID).Error; err != nil {
+			return fmt.Errorf("failed to assign rows to the default tenant: %w", err)
+		}
+	}
+
+	// Deliveries belong to the tenant of their subscription
+	subscriptions := db.Model(&models.WebhookSubscription{}).
+		Select("tenant_id").
+		Where("webhook_subscriptions.id = webhook_deliveries.subscription_id")
+	if err := db.Model(&models.WebhookDelivery{}).Where("tenant_id = ''").UpdateColumn("tenant_id", subscriptions).Error; err != nil {
+		return fmt.Errorf("failed to assign webhook deliveries to their tenant: %w", err)
+	}
+	return nil
+}
+
+// backfillNormalizedNames sets the normalized name of authors created before it existed
+func backfillNormalizedNames(db *gorm.DB) error {
+	var authors []models.Author
+	result := db.Where("normalized_name = '' OR normalized_name IS NULL").
+		FindInBatches(&authors, 500, func(tx *gorm.DB, batch int) error {
+			for _, author := range authors {
+				if err := tx.Model(&author).UpdateColumn("normalized_name", similarity.Normalize(author.Name)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to normalize author names: %w", result.Error)
+	}
 	return nil
 }
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/utils"
+)
+
+// Config describes the primary database, its read replicas and their pools
+type Config struct {
+	User     string
+	Password string
+	Name     string
+
+	// Primary is the address (host:port) of the primary, which takes every
+	// write and the reads not sent to a replica
+	Primary string
+	// Replicas are the addresses of the read replicas
+	Replicas []string
+
+	// Pool configures the pool of the primary and of each replica
+	Pool PoolOptions
+	// DialTimeout bounds each attempt to open a connection
+	DialTimeout time.Duration
+	// RetryTimeout is how long startup keeps retrying to connect
+	RetryTimeout time.Duration
+	// QueryTimeout bounds each statement; 0 means no timeout
+	QueryTimeout time.Duration
+}
+
+// ConfigFromEnv reads the database configuration from DB_* environment variables
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		User:     utils.GetEnv("DB_USER", "demo"),
+		Password: utils.GetEnv("DB_PASS", "password"),
+		Name:     utils.GetEnv("DB_NAME", "book_store"),
+		Primary:  utils.GetEnv("DB_ADDR", "127.0.0.1") + ":" + utils.GetEnv("DB_PORT", "3306"),
+	}
+
+	for _, addr := range strings.Split(utils.GetEnv("DB_REPLICAS", ""), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.Replicas = append(cfg.Replicas, addr)
+		}
+	}
+
+	var err error
+	if cfg.Pool.MaxOpenConns, err = envInt("DB_MAX_OPEN_CONNS", "25"); err != nil {
+		return Config{}, err
+	}
+	if cfg.Pool.MaxIdleConns, err = envInt("DB_MAX_IDLE_CONNS", "10"); err != nil {
+		return Config{}, err
+	}
+	if cfg.Pool.ConnMaxLifetime, err = envDuration("DB_CONN_MAX_LIFETIME", "30m"); err != nil {
+		return Config{}, err
+	}
+	if cfg.Pool.ConnMaxIdleTime, err = envDuration("DB_CONN_MAX_IDLE_TIME", "5m"); err != nil {
+		return Config{}, err
+	}
+	if cfg.DialTimeout, err = envDuration("DB_DIAL_TIMEOUT", "5s"); err != nil {
+		return Config{}, err
+	}
+	if cfg.RetryTimeout, err = envDuration("DB_CONNECT_RETRY_TIMEOUT", "1m"); err != nil {
+		return Config{}, err
+	}
+	if cfg.QueryTimeout, err = envDuration("DB_QUERY_TIMEOUT", "10s"); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// DSN returns the MySQL data source name of the database at addr
+func (c Config) DSN(addr string) string {
+	dsn := fmt.Sprintf(
+		"%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		c.User,
+		c.Password,
+		addr,
+		c.Name,
+	)
+	if c.DialTimeout > 0 {
+		dsn += "&timeout=" + c.DialTimeout.String()
+	}
+	return dsn
+}
+
+// envInt reads a non-negative integer environment variable
+func envInt(key, defaultValue string) (int, error) {
+	n, err := strconv.Atoi(utils.GetEnv(key, defaultValue))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: must be a non-negative integer", key)
+	}
+	return n, nil
+}
+
+// envDuration reads a non-negative duration environment variable
+func envDuration(key, defaultValue string) (time.Duration, error) {
+	d, err := time.ParseDuration(utils.GetEnv(key, defaultValue))
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s: must be a non-negative duration such as 30s", key)
+	}
+	return d, nil
+}
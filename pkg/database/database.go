@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	// Registers the "mysql" driver used by replicas
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Backoff between connection attempts
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// PoolOptions configures a connection pool
+type PoolOptions struct {
+	// MaxOpenConns caps the open connections; 0 means unlimited
+	MaxOpenConns int
+	// MaxIdleConns caps the idle connections kept for reuse
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections older than this; 0 keeps them
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime closes connections idle for longer than this; 0 keeps them
+	ConnMaxIdleTime time.Duration
+}
+
+// Apply configures db with the options
+func (o PoolOptions) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(o.MaxOpenConns)
+	db.SetMaxIdleConns(o.MaxIdleConns)
+	db.SetConnMaxLifetime(o.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(o.ConnMaxIdleTime)
+}
+
+// Connect calls connect until it succeeds, waiting with exponential backoff
+// between attempts, e.g. while the database container is still starting.
+// It gives up with the last error once timeout has elapsed; a zero timeout
+// makes a single attempt.
+func Connect(ctx context.Context, logger *slog.Logger, name string, timeout time.Duration, connect func(ctx context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+	backoff := initialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			return nil
+		}
+
+		wait := min(backoff, time.Until(deadline))
+		if wait <= 0 {
+			return fmt.Errorf("failed to connect to %s after %d attempts: %w", name, attempt, err)
+		}
+		logger.WarnContext(ctx, "Database is not ready, retrying", "database", name, "attempt", attempt, "retry_in", wait, "error", err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to connect to %s: %w", name, ctx.Err())
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// OpenReplica opens a connection pool to a MySQL read replica and checks
+// that it is reachable
+func OpenReplica(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Statement settings of the router
+const (
+	// replicaKey marks the queries allowed on a replica
+	replicaKey = "database:replica"
+	// primaryKey holds the pool a query was switched from
+	primaryKey = "database:primary"
+)
+
+// sessionKey is the context key of the request's session
+type sessionKey struct{}
+
+// session records whether a request has written to the primary
+type session struct {
+	written atomic.Bool
+}
+
+// WithSession starts a session in ctx: once a statement run with the
+// returned context writes, its later reads go to the primary too, so that
+// the request reads its own writes
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// WithPrimary returns a copy of ctx whose reads all go to the primary, as
+// if its session had written. Reads that fill a cache use it, since a row
+// read from a lagging replica would be served until the entry expires.
+func WithPrimary(ctx context.Context) context.Context {
+	s := &session{}
+	s.written.Store(true)
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// Replica is a GORM scope letting a query read from a replica, e.g.
+// db.Scopes(database.Replica).Find(&books). Queries inside transactions, or
+// made after their session wrote, still read from the primary.
+func Replica(db *gorm.DB) *gorm.DB {
+	return db.Set(replicaKey, true)
+}
+
+// ReplicaRouter sends the queries marked with the Replica scope to the read
+// replicas, in turn. Every other statement uses the primary.
+type ReplicaRouter struct {
+	replicas []*sql.DB
+	next     atomic.Uint64
+}
+
+// NewReplicaRouter creates a ReplicaRouter; without replicas every
+// statement uses the primary
+func NewReplicaRouter(replicas ...*sql.DB) *ReplicaRouter {
+	return &ReplicaRouter{replicas: replicas}
+}
+
+// Name implements gorm.Plugin
+func (r *ReplicaRouter) Name() string {
+	return "replicas"
+}
+
+// Initialize implements gorm.Plugin by registering the routing callbacks
+func (r *ReplicaRouter) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	if err := cb.Query().Before("gorm:query").Register("replicas:read", r.read); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("replicas:restore", r.restore); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("replicas:read", r.read); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("replicas:restore", r.restore); err != nil {
+		return err
+	}
+	if err := cb.Create().Before("gorm:create").Register("replicas:write", r.write); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("replicas:write", r.write); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("replicas:write", r.write); err != nil {
+		return err
+	}
+	return cb.Raw().Before("gorm:raw").Register("replicas:write", r.write)
+}
+
+// read switches a query to a replica when it is allowed to use one
+func (r *ReplicaRouter) read(db *gorm.DB) {
+	if len(r.replicas) == 0 || db.Error != nil {
+		return
+	}
+	if _, ok := db.Get(replicaKey); !ok {
+		return
+	}
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if s, ok := db.Statement.Context.Value(sessionKey{}).(*session); ok && s.written.Load() {
+		return
+	}
+
+	db.InstanceSet(primaryKey, db.Statement.ConnPool)
+	db.Statement.ConnPool = r.replicas[r.next.Add(1)%uint64(len(r.replicas))]
+}
+
+// restore switches the statement back to the primary, as chained statements
+// can reuse it
+func (r *ReplicaRouter) restore(db *gorm.DB) {
+	if value, ok := db.InstanceGet(primaryKey); ok {
+		db.Statement.ConnPool = value.(gorm.ConnPool)
+	}
+}
+
+// write records that the statement's session wrote to the primary
+func (r *ReplicaRouter) write(db *gorm.DB) {
+	if s, ok := db.Statement.Context.Value(sessionKey{}).(*session); ok {
+		s.written.Store(true)
+	}
+}
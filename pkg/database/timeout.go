@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// timeoutKey is the statement instance setting holding the timeout's state
+const timeoutKey = "database:timeout"
+
+// QueryTimeout bounds how long each statement may run. Statements whose
+// context has an earlier deadline keep it. Row statements (Row, Rows and
+// Scan) are not bounded, as their rows are read after the callbacks ran.
+type QueryTimeout struct {
+	timeout time.Duration
+}
+
+// NewQueryTimeout creates a QueryTimeout; a zero timeout disables it
+func NewQueryTimeout(timeout time.Duration) *QueryTimeout {
+	return &QueryTimeout{timeout: timeout}
+}
+
+// Name implements gorm.Plugin
+func (p *QueryTimeout) Name() string {
+	return "timeout"
+}
+
+// Initialize implements gorm.Plugin by registering before/after callbacks
+func (p *QueryTimeout) Initialize(db *gorm.DB) error {
+	if p.timeout <= 0 {
+		return nil
+	}
+	cb := db.Callback()
+
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, r := range register {
+		if err := r.before("timeout:before_"+r.operation, p.before); err != nil {
+			return err
+		}
+		if err := r.after("timeout:after_"+r.operation, p.after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timeoutState is what after needs to undo before
+type timeoutState struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// before sets the statement's deadline
+func (p *QueryTimeout) before(db *gorm.DB) {
+	parent := db.Statement.Context
+	ctx, cancel := context.WithTimeout(parent, p.timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(timeoutKey, timeoutState{parent: parent, cancel: cancel})
+}
+
+// after releases the statement's timer and restores its context, as chained
+// statements can reuse it
+func (p *QueryTimeout) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(timeoutKey)
+	if !ok {
+		return
+	}
+	if state, ok := value.(timeoutState); ok {
+		state.cancel()
+		db.Statement.Context = state.parent
+	}
+}
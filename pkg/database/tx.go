@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Retries of transactions that lost a deadlock or a lock wait
+const (
+	maxTxAttempts  = 3
+	initialTxRetry = 20 * time.Millisecond
+)
+
+// MySQL errors after which a transaction can simply be run again
+const (
+	errLockWaitTimeout    = 1205
+	errLockDeadlock       = 1213
+	sqlStateSerialization = "40001"
+)
+
+// txKey is the context key of the current transaction
+type txKey struct{}
+
+// txState is a transaction (or savepoint) carried by a context
+type txState struct {
+	db *gorm.DB
+	// hooks run once the outermost transaction commits
+	hooks []func()
+	// parent is the transaction a savepoint was taken in
+	parent *txState
+	// done is set once the transaction has ended. Its context, e.g. in an
+	// AfterCommit hook, then works in the parent, or outside of any.
+	done bool
+}
+
+// current returns the transaction of ctx that is still running, if any
+func current(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKey{}).(*txState)
+	for state != nil && state.done {
+		state = state.parent
+	}
+	return state
+}
+
+// TxManager runs units of work spanning several repositories in a single
+// transaction carried by their context. Repositories join it by getting
+// their connection with FromContext.
+type TxManager struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewTxManager creates a TxManager for db
+func NewTxManager(db *gorm.DB, logger *slog.Logger) *TxManager {
+	return &TxManager{db: db, logger: logger}
+}
+
+// WithinTransaction runs fn in a transaction, committed when fn returns nil
+// and rolled back when it returns an error or panics (the panic is returned
+// as an error). Inside another transaction it runs in a savepoint, so that
+// its failure leaves the outer transaction usable. An outermost transaction
+// that loses a deadlock or lock wait is run again, up to 3 times, so fn must
+// not have effects outside the database other than through AfterCommit.
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if parent := current(ctx); parent != nil {
+		state := &txState{parent: parent}
+		err := parent.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			state.db = tx
+			return m.run(ctx, state, fn)
+		})
+		state.done = true
+		if err == nil {
+			parent.hooks = append(parent.hooks, state.hooks...)
+		}
+		return err
+	}
+
+	backoff := initialTxRetry
+	for attempt := 1; ; attempt++ {
+		state := &txState{}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			state.db = tx
+			return m.run(ctx, state, fn)
+		})
+		state.done = true
+		if err == nil {
+			for _, hook := range state.hooks {
+				hook()
+			}
+			return nil
+		}
+		if attempt >= maxTxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		// Jitter keeps the transactions that deadlocked from colliding again
+		wait := backoff/2 + rand.N(backoff)
+		logging.FromContext(ctx, m.logger).WarnContext(ctx, "Transaction failed, retrying", "attempt", attempt, "retry_in", wait, "error", err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// AfterCommit runs fn once the transaction of ctx has committed; see the
+// package function
+func (m *TxManager) AfterCommit(ctx context.Context, fn func()) {
+	AfterCommit(ctx, fn)
+}
+
+// run calls fn with the transaction in its context, turning a panic into an error
+func (m *TxManager) run(ctx context.Context, state *txState, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx, m.logger).ErrorContext(ctx, "Panic in transaction, rolling back", "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic in transaction: %v", r)
+		}
+	}()
+	return fn(context.WithValue(ctx, txKey{}, state))
+}
+
+// FromContext returns the transaction of ctx, or db outside of one, bound to ctx
+func FromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state := current(ctx); state != nil {
+		return state.db.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTransaction reports whether ctx carries a transaction
+func InTransaction(ctx context.Context) bool {
+	return current(ctx) != nil
+}
+
+// AfterCommit runs fn once the transaction of ctx has committed, or right
+// away outside of a transaction. It is dropped when the transaction, or the
+// savepoint it was registered in, is rolled back.
+func AfterCommit(ctx context.Context, fn func()) {
+	if state := current(ctx); state != nil {
+		state.hooks = append(state.hooks, fn)
+		return
+	}
+	fn()
+}
+
+// IsRetryable reports whether err ended a transaction that can be run again:
+// a deadlock, a lock wait timeout or a serialization failure
+func IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == errLockDeadlock ||
+		mysqlErr.Number == errLockWaitTimeout ||
+		string(mysqlErr.SQLState[:]) == sqlStateSerialization
+}
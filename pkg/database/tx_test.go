@@ -0,0 +1,258 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// entry is a row written by the transactions under test
+type entry struct {
+	ID   uint
+	Name string
+}
+
+// newTxManager returns a TxManager on a SQLite database with an entries table
+func newTxManager(t *testing.T) (*database.TxManager, *gorm.DB) {
+	t.Helper()
+	db := repotest.SQLite(t)
+	if err := db.AutoMigrate(&entry{}); err != nil {
+		t.Fatal(err)
+	}
+	return database.NewTxManager(db, logging.Discard()), db
+}
+
+// insert writes an entry in the transaction of ctx
+func insert(ctx context.Context, db *gorm.DB, name string) error {
+	return database.FromContext(ctx, db).Create(&entry{Name: name}).Error
+}
+
+// names returns the names of the committed entries
+func names(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var names []string
+	if err := db.Model(&entry{}).Order("id").Pluck("name", &names).Error; err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestWithinTransactionCommitsAndRollsBack(t *testing.T) {
+	tx, db := newTxManager(t)
+	errFailed := errors.New("failed")
+
+	err := tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		if !database.InTransaction(ctx) {
+			t.Error("InTransaction() = false inside a transaction")
+		}
+		return insert(ctx, db, "committed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		if err := insert(ctx, db, "rolled back"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Errorf("WithinTransaction() error = %v, want the error of fn", err)
+	}
+
+	if got := names(t, db); !slices.Equal(got, []string{"committed"}) {
+		t.Errorf("entries = %v, want only the committed one", got)
+	}
+	if database.InTransaction(t.Context()) {
+		t.Error("InTransaction() = true outside of a transaction")
+	}
+}
+
+func TestWithinTransactionRollsBackOnPanic(t *testing.T) {
+	tx, db := newTxManager(t)
+
+	err := tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		if err := insert(ctx, db, "panicked"); err != nil {
+			return err
+		}
+		panic("nil map")
+	})
+	if err == nil || err.Error() != "panic in transaction: nil map" {
+		t.Errorf("WithinTransaction() error = %v, want the panic", err)
+	}
+	if got := names(t, db); len(got) != 0 {
+		t.Errorf("entries = %v, want none", got)
+	}
+}
+
+func TestNestedTransactionsUseSavepoints(t *testing.T) {
+	tx, db := newTxManager(t)
+	errFailed := errors.New("failed")
+
+	err := tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		if err := insert(ctx, db, "outer"); err != nil {
+			return err
+		}
+
+		// A failed savepoint leaves the outer transaction usable
+		err := tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, db, "failed savepoint"); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("nested WithinTransaction() error = %v, want the error of fn", err)
+		}
+
+		err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, db, "savepoint"); err != nil {
+				return err
+			}
+			// Savepoints nest
+			return tx.WithinTransaction(ctx, func(ctx context.Context) error {
+				return insert(ctx, db, "inner savepoint")
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		// Nothing is visible outside until the outer transaction commits
+		if got := names(t, db.Session(&gorm.Session{NewDB: true}).WithContext(context.Background())); len(got) != 0 {
+			t.Errorf("entries before the commit = %v, want none", got)
+		}
+		return insert(ctx, db, "after savepoints")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"outer", "savepoint", "inner savepoint", "after savepoints"}
+	if got := names(t, db); !slices.Equal(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
+
+func TestWithinTransactionRetriesDeadlocks(t *testing.T) {
+	tx, db := newTxManager(t)
+
+	attempts := 0
+	err := tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		attempts++
+		if err := insert(ctx, db, "attempt"); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("WithinTransaction() = %v after %d attempts, want success on the third", err, attempts)
+	}
+	if got := names(t, db); len(got) != 1 {
+		t.Errorf("entries = %v, want only the write of the last attempt", got)
+	}
+
+	// Attempts are limited
+	attempts = 0
+	err = tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		attempts++
+		return &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}
+	})
+	if !database.IsRetryable(err) || attempts != 3 {
+		t.Errorf("WithinTransaction() = %v after %d attempts, want a lock wait timeout after 3", err, attempts)
+	}
+
+	// A savepoint is not run again on its own, the outermost transaction is
+	attempts = 0
+	err = tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		return tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			attempts++
+			return &mysql.MySQLError{Number: 1213}
+		})
+	})
+	if !database.IsRetryable(err) || attempts != 3 {
+		t.Errorf("nested WithinTransaction() = %v after %d attempts, want the outer transaction retried 3 times", err, attempts)
+	}
+
+	// Other errors are not retried
+	attempts = 0
+	err = tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		attempts++
+		return &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("WithinTransaction() = %v after %d attempts, want one attempt", err, attempts)
+	}
+}
+
+func TestAfterCommit(t *testing.T) {
+	tx, _ := newTxManager(t)
+	errFailed := errors.New("failed")
+
+	var ran []string
+	hook := func(name string) func() {
+		return func() { ran = append(ran, name) }
+	}
+
+	err := tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		tx.AfterCommit(ctx, hook("outer"))
+
+		_ = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			tx.AfterCommit(ctx, hook("rolled back savepoint"))
+			return errFailed
+		})
+		_ = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			tx.AfterCommit(ctx, hook("savepoint"))
+			return nil
+		})
+
+		if len(ran) != 0 {
+			t.Errorf("hooks ran before the commit: %v", ran)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"outer", "savepoint"}; !slices.Equal(ran, want) {
+		t.Errorf("hooks run = %v, want %v", ran, want)
+	}
+
+	// Hooks of a rolled back transaction are dropped, and those of an attempt
+	// that is retried do not run twice
+	ran = nil
+	_ = tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		tx.AfterCommit(ctx, hook("rolled back"))
+		return errFailed
+	})
+	attempts := 0
+	_ = tx.WithinTransaction(t.Context(), func(ctx context.Context) error {
+		attempts++
+		tx.AfterCommit(ctx, hook("retried"))
+		if attempts == 1 {
+			return &mysql.MySQLError{Number: 1213}
+		}
+		return nil
+	})
+	if want := []string{"retried"}; !slices.Equal(ran, want) {
+		t.Errorf("hooks run = %v, want %v", ran, want)
+	}
+
+	// Outside of a transaction hooks run right away
+	ran = nil
+	tx.AfterCommit(t.Context(), hook("now"))
+	if !slices.Equal(ran, []string{"now"}) {
+		t.Errorf("hooks run = %v, want the hook run right away", ran)
+	}
+}
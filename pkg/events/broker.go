@@ -0,0 +1,146 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Message is a message published to a Broker
+type Message struct {
+	Subject string
+	// Key is used by partitioned brokers to keep messages of one aggregate in order
+	Key     string
+	Data    []byte
+	Headers map[string]string
+}
+
+// Broker is a minimal NATS/Kafka-style message publisher
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// BrokerSink publishes events to a Broker on "<prefix>.<event type>" subjects
+type BrokerSink struct {
+	broker Broker
+	prefix string
+}
+
+// NewBrokerSink creates a new BrokerSink
+func NewBrokerSink(broker Broker, prefix string) *BrokerSink {
+	return &BrokerSink{
+		broker: broker,
+		prefix: prefix,
+	}
+}
+
+// Publish encodes the event and publishes it keyed by its aggregate
+func (s *BrokerSink) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	subject := string(event.Type)
+	if s.prefix != "" {
+		subject = s.prefix + "." + subject
+	}
+
+	return s.broker.Publish(ctx, Message{
+		Subject: subject,
+		Key:     event.AggregateType + ":" + event.AggregateID,
+		Data:    data,
+		Headers: map[string]string{
+			"event-id":   event.ID,
+			"event-type": string(event.Type),
+		},
+	})
+}
+
+// MemoryBroker is an in-process Broker, mainly useful for tests.
+// Subscribers are called synchronously in publish order.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	messages []Message
+	subs     map[int]memorySubscription
+	nextID   int
+}
+
+type memorySubscription struct {
+	pattern string
+	handler func(Message)
+}
+
+// NewMemoryBroker creates a new MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subs: map[int]memorySubscription{},
+	}
+}
+
+// Publish records the message and hands it to every matching subscriber
+func (b *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	b.messages = append(b.messages, msg)
+	handlers := make([]func(Message), 0, len(b.subs))
+	for _, sub := range b.subs {
+		if subjectMatches(sub.pattern, msg.Subject) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(msg)
+	}
+
+	return nil
+}
+
+// Subscribe registers handler for subjects matching pattern.
+// Patterns use NATS wildcards: "*" matches one token and ">" the rest.
+// The returned function removes the subscription.
+func (b *MemoryBroker) Subscribe(pattern string, handler func(Message)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = memorySubscription{pattern: pattern, handler: handler}
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// Messages returns a copy of every message published so far
+func (b *MemoryBroker) Messages() []Message {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return append([]Message(nil), b.messages...)
+}
+
+// subjectMatches reports whether subject matches a NATS-style pattern
+func subjectMatches(pattern string, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
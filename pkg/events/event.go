@@ -0,0 +1,150 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Type identifies the kind of a domain event
+type Type string
+
+const (
+	BookCreated   Type = "book.created"
+	BookUpdated   Type = "book.updated"
+	BookDeleted   Type = "book.deleted"
+	AuthorCreated Type = "author.created"
+	AuthorMerged  Type = "author.merged"
+)
+
+// AllTypes lists every event type emitted by the catalog
+var AllTypes = []Type{BookCreated, BookUpdated, BookDeleted, AuthorCreated, AuthorMerged}
+
+// Aggregate types that events are ordered by
+const (
+	AggregateBook   = "book"
+	AggregateAuthor = "author"
+)
+
+// Event is a domain event describing a change to the catalog
+type Event struct {
+	ID            string          `json:"id"`
+	Sequence      uint64          `json:"sequence"`
+	Type          Type            `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+	// TenantID is the store the event happened in
+	TenantID string `json:"tenant_id"`
+}
+
+// FieldChange holds the previous and new value of a changed field
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// BookCreatedPayload is the payload of a BookCreated event
+type BookCreatedPayload struct {
+	Book models.Book `json:"book"`
+}
+
+// BookUpdatedPayload is the payload of a BookUpdated event
+type BookUpdatedPayload struct {
+	Book    models.Book            `json:"book"`
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// BookDeletedPayload is the payload of a BookDeleted event
+type BookDeletedPayload struct {
+	Book models.Book `json:"book"`
+}
+
+// AuthorCreatedPayload is the payload of an AuthorCreated event
+type AuthorCreatedPayload struct {
+	Author models.Author `json:"author"`
+}
+
+// AuthorMergedPayload is the payload of an AuthorMerged event. The books of
+// the merged authors now belong to Author, and their IDs redirect to it.
+type AuthorMergedPayload struct {
+	Author    models.Author `json:"author"`
+	MergedIDs []string      `json:"merged_ids"`
+}
+
+// New creates an event of the given type with payload encoded as JSON
+func New(eventType Type, aggregateType string, aggregateID string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	return Event{
+		ID:            uuid.New().String(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       data,
+	}, nil
+}
+
+// ToOutbox converts the event into an outbox row
+func (e Event) ToOutbox() *models.OutboxEvent {
+	return &models.OutboxEvent{
+		EventID:       e.ID,
+		EventType:     string(e.Type),
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		Payload:       string(e.Payload),
+		CreatedAt:     e.OccurredAt,
+	}
+}
+
+// FromOutbox converts an outbox row back into an event
+func FromOutbox(row models.OutboxEvent) Event {
+	return Event{
+		ID:            row.EventID,
+		Sequence:      row.ID,
+		Type:          Type(row.EventType),
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		OccurredAt:    row.CreatedAt,
+		Payload:       json.RawMessage(row.Payload),
+		TenantID:      row.TenantID,
+	}
+}
+
+// BookChanges returns the fields of update that differ from current.
+// Zero-valued fields in update are ignored, matching GORM's struct updates.
+func BookChanges(current models.Book, update models.Book) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+
+	if update.Name != "" && update.Name != current.Name {
+		changes["name"] = FieldChange{Old: current.Name, New: update.Name}
+	}
+	if update.AuthorID != "" && update.AuthorID != current.AuthorID {
+		changes["author_id"] = FieldChange{Old: current.AuthorID, New: update.AuthorID}
+	}
+	if update.Publisher != "" && update.Publisher != current.Publisher {
+		changes["publisher"] = FieldChange{Old: current.Publisher, New: update.Publisher}
+	}
+	if update.PublishedYear != 0 && update.PublishedYear != current.PublishedYear {
+		changes["published_year"] = FieldChange{Old: current.PublishedYear, New: update.PublishedYear}
+	}
+	if update.Description != "" && update.Description != current.Description {
+		changes["description"] = FieldChange{Old: current.Description, New: update.Description}
+	}
+	if update.Price != 0 && update.Price != current.Price {
+		changes["price"] = FieldChange{Old: current.Price, New: update.Price}
+	}
+	if update.Pages != 0 && update.Pages != current.Pages {
+		changes["pages"] = FieldChange{Old: current.Pages, New: update.Pages}
+	}
+
+	return changes
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+)
+
+// Relay publishes outbox events to sinks.
+//
+// Delivery is at least once: an event is only marked as published after every
+// sink accepted it, so a crash or a failing sink leads to redelivery. Events of
+// one aggregate are delivered in outbox order; when one of them fails, later
+// events of the same aggregate wait for the next pass while other aggregates
+// keep flowing. A failed event is retried with exponential backoff, and later
+// events of its aggregate wait for it.
+type Relay struct {
+	store     repository.OutboxRepository
+	sinks     []Sink
+	interval  time.Duration
+	batchSize int
+	// baseBackoff is the delay before the first retry; it doubles on every
+	// retry up to maxBackoff
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	logger      *slog.Logger
+}
+
+// NewRelay creates a new Relay polling store every interval
+func NewRelay(store repository.OutboxRepository, interval time.Duration, logger *slog.Logger, sinks ...Sink) *Relay {
+	return &Relay{
+		store:       store,
+		sinks:       sinks,
+		interval:    interval,
+		batchSize:   100,
+		baseBackoff: time.Second,
+		maxBackoff:  10 * time.Minute,
+		logger:      logger,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Flush(ctx); err != nil {
+			r.logger.Error("Failed to relay outbox events", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush makes a single pass over pending outbox events and returns how many were published
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	rows, err := r.store.FetchPending(ctx, time.Now(), r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := map[string]bool{}
+
+	for _, row := range rows {
+		aggregate := row.AggregateType + ":" + row.AggregateID
+		if blocked[aggregate] {
+			continue
+		}
+
+		event := FromOutbox(row)
+		if err := r.publish(ctx, event); err != nil {
+			blocked[aggregate] = true
+			retryAt := time.Now().Add(r.backoff(row.Attempts + 1))
+			r.logger.Error("Failed to publish event", "event_id", event.ID, "type", event.Type, "retry_at", retryAt, "error", err)
+			if err := r.store.MarkFailed(ctx, row.ID, err.Error(), retryAt); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := r.store.MarkPublished(ctx, row.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// backoff returns the delay before retry number attempt, with full jitter
+// over the upper half of the exponential window
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.baseBackoff
+	for i := 1; i < attempt && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
+
+// publish hands the event to every sink
+func (r *Relay) publish(ctx context.Context, event Event) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("sink %T: %w", sink, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// failingSink rejects the events of one aggregate and records the others
+type failingSink struct {
+	aggregateID string
+	published   []string
+}
+
+func (s *failingSink) Publish(ctx context.Context, event events.Event) error {
+	if event.AggregateID == s.aggregateID {
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, event.AggregateID)
+	return nil
+}
+
+func TestRelayBacksOffFailedAggregates(t *testing.T) {
+	db := repotest.SQLite(t)
+	ctx := tenant.WithAllTenants(context.Background())
+	store := impl.NewOutboxRepository(db)
+
+	for _, aggregateID := range []string{"failing", "failing", "healthy"} {
+		event, err := events.New(events.BookUpdated, "book", aggregateID, events.BookUpdatedPayload{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := event.ToOutbox()
+		row.TenantID = "default"
+		if err := db.WithContext(ctx).Create(row).Error; err != nil {
+			t.Fatalf("failed to write outbox event: %v", err)
+		}
+	}
+
+	sink := &failingSink{aggregateID: "failing"}
+	relay := events.NewRelay(store, time.Second, logging.Discard(), sink)
+
+	published, err := relay.Flush(ctx)
+	if err != nil || published != 1 {
+		t.Fatalf("Flush() = %d, %v, want 1 published", published, err)
+	}
+
+	// The failed event and the one behind it wait for the retry
+	rows, err := store.FetchPending(ctx, time.Now(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Fatalf("FetchPending() before the retry returned %d events, want none", len(rows))
+	}
+
+	var failed models.OutboxEvent
+	if err := db.WithContext(ctx).Order("id").First(&failed, "aggregate_id = ?", "failing").Error; err != nil {
+		t.Fatal(err)
+	}
+	if failed.Attempts != 1 || failed.LastError == "" || failed.NextAttemptAt == nil {
+		t.Fatalf("failed event = %+v, want one attempt with an error and a retry time", failed)
+	}
+
+	rows, err = store.FetchPending(ctx, failed.NextAttemptAt.Add(time.Second), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].ID != failed.ID {
+		t.Fatalf("FetchPending() after the retry returned %d events, want both events of the failed aggregate", len(rows))
+	}
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Sink receives published domain events.
+// Publish must be safe to call again with the same event, since the relay
+// delivers events at least once.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// LogSink writes every event to a logger
+type LogSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSink creates a new LogSink writing to logger
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+// Publish logs the event
+func (s *LogSink) Publish(ctx context.Context, event Event) error {
+	s.logger.InfoContext(ctx, "Domain event",
+		"event_id", event.ID,
+		"type", event.Type,
+		"aggregate_type", event.AggregateType,
+		"aggregate_id", event.AggregateID,
+		"sequence", event.Sequence,
+	)
+	return nil
+}
+
+// WebhookSink POSTs every event as JSON to a fixed URL
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookSink creates a new WebhookSink posting to url
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish sends the event to the webhook and fails on any non-2xx response
+func (s *WebhookSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Type", string(event.Type))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package fixtures provides deterministic data sets of authors and books,
+// embedded in the binary, and a Loader writing them through the catalog
+// repositories. The seed command loads them into a database, and tests into
+// the in-memory or SQLite repositories.
+package fixtures
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+//go:embed data/*.yaml
+var data embed.FS
+
+// names are the data sets, from the smallest to the largest
+var names = []string{"small", "medium", "large"}
+
+// DataSet is a named set of authors, each with its books
+type DataSet struct {
+	Name        string          `json:"-"`
+	Description string          `json:"description"`
+	Authors     []models.Author `json:"authors"`
+}
+
+// file is the content of a data set file: its authors, or the Spec they
+// are generated from
+type file struct {
+	Description string          `json:"description"`
+	Authors     []models.Author `json:"authors"`
+	Generate    *Spec           `json:"generate"`
+}
+
+// Names returns the names of the data sets, from the smallest to the largest
+func Names() []string {
+	return slices.Clone(names)
+}
+
+// Get returns a new copy of the data set called name
+func Get(name string) (*DataSet, error) {
+	if !slices.Contains(names, name) {
+		return nil, fmt.Errorf("unknown data set %q: must be one of %s", name, strings.Join(names, ", "))
+	}
+
+	var content file
+	if err := decode("data/"+name+".yaml", &content); err != nil {
+		return nil, err
+	}
+	if content.Generate != nil {
+		set, err := Generate(name, *content.Generate)
+		if err != nil {
+			return nil, err
+		}
+		set.Description = content.Description
+		return set, nil
+	}
+	return &DataSet{Name: name, Description: content.Description, Authors: content.Authors}, nil
+}
+
+// decode decodes an embedded YAML file into v, following v's JSON tags
+func decode(path string, v any) error {
+	raw, err := data.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// Going through JSON applies the field names of the API
+	var decoded any
+	if err := yaml.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// Books returns the books of the set, each with its author
+func (d *DataSet) Books() []models.Book {
+	books := make([]models.Book, 0, d.BookCount())
+	for _, author := range d.Authors {
+		for _, book := range author.Books {
+			book.AuthorID = author.ID
+			book.Author = models.Author{ID: author.ID, Name: author.Name, Bio: author.Bio}
+			books = append(books, book)
+		}
+	}
+	return books
+}
+
+// BookCount returns the number of books of the set
+func (d *DataSet) BookCount() int {
+	count := 0
+	for _, author := range d.Authors {
+		count += len(author.Books)
+	}
+	return count
+}
+
+// namespace is the namespace of the name-based UUIDs of fixtures
+var namespace = uuid.MustParse("6f1c2b7e-3d4a-4e8b-9c1f-5a2d7e9b0c34")
+
+// assignIDs gives the authors and books of the set the IDs they have in
+// the tenant. IDs follow from names, so loading a set again finds the
+// records it wrote; the tenant is part of them since IDs are unique across
+// tenants.
+func (d *DataSet) assignIDs(tenantID string) {
+	for i := range d.Authors {
+		author := &d.Authors[i]
+		author.ID = nameID(tenantID, d.Name, author.Name)
+		for j := range author.Books {
+			author.Books[j].ID = nameID(tenantID, d.Name, author.Name, author.Books[j].Name)
+			author.Books[j].AuthorID = author.ID
+		}
+	}
+}
+
+// nameID returns the UUID named by parts
+func nameID(parts ...string) string {
+	return uuid.NewSHA1(namespace, []byte(strings.Join(parts, "\x00"))).String()
+}
@@ -0,0 +1,241 @@
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// Spec describes a synthetic data set
+type Spec struct {
+	// Seed selects the data set; a seed always generates the same one
+	Seed  uint64 `json:"seed"`
+	Books int    `json:"books"`
+	// BooksPerAuthor is the mean number of books of an author
+	BooksPerAuthor float64 `json:"books_per_author"`
+	// LatestYear is the year of the most recent books
+	LatestYear int `json:"latest_year"`
+}
+
+// vocabulary is what synthetic data sets are made of
+type vocabulary struct {
+	FirstNames []string `json:"first_names"`
+	LastNames  []string `json:"last_names"`
+	Adjectives []string `json:"adjectives"`
+	Nouns      []string `json:"nouns"`
+	Places     []string `json:"places"`
+	Publishers []string `json:"publishers"`
+	Genres     []genre  `json:"genres"`
+}
+
+type genre struct {
+	Name string `json:"name"`
+	Form string `json:"form"`
+	// Pages is the usual number of pages of the genre's books
+	Pages int `json:"pages"`
+}
+
+// Generate generates the synthetic data set of spec. Like in real
+// catalogs, most authors have a few books and some have many, a few
+// publishers and genres publish most books, recent years have more books
+// than older ones, and prices follow lengths.
+func Generate(name string, spec Spec) (*DataSet, error) {
+	if spec.Books <= 0 || spec.BooksPerAuthor < 1 || spec.LatestYear <= 0 {
+		return nil, errors.New("spec must have books, at least one book per author and a latest year")
+	}
+
+	var words vocabulary
+	if err := decode("data/words.yaml", &words); err != nil {
+		return nil, err
+	}
+
+	g := &generator{spec: spec, words: words, random: newRandom(spec.Seed), names: map[string]bool{}}
+	set := &DataSet{Name: name}
+	for remaining := spec.Books; remaining > 0; {
+		author := g.author(remaining)
+		remaining -= len(author.Books)
+		set.Authors = append(set.Authors, author)
+	}
+	return set, nil
+}
+
+// Spread of the number of books of authors; the log of the number is
+// normally distributed
+const booksSigma = 1.0
+
+type generator struct {
+	spec  Spec
+	words vocabulary
+	*random
+	// names are the names of the authors so far, which are unique
+	names map[string]bool
+}
+
+// author generates an author with at most limit books
+func (g *generator) author(limit int) models.Author {
+	name := g.authorName()
+	kind := g.words.Genres[g.zipf(len(g.words.Genres))]
+	home := pick(g.random, g.words.Places)
+	publisher := g.words.Publishers[g.zipf(len(g.words.Publishers))]
+
+	// Careers began more recently more often, and last up to 40 years
+	start := g.spec.LatestYear - min(int(g.exponential()*30), 120)
+	end := min(start+40, g.spec.LatestYear)
+
+	mu := math.Log(g.spec.BooksPerAuthor) - booksSigma*booksSigma/2
+	count := int(math.Round(math.Exp(mu + booksSigma*g.normal())))
+	count = min(max(count, 1), 80, limit)
+
+	titles := map[string]bool{}
+	books := make([]models.Book, 0, count)
+	for len(books) < count {
+		title := g.title(titles)
+		pages := int(math.Round(float64(kind.Pages) * (1 + 0.3*g.normal())))
+		pages = min(max(pages, 32), 1600)
+
+		book := models.Book{
+			Name:          title,
+			Publisher:     publisher,
+			PublishedYear: uint(start + g.intn(end-start+1)),
+			Description:   fmt.Sprintf("A %s about %s.", kind.Form, g.theme()),
+			Price:         price(math.Exp(math.Log(13)+0.35*g.normal()) * math.Pow(float64(pages)/330, 0.35)),
+			Pages:         pages,
+		}
+		// A quarter of the books come out with other publishers
+		if g.float() < 0.25 {
+			book.Publisher = g.words.Publishers[g.zipf(len(g.words.Publishers))]
+		}
+		books = append(books, book)
+	}
+	slices.SortStableFunc(books, func(a, b models.Book) int { return int(a.PublishedYear) - int(b.PublishedYear) })
+
+	return models.Author{
+		Name:  name,
+		Bio:   fmt.Sprintf("%s writes %s from %s. Their first book came out in %d.", name, kind.Name, home, start),
+		Books: books,
+	}
+}
+
+// authorName returns a name no author has so far
+func (g *generator) authorName() string {
+	for attempt := 0; ; attempt++ {
+		name := pick(g.random, g.words.FirstNames) + " " + pick(g.random, g.words.LastNames)
+		if attempt > 0 {
+			initial := string(rune('A' + g.intn(26)))
+			name = pick(g.random, g.words.FirstNames) + " " + initial + ". " + pick(g.random, g.words.LastNames)
+		}
+		if attempt >= 100 {
+			name = fmt.Sprintf("%s %d", name, len(g.names))
+		}
+		if !g.names[name] {
+			g.names[name] = true
+			return name
+		}
+	}
+}
+
+// title returns a book title not in used, and adds it
+func (g *generator) title(used map[string]bool) string {
+	adjective := func() string { return pick(g.random, g.words.Adjectives) }
+	noun := func() string { return pick(g.random, g.words.Nouns) }
+	place := func() string { return pick(g.random, g.words.Places) }
+	patterns := []func() string{
+		func() string { return "The " + adjective() + " " + noun() },
+		func() string { return "The " + noun() + " of " + place() },
+		func() string { return adjective() + " " + noun() },
+		func() string { return "The " + noun() + "'s " + noun() },
+		func() string { return "The " + noun() + " and the " + noun() },
+		func() string { return noun() + " in " + place() },
+		func() string { return "Beyond the " + adjective() + " " + noun() },
+		func() string { return "After the " + noun() },
+	}
+
+	for attempt := 0; ; attempt++ {
+		title := pick(g.random, patterns)()
+		if attempt >= 100 {
+			title = fmt.Sprintf("%s, Volume %d", title, len(used)+1)
+		}
+		if !used[title] {
+			used[title] = true
+			return title
+		}
+	}
+}
+
+// theme returns what a book is about
+func (g *generator) theme() string {
+	noun := strings.ToLower(pick(g.random, g.words.Nouns))
+	adjective := strings.ToLower(pick(g.random, g.words.Adjectives))
+	place := pick(g.random, g.words.Places)
+	switch g.intn(3) {
+	case 0:
+		return fmt.Sprintf("the %s %s of %s", adjective, noun, place)
+	case 1:
+		return fmt.Sprintf("a journey from %s to %s", place, pick(g.random, g.words.Places))
+	default:
+		return fmt.Sprintf("the last %s of %s", noun, place)
+	}
+}
+
+// price rounds amount to a price ending in .99
+func price(amount float64) float64 {
+	amount = min(max(amount, 2), 79)
+	return math.Floor(amount) + 0.99
+}
+
+// random draws from a PCG generator. Only the generator's output, which
+// math/rand/v2 specifies, is used, so that a seed gives the same data on
+// every Go version.
+type random struct {
+	pcg *rand.PCG
+}
+
+func newRandom(seed uint64) *random {
+	return &random{pcg: rand.NewPCG(seed, 0)}
+}
+
+// float returns a number in [0, 1)
+func (r *random) float() float64 {
+	return float64(r.pcg.Uint64()>>11) / (1 << 53)
+}
+
+// intn returns a number in [0, n)
+func (r *random) intn(n int) int {
+	return int(r.pcg.Uint64() % uint64(n))
+}
+
+// normal returns a normally distributed number of mean 0 and deviation 1
+func (r *random) normal() float64 {
+	return math.Sqrt(-2*math.Log(1-r.float())) * math.Cos(2*math.Pi*r.float())
+}
+
+// exponential returns an exponentially distributed number of mean 1
+func (r *random) exponential() float64 {
+	return -math.Log(1 - r.float())
+}
+
+// zipf returns a number in [0, n), where i is drawn 1/(i+1) as often as 0
+func (r *random) zipf(n int) int {
+	total := 0.0
+	for i := range n {
+		total += 1 / float64(i+1)
+	}
+	x := r.float() * total
+	for i := range n {
+		x -= 1 / float64(i+1)
+		if x < 0 {
+			return i
+		}
+	}
+	return n - 1
+}
+
+// pick returns a random item
+func pick[T any](r *random, items []T) T {
+	return items[r.intn(len(items))]
+}
@@ -0,0 +1,185 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// chunkSize is the number of authors written, with their books, in each
+// transaction
+const chunkSize = 200
+
+// LoaderOptions configures a Loader
+type LoaderOptions struct {
+	// Progress, when set, is told how many books of the set are loaded
+	// after each transaction
+	Progress func(loaded int, total int)
+}
+
+// Counts counts the records of a data set by what loading did to them
+type Counts struct {
+	// Written records were created, or reset to their content in the set
+	Written   int `json:"written"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Result reports what loading a data set did
+type Result struct {
+	Set     string `json:"set"`
+	Tenant  string `json:"tenant"`
+	Authors Counts `json:"authors"`
+	Books   Counts `json:"books"`
+}
+
+// Loader writes data sets through the book and author repositories, as
+// seed data: loading a set records no audit entries and publishes no events
+type Loader struct {
+	books   repository.BookRepository
+	authors repository.AuthorRepository
+	tx      service.TransactionManager
+	options LoaderOptions
+}
+
+// NewLoader creates a Loader writing in transactions of tx
+func NewLoader(books repository.BookRepository, authors repository.AuthorRepository, tx service.TransactionManager, options LoaderOptions) *Loader {
+	return &Loader{
+		books:   books,
+		authors: authors,
+		tx:      tx,
+		options: options,
+	}
+}
+
+// Load brings the authors and books of set to their content in the set,
+// in the tenant of ctx, and gives them their IDs there. Loading a set
+// again leaves its records as they are, unless they were changed or
+// deleted since; other records are never touched.
+func (l *Loader) Load(ctx context.Context, set *DataSet) (*Result, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to load data set %s: %w", set.Name, tenant.ErrMissing)
+	}
+	set.assignIDs(tenantID)
+	ctx = repository.WithSeed(ctx)
+
+	result := &Result{Set: set.Name, Tenant: tenantID}
+	total, loaded := set.BookCount(), 0
+	for start := 0; start < len(set.Authors); start += chunkSize {
+		chunk := set.Authors[start:min(start+chunkSize, len(set.Authors))]
+
+		var counts Result
+		err := l.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			// Retried transactions count again
+			counts = Result{}
+			return l.loadChunk(ctx, chunk, &counts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load data set %s: %w", set.Name, err)
+		}
+
+		result.Authors.Written += counts.Authors.Written
+		result.Authors.Unchanged += counts.Authors.Unchanged
+		result.Books.Written += counts.Books.Written
+		result.Books.Unchanged += counts.Books.Unchanged
+		for _, author := range chunk {
+			loaded += len(author.Books)
+		}
+		if l.options.Progress != nil {
+			l.options.Progress(loaded, total)
+		}
+	}
+	return result, nil
+}
+
+// loadChunk writes the authors of a chunk and their books that differ from
+// the stored ones
+func (l *Loader) loadChunk(ctx context.Context, chunk []models.Author, counts *Result) error {
+	ids := make([]string, len(chunk))
+	for i, author := range chunk {
+		ids[i] = author.ID
+	}
+
+	stored, err := l.authors.GetAuthorsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	storedAuthors := make(map[string]models.Author, len(stored))
+	for _, author := range stored {
+		storedAuthors[author.ID] = author
+	}
+
+	storedBooks := map[string]models.Book{}
+	if len(stored) > 0 {
+		books, err := l.books.GetBooksByAuthorIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, book := range books {
+			storedBooks[book.ID] = book
+		}
+	}
+
+	for i := range chunk {
+		author := &chunk[i]
+		// The books are written on their own
+		fields := models.Author{ID: author.ID, Name: author.Name, Bio: author.Bio}
+
+		current, exists := storedAuthors[author.ID]
+		switch {
+		case !exists:
+			if err := l.authors.CreateAuthor(ctx, &fields); err != nil {
+				return err
+			}
+			counts.Authors.Written++
+		case current.Name != author.Name || current.Bio != author.Bio:
+			if err := l.authors.UpdateAuthor(ctx, &fields); err != nil {
+				return err
+			}
+			counts.Authors.Written++
+		default:
+			counts.Authors.Unchanged++
+		}
+
+		for j := range author.Books {
+			book := &author.Books[j]
+			book.AuthorID = author.ID
+			book.Author = models.Author{ID: author.ID, Name: author.Name, Bio: author.Bio}
+
+			current, found := storedBooks[book.ID]
+			switch {
+			case !exists:
+				// The books of new authors are new too
+				if err := l.books.CreateBook(ctx, book); err != nil {
+					return err
+				}
+				counts.Books.Written++
+			case !found || !sameBook(current, *book):
+				// Restoring also brings back deleted books and books moved
+				// to other authors
+				if err := l.books.RestoreBook(ctx, book); err != nil {
+					return err
+				}
+				counts.Books.Written++
+			default:
+				counts.Books.Unchanged++
+			}
+		}
+	}
+	return nil
+}
+
+// sameBook reports whether the stored book a has the content of b
+func sameBook(a models.Book, b models.Book) bool {
+	return a.Name == b.Name &&
+		a.AuthorID == b.AuthorID &&
+		a.Publisher == b.Publisher &&
+		a.PublishedYear == b.PublishedYear &&
+		a.Description == b.Description &&
+		a.Price == b.Price &&
+		a.Pages == b.Pages
+}
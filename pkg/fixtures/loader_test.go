@@ -0,0 +1,188 @@
+package fixtures_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/fixtures"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+// catalog is a SQLite catalog for the loader tests
+type catalog struct {
+	db      *gorm.DB
+	books   repository.BookRepository
+	authors repository.AuthorRepository
+	loader  *fixtures.Loader
+}
+
+func newCatalog(t *testing.T) *catalog {
+	t.Helper()
+	db := repotest.SQLite(t)
+	c := &catalog{db: db, books: impl.NewBookRepository(db), authors: impl.NewAuthorRepository(db)}
+	c.loader = fixtures.NewLoader(c.books, c.authors, database.NewTxManager(db, logging.Discard()), fixtures.LoaderOptions{})
+	return c
+}
+
+// load loads the data set called name in ctx
+func (c *catalog) load(t *testing.T, ctx context.Context, name string) *fixtures.Result {
+	t.Helper()
+	set, err := fixtures.Get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.loader.Load(ctx, set)
+	if err != nil {
+		t.Fatalf("Load(%s): %v", name, err)
+	}
+	return result
+}
+
+// allBooks returns the books of ctx, sorted by ID
+func (c *catalog) allBooks(t *testing.T, ctx context.Context) []models.Book {
+	t.Helper()
+	var books []models.Book
+	if err := c.db.WithContext(ctx).Order("id").Find(&books).Error; err != nil {
+		t.Fatal(err)
+	}
+	return books
+}
+
+func TestLoaderIsIdempotent(t *testing.T) {
+	c := newCatalog(t)
+	ctx := tenant.WithID(t.Context(), tenant.DefaultID)
+	set, _ := fixtures.Get("small")
+	authors, books := len(set.Authors), set.BookCount()
+
+	first := c.load(t, ctx, "small")
+	if first.Authors != (fixtures.Counts{Written: authors}) || first.Books != (fixtures.Counts{Written: books}) {
+		t.Fatalf("first Load = %+v, want %d authors and %d books written", first, authors, books)
+	}
+	loaded := c.allBooks(t, ctx)
+
+	again := c.load(t, ctx, "small")
+	if again.Authors != (fixtures.Counts{Unchanged: authors}) || again.Books != (fixtures.Counts{Unchanged: books}) {
+		t.Fatalf("second Load = %+v, want everything unchanged", again)
+	}
+	if current := c.allBooks(t, ctx); len(current) != len(loaded) {
+		t.Fatalf("second Load left %d books, want %d", len(current), len(loaded))
+	}
+
+	// Changed and deleted books are written again, other books are left alone
+	if err := c.books.UpdateBook(ctx, loaded[0].ID, &models.Book{Price: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.books.DeleteBook(ctx, loaded[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	extra := models.Book{Name: "Not in the set", Price: 5, Author: models.Author{ID: loaded[2].AuthorID}}
+	if err := c.books.CreateBook(ctx, &extra); err != nil {
+		t.Fatal(err)
+	}
+
+	repaired := c.load(t, ctx, "small")
+	if repaired.Books != (fixtures.Counts{Written: 2, Unchanged: books - 2}) || repaired.Authors.Written != 0 {
+		t.Fatalf("Load after changes = %+v, want the 2 changed books written", repaired)
+	}
+	current := c.allBooks(t, ctx)
+	if len(current) != len(loaded)+1 {
+		t.Fatalf("Load after changes left %d books, want %d", len(current), len(loaded)+1)
+	}
+	book, err := c.books.GetBookByID(ctx, loaded[0].ID)
+	if err != nil || book.Price != loaded[0].Price {
+		t.Fatalf("changed book after Load = %+v, %v, want its price restored to %v", book, err, loaded[0].Price)
+	}
+	if _, err := c.books.GetBookByID(ctx, extra.ID); err != nil {
+		t.Fatalf("book out of the set after Load: %v", err)
+	}
+}
+
+func TestLoaderIsDeterministic(t *testing.T) {
+	first, err := fixtures.Get("medium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := fixtures.Get("medium")
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("Get(medium) returned different data sets")
+	}
+
+	spec := fixtures.Spec{Seed: 7, Books: 200, BooksPerAuthor: 3, LatestYear: 2026}
+	generated, err := fixtures.Generate("test", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := fixtures.Generate("test", spec); !reflect.DeepEqual(generated, again) {
+		t.Fatal("Generate returned different data sets for the same seed")
+	}
+	spec.Seed = 8
+	if other, _ := fixtures.Generate("test", spec); reflect.DeepEqual(generated.Books(), other.Books()) {
+		t.Fatal("Generate returned the same data set for another seed")
+	}
+
+	// Loading the set in two databases gives the same catalog, with IDs of
+	// the tenant
+	ctx := tenant.WithID(t.Context(), tenant.DefaultID)
+	a, b := newCatalog(t), newCatalog(t)
+	a.load(t, ctx, "medium")
+	b.load(t, ctx, "medium")
+	booksA, booksB := a.allBooks(t, ctx), b.allBooks(t, ctx)
+	if len(booksA) != first.BookCount() || len(booksA) != len(booksB) {
+		t.Fatalf("loaded %d and %d books, want %d", len(booksA), len(booksB), first.BookCount())
+	}
+	for i := range booksA {
+		x, y := booksA[i], booksB[i]
+		if x.ID != y.ID || x.AuthorID != y.AuthorID || x.Name != y.Name || x.Price != y.Price || x.PublishedYear != y.PublishedYear {
+			t.Fatalf("book %d differs: %+v and %+v", i, x, y)
+		}
+	}
+
+	other := tenant.WithID(t.Context(), "acme")
+	if err := impl.NewTenantRepository(a.db).CreateTenant(t.Context(), &models.Tenant{ID: "acme", Name: "Acme", Currency: "USD", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	a.load(t, other, "medium")
+	if books := a.allBooks(t, other); len(books) != len(booksA) || books[0].ID == booksA[0].ID {
+		t.Fatal("the set loaded in another tenant did not get IDs of its own")
+	}
+}
+
+func TestLoaderWritesSeedData(t *testing.T) {
+	c := newCatalog(t)
+	ctx := tenant.WithID(t.Context(), tenant.DefaultID)
+	c.load(t, ctx, "small")
+
+	entries, err := impl.NewAuditRepository(c.db).ListEntries(ctx, repository.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Load recorded %d audit entries, want none", len(entries))
+	}
+	events, err := impl.NewOutboxRepository(c.db).FetchPending(tenant.WithAllTenants(t.Context()), time.Now().Add(time.Hour), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Load recorded %d outbox events, want none", len(events))
+	}
+
+	// Changes after seeding are recorded as usual
+	books := c.allBooks(t, ctx)
+	if err := c.books.UpdateBook(ctx, books[0].ID, &models.Book{Price: 1}); err != nil {
+		t.Fatal(err)
+	}
+	events, err = impl.NewOutboxRepository(c.db).FetchPending(tenant.WithAllTenants(t.Context()), time.Now().Add(time.Hour), 1000)
+	if err != nil || len(events) != 1 || events[0].AggregateID != books[0].ID {
+		t.Fatalf("outbox after an update = %+v, %v, want its event", events, err)
+	}
+}
@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes set in the "code" extension of errors
+const (
+	CodeBadUserInput = "BAD_USER_INPUT"
+	CodeNotFound     = "NOT_FOUND"
+	CodeInternal     = "INTERNAL_SERVER_ERROR"
+)
+
+// inputError reports invalid arguments
+func inputError(msg string) error {
+	err := gqlerror.Errorf("%s", msg)
+	errcode.Set(err, CodeBadUserInput)
+	return err
+}
+
+// errorPresenter maps service and repository errors to GraphQL errors with a
+// code extension. Internal errors are logged and not described to clients.
+func errorPresenter(logger *slog.Logger) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		presented := graphql.DefaultErrorPresenter(ctx, err)
+
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) && gqlErr.Extensions["code"] != nil {
+			return presented
+		}
+
+		switch {
+		case service.IsValidationError(err):
+			errcode.Set(presented, CodeBadUserInput)
+		case errors.Is(err, repository.ErrNotFound):
+			errcode.Set(presented, CodeNotFound)
+		default:
+			logging.FromContext(ctx, logger).ErrorContext(ctx, "GraphQL resolver failed",
+				"path", presented.Path.String(), "error", err)
+			presented.Message = "internal error"
+			errcode.Set(presented, CodeInternal)
+		}
+		return presented
+	}
+}
@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Limits protects the database from expensive queries
+type Limits struct {
+	// MaxDepth is the maximum nesting of selected fields
+	MaxDepth int
+	// MaxComplexity is the maximum query complexity; list fields cost their
+	// page size times the cost of their selection
+	MaxComplexity int
+}
+
+// DefaultLimits returns the default query limits
+func DefaultLimits() Limits {
+	return Limits{
+		MaxDepth:      8,
+		MaxComplexity: 5000,
+	}
+}
+
+// complexity sets the cost of the list fields of the schema
+func complexity(root *ComplexityRoot) {
+	root.Query.Books = func(childComplexity int, filter *BookFilter, first *int, after *string) int {
+		return pageSize(first) * childComplexity
+	}
+	root.Query.Authors = func(childComplexity int, first *int, after *string) int {
+		return pageSize(first) * childComplexity
+	}
+	root.Author.Books = func(childComplexity int, first *int) int {
+		return pageSize(first) * childComplexity
+	}
+}
+
+// depthLimit rejects operations nesting fields deeper than a fixed depth.
+// Introspection queries are exempt.
+type depthLimit struct {
+	maxDepth int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = depthLimit{}
+
+// ExtensionName implements graphql.HandlerExtension
+func (depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate implements graphql.HandlerExtension
+func (depthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext implements graphql.OperationContextMutator
+func (d depthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	if depth := selectionDepth(rc.Operation.SelectionSet); depth > d.maxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.maxDepth)
+		errcode.Set(err, "DEPTH_LIMIT_EXCEEDED")
+		return err
+	}
+	return nil
+}
+
+// selectionDepth returns the nesting depth of a selection set. Fragments do
+// not add a level, and introspection fields are not counted.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range set {
+		var d int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + selectionDepth(s.SelectionSet)
+		case *ast.InlineFragment:
+			d = selectionDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				d = selectionDepth(s.Definition.SelectionSet)
+			}
+		}
+		depth = max(depth, d)
+	}
+	return depth
+}
+
+// Page sizes of list fields
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageSize returns the effective page size of a `first` argument
+func pageSize(first *int) int {
+	if first == nil {
+		return defaultPageSize
+	}
+	return min(max(*first, 0), maxPageSize)
+}
+
+// validatePageSize checks a `first` argument
+func validatePageSize(first *int) error {
+	if first != nil && (*first < 0 || *first > maxPageSize) {
+		return inputError(fmt.Sprintf("first must be between 0 and %d", maxPageSize))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loader batches and caches loads of the same kind made while resolving a
+// single request, so that resolving a list of N parents costs one query
+// instead of N. Loads made within wait of each other share a fetch.
+type Loader[K comparable, V any] struct {
+	fetch    func(ctx context.Context, keys []K) (map[K]V, error)
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	results map[K]*loadResult[V]
+	pending []K
+}
+
+// loadResult is the outcome of loading one key
+type loadResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// NewLoader creates a Loader. fetch returns the values of the keys it found;
+// missing keys resolve to the zero value of V.
+func NewLoader[K comparable, V any](wait time.Duration, maxBatch int, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		results:  map[K]*loadResult[V]{},
+	}
+}
+
+// Load returns the value of key, waiting for the batch it joins to be fetched
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	result, ok := l.results[key]
+	if !ok {
+		result = &loadResult[V]{done: make(chan struct{})}
+		l.results[key] = result
+		l.pending = append(l.pending, key)
+
+		switch {
+		case len(l.pending) >= l.maxBatch:
+			go l.dispatch(ctx, l.takePending())
+		case len(l.pending) == 1:
+			time.AfterFunc(l.wait, func() {
+				l.mu.Lock()
+				keys := l.takePending()
+				l.mu.Unlock()
+				l.dispatch(ctx, keys)
+			})
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// takePending removes and returns the keys waiting for a fetch; l.mu must be held
+func (l *Loader[K, V]) takePending() []K {
+	keys := l.pending
+	l.pending = nil
+	return keys
+}
+
+// dispatch fetches keys and resolves their results
+func (l *Loader[K, V]) dispatch(ctx context.Context, keys []K) {
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.fetch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		result := l.results[key]
+		result.value, result.err = values[key], err
+		close(result.done)
+	}
+}
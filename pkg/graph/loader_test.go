@@ -0,0 +1,112 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/graph"
+)
+
+// recordingFetch returns squares of the keys it is given, except for
+// negative keys, which it does not find, and records its batches
+type recordingFetch struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (f *recordingFetch) fetch(ctx context.Context, keys []int) (map[int]int, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, slices.Sorted(slices.Values(keys)))
+	f.mu.Unlock()
+
+	values := map[int]int{}
+	for _, key := range keys {
+		if key >= 0 {
+			values[key] = key * key
+		}
+	}
+	return values, nil
+}
+
+// loadAll loads keys concurrently and returns their values
+func loadAll(t *testing.T, loader *graph.Loader[int, int], keys []int) []int {
+	t.Helper()
+
+	values := make([]int, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := loader.Load(t.Context(), key)
+			if err != nil {
+				t.Errorf("Load(%d) error = %v", key, err)
+			}
+			values[i] = value
+		}()
+	}
+	wg.Wait()
+	return values
+}
+
+func TestLoaderBatches(t *testing.T) {
+	var f recordingFetch
+	loader := graph.NewLoader(10*time.Millisecond, 100, f.fetch)
+
+	values := loadAll(t, loader, []int{1, 2, 2, 3, -1})
+	if want := []int{1, 4, 4, 9, 0}; !slices.Equal(values, want) {
+		t.Errorf("Load() values = %v, want %v", values, want)
+	}
+	if want := [][]int{{-1, 1, 2, 3}}; !slices.EqualFunc(f.batches, want, slices.Equal) {
+		t.Errorf("fetched batches = %v, want %v", f.batches, want)
+	}
+
+	// Loaded keys are cached
+	if value, err := loader.Load(t.Context(), 3); err != nil || value != 9 {
+		t.Errorf("Load(3) again = %d, %v", value, err)
+	}
+	if len(f.batches) != 1 {
+		t.Errorf("fetched batches = %v, want no new fetch", f.batches)
+	}
+}
+
+func TestLoaderSplitsLargeBatches(t *testing.T) {
+	var f recordingFetch
+	loader := graph.NewLoader(time.Hour, 2, f.fetch)
+
+	// A full batch is fetched without waiting
+	values := loadAll(t, loader, []int{1, 2, 3, 4})
+	if want := []int{1, 4, 9, 16}; !slices.Equal(values, want) {
+		t.Errorf("Load() values = %v, want %v", values, want)
+	}
+	if len(f.batches) != 2 || len(f.batches[0]) != 2 || len(f.batches[1]) != 2 {
+		t.Errorf("fetched batches = %v, want two of two keys", f.batches)
+	}
+}
+
+func TestLoaderReturnsFetchErrors(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	loader := graph.NewLoader(time.Millisecond, 100, func(ctx context.Context, keys []int) (map[int]int, error) {
+		return nil, errFetch
+	})
+
+	if _, err := loader.Load(t.Context(), 1); !errors.Is(err, errFetch) {
+		t.Errorf("Load() error = %v, want the fetch error", err)
+	}
+}
+
+func TestLoaderStopsWaitingOnCancel(t *testing.T) {
+	loader := graph.NewLoader(time.Hour, 100, func(ctx context.Context, keys []int) (map[int]int, error) {
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := loader.Load(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Load() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+)
+
+// Batching settings of the request-scoped loaders
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+// Loaders holds the batch loaders of one GraphQL request
+type Loaders struct {
+	AuthorByID          *Loader[string, *models.Author]
+	BooksByAuthorID     *Loader[string, []models.Book]
+	BookCountByAuthorID *Loader[string, int]
+}
+
+// NewLoaders creates the loaders for a single request
+func NewLoaders(bookService service.BookService, authorService service.AuthorService) *Loaders {
+	return &Loaders{
+		AuthorByID: NewLoader(loaderWait, loaderMaxBatch, func(ctx context.Context, ids []string) (map[string]*models.Author, error) {
+			authors, err := authorService.GetAuthorsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[string]*models.Author, len(authors))
+			for i := range authors {
+				byID[authors[i].ID] = &authors[i]
+			}
+			return byID, nil
+		}),
+
+		BooksByAuthorID: NewLoader(loaderWait, loaderMaxBatch, func(ctx context.Context, authorIDs []string) (map[string][]models.Book, error) {
+			books, err := bookService.GetBooksByAuthorIDs(ctx, authorIDs)
+			if err != nil {
+				return nil, err
+			}
+
+			byAuthor := make(map[string][]models.Book, len(authorIDs))
+			for _, book := range books {
+				byAuthor[book.AuthorID] = append(byAuthor[book.AuthorID], book)
+			}
+			return byAuthor, nil
+		}),
+
+		BookCountByAuthorID: NewLoader(loaderWait, loaderMaxBatch, bookService.CountBooksByAuthorIDs),
+	}
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns a copy of ctx carrying loaders
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+// loadersFrom returns the loaders stored in ctx
+func loadersFrom(ctx context.Context) *Loaders {
+	return ctx.Value(loadersKey{}).(*Loaders)
+}
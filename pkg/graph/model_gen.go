@@ -0,0 +1,67 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graph
+
+import (
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+type AuthorConnection struct {
+	Nodes    []*models.Author `json:"nodes"`
+	PageInfo *PageInfo        `json:"pageInfo"`
+}
+
+type AuthorInput struct {
+	// An existing author; name and bio are ignored when set.
+	ID   *string `json:"id,omitempty"`
+	Name *string `json:"name,omitempty"`
+	Bio  *string `json:"bio,omitempty"`
+}
+
+type BookConnection struct {
+	Nodes    []*models.Book `json:"nodes"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+// Filters books. Omitted fields match everything.
+type BookFilter struct {
+	// Matched against the book name and description.
+	Query *string `json:"query,omitempty"`
+	// Matched against the author name.
+	Author    *string  `json:"author,omitempty"`
+	Publisher *string  `json:"publisher,omitempty"`
+	MinPrice  *float64 `json:"minPrice,omitempty"`
+	MaxPrice  *float64 `json:"maxPrice,omitempty"`
+}
+
+type CreateBookInput struct {
+	Name          string       `json:"name"`
+	Author        *AuthorInput `json:"author"`
+	Publisher     *string      `json:"publisher,omitempty"`
+	PublishedYear *int         `json:"publishedYear,omitempty"`
+	Description   *string      `json:"description,omitempty"`
+	Price         *float64     `json:"price,omitempty"`
+	Pages         *int         `json:"pages,omitempty"`
+}
+
+type Mutation struct {
+}
+
+type PageInfo struct {
+	HasNextPage bool `json:"hasNextPage"`
+	// Pass as `after` to fetch the next page.
+	EndCursor *string `json:"endCursor,omitempty"`
+}
+
+type Query struct {
+}
+
+// Omitted fields are left unchanged.
+type UpdateBookInput struct {
+	Name          *string  `json:"name,omitempty"`
+	Publisher     *string  `json:"publisher,omitempty"`
+	PublishedYear *int     `json:"publishedYear,omitempty"`
+	Description   *string  `json:"description,omitempty"`
+	Price         *float64 `json:"price,omitempty"`
+	Pages         *int     `json:"pages,omitempty"`
+}
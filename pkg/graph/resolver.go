@@ -0,0 +1,71 @@
+package graph
+
+//go:generate gqlgen generate --config gqlgen.yml
+
+import (
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+)
+
+// Resolver is the root resolver of the GraphQL schema
+type Resolver struct {
+	bookService   service.BookService
+	authorService service.AuthorService
+}
+
+// NewResolver creates a new Resolver backed by the given services
+func NewResolver(bookService service.BookService, authorService service.AuthorService) *Resolver {
+	return &Resolver{
+		bookService:   bookService,
+		authorService: authorService,
+	}
+}
+
+// decodeCursor returns the key an `after` cursor points after; nil for the
+// first page
+func decodeCursor(after *string) (*pagination.Key, error) {
+	key, err := pagination.DecodeCursor(valueOr(after, ""))
+	if err != nil {
+		return nil, inputError(err.Error())
+	}
+	return key, nil
+}
+
+// pageInfo describes a page of size items fetched with one extra item to
+// detect a next page. Its end cursor points after the last item shown.
+func pageInfo[T any](fetched []T, size int, key func(T) pagination.Key) *PageInfo {
+	info := &PageInfo{HasNextPage: len(fetched) > size}
+	if shown := min(len(fetched), size); shown > 0 {
+		cursor := pagination.EncodeCursor(key(fetched[shown-1]))
+		info.EndCursor = &cursor
+	}
+	return info
+}
+
+// bookKey is the position of a book in lists ordered by name
+func bookKey(book models.Book) pagination.Key {
+	return pagination.Key{Name: book.Name, ID: book.ID}
+}
+
+// authorKey is the position of an author in lists ordered by name
+func authorKey(author models.Author) pagination.Key {
+	return pagination.Key{Name: author.Name, ID: author.ID}
+}
+
+// pointers returns pointers to the elements of items
+func pointers[T any](items []T) []*T {
+	result := make([]*T, len(items))
+	for i := range items {
+		result[i] = &items[i]
+	}
+	return result
+}
+
+// valueOr returns *p, or fallback when p is nil
+func valueOr[T any](p *T, fallback T) T {
+	if p == nil {
+		return fallback
+	}
+	return *p
+}
@@ -0,0 +1,206 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.76
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+)
+
+// Books is the resolver for the books field.
+func (r *authorResolver) Books(ctx context.Context, obj *models.Author, first *int) ([]*models.Book, error) {
+	if err := validatePageSize(first); err != nil {
+		return nil, err
+	}
+
+	books, err := loadersFrom(ctx).BooksByAuthorID.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return pointers(books[:min(len(books), pageSize(first))]), nil
+}
+
+// BookCount is the resolver for the bookCount field.
+func (r *authorResolver) BookCount(ctx context.Context, obj *models.Author) (int, error) {
+	return loadersFrom(ctx).BookCountByAuthorID.Load(ctx, obj.ID)
+}
+
+// PublishedYear is the resolver for the publishedYear field.
+func (r *bookResolver) PublishedYear(ctx context.Context, obj *models.Book) (int, error) {
+	return int(obj.PublishedYear), nil
+}
+
+// Author is the resolver for the author field.
+func (r *bookResolver) Author(ctx context.Context, obj *models.Book) (*models.Author, error) {
+	// Books loaded through the service come with their author
+	if obj.Author.ID != "" {
+		return &obj.Author, nil
+	}
+
+	author, err := loadersFrom(ctx).AuthorByID.Load(ctx, obj.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		return nil, fmt.Errorf("author with ID %s %w", obj.AuthorID, repository.ErrNotFound)
+	}
+	return author, nil
+}
+
+// CreateBook is the resolver for the createBook field.
+func (r *mutationResolver) CreateBook(ctx context.Context, input CreateBookInput) (*models.Book, error) {
+	book := &models.Book{
+		Name:          input.Name,
+		Publisher:     valueOr(input.Publisher, ""),
+		PublishedYear: uint(max(valueOr(input.PublishedYear, 0), 0)),
+		Description:   valueOr(input.Description, ""),
+		Price:         valueOr(input.Price, 0),
+		Pages:         valueOr(input.Pages, 0),
+	}
+	if input.Author != nil {
+		book.Author = models.Author{
+			ID:   valueOr(input.Author.ID, ""),
+			Name: valueOr(input.Author.Name, ""),
+			Bio:  valueOr(input.Author.Bio, ""),
+		}
+	}
+
+	if book.Author.ID != "" {
+		// Reuse the existing author as is
+		author, err := r.authorService.GetAuthorByID(ctx, book.Author.ID)
+		if err != nil {
+			return nil, err
+		}
+		book.Author = *author
+	}
+
+	if err := r.bookService.CreateBook(ctx, book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// UpdateBook is the resolver for the updateBook field.
+func (r *mutationResolver) UpdateBook(ctx context.Context, id string, input UpdateBookInput) (*models.Book, error) {
+	if input.PublishedYear != nil && *input.PublishedYear < 0 {
+		return nil, inputError("publishedYear cannot be negative")
+	}
+
+	book := &models.Book{
+		Name:          valueOr(input.Name, ""),
+		Publisher:     valueOr(input.Publisher, ""),
+		PublishedYear: uint(valueOr(input.PublishedYear, 0)),
+		Description:   valueOr(input.Description, ""),
+		Price:         valueOr(input.Price, 0),
+		Pages:         valueOr(input.Pages, 0),
+	}
+
+	if err := r.bookService.UpdateBook(ctx, id, book); err != nil {
+		return nil, err
+	}
+	return r.bookService.GetBookByID(ctx, id)
+}
+
+// DeleteBook is the resolver for the deleteBook field.
+func (r *mutationResolver) DeleteBook(ctx context.Context, id string) (bool, error) {
+	if err := r.bookService.DeleteBook(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Book is the resolver for the book field.
+func (r *queryResolver) Book(ctx context.Context, id string) (*models.Book, error) {
+	book, err := r.bookService.GetBookByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return book, err
+}
+
+// Books is the resolver for the books field.
+func (r *queryResolver) Books(ctx context.Context, filter *BookFilter, first *int, after *string) (*BookConnection, error) {
+	if err := validatePageSize(first); err != nil {
+		return nil, err
+	}
+
+	afterKey, err := decodeCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	size := pageSize(first)
+	search := repository.BookSearch{Limit: size + 1, After: afterKey}
+	if filter != nil {
+		search.Query = valueOr(filter.Query, "")
+		search.Author = valueOr(filter.Author, "")
+		search.Publisher = valueOr(filter.Publisher, "")
+		search.MinPrice = valueOr(filter.MinPrice, 0)
+		search.MaxPrice = valueOr(filter.MaxPrice, 0)
+	}
+
+	books, err := r.bookService.SearchBooks(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BookConnection{
+		Nodes:    pointers(books[:min(len(books), size)]),
+		PageInfo: pageInfo(books, size, bookKey),
+	}, nil
+}
+
+// Author is the resolver for the author field.
+func (r *queryResolver) Author(ctx context.Context, id string) (*models.Author, error) {
+	author, err := r.authorService.GetAuthorByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return author, err
+}
+
+// Authors is the resolver for the authors field.
+func (r *queryResolver) Authors(ctx context.Context, first *int, after *string) (*AuthorConnection, error) {
+	if err := validatePageSize(first); err != nil {
+		return nil, err
+	}
+
+	afterKey, err := decodeCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	size := pageSize(first)
+	authors, err := r.authorService.ListAuthors(ctx, size+1, afterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorConnection{
+		Nodes:    pointers(authors[:min(len(authors), size)]),
+		PageInfo: pageInfo(authors, size, authorKey),
+	}, nil
+}
+
+// Author returns AuthorResolver implementation.
+func (r *Resolver) Author() AuthorResolver { return &authorResolver{r} }
+
+// Book returns BookResolver implementation.
+func (r *Resolver) Book() BookResolver { return &bookResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type authorResolver struct{ *Resolver }
+type bookResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Server executes GraphQL operations against the catalog
+type Server struct {
+	exec          *executor.Executor
+	bookService   service.BookService
+	authorService service.AuthorService
+}
+
+// NewServer creates a new Server enforcing limits
+func NewServer(bookService service.BookService, authorService service.AuthorService, limits Limits, logger *slog.Logger) *Server {
+	config := Config{Resolvers: NewResolver(bookService, authorService)}
+	complexity(&config.Complexity)
+
+	exec := executor.New(NewExecutableSchema(config))
+	exec.Use(extension.Introspection{})
+	exec.Use(depthLimit{maxDepth: limits.MaxDepth})
+	exec.Use(extension.FixedComplexityLimit(limits.MaxComplexity))
+	exec.SetErrorPresenter(errorPresenter(logger))
+	exec.SetRecoverFunc(func(ctx context.Context, err any) error {
+		return fmt.Errorf("panic: %v", err)
+	})
+
+	return &Server{
+		exec:          exec,
+		bookService:   bookService,
+		authorService: authorService,
+	}
+}
+
+// Execute runs a single operation. It reports whether the operation was
+// valid; invalid operations are not executed and the response describes why.
+func (s *Server) Execute(ctx context.Context, params *graphql.RawParams) (*graphql.Response, bool) {
+	ctx = graphql.StartOperationTrace(ctx)
+	ctx = WithLoaders(ctx, NewLoaders(s.bookService, s.authorService))
+
+	now := graphql.Now()
+	params.ReadTime = graphql.TraceTiming{Start: now, End: now}
+
+	rc, errs := s.exec.CreateOperationContext(ctx, params)
+	if errs != nil {
+		return s.exec.DispatchError(graphql.WithOperationContext(ctx, rc), errs), false
+	}
+
+	responses, ctx := s.exec.DispatchOperation(ctx, rc)
+	return responses(ctx), true
+}
+
+// Error returns the response to a request that could not be decoded
+func (s *Server) Error(ctx context.Context, msg string) *graphql.Response {
+	err := gqlerror.Errorf("%s", msg)
+	errcode.Set(err, CodeBadUserInput)
+	return s.exec.DispatchError(ctx, gqlerror.List{err})
+}
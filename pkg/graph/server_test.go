@@ -0,0 +1,198 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/dtg-lucifer/go-bookstore/pkg/graph"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/memory"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// countingBookService counts the batch loads of a BookService
+type countingBookService struct {
+	service.BookService
+	getByAuthors   atomic.Int32
+	countByAuthors atomic.Int32
+}
+
+func (s *countingBookService) GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) ([]models.Book, error) {
+	s.getByAuthors.Add(1)
+	return s.BookService.GetBooksByAuthorIDs(ctx, authorIDs)
+}
+
+func (s *countingBookService) CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (map[string]int, error) {
+	s.countByAuthors.Add(1)
+	return s.BookService.CountBooksByAuthorIDs(ctx, authorIDs)
+}
+
+// newTestServer returns a server on an in-memory catalog of authors, each with
+// two books, and the context of the tenant owning them
+func newTestServer(t *testing.T, limits graph.Limits, authors int) (*graph.Server, *countingBookService, context.Context) {
+	t.Helper()
+
+	store := memory.NewStore()
+	books := &countingBookService{
+		BookService: service.NewBookService(store.Books(), store.Authors(), store, logging.Discard(), service.BookServiceOptions{}),
+	}
+	ctx := tenant.WithID(t.Context(), tenant.DefaultID)
+	for i := range authors {
+		author := models.Author{Name: fmt.Sprintf("Author %d", i)}
+		for j := range 2 {
+			book := models.Book{Name: fmt.Sprintf("Book %d.%d", i, j), Price: 10, Author: author}
+			if err := books.CreateBook(ctx, &book); err != nil {
+				t.Fatal(err)
+			}
+			author = book.Author
+		}
+	}
+
+	return graph.NewServer(books, service.NewAuthorService(store.Authors()), limits, logging.Discard()), books, ctx
+}
+
+// errorCodes returns the code extensions of the errors of a response
+func errorCodes(response *graphql.Response) []any {
+	var codes []any
+	for _, err := range response.Errors {
+		codes = append(codes, err.Extensions["code"])
+	}
+	return codes
+}
+
+func TestDepthLimit(t *testing.T) {
+	server, _, ctx := newTestServer(t, graph.Limits{MaxDepth: 3, MaxComplexity: 5000}, 1)
+
+	tests := []struct {
+		name  string
+		query string
+		ok    bool
+	}{
+		{"at the limit", `{ authors { nodes { name } } }`, true},
+		{"over the limit", `{ authors { nodes { books { name } } } }`, false},
+		{"over the limit in a fragment", `{ authors { ...nodes } } fragment nodes on AuthorConnection { nodes { books { name } } }`, false},
+		{"fragments add no level", `{ authors { ... on AuthorConnection { nodes { name } } } }`, true},
+		{"introspection is not counted", `{ authors { nodes { __typename name } } __schema { types { fields { type { name } } } } }`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, ok := server.Execute(ctx, &graphql.RawParams{Query: tt.query})
+			if ok != tt.ok {
+				t.Fatalf("Execute() ok = %v, want %v; errors: %v", ok, tt.ok, response.Errors)
+			}
+			if tt.ok && len(response.Errors) != 0 {
+				t.Fatalf("Execute() errors = %v", response.Errors)
+			}
+			if codes := errorCodes(response); !tt.ok && (len(codes) != 1 || codes[0] != "DEPTH_LIMIT_EXCEEDED") {
+				t.Errorf("Execute() error codes = %v, want DEPTH_LIMIT_EXCEEDED", codes)
+			}
+		})
+	}
+}
+
+func TestComplexityLimit(t *testing.T) {
+	server, _, ctx := newTestServer(t, graph.DefaultLimits(), 1)
+
+	tests := []struct {
+		name  string
+		query string
+		ok    bool
+	}{
+		// 20 × (1 + 20 × 1)
+		{"default page sizes", `{ authors { nodes { books { name } } } }`, true},
+		// 100 × (1 + 1 + 100 × 1)
+		{"large nested pages", `{ books(first: 100) { nodes { author { books(first: 100) { name } } } } }`, false},
+		// The page size of a variable is known before execution
+		{"large page in a variable", `query($n: Int) { books(first: $n) { nodes { author { books(first: $n) { name } } } } }`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, ok := server.Execute(ctx, &graphql.RawParams{
+				Query:     tt.query,
+				Variables: map[string]any{"n": json.Number("100")},
+			})
+			if ok != tt.ok {
+				t.Fatalf("Execute() ok = %v, want %v; errors: %v", ok, tt.ok, response.Errors)
+			}
+			if codes := errorCodes(response); !tt.ok && (len(codes) != 1 || codes[0] != "COMPLEXITY_LIMIT_EXCEEDED") {
+				t.Errorf("Execute() error codes = %v, want COMPLEXITY_LIMIT_EXCEEDED", codes)
+			}
+		})
+	}
+}
+
+func TestServerBatchesLoads(t *testing.T) {
+	server, books, ctx := newTestServer(t, graph.DefaultLimits(), 5)
+
+	response, ok := server.Execute(ctx, &graphql.RawParams{
+		Query: `{ authors { nodes { name bookCount books { name author { name } } } } }`,
+	})
+	if !ok || len(response.Errors) != 0 {
+		t.Fatalf("Execute() = %v, %v", ok, response.Errors)
+	}
+
+	var data struct {
+		Authors struct {
+			Nodes []struct {
+				Name      string
+				BookCount int
+				Books     []struct {
+					Name   string
+					Author struct{ Name string }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(response.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Authors.Nodes) != 5 {
+		t.Fatalf("authors = %d, want 5", len(data.Authors.Nodes))
+	}
+	for _, author := range data.Authors.Nodes {
+		if author.BookCount != 2 || len(author.Books) != 2 || author.Books[0].Author.Name != author.Name {
+			t.Errorf("author = %+v, want its two books", author)
+		}
+	}
+
+	// One load for the books and one for the counts of all five authors
+	if got := books.getByAuthors.Load(); got != 1 {
+		t.Errorf("GetBooksByAuthorIDs() calls = %d, want 1", got)
+	}
+	if got := books.countByAuthors.Load(); got != 1 {
+		t.Errorf("CountBooksByAuthorIDs() calls = %d, want 1", got)
+	}
+}
+
+func TestServerHidesInternalErrors(t *testing.T) {
+	store := memory.NewStore()
+	books := failingBookService{service.NewBookService(store.Books(), store.Authors(), store, logging.Discard(), service.BookServiceOptions{})}
+	server := graph.NewServer(books, service.NewAuthorService(store.Authors()), graph.DefaultLimits(), logging.Discard())
+
+	response, ok := server.Execute(tenant.WithID(t.Context(), tenant.DefaultID), &graphql.RawParams{
+		Query: `{ books { nodes { name } } }`,
+	})
+	if !ok || len(response.Errors) != 1 {
+		t.Fatalf("Execute() = %v, %v, want one error", ok, response.Errors)
+	}
+	if err := response.Errors[0]; err.Message != "internal error" || err.Extensions["code"] != graph.CodeInternal {
+		t.Errorf("error = %q %v, want an undescribed internal error", err.Message, err.Extensions)
+	}
+}
+
+// failingBookService fails to search books with an error that must not reach clients
+type failingBookService struct {
+	service.BookService
+}
+
+func (failingBookService) SearchBooks(context.Context, repository.BookSearch) ([]models.Book, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:3306: connection refused")
+}
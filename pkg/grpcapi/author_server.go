@@ -0,0 +1,45 @@
+package grpcapi
+
+import (
+	"context"
+
+	bookstorev1 "github.com/dtg-lucifer/go-bookstore/pkg/pb/bookstore/v1"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+)
+
+// AuthorServer implements the gRPC AuthorService on top of service.AuthorService
+type AuthorServer struct {
+	bookstorev1.UnimplementedAuthorServiceServer
+
+	authorService service.AuthorService
+}
+
+// NewAuthorServer creates a new AuthorServer
+func NewAuthorServer(authorService service.AuthorService) *AuthorServer {
+	return &AuthorServer{
+		authorService: authorService,
+	}
+}
+
+// GetAuthor returns an author by its ID
+func (s *AuthorServer) GetAuthor(ctx context.Context, req *bookstorev1.GetAuthorRequest) (*bookstorev1.Author, error) {
+	author, err := s.authorService.GetAuthorByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoAuthor(author), nil
+}
+
+// ListAuthors returns every author
+func (s *AuthorServer) ListAuthors(ctx context.Context, req *bookstorev1.ListAuthorsRequest) (*bookstorev1.ListAuthorsResponse, error) {
+	authors, err := s.authorService.GetAllAuthors(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	result := make([]*bookstorev1.Author, len(authors))
+	for i := range authors {
+		result[i] = toProtoAuthor(&authors[i])
+	}
+	return &bookstorev1.ListAuthorsResponse{Authors: result}, nil
+}
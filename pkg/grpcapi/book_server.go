@@ -0,0 +1,95 @@
+package grpcapi
+
+import (
+	"context"
+
+	bookstorev1 "github.com/dtg-lucifer/go-bookstore/pkg/pb/bookstore/v1"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// BookServer implements the gRPC BookService on top of service.BookService
+type BookServer struct {
+	bookstorev1.UnimplementedBookServiceServer
+
+	bookService service.BookService
+}
+
+// NewBookServer creates a new BookServer
+func NewBookServer(bookService service.BookService) *BookServer {
+	return &BookServer{
+		bookService: bookService,
+	}
+}
+
+// GetBook returns a book by its ID
+func (s *BookServer) GetBook(ctx context.Context, req *bookstorev1.GetBookRequest) (*bookstorev1.Book, error) {
+	book, err := s.bookService.GetBookByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoBook(book), nil
+}
+
+// ListBooks streams every book
+func (s *BookServer) ListBooks(req *bookstorev1.ListBooksRequest, stream grpc.ServerStreamingServer[bookstorev1.Book]) error {
+	books, err := s.bookService.GetAllBooks(stream.Context())
+	if err != nil {
+		return statusError(err)
+	}
+
+	for i := range books {
+		if err := stream.Send(toProtoBook(&books[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateBook creates a book and returns it
+func (s *BookServer) CreateBook(ctx context.Context, req *bookstorev1.CreateBookRequest) (*bookstorev1.Book, error) {
+	book := fromProtoBook(req.GetBook())
+	if err := s.bookService.CreateBook(ctx, book); err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoBook(book), nil
+}
+
+// UpdateBook updates a book and returns its new state
+func (s *BookServer) UpdateBook(ctx context.Context, req *bookstorev1.UpdateBookRequest) (*bookstorev1.Book, error) {
+	if err := s.bookService.UpdateBook(ctx, req.GetId(), fromProtoBook(req.GetBook())); err != nil {
+		return nil, statusError(err)
+	}
+
+	book, err := s.bookService.GetBookByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoBook(book), nil
+}
+
+// DeleteBook deletes a book
+func (s *BookServer) DeleteBook(ctx context.Context, req *bookstorev1.DeleteBookRequest) (*emptypb.Empty, error) {
+	if err := s.bookService.DeleteBook(ctx, req.GetId()); err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// SearchBooks returns the books matching the request's filters
+func (s *BookServer) SearchBooks(ctx context.Context, req *bookstorev1.SearchBooksRequest) (*bookstorev1.SearchBooksResponse, error) {
+	books, err := s.bookService.SearchBooks(ctx, repository.BookSearch{
+		Query:     req.GetQuery(),
+		Author:    req.GetAuthor(),
+		Publisher: req.GetPublisher(),
+		MinPrice:  req.GetMinPrice(),
+		MaxPrice:  req.GetMaxPrice(),
+		Limit:     int(req.GetLimit()),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &bookstorev1.SearchBooksResponse{Books: toProtoBooks(books)}, nil
+}
@@ -0,0 +1,80 @@
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	bookstorev1 "github.com/dtg-lucifer/go-bookstore/pkg/pb/bookstore/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProtoBook converts a book model into its protobuf message
+func toProtoBook(book *models.Book) *bookstorev1.Book {
+	return &bookstorev1.Book{
+		Id:            book.ID,
+		Name:          book.Name,
+		AuthorId:      book.AuthorID,
+		Author:        toProtoAuthor(&book.Author),
+		Publisher:     book.Publisher,
+		PublishedYear: uint32(book.PublishedYear),
+		Description:   book.Description,
+		Price:         book.Price,
+		Pages:         int32(book.Pages),
+		CreatedAt:     toProtoTime(book.CreatedAt),
+		UpdatedAt:     toProtoTime(book.UpdatedAt),
+	}
+}
+
+// toProtoBooks converts a list of book models
+func toProtoBooks(books []models.Book) []*bookstorev1.Book {
+	result := make([]*bookstorev1.Book, len(books))
+	for i := range books {
+		result[i] = toProtoBook(&books[i])
+	}
+	return result
+}
+
+// toProtoAuthor converts an author model into its protobuf message
+func toProtoAuthor(author *models.Author) *bookstorev1.Author {
+	return &bookstorev1.Author{
+		Id:        author.ID,
+		Name:      author.Name,
+		Bio:       author.Bio,
+		CreatedAt: toProtoTime(author.CreatedAt),
+		UpdatedAt: toProtoTime(author.UpdatedAt),
+	}
+}
+
+// fromProtoBook converts a protobuf book into a model; timestamps are ignored
+func fromProtoBook(book *bookstorev1.Book) *models.Book {
+	if book == nil {
+		return nil
+	}
+
+	result := &models.Book{
+		ID:            book.GetId(),
+		Name:          book.GetName(),
+		AuthorID:      book.GetAuthorId(),
+		Publisher:     book.GetPublisher(),
+		PublishedYear: uint(book.GetPublishedYear()),
+		Description:   book.GetDescription(),
+		Price:         book.GetPrice(),
+		Pages:         int(book.GetPages()),
+	}
+	if author := book.GetAuthor(); author != nil {
+		result.Author = models.Author{
+			ID:   author.GetId(),
+			Name: author.GetName(),
+			Bio:  author.GetBio(),
+		}
+	}
+	return result
+}
+
+// toProtoTime converts a time, leaving zero times unset
+func toProtoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
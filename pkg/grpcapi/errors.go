@@ -0,0 +1,60 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeForError maps a service or repository error to a gRPC status code
+func codeForError(err error) codes.Code {
+	switch {
+	case err == nil:
+		return codes.OK
+	case service.IsValidationError(err):
+		return codes.InvalidArgument
+	case errors.Is(err, repository.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// statusError converts a service or repository error into a gRPC status error.
+// Internal errors are not described to the client; the logging interceptor
+// records the original error.
+func statusError(err error) error {
+	code := codeForError(err)
+	if code == codes.Internal {
+		return &internalError{err: err}
+	}
+	return status.Error(code, err.Error())
+}
+
+// internalError hides an internal error from clients while keeping it for the logs
+type internalError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *internalError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the original error
+func (e *internalError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus implements the interface used by the status package
+func (e *internalError) GRPCStatus() *status.Status {
+	return status.New(codes.Internal, "internal error")
+}
@@ -0,0 +1,50 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+		msg  string
+	}{
+		{"validation", fmt.Errorf("create: %w", &service.ValidationError{}), codes.InvalidArgument, "create: "},
+		{"not found", fmt.Errorf("book 1: %w", repository.ErrNotFound), codes.NotFound, "book 1: " + repository.ErrNotFound.Error()},
+		{"canceled", context.Canceled, codes.Canceled, context.Canceled.Error()},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded, "query: " + context.DeadlineExceeded.Error()},
+		{"internal", errors.New("dial tcp 10.0.0.5:3306: connection refused"), codes.Internal, "internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codeForError(tt.err); got != tt.code {
+				t.Errorf("codeForError() = %s, want %s", got, tt.code)
+			}
+
+			err := statusError(tt.err)
+			if st := status.Convert(err); st.Code() != tt.code || st.Message() != tt.msg {
+				t.Errorf("statusError() = %s %q, want %s %q", st.Code(), st.Message(), tt.code, tt.msg)
+			}
+		})
+	}
+
+	if codeForError(nil) != codes.OK {
+		t.Errorf("codeForError(nil) = %s, want OK", codeForError(nil))
+	}
+
+	// Hidden errors keep the original for the logs
+	original := errors.New("disk full")
+	if err := statusError(original); !errors.Is(err, original) || err.Error() != "disk full" {
+		t.Errorf("statusError() = %v, want to wrap the original error", err)
+	}
+}
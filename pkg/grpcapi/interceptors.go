@@ -0,0 +1,204 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from incoming calls, matching the REST headers. The
+// actor is only read from trusted proxies.
+const (
+	ActorMetadataKey     = "x-actor"
+	RequestIDMetadataKey = "x-request-id"
+)
+
+// Tenants resolves the tenant of calls to the bookstore services
+type Tenants struct {
+	Resolver   tenant.Resolver
+	Repository repository.TenantRepository
+}
+
+// UnaryInterceptor prepares the context of every unary call (see callContext),
+// turns panics into Internal errors and logs the completed call
+func UnaryInterceptor(logger *slog.Logger, tenants Tenants, actors audit.ActorResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx, callLogger := callContext(ctx, logger, actors, info.FullMethod)
+		start := time.Now()
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, callLogger, r)
+			}
+			logCall(ctx, callLogger, start, err)
+		}()
+
+		scoped, err := tenantContext(ctx, tenants, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(scoped, req)
+	}
+}
+
+// StreamInterceptor is the streaming counterpart of UnaryInterceptor
+func StreamInterceptor(logger *slog.Logger, tenants Tenants, actors audit.ActorResolver) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, callLogger := callContext(stream.Context(), logger, actors, info.FullMethod)
+		start := time.Now()
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, callLogger, r)
+			}
+			logCall(ctx, callLogger, start, err)
+		}()
+
+		scoped, err := tenantContext(ctx, tenants, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: scoped})
+	}
+}
+
+// tenantContext scopes the context of a call to a bookstore service to the
+// tenant named by its metadata (with the same sources as REST requests, the
+// header being lower case metadata) or by its authority. Health and
+// reflection calls are not scoped.
+func tenantContext(ctx context.Context, tenants Tenants, method string) (context.Context, error) {
+	if !strings.HasPrefix(method, "/bookstore.") {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	id, err := tenants.Resolver.Resolve(tenant.Request{
+		Header: func(name string) string { return firstValue(md, strings.ToLower(name)) },
+		Host:   firstValue(md, ":authority"),
+	})
+	if errors.Is(err, tenant.ErrInvalidToken) {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "a tenant is required, e.g. in the %s metadata", strings.ToLower(tenants.Resolver.Header))
+	}
+
+	current, err := tenants.Repository.GetTenantByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !current.Active) {
+		return nil, status.Errorf(codes.NotFound, "tenant %s not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tenant.WithID(ctx, current.ID), nil
+}
+
+// callContext stores the caller identified by actors and the request ID
+// from the call metadata for the audit log, a request-scoped logger and a
+// database session in ctx. A request ID is generated when the caller sent
+// none.
+func callContext(ctx context.Context, logger *slog.Logger, actors audit.ActorResolver, method string) (context.Context, *slog.Logger) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var addr netip.Addr
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if addrPort, err := netip.ParseAddrPort(p.Addr.String()); err == nil {
+			addr = addrPort.Addr()
+		}
+	}
+	actor := audit.Actor{
+		ID: actors.Resolve(audit.ActorRequest{
+			Authorization: firstValue(md, "authorization"),
+			Header:        firstValue(md, ActorMetadataKey),
+			Peer:          addr,
+		}),
+		RequestID: firstValue(md, RequestIDMetadataKey),
+	}
+	if actor.RequestID == "" {
+		actor.RequestID = uuid.NewString()
+	}
+
+	attrs := []any{
+		"request_id", actor.RequestID,
+		"actor", actor.ID,
+		"grpc_method", method,
+	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		attrs = append(attrs, "trace_id", spanCtx.TraceID().String())
+	}
+	callLogger := logger.With(attrs...)
+
+	ctx = audit.WithActor(ctx, actor)
+	ctx = logging.WithLogger(ctx, callLogger)
+	ctx = database.WithSession(ctx)
+	return ctx, callLogger
+}
+
+// logCall logs a completed call with its status code
+func logCall(ctx context.Context, logger *slog.Logger, start time.Time, err error) {
+	code := status.Code(err)
+
+	fields := []any{
+		"code", code.String(),
+		"latency", time.Since(start),
+	}
+	if err != nil {
+		fields = append(fields, "error", err)
+	}
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	logger.Log(ctx, level, "Call completed", fields...)
+}
+
+// recovered logs a recovered panic and converts it into an Internal error
+func recovered(ctx context.Context, logger *slog.Logger, r any) error {
+	logger.ErrorContext(ctx, "Panic while handling call", "panic", r, "stack", string(debug.Stack()))
+	return &internalError{err: fmt.Errorf("panic: %v", r)}
+}
+
+// firstValue returns the first value of a metadata key, or ""
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the prepared call context
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,37 @@
+package grpcapi
+
+import (
+	"log/slog"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	bookstorev1 "github.com/dtg-lucifer/go-bookstore/pkg/pb/bookstore/v1"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer creates a gRPC server exposing the book and author services, the
+// standard health service and server reflection. Calls are traced, logged,
+// attributed in the audit log and scoped to a tenant like REST requests.
+func NewServer(bookService service.BookService, authorService service.AuthorService, tenants Tenants, actors audit.ActorResolver, logger *slog.Logger) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(UnaryInterceptor(logger, tenants, actors)),
+		grpc.ChainStreamInterceptor(StreamInterceptor(logger, tenants, actors)),
+	)
+
+	bookstorev1.RegisterBookServiceServer(server, NewBookServer(bookService))
+	bookstorev1.RegisterAuthorServiceServer(server, NewAuthorServer(authorService))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(bookstorev1.BookService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(bookstorev1.AuthorService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return server
+}
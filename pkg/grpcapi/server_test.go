@@ -0,0 +1,314 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/grpcapi"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	bookstorev1 "github.com/dtg-lucifer/go-bookstore/pkg/pb/bookstore/v1"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const adminToken = "admin-token"
+
+// recordingBookService records the context of the last book read, and fails
+// or panics on request
+type recordingBookService struct {
+	service.BookService
+
+	mu   sync.Mutex
+	last context.Context
+}
+
+func (s *recordingBookService) GetBookByID(ctx context.Context, id string) (*models.Book, error) {
+	s.mu.Lock()
+	s.last = ctx
+	s.mu.Unlock()
+
+	switch id {
+	case "fail":
+		return nil, errors.New("dial tcp 10.0.0.5:3306: connection refused")
+	case "panic":
+		panic("nil map")
+	}
+	return s.BookService.GetBookByID(ctx, id)
+}
+
+// lastContext returns the context of the last book read
+func (s *recordingBookService) lastContext() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// testServer is a gRPC server on a SQLite catalog with the default tenant,
+// an active tenant acme and an inactive tenant closed, reached in memory
+type testServer struct {
+	books   *recordingBookService
+	book    bookstorev1.BookServiceClient
+	author  bookstorev1.AuthorServiceClient
+	health  healthpb.HealthClient
+	context context.Context
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	db := repotest.SQLite(t)
+	tenants := impl.NewTenantRepository(db)
+	for _, current := range []models.Tenant{{ID: "acme", Name: "Acme", Active: true}, {ID: "closed", Name: "Closed"}} {
+		if err := tenants.CreateTenant(t.Context(), &current); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	books := &recordingBookService{
+		BookService: service.NewBookService(impl.NewBookRepository(db), impl.NewAuthorRepository(db),
+			database.NewTxManager(db, logging.Discard()), logging.Discard(), service.BookServiceOptions{}),
+	}
+	server := grpcapi.NewServer(books, service.NewAuthorService(impl.NewAuthorRepository(db)), grpcapi.Tenants{
+		Resolver:   tenant.Resolver{Sources: []string{tenant.SourceHeader}, Header: tenant.DefaultHeader},
+		Repository: tenants,
+	}, audit.ActorResolver{AdminToken: adminToken}, logging.Discard())
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{
+		books:   books,
+		book:    bookstorev1.NewBookServiceClient(conn),
+		author:  bookstorev1.NewAuthorServiceClient(conn),
+		health:  healthpb.NewHealthClient(conn),
+		context: metadata.AppendToOutgoingContext(t.Context(), "x-tenant-id", tenant.DefaultID),
+	}
+}
+
+// createBook creates a book by Ursula K. Le Guin through the API
+func (s *testServer) createBook(t *testing.T, ctx context.Context, name string, price float64) *bookstorev1.Book {
+	t.Helper()
+	book, err := s.book.CreateBook(ctx, &bookstorev1.CreateBookRequest{Book: &bookstorev1.Book{
+		Name:   name,
+		Price:  price,
+		Author: &bookstorev1.Author{Name: "Ursula K. Le Guin"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateBook(%s): %v", name, err)
+	}
+	return book
+}
+
+func TestBookServiceCRUD(t *testing.T) {
+	s := newTestServer(t)
+	ctx := s.context
+
+	created := s.createBook(t, ctx, "The Dispossessed", 12)
+	if created.GetId() == "" || created.GetAuthorId() == "" || created.GetCreatedAt() == nil {
+		t.Fatalf("CreateBook() = %v, want a stored book", created)
+	}
+
+	got, err := s.book.GetBook(ctx, &bookstorev1.GetBookRequest{Id: created.GetId()})
+	if err != nil || got.GetName() != "The Dispossessed" || got.GetAuthor().GetName() != "Ursula K. Le Guin" {
+		t.Fatalf("GetBook() = %v, %v", got, err)
+	}
+
+	updated, err := s.book.UpdateBook(ctx, &bookstorev1.UpdateBookRequest{Id: created.GetId(), Book: &bookstorev1.Book{Price: 14}})
+	if err != nil || updated.GetPrice() != 14 || updated.GetName() != "The Dispossessed" {
+		t.Fatalf("UpdateBook() = %v, %v, want the new price only", updated, err)
+	}
+
+	author, err := s.author.GetAuthor(ctx, &bookstorev1.GetAuthorRequest{Id: created.GetAuthorId()})
+	if err != nil || author.GetName() != "Ursula K. Le Guin" {
+		t.Fatalf("GetAuthor() = %v, %v", author, err)
+	}
+	authors, err := s.author.ListAuthors(ctx, &bookstorev1.ListAuthorsRequest{})
+	if err != nil || len(authors.GetAuthors()) != 1 {
+		t.Fatalf("ListAuthors() = %v, %v, want one author", authors, err)
+	}
+
+	if _, err := s.book.DeleteBook(ctx, &bookstorev1.DeleteBookRequest{Id: created.GetId()}); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	if _, err := s.book.GetBook(ctx, &bookstorev1.GetBookRequest{Id: created.GetId()}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetBook() of a deleted book: %v, want NotFound", err)
+	}
+	if _, err := s.book.DeleteBook(ctx, &bookstorev1.DeleteBookRequest{Id: created.GetId()}); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteBook() of a deleted book: %v, want NotFound", err)
+	}
+
+	_, err = s.book.CreateBook(ctx, &bookstorev1.CreateBookRequest{Book: &bookstorev1.Book{Price: 10}})
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() == "" {
+		t.Errorf("CreateBook() without a name: %v, want a described InvalidArgument", err)
+	}
+}
+
+func TestListAndSearchBooks(t *testing.T) {
+	s := newTestServer(t)
+	ctx := s.context
+	for i, name := range []string{"A Wizard of Earthsea", "The Tombs of Atuan", "The Farthest Shore"} {
+		s.createBook(t, ctx, name, float64(10+i))
+	}
+
+	stream, err := s.book.ListBooks(ctx, &bookstorev1.ListBooksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		book, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ListBooks() Recv: %v", err)
+		}
+		names = append(names, book.GetName())
+	}
+	if len(names) != 3 {
+		t.Errorf("ListBooks() streamed %v, want the three books", names)
+	}
+
+	found, err := s.book.SearchBooks(ctx, &bookstorev1.SearchBooksRequest{Query: "the", MinPrice: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if books := found.GetBooks(); len(books) != 2 {
+		t.Errorf("SearchBooks() = %v, want the two books from 11", books)
+	}
+	found, err = s.book.SearchBooks(ctx, &bookstorev1.SearchBooksRequest{Author: "Le Guin", Limit: 1})
+	if err != nil || len(found.GetBooks()) != 1 {
+		t.Errorf("SearchBooks() with a limit = %v, %v, want one book", found, err)
+	}
+}
+
+func TestTenantInterceptor(t *testing.T) {
+	s := newTestServer(t)
+	book := s.createBook(t, s.context, "Lavinia", 10)
+	acme := metadata.AppendToOutgoingContext(t.Context(), "x-tenant-id", "acme")
+
+	// A book is only found in its tenant, in unary and streaming calls
+	if _, err := s.book.GetBook(acme, &bookstorev1.GetBookRequest{Id: book.GetId()}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetBook() in another tenant: %v, want NotFound", err)
+	}
+	if id, _ := tenant.FromContext(s.books.lastContext()); id != "acme" {
+		t.Errorf("call tenant = %q, want acme", id)
+	}
+	stream, err := s.book.ListBooks(acme, &bookstorev1.ListBooksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("ListBooks() in another tenant: %v, want no books", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"no tenant", t.Context(), codes.InvalidArgument},
+		{"unknown tenant", metadata.AppendToOutgoingContext(t.Context(), "x-tenant-id", "unknown"), codes.NotFound},
+		{"inactive tenant", metadata.AppendToOutgoingContext(t.Context(), "x-tenant-id", "closed"), codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.book.GetBook(tt.ctx, &bookstorev1.GetBookRequest{Id: book.GetId()}); status.Code(err) != tt.want {
+				t.Errorf("GetBook() error = %v, want %s", err, tt.want)
+			}
+			stream, err := s.book.ListBooks(tt.ctx, &bookstorev1.ListBooksRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if status.Code(err) != tt.want {
+				t.Errorf("ListBooks() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	// Health checks are not scoped to a tenant
+	response, err := s.health.Check(t.Context(), &healthpb.HealthCheckRequest{Service: bookstorev1.BookService_ServiceDesc.ServiceName})
+	if err != nil || response.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Check() = %v, %v, want SERVING", response, err)
+	}
+}
+
+func TestActorInterceptor(t *testing.T) {
+	s := newTestServer(t)
+	book := s.createBook(t, s.context, "The Lathe of Heaven", 10)
+
+	tests := []struct {
+		name      string
+		md        []string
+		actor     string
+		requestID string
+	}{
+		{"admin token", []string{"authorization", "Bearer " + adminToken, "x-request-id", "req-1"}, audit.AdminActor, "req-1"},
+		{"another token", []string{"authorization", "Bearer nope"}, "", ""},
+		// The caller is not a trusted proxy
+		{"actor metadata", []string{"x-actor", "mallory"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(s.context, tt.md...)
+			if _, err := s.book.GetBook(ctx, &bookstorev1.GetBookRequest{Id: book.GetId()}); err != nil {
+				t.Fatal(err)
+			}
+
+			actor := audit.ActorFromContext(s.books.lastContext())
+			if id, _ := audit.IdentifiedActor(s.books.lastContext()); id != tt.actor {
+				t.Errorf("actor = %q, want %q", id, tt.actor)
+			}
+			if tt.requestID != "" && actor.RequestID != tt.requestID {
+				t.Errorf("request ID = %q, want %q", actor.RequestID, tt.requestID)
+			}
+			if actor.RequestID == "" {
+				t.Error("request ID is empty, want a generated one")
+			}
+		})
+	}
+}
+
+func TestInternalErrorsAreHidden(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, id := range []string{"fail", "panic"} {
+		_, err := s.book.GetBook(s.context, &bookstorev1.GetBookRequest{Id: id})
+		if st := status.Convert(err); st.Code() != codes.Internal || st.Message() != "internal error" {
+			t.Errorf("GetBook(%s) error = %v, want an undescribed Internal error", id, err)
+		}
+	}
+
+	// The server survives the panic
+	if _, err := s.book.GetBook(s.context, &bookstorev1.GetBookRequest{Id: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetBook() after a panic: %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler handles HTTP requests related to the audit log
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler with the provided service
+func NewAuditHandler(service service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: service,
+	}
+}
+
+// GetAuditEntries handles GET /audit request
+func (h *AuditHandler) GetAuditEntries(ctx *fiber.Ctx) error {
+	entries, err := h.auditService.ListEntries(ctx.UserContext(), repository.AuditFilter{
+		EntityType: ctx.Query("entity"),
+		EntityID:   ctx.Query("id"),
+		Actor:      ctx.Query("actor"),
+		Limit:      ctx.QueryInt("limit"),
+	})
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Audit entries retrieved successfully", entries)
+}
+
+// GetBookHistory handles GET /books/:id/history request
+func (h *AuditHandler) GetBookHistory(ctx *fiber.Ctx) error {
+	entries, err := h.auditService.BookHistory(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Book history retrieved successfully", entries)
+}
+
+// revertRequest is the body of POST /books/:id/revert
+type revertRequest struct {
+	Version int `json:"version"`
+}
+
+// RevertBook handles POST /books/:id/revert request
+func (h *AuditHandler) RevertBook(ctx *fiber.Ctx) error {
+	body := new(revertRequest)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	book, err := h.auditService.RevertBook(ctx.UserContext(), ctx.Params("id"), body.Version)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Book reverted successfully", book)
+}
@@ -0,0 +1,216 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/middleware"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+// newAuditTestApp serves the book and audit routes on a SQLite catalog of
+// the default tenant. Every caller is a trusted proxy, so X-Actor names the
+// actor recorded in the audit log.
+func newAuditTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	db := repotest.SQLite(t)
+	bookService := service.NewBookService(impl.NewBookRepository(db), impl.NewAuthorRepository(db),
+		database.NewTxManager(db, logging.Discard()), logging.Discard(), service.BookServiceOptions{})
+	bookHandler := handlers.NewBookHandler(bookService, 0)
+	auditHandler := handlers.NewAuditHandler(service.NewAuditService(impl.NewAuditRepository(db), bookService, logging.Discard()))
+
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(tenant.WithID(ctx.UserContext(), tenant.DefaultID))
+		return ctx.Next()
+	})
+	app.Use(middleware.Actor(audit.ActorResolver{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")},
+	}))
+
+	router := app.Group("/api/v1", handlers.UseVersion(handlers.V1))
+	router.Get("/books/:id", bookHandler.GetBookById).Name(handlers.V1.Name + "." + handlers.RouteBook)
+	router.Post("/books", bookHandler.CreateBook)
+	router.Put("/books/:id", bookHandler.UpdateBook)
+	router.Delete("/books/:id", bookHandler.DeleteBook)
+	router.Get("/books/:id/history", auditHandler.GetBookHistory)
+	router.Post("/books/:id/revert", auditHandler.RevertBook)
+	router.Get("/audit", auditHandler.GetAuditEntries)
+	return app
+}
+
+// history returns the audit entries of a book, oldest first
+func history(t *testing.T, api *apitest.Client, id string) []models.AuditEntry {
+	t.Helper()
+	var entries []models.AuditEntry
+	api.Get("/api/v1/books/" + id + "/history").ExpectStatus(http.StatusOK).Data(&entries)
+	return entries
+}
+
+// changes decodes the changes of an audit entry
+func changes(t *testing.T, entry models.AuditEntry) map[string]events.FieldChange {
+	t.Helper()
+	var changes map[string]events.FieldChange
+	if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+func TestAuditHandlerRecordsChanges(t *testing.T) {
+	api := apitest.New(t, newAuditTestApp(t))
+	alice, bob := api.WithHeader(middleware.ActorHeader, "alice"), api.WithHeader(middleware.ActorHeader, "bob")
+
+	var book models.Book
+	alice.Post("/api/v1/books", map[string]any{
+		"name":   "Dune",
+		"price":  10,
+		"author": map[string]any{"name": "Frank Herbert"},
+	}).ExpectStatus(http.StatusCreated).Data(&book)
+	bob.Put("/api/v1/books/"+book.ID, map[string]any{"price": 12, "publisher": "Ace"}).ExpectStatus(http.StatusOK)
+	// An update that changes nothing is not recorded
+	bob.Put("/api/v1/books/"+book.ID, map[string]any{"price": 12}).ExpectStatus(http.StatusOK)
+	api.Delete("/api/v1/books/" + book.ID).ExpectStatus(http.StatusOK)
+
+	entries := history(t, api, book.ID)
+	if len(entries) != 3 {
+		t.Fatalf("history = %+v, want three entries", entries)
+	}
+	want := []struct {
+		action string
+		actor  string
+	}{
+		{audit.ActionCreate, "alice"},
+		{audit.ActionUpdate, "bob"},
+		{audit.ActionDelete, audit.AnonymousActor},
+	}
+	for i, entry := range entries {
+		if entry.Version != i+1 || entry.Action != want[i].action || entry.Actor != want[i].actor || entry.RequestID == "" {
+			t.Errorf("entry %d = version %d, %s by %q in %q, want %s by %q",
+				i, entry.Version, entry.Action, entry.Actor, entry.RequestID, want[i].action, want[i].actor)
+		}
+	}
+
+	update := changes(t, entries[1])
+	wantUpdate := map[string]events.FieldChange{
+		"price":     {Old: 10.0, New: 12.0},
+		"publisher": {Old: "", New: "Ace"},
+	}
+	if len(update) != len(wantUpdate) || update["price"] != wantUpdate["price"] || update["publisher"] != wantUpdate["publisher"] {
+		t.Errorf("update changes = %v, want %v", update, wantUpdate)
+	}
+	if deletion := changes(t, entries[2]); deletion["name"].Old != "Dune" || deletion["name"].New != nil {
+		t.Errorf("delete changes = %v, want every field removed", deletion)
+	}
+
+	// The audit log can be filtered by actor
+	var byBob []models.AuditEntry
+	api.Get("/api/v1/audit?actor=bob").ExpectStatus(http.StatusOK).Data(&byBob)
+	if len(byBob) != 1 || byBob[0].Action != audit.ActionUpdate {
+		t.Errorf("entries of bob = %+v, want the update", byBob)
+	}
+}
+
+func TestAuditHandlerRevertsUpdates(t *testing.T) {
+	api := apitest.New(t, newAuditTestApp(t)).WithHeader(middleware.ActorHeader, "alice")
+
+	var book models.Book
+	api.Post("/api/v1/books", map[string]any{
+		"name":   "Dune",
+		"price":  10,
+		"author": map[string]any{"name": "Frank Herbert"},
+	}).ExpectStatus(http.StatusCreated).Data(&book)
+	api.Put("/api/v1/books/"+book.ID, map[string]any{"name": "Dune Messiah", "price": 12}).ExpectStatus(http.StatusOK)
+
+	var reverted models.Book
+	api.Post("/api/v1/books/"+book.ID+"/revert", map[string]any{"version": 1}).ExpectStatus(http.StatusOK).Data(&reverted)
+	if reverted.Name != "Dune" || reverted.Price != 10 {
+		t.Errorf("revert returned %+v, want version 1", reverted)
+	}
+	var current models.Book
+	api.Get("/api/v1/books/" + book.ID).ExpectStatus(http.StatusOK).Data(&current)
+	if current.Name != "Dune" || current.Price != 10 || current.AuthorID != book.AuthorID {
+		t.Errorf("book after the revert = %+v, want version 1", current)
+	}
+
+	// The revert is recorded as a new version
+	entries := history(t, api, book.ID)
+	if len(entries) != 3 || entries[2].Action != audit.ActionRevert || entries[2].Actor != "alice" {
+		t.Fatalf("history = %+v, want a revert by alice", entries)
+	}
+	if revert := changes(t, entries[2]); revert["price"].Old != 12.0 || revert["price"].New != 10.0 {
+		t.Errorf("revert changes = %v, want the price back to 10", revert)
+	}
+}
+
+func TestAuditHandlerRevertsDeletions(t *testing.T) {
+	api := apitest.New(t, newAuditTestApp(t))
+
+	var book models.Book
+	api.Post("/api/v1/books", map[string]any{
+		"name":   "Dune",
+		"price":  10,
+		"author": map[string]any{"name": "Frank Herbert"},
+	}).ExpectStatus(http.StatusCreated).Data(&book)
+	api.Put("/api/v1/books/"+book.ID, map[string]any{"price": 12}).ExpectStatus(http.StatusOK)
+	api.Delete("/api/v1/books/" + book.ID).ExpectStatus(http.StatusOK)
+	api.Get("/api/v1/books/" + book.ID).ExpectStatus(http.StatusNotFound)
+
+	// The version that deleted the book cannot be restored
+	api.Post("/api/v1/books/"+book.ID+"/revert", map[string]any{"version": 3}).ExpectStatus(http.StatusBadRequest)
+
+	api.Post("/api/v1/books/"+book.ID+"/revert", map[string]any{"version": 2}).ExpectStatus(http.StatusOK)
+	var restored models.Book
+	api.Get("/api/v1/books/" + book.ID).ExpectStatus(http.StatusOK).Data(&restored)
+	if restored.Name != "Dune" || restored.Price != 12 || restored.AuthorID != book.AuthorID || restored.Author.Name != "Frank Herbert" {
+		t.Errorf("restored book = %+v, want version 2", restored)
+	}
+	if entries := history(t, api, book.ID); len(entries) != 4 || entries[3].Action != audit.ActionRevert {
+		t.Errorf("history = %+v, want the restore recorded", entries)
+	}
+}
+
+func TestAuditHandlerRevertErrors(t *testing.T) {
+	api := apitest.New(t, newAuditTestApp(t))
+
+	var book models.Book
+	api.Post("/api/v1/books", map[string]any{
+		"name":   "Dune",
+		"price":  10,
+		"author": map[string]any{"name": "Frank Herbert"},
+	}).ExpectStatus(http.StatusCreated).Data(&book)
+
+	tests := []struct {
+		name string
+		id   string
+		body any
+		want int
+	}{
+		{"unknown version", book.ID, map[string]any{"version": 9}, http.StatusNotFound},
+		{"unknown book", "unknown", map[string]any{"version": 1}, http.StatusNotFound},
+		{"no version", book.ID, map[string]any{}, http.StatusBadRequest},
+		{"negative version", book.ID, map[string]any{"version": -1}, http.StatusBadRequest},
+		{"invalid body", book.ID, "version", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.Post("/api/v1/books/"+tt.id+"/revert", tt.body).ExpectStatus(tt.want)
+		})
+	}
+}
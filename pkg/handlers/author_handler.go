@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthorHandler handles HTTP requests related to authors
+type AuthorHandler struct {
+	authorService service.AuthorService
+	bookService   service.BookService
+}
+
+// NewAuthorHandler creates a new AuthorHandler with the provided services
+func NewAuthorHandler(authorService service.AuthorService, bookService service.BookService) *AuthorHandler {
+	return &AuthorHandler{
+		authorService: authorService,
+		bookService:   bookService,
+	}
+}
+
+// GetAuthorById handles GET /authors/:id request.
+// IDs of merged authors return the author they were merged into.
+func (h *AuthorHandler) GetAuthorById(ctx *fiber.Ctx) error {
+	author, err := h.authorService.GetAuthorByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Author retrieved successfully", author)
+}
+
+// GetDuplicates handles GET /authors/duplicates request.
+// The threshold query parameter sets the name similarity, from 0.7 to 1.
+func (h *AuthorHandler) GetDuplicates(ctx *fiber.Ctx) error {
+	threshold := ctx.QueryFloat("threshold", service.DefaultDuplicateThreshold)
+
+	groups, err := h.authorService.FindDuplicates(ctx.UserContext(), threshold)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Duplicate authors retrieved successfully", groups)
+}
+
+// mergeRequest is the body of POST /authors/:id/merge
+type mergeRequest struct {
+	// AuthorIDs are the authors merged into the one of the path
+	AuthorIDs []string `json:"author_ids" validate:"required"`
+}
+
+// MergeAuthors handles POST /authors/:id/merge request
+func (h *AuthorHandler) MergeAuthors(ctx *fiber.Ctx) error {
+	body := new(mergeRequest)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	author, err := h.bookService.MergeAuthors(ctx.UserContext(), ctx.Params("id"), body.AuthorIDs)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Authors merged successfully", author)
+}
@@ -0,0 +1,144 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// createBookBy creates a book through the API with a new author named author
+func createBookBy(t *testing.T, api *apitest.Client, name string, author string) models.Book {
+	t.Helper()
+	var book models.Book
+	api.Post("/api/v1/books", map[string]any{
+		"name":   name,
+		"price":  10,
+		"author": map[string]any{"name": author},
+	}).ExpectStatus(http.StatusCreated).Data(&book)
+	return book
+}
+
+// duplicates returns the groups of duplicate authors at threshold, as the IDs of their authors
+func duplicates(t *testing.T, api *apitest.Client, threshold string) [][]string {
+	t.Helper()
+	var groups []models.AuthorDuplicates
+	api.Get("/api/v1/authors/duplicates?threshold=" + threshold).ExpectStatus(http.StatusOK).Data(&groups)
+
+	ids := [][]string{}
+	for _, group := range groups {
+		var groupIDs []string
+		for _, author := range group.Authors {
+			groupIDs = append(groupIDs, author.ID)
+		}
+		ids = append(ids, groupIDs)
+	}
+	return ids
+}
+
+func TestAuthorHandlerDuplicates(t *testing.T) {
+	api := apitest.New(t, newTestApp(t)).WithHeader("X-Tenant-ID", "default")
+	rowling := createBookBy(t, api, "Harry Potter and the Philosopher's Stone", "J.K. Rowling")
+	alias := createBookBy(t, api, "Harry Potter and the Chamber of Secrets", "JK Rowling")
+	frank := createBookBy(t, api, "Dune", "Frank Herbert")
+	brian := createBookBy(t, api, "Dune: House Atreides", "Brian Herbert")
+	createBookBy(t, api, "Stories of Your Life", "Ted Chiang")
+
+	// Authors are grouped in creation order
+	got := duplicates(t, api, "0.92")
+	if len(got) != 1 || len(got[0]) != 2 || got[0][0] != rowling.AuthorID || got[0][1] != alias.AuthorID {
+		t.Errorf("duplicates at 0.92 = %v, want the two Rowlings", got)
+	}
+	got = duplicates(t, api, "0.85")
+	if len(got) != 2 || got[1][0] != frank.AuthorID || got[1][1] != brian.AuthorID {
+		t.Errorf("duplicates at 0.85 = %v, want the Rowlings and the Herberts", got)
+	}
+
+	// The default threshold is 0.92
+	var groups []models.AuthorDuplicates
+	api.Get("/api/v1/authors/duplicates").ExpectStatus(http.StatusOK).Data(&groups)
+	if len(groups) != 1 || groups[0].Similarity < 0.92 || groups[0].Similarity > 1 {
+		t.Errorf("duplicates = %+v, want the Rowlings", groups)
+	}
+
+	for _, threshold := range []string{"0.5", "1.5", "-1"} {
+		api.Get("/api/v1/authors/duplicates?threshold=" + threshold).ExpectStatus(http.StatusBadRequest)
+	}
+}
+
+func TestAuthorHandlerMergeAuthors(t *testing.T) {
+	api := apitest.New(t, newTestApp(t)).WithHeader("X-Tenant-ID", "default")
+	first := createBookBy(t, api, "Harry Potter and the Philosopher's Stone", "J.K. Rowling")
+	second := createBookBy(t, api, "Harry Potter and the Chamber of Secrets", "JK Rowling")
+	third := createBookBy(t, api, "Harry Potter and the Prisoner of Azkaban", "J. K. Rowling")
+
+	var survivor models.Author
+	api.Post("/api/v2/authors/"+first.AuthorID+"/merge", map[string]any{
+		"author_ids": []string{second.AuthorID, third.AuthorID},
+	}).ExpectStatus(http.StatusOK).Data(&survivor)
+	if survivor.ID != first.AuthorID || survivor.Name != "J.K. Rowling" {
+		t.Errorf("merged author = %+v, want the one of the path", survivor)
+	}
+
+	// The books of the merged authors now belong to the survivor
+	for _, book := range []models.Book{second, third} {
+		var moved models.Book
+		api.Get("/api/v1/books/" + book.ID).ExpectStatus(http.StatusOK).Data(&moved)
+		if moved.AuthorID != first.AuthorID {
+			t.Errorf("book %s has author %s, want %s", book.Name, moved.AuthorID, first.AuthorID)
+		}
+	}
+
+	// The merged authors are gone: they are no longer duplicates, and their
+	// IDs lead to the survivor
+	if got := duplicates(t, api, "0.7"); len(got) != 0 {
+		t.Errorf("duplicates after the merge = %v, want none", got)
+	}
+	var redirected models.Author
+	api.Get("/api/v1/authors/" + second.AuthorID).ExpectStatus(http.StatusOK).Data(&redirected)
+	if redirected.ID != first.AuthorID {
+		t.Errorf("merged author %s resolves to %s, want %s", second.AuthorID, redirected.ID, first.AuthorID)
+	}
+	// Merging them again fails
+	api.Post("/api/v1/authors/"+first.AuthorID+"/merge", map[string]any{
+		"author_ids": []string{second.AuthorID},
+	}).ExpectStatus(http.StatusNotFound)
+}
+
+func TestAuthorHandlerMergeAuthorsErrors(t *testing.T) {
+	api := apitest.New(t, newTestApp(t)).WithHeader("X-Tenant-ID", "default")
+	first := createBookBy(t, api, "Dune", "Frank Herbert")
+	second := createBookBy(t, api, "Dune Messiah", "Frank Herbert")
+
+	tests := []struct {
+		name   string
+		author string
+		body   any
+		want   int
+	}{
+		{"into itself", first.AuthorID, map[string]any{"author_ids": []string{first.AuthorID}}, http.StatusBadRequest},
+		{"into itself among others", first.AuthorID, map[string]any{"author_ids": []string{second.AuthorID, first.AuthorID}}, http.StatusBadRequest},
+		{"listed twice", first.AuthorID, map[string]any{"author_ids": []string{second.AuthorID, second.AuthorID}}, http.StatusBadRequest},
+		{"nothing to merge", first.AuthorID, map[string]any{"author_ids": []string{}}, http.StatusBadRequest},
+		{"empty ID", first.AuthorID, map[string]any{"author_ids": []string{""}}, http.StatusBadRequest},
+		{"invalid body", first.AuthorID, "author_ids", http.StatusBadRequest},
+		{"unknown author", "unknown", map[string]any{"author_ids": []string{second.AuthorID}}, http.StatusNotFound},
+		{"unknown author to merge", first.AuthorID, map[string]any{"author_ids": []string{second.AuthorID, "unknown"}}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.Post("/api/v1/authors/"+tt.author+"/merge", tt.body).ExpectStatus(tt.want)
+		})
+	}
+
+	// Failed merges change nothing
+	var book models.Book
+	api.Get("/api/v1/books/" + second.ID).ExpectStatus(http.StatusOK).Data(&book)
+	if book.AuthorID != second.AuthorID {
+		t.Errorf("book author = %s after failed merges, want %s", book.AuthorID, second.AuthorID)
+	}
+	if got := duplicates(t, api, "0.92"); len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("duplicates after failed merges = %v, want both authors", got)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// batchItemResult is a BatchResult with the HTTP status code of its error
+type batchItemResult struct {
+	models.BatchResult
+	Code int `json:"code"`
+}
+
+// BatchBooks handles POST /books/batch request
+func (h *BookHandler) BatchBooks(ctx *fiber.Ctx) error {
+	body := new(models.BatchRequest)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	results, err := h.bookService.BatchBooks(ctx.UserContext(), body.Mode, body.Operations)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	items := make([]batchItemResult, len(results))
+	failed := 0
+	for i, result := range results {
+		items[i] = batchItemResult{BatchResult: result, Code: http.StatusOK}
+		switch result.Status {
+		case models.BatchStatusCreated:
+			items[i].Code = http.StatusCreated
+		case models.BatchStatusFailed:
+			items[i].Code = statusForError(result.Err)
+			failed++
+		case models.BatchStatusRolledBack, models.BatchStatusSkipped:
+			items[i].Code = http.StatusFailedDependency
+		}
+	}
+
+	if failed == 0 {
+		return respond(ctx, http.StatusOK, "Batch applied successfully", items)
+	}
+
+	if body.Mode == models.BatchModeBestEffort {
+		return respond(ctx, http.StatusMultiStatus, "Batch partially applied", items)
+	}
+
+	return versionOf(ctx).Serializer.Write(ctx, http.StatusUnprocessableEntity, Reply{
+		Error: "Batch rolled back",
+		Data:  items,
+	})
+}
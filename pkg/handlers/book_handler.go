@@ -1,45 +1,123 @@
 package handlers
 
 import (
-	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
 	"github.com/dtg-lucifer/go-bookstore/pkg/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 // BookHandler handles HTTP requests related to books
 type BookHandler struct {
-	bookService service.BookService
+	bookService  service.BookService
+	cacheControl string
 }
 
-// NewBookHandler creates a new BookHandler with the provided service
-func NewBookHandler(service service.BookService) *BookHandler {
+// NewBookHandler creates a new BookHandler with the provided service.
+// Clients may cache catalog reads for maxAge; with a zero maxAge they must
+// revalidate them on every use.
+func NewBookHandler(service service.BookService, maxAge time.Duration) *BookHandler {
+	cacheControl := "no-cache"
+	if maxAge > 0 {
+		cacheControl = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	}
+
 	return &BookHandler{
-		bookService: service,
+		bookService:  service,
+		cacheControl: cacheControl,
 	}
 }
 
 // GetAllBooks handles GET /books request
 func (h *BookHandler) GetAllBooks(ctx *fiber.Ctx) error {
-	books, err := h.bookService.GetAllBooks(context.Background())
+	books, err := h.bookService.GetAllBooks(ctx.UserContext())
+	if err != nil {
+		return fail(ctx, http.StatusInternalServerError, "Failed to retrieve books")
+	}
+
+	// Deletions only show up in the catalog's modification time
+	lastModified, err := h.bookService.LastModified(ctx.UserContext())
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve books",
-		})
+		return fail(ctx, http.StatusInternalServerError, "Failed to retrieve books")
+	}
+	for _, book := range books {
+		lastModified = latest(lastModified, bookLastModified(book))
+	}
+
+	if h.notModified(ctx, lastModified) {
+		return ctx.SendStatus(http.StatusNotModified)
 	}
 
 	if len(books) == 0 {
-		return ctx.Status(http.StatusOK).JSON(fiber.Map{
-			"message": "No books found",
-			"data":    []models.Book{},
-		})
+		return respond(ctx, http.StatusOK, "No books found", []models.Book{})
+	}
+
+	return respond(ctx, http.StatusOK, "Books retrieved successfully", books)
+}
+
+// Page sizes of paginated book lists
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ListBooks handles GET /books request of paginated versions.
+// Supported query parameters are limit, cursor, q, author, publisher,
+// min_price and max_price. Books are ordered by name, then ID, and the
+// cursor of the next page points after the last book of the page.
+func (h *BookHandler) ListBooks(ctx *fiber.Ctx) error {
+	after, err := pagination.DecodeCursor(ctx.Query("cursor"))
+	if err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid cursor")
 	}
 
-	return ctx.Status(http.StatusOK).JSON(fiber.Map{
-		"message": "Books retrieved successfully",
-		"data":    books,
+	limit := ctx.QueryInt("limit", defaultPageSize)
+	if limit < 1 || limit > maxPageSize {
+		return fail(ctx, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPageSize))
+	}
+
+	// Fetch one more book than requested to know whether there is a next page
+	books, err := h.bookService.SearchBooks(ctx.UserContext(), repository.BookSearch{
+		Query:     ctx.Query("q"),
+		Author:    ctx.Query("author"),
+		Publisher: ctx.Query("publisher"),
+		MinPrice:  ctx.QueryFloat("min_price"),
+		MaxPrice:  ctx.QueryFloat("max_price"),
+		Limit:     limit + 1,
+		After:     after,
+	})
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	lastModified, err := h.bookService.LastModified(ctx.UserContext())
+	if err != nil {
+		return fail(ctx, http.StatusInternalServerError, "Failed to retrieve books")
+	}
+	for _, book := range books {
+		lastModified = latest(lastModified, bookLastModified(book))
+	}
+
+	if h.notModified(ctx, lastModified) {
+		return ctx.SendStatus(http.StatusNotModified)
+	}
+
+	page := &Page{HasMore: len(books) > limit}
+	if page.HasMore {
+		books = books[:limit]
+		last := books[limit-1]
+		page.NextCursor = pagination.EncodeCursor(pagination.Key{Name: last.Name, ID: last.ID})
+	}
+
+	return versionOf(ctx).Serializer.Write(ctx, http.StatusOK, Reply{
+		Message: "Books retrieved successfully",
+		Data:    books,
+		Page:    page,
 	})
 }
 
@@ -47,97 +125,102 @@ func (h *BookHandler) GetAllBooks(ctx *fiber.Ctx) error {
 func (h *BookHandler) GetBookById(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 	if id == "" {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Book ID is required",
-		})
+		return fail(ctx, http.StatusBadRequest, "Book ID is required")
 	}
 
-	book, err := h.bookService.GetBookByID(context.Background(), id)
+	book, err := h.bookService.GetBookByID(ctx.UserContext(), id)
 	if err != nil {
-		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return fail(ctx, statusForError(err), err.Error())
 	}
 
-	return ctx.Status(http.StatusOK).JSON(fiber.Map{
-		"message": "Book retrieved successfully",
-		"data":    book,
-	})
+	if h.notModified(ctx, bookLastModified(*book)) {
+		return ctx.SendStatus(http.StatusNotModified)
+	}
+
+	return respond(ctx, http.StatusOK, "Book retrieved successfully", book)
 }
 
-// CreateBook handles POST /books/create request
+// CreateBook handles POST /books request
 func (h *BookHandler) CreateBook(ctx *fiber.Ctx) error {
 	body := new(models.Book)
 	if err := ctx.BodyParser(body); err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
 	}
 
-	if err := h.bookService.CreateBook(context.Background(), body); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.bookService.CreateBook(ctx.UserContext(), body); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
 	}
 
-	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
-		"message": "Book created successfully",
-		"data":    body,
-	})
+	setLocation(ctx, RouteBook, body.ID)
+	return respond(ctx, http.StatusCreated, "Book created successfully", body)
 }
 
 // UpdateBook handles PUT /books/:id request
 func (h *BookHandler) UpdateBook(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 	if id == "" {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Book ID is required",
-		})
+		return fail(ctx, http.StatusBadRequest, "Book ID is required")
 	}
 
 	body := new(models.Book)
 	if err := ctx.BodyParser(body); err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
 	}
 
-	if err := h.bookService.UpdateBook(context.Background(), id, body); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.bookService.UpdateBook(ctx.UserContext(), id, body); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
 	}
 
 	// Fetch the updated book to return in the response
-	updatedBook, err := h.bookService.GetBookByID(context.Background(), id)
+	updatedBook, err := h.bookService.GetBookByID(ctx.UserContext(), id)
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Book updated but failed to retrieve the updated data",
-		})
+		return fail(ctx, http.StatusInternalServerError, "Book updated but failed to retrieve the updated data")
 	}
 
-	return ctx.Status(http.StatusOK).JSON(fiber.Map{
-		"message": "Book updated successfully",
-		"data":    updatedBook,
-	})
+	return respond(ctx, http.StatusOK, "Book updated successfully", updatedBook)
 }
 
 // DeleteBook handles DELETE /books/:id request
 func (h *BookHandler) DeleteBook(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 	if id == "" {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Book ID is required",
-		})
+		return fail(ctx, http.StatusBadRequest, "Book ID is required")
 	}
 
-	if err := h.bookService.DeleteBook(context.Background(), id); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.bookService.DeleteBook(ctx.UserContext(), id); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
 	}
 
-	return ctx.Status(http.StatusOK).JSON(fiber.Map{
-		"message": "Book deleted successfully",
-	})
+	return respond(ctx, http.StatusOK, "Book deleted successfully", nil)
+}
+
+// notModified sets the caching headers of a catalog read and reports whether
+// the client's copy, per If-Modified-Since, is still fresh
+func (h *BookHandler) notModified(ctx *fiber.Ctx, lastModified time.Time) bool {
+	ctx.Set(fiber.HeaderCacheControl, h.cacheControl)
+	if lastModified.IsZero() {
+		return false
+	}
+
+	ctx.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+
+	since, err := http.ParseTime(ctx.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a one second resolution
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// bookLastModified returns when a book or its author was last updated
+func bookLastModified(book models.Book) time.Time {
+	return latest(book.UpdatedAt, book.Author.UpdatedAt)
+}
+
+// latest returns the later of two times
+func latest(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
 }
@@ -0,0 +1,197 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/memory"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTestApp serves the book and author routes of every version under /api,
+// like the server does, on an in-memory catalog. Requests are scoped to the
+// tenant named by X-Tenant-ID.
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	store := memory.NewStore()
+	bookService := service.NewBookService(store.Books(), store.Authors(), store, logging.Discard(), service.BookServiceOptions{})
+	bookHandler := handlers.NewBookHandler(bookService, 0)
+	authorHandler := handlers.NewAuthorHandler(service.NewAuthorService(store.Authors()), bookService)
+
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		// Header values are only valid during the request, and the store
+		// keeps the tenant ID
+		if id := ctx.Get("X-Tenant-ID"); id != "" {
+			ctx.SetUserContext(tenant.WithID(ctx.UserContext(), strings.Clone(id)))
+		}
+		return ctx.Next()
+	})
+	for _, version := range []handlers.Version{handlers.V1, handlers.V2} {
+		router := app.Group("/api/"+version.Name, handlers.UseVersion(version))
+		if version.Paginated {
+			router.Get("/books", bookHandler.ListBooks)
+		} else {
+			router.Get("/books", bookHandler.GetAllBooks)
+		}
+		router.Get("/books/:id", bookHandler.GetBookById).Name(version.Name + "." + handlers.RouteBook)
+		router.Post("/books", bookHandler.CreateBook)
+		router.Put("/books/:id", bookHandler.UpdateBook)
+		router.Delete("/books/:id", bookHandler.DeleteBook)
+		router.Get("/authors/duplicates", authorHandler.GetDuplicates)
+		router.Get("/authors/:id", authorHandler.GetAuthorById)
+		router.Post("/authors/:id/merge", authorHandler.MergeAuthors)
+	}
+	return app
+}
+
+// createBook creates a book through the API and returns it
+func createBook(t *testing.T, api *apitest.Client, name string) models.Book {
+	t.Helper()
+	var book models.Book
+	api.Post("/api/v1/books", map[string]any{
+		"name":   name,
+		"price":  10,
+		"author": map[string]any{"name": "Frank Herbert"},
+	}).ExpectStatus(http.StatusCreated).Data(&book)
+	return book
+}
+
+func TestBookHandlerStatuses(t *testing.T) {
+	api := apitest.New(t, newTestApp(t)).WithHeader("X-Tenant-ID", "default")
+	book := createBook(t, api, "Dune")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"get", http.MethodGet, "/api/v1/books/" + book.ID, nil, http.StatusOK},
+		{"get unknown", http.MethodGet, "/api/v1/books/unknown", nil, http.StatusNotFound},
+		{"get unknown v2", http.MethodGet, "/api/v2/books/unknown", nil, http.StatusNotFound},
+		{"create without name", http.MethodPost, "/api/v1/books", map[string]any{"author": map[string]any{"name": "Frank Herbert"}}, http.StatusBadRequest},
+		{"create without author", http.MethodPost, "/api/v2/books", map[string]any{"name": "Dune"}, http.StatusBadRequest},
+		{"update", http.MethodPut, "/api/v1/books/" + book.ID, map[string]any{"price": 12}, http.StatusOK},
+		{"update unknown", http.MethodPut, "/api/v1/books/unknown", map[string]any{"price": 12}, http.StatusNotFound},
+		{"delete unknown", http.MethodDelete, "/api/v2/books/unknown", nil, http.StatusNotFound},
+		{"author", http.MethodGet, "/api/v1/authors/" + book.AuthorID, nil, http.StatusOK},
+		{"unknown author", http.MethodGet, "/api/v2/authors/unknown", nil, http.StatusNotFound},
+		{"list with a bad cursor", http.MethodGet, "/api/v2/books?cursor=nope", nil, http.StatusBadRequest},
+		{"list with a bad limit", http.MethodGet, "/api/v2/books?limit=0", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.Do(tt.method, tt.path, tt.body).ExpectStatus(tt.want)
+		})
+	}
+}
+
+func TestBookHandlerLifecycle(t *testing.T) {
+	api := apitest.New(t, newTestApp(t)).WithHeader("X-Tenant-ID", "default")
+
+	created := api.Post("/api/v1/books", map[string]any{
+		"name":   "Dune",
+		"price":  10,
+		"author": map[string]any{"name": "Frank Herbert"},
+	}).ExpectStatus(http.StatusCreated)
+	var book models.Book
+	created.Data(&book)
+	if location := created.Header.Get(fiber.HeaderLocation); location != "/api/v1/books/"+book.ID {
+		t.Errorf("Location = %q, want the book's URL", location)
+	}
+
+	api.Put("/api/v2/books/"+book.ID, map[string]any{"price": 12}).ExpectStatus(http.StatusOK)
+	var updated models.Book
+	api.Get("/api/v2/books/" + book.ID).ExpectStatus(http.StatusOK).Data(&updated)
+	if updated.Price != 12 || updated.Name != "Dune" {
+		t.Errorf("updated book = %+v, want Dune at 12", updated)
+	}
+
+	// The catalog is unchanged since the client's copy
+	read := api.Get("/api/v1/books/" + book.ID).ExpectStatus(http.StatusOK)
+	api.WithHeader(fiber.HeaderIfModifiedSince, read.Header.Get(fiber.HeaderLastModified)).
+		Get("/api/v1/books/" + book.ID).
+		ExpectStatus(http.StatusNotModified)
+
+	api.Delete("/api/v2/books/" + book.ID).ExpectStatus(http.StatusNoContent)
+	api.Get("/api/v1/books/" + book.ID).ExpectStatus(http.StatusNotFound)
+	api.Delete("/api/v1/books/" + book.ID).ExpectStatus(http.StatusNotFound)
+}
+
+func TestBookHandlerListBooks(t *testing.T) {
+	api := apitest.New(t, newTestApp(t)).WithHeader("X-Tenant-ID", "default")
+	for _, name := range []string{"Dune", "Children of Dune", "Dune Messiah"} {
+		createBook(t, api, name)
+	}
+
+	var names []string
+	path := "/api/v2/books?limit=2"
+	for range 3 {
+		var page struct {
+			Data []models.Book `json:"data"`
+			Page handlers.Page `json:"page"`
+		}
+		api.Get(path).ExpectStatus(http.StatusOK).Decode(&page)
+		for _, book := range page.Data {
+			names = append(names, book.Name)
+		}
+		if !page.Page.HasMore {
+			break
+		}
+		path = "/api/v2/books?limit=2&cursor=" + page.Page.NextCursor
+	}
+
+	want := []string{"Children of Dune", "Dune", "Dune Messiah"}
+	if len(names) != len(want) {
+		t.Fatalf("pages listed %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("pages listed %v, want %v", names, want)
+		}
+	}
+
+	// v1 lists every book at once
+	var books []models.Book
+	api.Get("/api/v1/books").ExpectStatus(http.StatusOK).Data(&books)
+	if len(books) != len(want) {
+		t.Errorf("GET /api/v1/books listed %d books, want %d", len(books), len(want))
+	}
+}
+
+func TestBookHandlerTenants(t *testing.T) {
+	app := newTestApp(t)
+	book := createBook(t, apitest.New(t, app).WithHeader("X-Tenant-ID", "north"), "Dune")
+
+	south := apitest.New(t, app).WithHeader("X-Tenant-ID", "south")
+	south.Get("/api/v1/books/" + book.ID).ExpectStatus(http.StatusNotFound)
+	south.Put("/api/v1/books/"+book.ID, map[string]any{"price": 1}).ExpectStatus(http.StatusNotFound)
+	south.Delete("/api/v1/books/" + book.ID).ExpectStatus(http.StatusNotFound)
+
+	var books []models.Book
+	south.Get("/api/v1/books").ExpectStatus(http.StatusOK).Data(&books)
+	if len(books) != 0 {
+		t.Errorf("GET /api/v1/books in another tenant listed %d books, want none", len(books))
+	}
+
+	// Without a tenant, the repositories refuse to run
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/books", nil), int(5*time.Second/time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("GET /api/v1/books without a tenant: got status %d, want 500", resp.StatusCode)
+	}
+}
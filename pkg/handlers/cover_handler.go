@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CoverField is the multipart form field carrying an uploaded cover
+const CoverField = "cover"
+
+// CoverHandler handles HTTP requests related to book covers
+type CoverHandler struct {
+	coverService service.CoverService
+	maxSize      int64
+	cacheControl string
+}
+
+// NewCoverHandler creates a new CoverHandler with the provided service.
+// Uploads over maxSize bytes are refused before they are read, and clients
+// may cache images for maxAge before revalidating them.
+func NewCoverHandler(service service.CoverService, maxSize int64, maxAge time.Duration) *CoverHandler {
+	cacheControl := "no-cache"
+	if maxAge > 0 {
+		cacheControl = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	}
+
+	return &CoverHandler{
+		coverService: service,
+		maxSize:      maxSize,
+		cacheControl: cacheControl,
+	}
+}
+
+// UploadCover handles PUT /books/:id/cover request, whose multipart form
+// carries the image in its cover field. The format is detected from the
+// image itself, not from the content type of the part.
+func (h *CoverHandler) UploadCover(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return fail(ctx, http.StatusBadRequest, "Book ID is required")
+	}
+
+	header, err := ctx.FormFile(CoverField)
+	if err != nil {
+		return fail(ctx, http.StatusBadRequest, "A multipart form with a "+CoverField+" file is required")
+	}
+	if header.Size > h.maxSize {
+		return fail(ctx, http.StatusRequestEntityTooLarge, fmt.Sprintf("Cover must be at most %d bytes", h.maxSize))
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return fail(ctx, http.StatusBadRequest, "Failed to read the cover")
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		return fail(ctx, http.StatusBadRequest, "Failed to read the cover")
+	}
+
+	cover, err := h.coverService.UploadCover(ctx.UserContext(), id, data)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Cover uploaded successfully", cover)
+}
+
+// GetCover handles GET /books/:id/cover request. The size query parameter
+// selects a rendition by width, or the uploaded image with "original", the
+// default. Renditions are served as WebP to clients that list image/webp in
+// their Accept header, when it is smaller than the JPEG.
+func (h *CoverHandler) GetCover(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return fail(ctx, http.StatusBadRequest, "Book ID is required")
+	}
+
+	width := 0
+	if size := ctx.Query("size", "original"); size != "original" {
+		parsed, err := strconv.Atoi(size)
+		if err != nil || parsed <= 0 {
+			return fail(ctx, http.StatusBadRequest, "size must be original or a width in pixels")
+		}
+		width = parsed
+	}
+
+	image, err := h.coverService.OpenCover(ctx.UserContext(), id, width, acceptsExplicitly(ctx.Get(fiber.HeaderAccept), "image/webp"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	lastModified := image.Cover.UpdatedAt.UTC()
+	ctx.Set(fiber.HeaderCacheControl, h.cacheControl)
+	ctx.Set(fiber.HeaderVary, fiber.HeaderAccept)
+	ctx.Set(fiber.HeaderETag, image.ETag)
+	ctx.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	if imageNotModified(ctx, image.ETag, lastModified) {
+		image.Close()
+		return ctx.SendStatus(http.StatusNotModified)
+	}
+
+	ctx.Set(fiber.HeaderContentType, image.ContentType)
+	// The stream is closed once it has been sent
+	return ctx.Status(http.StatusOK).SendStream(image, int(image.Size))
+}
+
+// DeleteCover handles DELETE /books/:id/cover request
+func (h *CoverHandler) DeleteCover(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return fail(ctx, http.StatusBadRequest, "Book ID is required")
+	}
+
+	if err := h.coverService.DeleteCover(ctx.UserContext(), id); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Cover deleted successfully", nil)
+}
+
+// imageNotModified reports whether the client's copy of an image is still
+// fresh. If-None-Match takes precedence over If-Modified-Since.
+func imageNotModified(ctx *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if match := ctx.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(ctx.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a one second resolution
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// acceptsExplicitly reports whether an Accept header lists mediaType itself,
+// rather than through a wildcard, with a non-zero quality
+func acceptsExplicitly(accept string, mediaType string) bool {
+	for _, item := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(item, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), mediaType) {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
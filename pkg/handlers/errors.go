@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+)
+
+// statusForError maps a service or repository error to an HTTP status code
+func statusForError(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case service.IsValidationError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrCoverTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedCover):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedFile):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrInvalidLink), errors.Is(err, service.ErrDownloadLimit):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrLinkExpired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers carrying the SHA-256 digest of uploaded and downloaded files
+const (
+	HeaderContentDigest = "Content-Digest"
+	HeaderReprDigest    = "Repr-Digest"
+)
+
+// FileHandler handles HTTP requests related to the ebook files of books
+// and their downloads
+type FileHandler struct {
+	fileService service.FileService
+}
+
+// NewFileHandler creates a new FileHandler with the provided service
+func NewFileHandler(service service.FileService) *FileHandler {
+	return &FileHandler{
+		fileService: service,
+	}
+}
+
+// ListFiles handles GET /books/:id/files request
+func (h *FileHandler) ListFiles(ctx *fiber.Ctx) error {
+	files, err := h.fileService.ListFiles(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Files retrieved successfully", files)
+}
+
+// UploadFile handles PUT /books/:id/files/:format request, whose body is the
+// file itself, of the size given by its Content-Length. The file name offered to downloaders is read from the
+// Content-Disposition header, and the file is checked against the sha-256
+// of the Content-Digest header, when they are present.
+func (h *FileHandler) UploadFile(ctx *fiber.Ctx) error {
+	var filename string
+	if _, params, err := mime.ParseMediaType(ctx.Get(fiber.HeaderContentDisposition)); err == nil {
+		filename = params["filename"]
+	}
+	checksum, ok := parseContentDigest(ctx.Get(HeaderContentDigest))
+	if !ok {
+		return fail(ctx, http.StatusBadRequest, "Content-Digest must be of the form sha-256=:<base64>:")
+	}
+
+	size := ctx.Request().Header.ContentLength()
+	if size < 0 {
+		return fail(ctx, http.StatusLengthRequired, "Content-Length is required")
+	}
+	// The body is streamed to storage when the app streams request bodies
+	var body io.Reader = ctx.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(ctx.Body())
+	}
+
+	file, err := h.fileService.UploadFile(ctx.UserContext(), ctx.Params("id"), ctx.Params("format"), filename, body, int64(size), checksum)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "File uploaded successfully", file)
+}
+
+// DeleteFile handles DELETE /books/:id/files/:format request
+func (h *FileHandler) DeleteFile(ctx *fiber.Ctx) error {
+	if err := h.fileService.DeleteFile(ctx.UserContext(), ctx.Params("id"), ctx.Params("format")); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "File deleted successfully", nil)
+}
+
+// CreateLink handles POST /books/:id/files/:format/links request, which the
+// store calls once a user has bought the book. The route is reserved to the
+// store, which names the user and the purchase, and the link is the only
+// way to download the file.
+func (h *FileHandler) CreateLink(ctx *fiber.Ctx) error {
+	body := new(models.DownloadLinkRequest)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	link, err := h.fileService.CreateLink(ctx.UserContext(), ctx.Params("id"), ctx.Params("format"), *body)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	name := versionOf(ctx).Name + "." + RouteDownload
+	if url, err := ctx.GetRouteURL(name, fiber.Map{"token": link.Token}); err == nil && url != "" {
+		link.URL = ctx.BaseURL() + url
+	}
+	return respond(ctx, http.StatusCreated, "Download link created successfully", link)
+}
+
+// Download handles GET /downloads/:token request. The token names the
+// tenant, so the route is not scoped to the tenant of the request. A single
+// byte range may be requested, to resume a download; If-Range falls back to
+// the whole file when it has changed since.
+func (h *FileHandler) Download(ctx *fiber.Ctx) error {
+	grant, err := h.fileService.VerifyLink(ctx.UserContext(), ctx.Params("token"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+	ctx.SetUserContext(tenant.WithID(ctx.UserContext(), grant.TenantID))
+
+	file, err := h.fileService.GetFile(ctx.UserContext(), grant.BookID, grant.Format)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	etag := `"` + file.SHA256 + `"`
+	lastModified := file.UpdatedAt.UTC()
+	ctx.Set(fiber.HeaderAcceptRanges, "bytes")
+	ctx.Set(fiber.HeaderETag, etag)
+	ctx.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	ctx.Set(fiber.HeaderCacheControl, "private, no-store")
+	ctx.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}))
+	if sum, err := hex.DecodeString(file.SHA256); err == nil {
+		ctx.Set(HeaderReprDigest, "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+	}
+
+	status, offset, length := http.StatusOK, int64(0), file.Size
+	if header := ctx.Get(fiber.HeaderRange); header != "" && ifRangeMatches(ctx.Get(fiber.HeaderIfRange), etag, lastModified) {
+		if start, n, ok := byteRange(header, file.Size); ok {
+			if n == 0 {
+				ctx.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(file.Size, 10))
+				return fail(ctx, http.StatusRequestedRangeNotSatisfiable, "Range not satisfiable")
+			}
+			status, offset, length = http.StatusPartialContent, start, n
+			ctx.Set(fiber.HeaderContentRange, "bytes "+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(start+n-1, 10)+"/"+strconv.FormatInt(file.Size, 10))
+		}
+	}
+
+	ctx.Set(fiber.HeaderContentType, file.ContentType)
+	// HEAD requests describe the download without counting it
+	if ctx.Method() == fiber.MethodHead {
+		ctx.Status(status)
+		ctx.Response().SkipBody = true
+		ctx.Response().Header.SetContentLength(int(length))
+		return nil
+	}
+
+	reader, err := h.fileService.OpenDownload(ctx.UserContext(), grant, file, offset, length)
+	if err != nil {
+		ctx.Response().Header.Del(fiber.HeaderContentRange)
+		ctx.Response().Header.Del(fiber.HeaderContentDisposition)
+		return fail(ctx, statusForError(err), err.Error())
+	}
+	// The stream is closed once it has been sent
+	return ctx.Status(status).SendStream(reader, int(length))
+}
+
+// parseContentDigest returns the hex sha-256 digest of a Content-Digest
+// header, "" when it has none. ok is false for a malformed sha-256 digest.
+func parseContentDigest(header string) (checksum string, ok bool) {
+	for _, item := range strings.Split(header, ",") {
+		algorithm, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		if !strings.EqualFold(algorithm, "sha-256") {
+			continue
+		}
+		encoded, found := strings.CutPrefix(value, ":")
+		encoded, closed := strings.CutSuffix(encoded, ":")
+		sum, err := base64.StdEncoding.DecodeString(encoded)
+		if !found || !closed || err != nil || len(sum) != 32 {
+			return "", false
+		}
+		return hex.EncodeToString(sum), true
+	}
+	return "", true
+}
+
+// ifRangeMatches reports whether a Range header applies given the If-Range
+// header, which must then be the current ETag or last modification time
+func ifRangeMatches(ifRange string, etag string, lastModified time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == etag
+	}
+	date, err := http.ParseTime(ifRange)
+	return err == nil && lastModified.Truncate(time.Second).Equal(date)
+}
+
+// byteRange parses a Range header asking for a single range of bytes of a
+// file of size bytes. ok is false for headers to ignore, which get the whole
+// file: other units, several ranges and invalid ones. A range beyond the
+// file is returned with a zero length.
+func byteRange(header string, size int64) (offset, length int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	// A suffix range asks for the last bytes
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		n = min(n, size)
+		return size - n, n, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return 0, 0, true
+	}
+	return start, end - start + 1, true
+}
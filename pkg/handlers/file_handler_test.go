@@ -0,0 +1,259 @@
+package handlers_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/storage"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// signingKey signs the download links of the file tests
+var signingKey = []byte("0123456789abcdef0123456789abcdef")
+
+// pdf is the content of the uploaded file
+var pdf = []byte("%PDF-1.7\nten bytes of content\n%%EOF\n")
+
+// newFileTestApp serves the file routes of v1 on a SQLite database, for
+// the default tenant, with a file uploaded for a book whose ID it returns.
+// Each user may download the file twice.
+func newFileTestApp(t *testing.T) (*apitest.Client, string) {
+	t.Helper()
+
+	db := repotest.SQLite(t)
+	books := impl.NewBookRepository(db)
+	options := service.DefaultFileServiceOptions()
+	options.DownloadLimit = 2
+	options.SigningKey = signingKey
+	fileService := service.NewFileService(impl.NewFileRepository(db), books, impl.NewTenantRepository(db), storage.NewFileStore(t.TempDir()), logging.Discard(), options)
+	fileHandler := handlers.NewFileHandler(fileService)
+
+	ctx := tenant.WithID(t.Context(), tenant.DefaultID)
+	author := &models.Author{Name: "Ursula K. Le Guin"}
+	if err := impl.NewAuthorRepository(db).CreateAuthor(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	book := &models.Book{Name: "The Dispossessed", Price: 10, Author: *author}
+	if err := books.CreateBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	router := app.Group("/api/v1", handlers.UseVersion(handlers.V1))
+	router.Get("/downloads/:token", fileHandler.Download).Name(handlers.V1.Name + "." + handlers.RouteDownload)
+	router.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(tenant.WithID(ctx.UserContext(), tenant.DefaultID))
+		return ctx.Next()
+	})
+	router.Put("/books/:id/files/:format", fileHandler.UploadFile)
+	router.Post("/books/:id/files/:format/links", fileHandler.CreateLink)
+
+	api := apitest.New(t, app)
+	api.Send(http.MethodPut, "/api/v1/books/"+book.ID+"/files/pdf", "application/pdf", pdf).ExpectStatus(http.StatusOK)
+	return api, book.ID
+}
+
+// createLink creates a download link of the PDF file of a book for a user
+// and returns the path of its download
+func createLink(t *testing.T, api *apitest.Client, bookID, userID string) string {
+	t.Helper()
+	var link models.DownloadLink
+	api.Post("/api/v1/books/"+bookID+"/files/pdf/links", map[string]any{
+		"user_id":     userID,
+		"purchase_id": "order-" + userID,
+	}).ExpectStatus(http.StatusCreated).Data(&link)
+
+	parsed, err := url.Parse(link.URL)
+	if err != nil || !strings.HasSuffix(parsed.Path, "/downloads/"+link.Token) {
+		t.Fatalf("link URL = %q, want the download of token %q", link.URL, link.Token)
+	}
+	return parsed.Path
+}
+
+func TestFileHandlerCreateLink(t *testing.T) {
+	api, bookID := newFileTestApp(t)
+	path := "/api/v1/books/" + bookID + "/files/pdf/links"
+
+	var link models.DownloadLink
+	api.Post(path, map[string]any{"user_id": "alice", "purchase_id": "order-1", "expires_in": 60}).
+		ExpectStatus(http.StatusCreated).
+		Data(&link)
+	if link.Downloads != 0 || link.DownloadLimit != 2 {
+		t.Errorf("link = %+v, want no downloads out of 2", link)
+	}
+	if remaining := time.Until(link.ExpiresAt); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("link expires in %s, want within a minute", remaining)
+	}
+
+	tests := []struct {
+		name string
+		body map[string]any
+		want int
+	}{
+		{"without a user", map[string]any{"purchase_id": "order-1"}, http.StatusBadRequest},
+		{"with a blank user", map[string]any{"user_id": "  ", "purchase_id": "order-1"}, http.StatusBadRequest},
+		{"with a control character", map[string]any{"user_id": "alice\n", "purchase_id": "order\x001"}, http.StatusBadRequest},
+		{"with a long user", map[string]any{"user_id": strings.Repeat("a", 192), "purchase_id": "order-1"}, http.StatusBadRequest},
+		{"without a purchase", map[string]any{"user_id": "alice"}, http.StatusBadRequest},
+		{"with a long expiry", map[string]any{"user_id": "alice", "purchase_id": "order-1", "expires_in": 7 * 24 * 3600}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.Post(path, tt.body).ExpectStatus(tt.want)
+		})
+	}
+
+	api.Post("/api/v1/books/unknown/files/pdf/links", map[string]any{"user_id": "alice", "purchase_id": "order-1"}).
+		ExpectStatus(http.StatusNotFound)
+	api.Post("/api/v1/books/"+bookID+"/files/epub/links", map[string]any{"user_id": "alice", "purchase_id": "order-1"}).
+		ExpectStatus(http.StatusNotFound)
+}
+
+func TestFileHandlerDownloadLimit(t *testing.T) {
+	api, bookID := newFileTestApp(t)
+
+	// A link created before the limit is reached is refused after it
+	first, second, late := createLink(t, api, bookID, "alice"), createLink(t, api, bookID, "alice"), createLink(t, api, bookID, "alice")
+	for _, path := range []string{first, second} {
+		if download := api.Get(path).ExpectStatus(http.StatusOK); string(download.Body) != string(pdf) {
+			t.Fatalf("GET %s = %q, want the file", path, download.Body)
+		}
+	}
+	api.Get(late).ExpectStatus(http.StatusForbidden)
+
+	// Links already counted can still be resumed
+	api.Get(first).ExpectStatus(http.StatusOK)
+
+	api.Post("/api/v1/books/"+bookID+"/files/pdf/links", map[string]any{"user_id": "alice", "purchase_id": "order-2"}).
+		ExpectStatus(http.StatusForbidden)
+
+	// Limits are kept per user
+	api.Get(createLink(t, api, bookID, "bob")).ExpectStatus(http.StatusOK)
+}
+
+func TestFileHandlerInvalidLinks(t *testing.T) {
+	api, bookID := newFileTestApp(t)
+	path := createLink(t, api, bookID, "alice")
+	token := path[strings.LastIndex(path, "/")+1:]
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// sign signs a grant like the service, with the test's key
+	sign := func(grant service.DownloadGrant) string {
+		data, err := json.Marshal(grant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded := base64.RawURLEncoding.EncodeToString(data)
+		mac := hmac.New(sha256.New, signingKey)
+		mac.Write([]byte(encoded))
+		return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	var grant service.DownloadGrant
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || json.Unmarshal(data, &grant) != nil {
+		t.Fatalf("token %q does not hold a grant", token)
+	}
+
+	otherUser := grant
+	otherUser.UserID = "mallory"
+	otherData, _ := json.Marshal(otherUser)
+	expired := grant
+	expired.Expires = time.Now().Add(-time.Minute).Unix()
+	otherTenant := grant
+	otherTenant.TenantID = "unknown"
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"tampered signature", payload + "." + strings.Repeat("A", len(signature)), http.StatusForbidden},
+		{"tampered grant", base64.RawURLEncoding.EncodeToString(otherData) + "." + signature, http.StatusForbidden},
+		{"without a signature", payload, http.StatusForbidden},
+		{"garbage", "not-a-token", http.StatusForbidden},
+		{"expired", sign(expired), http.StatusGone},
+		{"unknown tenant", sign(otherTenant), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.Get("/api/v1/downloads/" + tt.token).ExpectStatus(tt.want)
+		})
+	}
+
+	// None of them counted as a download
+	var link models.DownloadLink
+	api.Post("/api/v1/books/"+bookID+"/files/pdf/links", map[string]any{"user_id": "alice", "purchase_id": "order-1"}).
+		ExpectStatus(http.StatusCreated).
+		Data(&link)
+	if link.Downloads != 0 {
+		t.Errorf("invalid links counted %d downloads, want none", link.Downloads)
+	}
+}
+
+func TestFileHandlerRangedDownloads(t *testing.T) {
+	api, bookID := newFileTestApp(t)
+	path := createLink(t, api, bookID, "alice")
+	size := len(pdf)
+
+	head := api.Do(http.MethodHead, path, nil).ExpectStatus(http.StatusOK)
+	etag := head.Header.Get(fiber.HeaderETag)
+	if head.Header.Get(fiber.HeaderAcceptRanges) != "bytes" || etag == "" {
+		t.Fatalf("HEAD %s headers = %v, want byte ranges and an ETag", path, head.Header)
+	}
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		want         int
+		body         string
+		contentRange string
+	}{
+		{"range", map[string]string{"Range": "bytes=0-7"}, http.StatusPartialContent, string(pdf[:8]), "bytes 0-7/" + strconv.Itoa(size)},
+		{"open range", map[string]string{"Range": "bytes=9-"}, http.StatusPartialContent, string(pdf[9:]), "bytes 9-" + strconv.Itoa(size-1) + "/" + strconv.Itoa(size)},
+		{"suffix range", map[string]string{"Range": "bytes=-6"}, http.StatusPartialContent, string(pdf[size-6:]), "bytes " + strconv.Itoa(size-6) + "-" + strconv.Itoa(size-1) + "/" + strconv.Itoa(size)},
+		{"matching If-Range", map[string]string{"Range": "bytes=0-7", "If-Range": etag}, http.StatusPartialContent, string(pdf[:8]), "bytes 0-7/" + strconv.Itoa(size)},
+		{"stale If-Range", map[string]string{"Range": "bytes=0-7", "If-Range": `"stale"`}, http.StatusOK, string(pdf), ""},
+		{"several ranges", map[string]string{"Range": "bytes=0-1,4-5"}, http.StatusOK, string(pdf), ""},
+		{"past the end", map[string]string{"Range": "bytes=" + strconv.Itoa(size) + "-"}, http.StatusRequestedRangeNotSatisfiable, "", "bytes */" + strconv.Itoa(size)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := api
+			for name, value := range tt.headers {
+				client = client.WithHeader(name, value)
+			}
+			download := client.Get(path).ExpectStatus(tt.want)
+			if tt.body != "" && string(download.Body) != tt.body {
+				t.Errorf("body = %q, want %q", download.Body, tt.body)
+			}
+			if got := download.Header.Get(fiber.HeaderContentRange); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+		})
+	}
+
+	// Every request of the link counted once
+	var link models.DownloadLink
+	api.Post("/api/v1/books/"+bookID+"/files/pdf/links", map[string]any{"user_id": "alice", "purchase_id": "order-1"}).
+		ExpectStatus(http.StatusCreated).
+		Data(&link)
+	if link.Downloads != 1 {
+		t.Errorf("ranged requests of one link counted %d downloads, want 1", link.Downloads)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/dtg-lucifer/go-bookstore/pkg/graph"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GraphQLHandler handles GraphQL requests
+type GraphQLHandler struct {
+	server *graph.Server
+}
+
+// NewGraphQLHandler creates a new GraphQLHandler executing operations on server
+func NewGraphQLHandler(server *graph.Server) *GraphQLHandler {
+	return &GraphQLHandler{
+		server: server,
+	}
+}
+
+// Query handles POST /graphql request.
+// Operations that fail validation or exceed the query limits are answered
+// with 422; errors raised while resolving fields are reported with 200.
+func (h *GraphQLHandler) Query(ctx *fiber.Ctx) error {
+	// Numbers are kept as json.Number for variables to coerce to Int
+	var params graphql.RawParams
+	decoder := json.NewDecoder(bytes.NewReader(ctx.Body()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&params); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(
+			h.server.Error(ctx.UserContext(), "request body must be a JSON object with a query"),
+		)
+	}
+	params.Headers = http.Header{}
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		params.Headers.Add(string(key), string(value))
+	})
+
+	response, ok := h.server.Execute(ctx.UserContext(), &params)
+	if !ok {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
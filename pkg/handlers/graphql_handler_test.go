@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/graph"
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/memory"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newGraphQLTestApp serves POST /graphql on an in-memory catalog of three
+// books by three authors, in the default tenant
+func newGraphQLTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	store := memory.NewStore()
+	bookService := service.NewBookService(store.Books(), store.Authors(), store, logging.Discard(), service.BookServiceOptions{})
+	ctx := tenant.WithID(t.Context(), tenant.DefaultID)
+	for i := range 3 {
+		book := models.Book{
+			Name:   fmt.Sprintf("Book %d", i),
+			Price:  float64(10 + i),
+			Author: models.Author{Name: fmt.Sprintf("Author %d", i)},
+		}
+		if err := bookService.CreateBook(ctx, &book); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := graph.NewServer(bookService, service.NewAuthorService(store.Authors()), graph.DefaultLimits(), logging.Discard())
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(tenant.WithID(ctx.UserContext(), tenant.DefaultID))
+		return ctx.Next()
+	})
+	app.Post("/graphql", handlers.NewGraphQLHandler(server).Query)
+	return app
+}
+
+func TestGraphQLHandlerDecodesVariables(t *testing.T) {
+	api := apitest.New(t, newGraphQLTestApp(t))
+
+	// Whole numbers decoded from JSON coerce to Int, and any number to Float
+	var response struct {
+		Data struct {
+			Books struct {
+				Nodes []struct{ Name string }
+			}
+		}
+		Errors []any
+	}
+	api.Post("/graphql", map[string]any{
+		"query": `query($first: Int, $min: Float) {
+			books(first: $first, filter: { minPrice: $min }) { nodes { name } }
+		}`,
+		"variables": map[string]any{"first": 1, "min": 10.5},
+	}).ExpectStatus(http.StatusOK).Decode(&response)
+	if nodes := response.Data.Books.Nodes; len(response.Errors) != 0 || len(nodes) != 1 || nodes[0].Name != "Book 1" {
+		t.Errorf("books = %+v, errors = %v, want Book 1", nodes, response.Errors)
+	}
+}
+
+func TestGraphQLHandlerStatuses(t *testing.T) {
+	api := apitest.New(t, newGraphQLTestApp(t))
+
+	tests := []struct {
+		name string
+		body any
+		want int
+		code string
+	}{
+		{"not an object", "books", http.StatusBadRequest, graph.CodeBadUserInput},
+		{"fractional Int variable", map[string]any{
+			"query":     `query($first: Int) { books(first: $first) { nodes { name } } }`,
+			"variables": map[string]any{"first": 1.5},
+		}, http.StatusUnprocessableEntity, "GRAPHQL_VALIDATION_FAILED"},
+		{"unknown field", map[string]any{"query": `{ books { nodes { isbn } } }`}, http.StatusUnprocessableEntity, "GRAPHQL_VALIDATION_FAILED"},
+		{"page too large", map[string]any{"query": `{ books(first: 101) { nodes { name } } }`}, http.StatusOK, graph.CodeBadUserInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response graphql.Response
+			api.Post("/graphql", tt.body).ExpectStatus(tt.want).Decode(&response)
+			if len(response.Errors) != 1 || response.Errors[0].Extensions["code"] != tt.code {
+				t.Errorf("errors = %v, want one with code %s", response.Errors, tt.code)
+			}
+		})
+	}
+}
@@ -1,6 +1,15 @@
 package handlers
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// healthStatus is the data of a health check response
+type healthStatus struct {
+	Status string `json:"status" validate:"required"`
+}
 
 // HealthHandler handles health check requests
 type HealthHandler struct{}
@@ -12,8 +21,7 @@ func NewHealthHandler() *HealthHandler {
 
 // HealthCheck handles GET /health request
 func (h *HealthHandler) HealthCheck(ctx *fiber.Ctx) error {
-	return ctx.JSON(fiber.Map{
-		"status":  "ok",
-		"message": "Service is running",
+	return respond(ctx, http.StatusOK, "Service is running", healthStatus{
+		Status: "ok",
 	})
 }
@@ -0,0 +1,767 @@
+package handlers
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/imaging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/openapi"
+	"github.com/dtg-lucifer/go-bookstore/pkg/stream"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIDocument describes every route of version, registered under baseURL,
+// as an OpenAPI document
+func APIDocument(baseURL string, version Version) *openapi.Document {
+	doc := openapi.New("Book Store API", version.Name, baseURL)
+	doc.Enum(models.BatchMode(""), models.BatchModeAtomic, models.BatchModeBestEffort)
+	doc.Enum(models.BatchOpType(""), models.BatchOpCreate, models.BatchOpUpdate, models.BatchOpDelete)
+	doc.Enum(models.BatchStatus(""), models.BatchStatusCreated, models.BatchStatusUpdated, models.BatchStatusDeleted,
+		models.BatchStatusFailed, models.BatchStatusRolledBack, models.BatchStatusSkipped)
+	doc.Enum(models.WebhookDeliveryStatus(""), models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed)
+
+	book := doc.Schema(models.Book{})
+	entries := openapi.Array(doc.Schema(models.AuditEntry{}))
+	webhook := doc.Schema(models.WebhookSubscription{})
+	delivery := doc.Schema(models.WebhookDelivery{})
+	errorBody := version.Serializer.ErrorSchema(doc, nil)
+
+	// Update operations in a batch only carry the fields to change
+	doc.Schema(models.BatchRequest{})
+	doc.Components.Schemas["BatchOperation"].Properties["book"] = doc.Partial("BookUpdate", models.Book{})
+
+	ok := func(description string, data *openapi.Schema) *openapi.Response {
+		return openapi.JSONResponse(description, version.Serializer.SuccessSchema(doc, data, false))
+	}
+	failure := func(description string) *openapi.Response {
+		return openapi.JSONResponse(description, errorBody)
+	}
+	// Replies without data carry a message, or no content at all
+	deletedStatus := "204"
+	deleted := func(description string) *openapi.Response {
+		return &openapi.Response{Description: description}
+	}
+	if messageBody := version.Serializer.SuccessSchema(doc, nil, false); messageBody != nil {
+		messageBody = doc.Define("Message", messageBody)
+		deletedStatus = "200"
+		deleted = func(description string) *openapi.Response {
+			return openapi.JSONResponse(description, messageBody)
+		}
+	}
+	created := func(response *openapi.Response) *openapi.Response {
+		response.Headers = map[string]*openapi.Header{
+			fiber.HeaderLocation: {Description: "URL of the created resource", Schema: openapi.String()},
+		}
+		return response
+	}
+	cached := func(response *openapi.Response) *openapi.Response {
+		response.Headers = map[string]*openapi.Header{
+			fiber.HeaderLastModified: {Description: "Time of the latest change", Schema: openapi.String()},
+			fiber.HeaderCacheControl: {Schema: openapi.String()},
+		}
+		return response
+	}
+	ifModifiedSince := openapi.HeaderParam(fiber.HeaderIfModifiedSince, "Answer 304 when nothing changed since")
+	bookID := openapi.PathParam("id", "Book ID")
+	webhookID := openapi.PathParam("id", "Webhook subscription ID")
+
+	// Health
+	doc.Add(http.MethodGet, "/health", &openapi.Operation{
+		OperationID: "healthCheck",
+		Summary:     "Check API health status",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Service is running", doc.Schema(healthStatus{})),
+		},
+	})
+
+	// Books
+	listBooks := &openapi.Operation{
+		OperationID: "listBooks",
+		Summary:     "Get all books",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{ifModifiedSince},
+		Responses: map[string]*openapi.Response{
+			"200": cached(ok("Books retrieved", openapi.Array(book))),
+			"304": {Description: "Not modified"},
+			"500": failure("Failed to retrieve books"),
+		},
+	}
+	if version.Paginated {
+		minLimit := 1.0
+		limit := &openapi.Schema{Type: "integer", Minimum: &minLimit}
+		price := &openapi.Schema{Type: "number"}
+		listBooks.Summary = "List books a page at a time, ordered by name"
+		listBooks.Description = "Pass the `next_cursor` of a page as `cursor` to fetch the next one."
+		listBooks.Parameters = append(listBooks.Parameters,
+			openapi.QueryParam("limit", fmt.Sprintf("Books per page, at most %d (default %d)", maxPageSize, defaultPageSize), limit),
+			openapi.QueryParam("cursor", "Cursor of the page to fetch", openapi.String()),
+			openapi.QueryParam("q", "Only books whose name or description contains this text", openapi.String()),
+			openapi.QueryParam("author", "Only books by authors whose name contains this text", openapi.String()),
+			openapi.QueryParam("publisher", "Only books by publishers whose name contains this text", openapi.String()),
+			openapi.QueryParam("min_price", "Minimum price", price),
+			openapi.QueryParam("max_price", "Maximum price", price),
+		)
+		listBooks.Responses["200"] = cached(openapi.JSONResponse("Page of books retrieved",
+			version.Serializer.SuccessSchema(doc, openapi.Array(book), true)))
+		listBooks.Responses["400"] = failure("Invalid limit or cursor")
+	}
+	doc.Add(http.MethodGet, "/books", listBooks)
+	doc.Add(http.MethodGet, "/books/stream", &openapi.Operation{
+		OperationID: "streamBooks",
+		Summary:     "Live stream of book changes",
+		Description: "Server-Sent Events. Each event's data is an `Event`; `reset` and `overflow` events ask the client to reload or reconnect.",
+		Tags:        []string{"books"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParam("author", "Only changes of books by this author ID or name", openapi.String()),
+			openapi.QueryParam("publisher", "Only changes of books by this publisher", openapi.String()),
+			openapi.QueryParam("last_event_id", "Resume after this event", openapi.String()),
+			openapi.HeaderParam("Last-Event-ID", "Resume after this event"),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "Event stream",
+				Content: map[string]openapi.MediaType{
+					"text/event-stream": {Schema: doc.Schema(stream.Event{})},
+				},
+			},
+		},
+	})
+	doc.Add(http.MethodGet, "/books/:id", &openapi.Operation{
+		OperationID: "getBook",
+		Summary:     "Get book by ID",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{bookID, ifModifiedSince},
+		Responses: map[string]*openapi.Response{
+			"200": cached(ok("Book retrieved", book)),
+			"304": {Description: "Not modified"},
+			"404": failure("Book not found"),
+			"500": failure("Failed to retrieve the book"),
+		},
+	})
+	createBook := func(operationID string) *openapi.Operation {
+		return &openapi.Operation{
+			OperationID: operationID,
+			Summary:     "Create a new book",
+			Description: "The author is matched by ID, or by name when `AUTHOR_MATCHING` is `name`, and created when it does not exist. The ID of a merged author stands for the author it was merged into.",
+			Tags:        []string{"books"},
+			RequestBody: openapi.JSON("Book to create", book),
+			Responses: map[string]*openapi.Response{
+				"201": created(ok("Book created", book)),
+				"400": failure("Invalid request body"),
+				"500": failure("Book could not be created"),
+			},
+		}
+	}
+	doc.Add(http.MethodPost, "/books", createBook("createBook"))
+
+	if version.Legacy {
+		alias := createBook("createBookDeprecated")
+		alias.Summary = "Create a new book (deprecated alias of POST /books)"
+		alias.Deprecated = true
+		alias.Responses["201"].Headers["Deprecation"] = &openapi.Header{Description: "When the route was deprecated (RFC 9745)", Schema: openapi.String()}
+		alias.Responses["201"].Headers["Sunset"] = &openapi.Header{Description: "When the route will be removed (RFC 8594)", Schema: openapi.String()}
+		doc.Add(http.MethodPost, "/books/create", alias)
+	}
+	doc.Add(http.MethodPost, "/books/batch", &openapi.Operation{
+		OperationID: "batchBooks",
+		Summary:     "Apply a batch of create/update/delete operations",
+		Tags:        []string{"books"},
+		RequestBody: openapi.JSON("Up to 1000 operations", doc.Schema(models.BatchRequest{})),
+		Responses: map[string]*openapi.Response{
+			"200": ok("Every operation was applied", openapi.Array(doc.Schema(batchItemResult{}))),
+			"207": ok("Some best-effort operations failed", openapi.Array(doc.Schema(batchItemResult{}))),
+			"400": failure("Invalid request body or batch"),
+			"422": openapi.JSONResponse("An atomic batch was rolled back",
+				version.Serializer.ErrorSchema(doc, openapi.Array(doc.Schema(batchItemResult{})))),
+		},
+	})
+	doc.Add(http.MethodPut, "/books/:id", &openapi.Operation{
+		OperationID: "updateBook",
+		Summary:     "Update a book",
+		Description: "Only the fields that are set are changed.",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{bookID},
+		RequestBody: openapi.JSON("Fields to change", doc.Partial("BookUpdate", models.Book{})),
+		Responses: map[string]*openapi.Response{
+			"200": ok("Book updated", book),
+			"400": failure("Invalid request body"),
+			"404": failure("Book not found"),
+			"500": failure("Book could not be updated"),
+		},
+	})
+	doc.Add(http.MethodDelete, "/books/:id", &openapi.Operation{
+		OperationID: "deleteBook",
+		Summary:     "Delete a book",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{bookID},
+		Responses: map[string]*openapi.Response{
+			deletedStatus: deleted("Book deleted"),
+			"404":         failure("Book not found"),
+			"500":         failure("Book could not be deleted"),
+		},
+	})
+	doc.Add(http.MethodGet, "/books/:id/history", &openapi.Operation{
+		OperationID: "getBookHistory",
+		Summary:     "Audit trail of a book, oldest version first",
+		Tags:        []string{"audit"},
+		Parameters:  []*openapi.Parameter{bookID},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Book history retrieved", entries),
+			"404": failure("Book has no history"),
+		},
+	})
+	doc.Add(http.MethodPost, "/books/:id/revert", &openapi.Operation{
+		OperationID: "revertBook",
+		Summary:     "Restore a book to the state after a version",
+		Tags:        []string{"audit"},
+		Parameters:  []*openapi.Parameter{bookID},
+		RequestBody: openapi.JSON("Version to restore", doc.Schema(revertRequest{})),
+		Responses: map[string]*openapi.Response{
+			"200": ok("Book reverted", book),
+			"400": failure("Invalid request body or version"),
+			"404": failure("Book or version not found"),
+		},
+	})
+
+	// Covers
+	cover := doc.Schema(models.BookCover{})
+	binary := &openapi.Schema{Type: "string", Format: "binary"}
+	images := map[string]openapi.MediaType{}
+	for _, contentType := range imaging.ContentTypes {
+		images[contentType] = openapi.MediaType{Schema: binary}
+	}
+	doc.Add(http.MethodPut, "/books/:id/cover", &openapi.Operation{
+		OperationID: "uploadCover",
+		Summary:     "Upload the cover of a book",
+		Description: "A JPEG, PNG, GIF or WebP image, whatever the content type of its part says, of at most `COVER_MAX_SIZE_MB`. It replaces the previous cover, and thumbnails are generated at each of `COVER_WIDTHS`.",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{bookID},
+		RequestBody: &openapi.RequestBody{
+			Description: "Multipart form with the image as its `" + CoverField + "` file",
+			Required:    true,
+			Content: map[string]openapi.MediaType{
+				"multipart/form-data": {Schema: openapi.Object(map[string]*openapi.Schema{CoverField: binary}, CoverField)},
+			},
+		},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Cover uploaded", cover),
+			"400": failure("Missing cover file"),
+			"404": failure("Book not found"),
+			"413": failure("Cover exceeds the size or pixel limit"),
+			"415": failure("Cover is not a JPEG, PNG, GIF or WebP image"),
+			"500": failure("Cover could not be stored"),
+		},
+	})
+	doc.Add(http.MethodGet, "/books/:id/cover", &openapi.Operation{
+		OperationID: "getCover",
+		Summary:     "Get the cover of a book, or one of its thumbnails",
+		Description: "Thumbnails are JPEG, or WebP for clients that list `image/webp` in `Accept` when the WebP is smaller. Responses vary on `Accept`.",
+		Tags:        []string{"books"},
+		Parameters: []*openapi.Parameter{
+			bookID,
+			openapi.QueryParam("size", "`original` (default) or one of the widths of `COVER_WIDTHS`, e.g. 320", openapi.String()),
+			openapi.HeaderParam(fiber.HeaderIfNoneMatch, "Answer 304 when the ETag matches"),
+			ifModifiedSince,
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "Cover image",
+				Headers: map[string]*openapi.Header{
+					fiber.HeaderETag:         {Description: "Changes with every upload", Schema: openapi.String()},
+					fiber.HeaderLastModified: {Description: "Time of the upload", Schema: openapi.String()},
+					fiber.HeaderCacheControl: {Schema: openapi.String()},
+					fiber.HeaderVary:         {Schema: openapi.String()},
+				},
+				Content: images,
+			},
+			"304": {Description: "Not modified"},
+			"400": failure("Invalid size"),
+			"404": failure("Book has no cover"),
+		},
+	})
+	doc.Add(http.MethodDelete, "/books/:id/cover", &openapi.Operation{
+		OperationID: "deleteCover",
+		Summary:     "Delete the cover of a book",
+		Description: "Covers are also deleted with their book, and do not come back when it is restored.",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{bookID},
+		Responses: map[string]*openapi.Response{
+			deletedStatus: deleted("Cover deleted"),
+			"404":         failure("Book has no cover"),
+		},
+	})
+
+	// Ebook files
+	file := doc.Schema(models.BookFile{})
+	format := openapi.PathParam("format", "Format of the file")
+	format.Schema.Enum = []any{models.FileFormatEPUB, models.FileFormatPDF}
+	ebooks := map[string]openapi.MediaType{}
+	for _, contentType := range models.FileContentTypes {
+		ebooks[contentType] = openapi.MediaType{Schema: binary}
+	}
+	doc.Add(http.MethodGet, "/books/:id/files", &openapi.Operation{
+		OperationID: "listFiles",
+		Summary:     "List the ebook files of a book",
+		Tags:        []string{"files"},
+		Parameters:  []*openapi.Parameter{bookID},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Files retrieved", openapi.Array(file)),
+			"404": failure("Book not found"),
+		},
+	})
+	doc.Add(http.MethodPut, "/books/:id/files/:format", &openapi.Operation{
+		OperationID: "uploadFile",
+		Summary:     "Upload the ebook file of a book in a format",
+		Description: "The body is the file itself, of at most `FILE_MAX_SIZE_MB` and sent with a `Content-Length`, which must be in the format of the path. It replaces the previous file in that format, keeping its download counts.",
+		Tags:        []string{"files"},
+		Parameters: []*openapi.Parameter{
+			bookID,
+			format,
+			openapi.HeaderParam(fiber.HeaderContentDisposition, "File name offered to downloaders, e.g. `attachment; filename=\"dune.epub\"`; the book name by default"),
+			openapi.HeaderParam(HeaderContentDigest, "Checksum the file must have, e.g. `sha-256=:<base64>:`"),
+		},
+		RequestBody: &openapi.RequestBody{
+			Description: "The ebook file",
+			Required:    true,
+			Content:     ebooks,
+		},
+		Responses: map[string]*openapi.Response{
+			"200": ok("File uploaded", file),
+			"400": failure("Empty file, or checksum mismatch"),
+			"404": failure("Book not found"),
+			"411": failure("Content-Length is missing"),
+			"413": failure("File exceeds the size limit"),
+			"415": failure("File is not in the format of the path"),
+			"500": failure("File could not be stored"),
+		},
+	})
+	doc.Add(http.MethodDelete, "/books/:id/files/:format", &openapi.Operation{
+		OperationID: "deleteFile",
+		Summary:     "Delete the ebook file of a book in a format",
+		Description: "Its download counts are deleted too. Files are also deleted with their book.",
+		Tags:        []string{"files"},
+		Parameters:  []*openapi.Parameter{bookID, format},
+		Responses: map[string]*openapi.Response{
+			deletedStatus: deleted("File deleted"),
+			"404":         failure("Book has no file in this format"),
+		},
+	})
+	doc.Add(http.MethodPost, "/books/:id/files/:format/links", &openapi.Operation{
+		OperationID: "createDownloadLink",
+		Summary:     "Create a download link of an ebook file for a user who bought it",
+		Description: "Reserved to the store, with the `ADMIN_TOKEN` bearer token: it names the user and vouches for their purchase. The link is signed, and expires after `expires_in` seconds, `DOWNLOAD_LINK_TTL` by default. It lets the user download the file until they reach `DOWNLOAD_LIMIT`.",
+		Tags:        []string{"files"},
+		Parameters:  []*openapi.Parameter{bookID, format},
+		RequestBody: openapi.JSON("User and purchase the link is for", doc.Schema(models.DownloadLinkRequest{})),
+		Responses: map[string]*openapi.Response{
+			"201": ok("Download link created", doc.Schema(models.DownloadLink{})),
+			"400": failure("Missing or invalid user or purchase, or invalid expiry"),
+			"401": failure("Missing or invalid administration token"),
+			"403": failure("Administration is disabled, or the user reached the download limit"),
+			"404": failure("Book has no file in this format"),
+		},
+	})
+	doc.Add(http.MethodGet, "/downloads/:token", &openapi.Operation{
+		OperationID: "download",
+		Summary:     "Download an ebook file through a download link",
+		Description: "The first request of a link counts towards the limit of the user, whatever its range; later ones, e.g. resuming the download with a `Range`, do not. Only single byte ranges are served; others get the whole file.",
+		Tags:        []string{"files"},
+		Parameters: []*openapi.Parameter{
+			openapi.PathParam("token", "Token of the download link"),
+			openapi.HeaderParam(fiber.HeaderRange, "Single byte range to resume a download, e.g. `bytes=1048576-`"),
+			openapi.HeaderParam(fiber.HeaderIfRange, "ETag or Last-Modified of the partial download; the whole file is sent when it changed"),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "Ebook file",
+				Headers: map[string]*openapi.Header{
+					fiber.HeaderETag:               {Description: "Quoted hex SHA-256 of the file", Schema: openapi.String()},
+					fiber.HeaderLastModified:       {Description: "Time of the upload", Schema: openapi.String()},
+					fiber.HeaderAcceptRanges:       {Schema: openapi.String()},
+					fiber.HeaderContentDisposition: {Schema: openapi.String()},
+					HeaderReprDigest:               {Description: "SHA-256 of the whole file, as `sha-256=:<base64>:`", Schema: openapi.String()},
+				},
+				Content: ebooks,
+			},
+			"206": {
+				Description: "Range of the ebook file",
+				Headers: map[string]*openapi.Header{
+					fiber.HeaderContentRange: {Schema: openapi.String()},
+					HeaderReprDigest:         {Description: "SHA-256 of the whole file", Schema: openapi.String()},
+				},
+				Content: ebooks,
+			},
+			"403": failure("Invalid link, or the user reached the download limit"),
+			"404": failure("File or tenant of the link not found"),
+			"410": failure("Link expired"),
+			"416": failure("Range beyond the end of the file"),
+		},
+	})
+
+	// Authors
+	author := doc.Schema(models.Author{})
+	authorID := openapi.PathParam("id", "Author ID")
+	minThreshold := 0.7
+	doc.Add(http.MethodGet, "/authors/duplicates", &openapi.Operation{
+		OperationID: "listDuplicateAuthors",
+		Summary:     "Groups of authors whose names are probably the same",
+		Description: "Names are normalized (case, accents, punctuation and spaces are ignored) and compared with the Jaro-Winkler similarity. Authors are ordered by creation in each group.",
+		Tags:        []string{"authors"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParam("threshold", "Lowest similarity of duplicate names, from 0.7 to 1 (default 0.92)",
+				&openapi.Schema{Type: "number", Minimum: &minThreshold}),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Duplicate authors retrieved", openapi.Array(doc.Schema(models.AuthorDuplicates{}))),
+			"400": failure("Invalid threshold"),
+			"500": failure("Failed to retrieve authors"),
+		},
+	})
+	doc.Add(http.MethodGet, "/authors/:id", &openapi.Operation{
+		OperationID: "getAuthor",
+		Summary:     "Get author by ID",
+		Description: "The ID of a merged author returns the author it was merged into.",
+		Tags:        []string{"authors"},
+		Parameters:  []*openapi.Parameter{authorID},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Author retrieved", author),
+			"404": failure("Author not found"),
+		},
+	})
+	doc.Add(http.MethodPost, "/authors/:id/merge", &openapi.Operation{
+		OperationID: "mergeAuthors",
+		Summary:     "Merge duplicate authors into this one",
+		Description: "Moves the books of the listed authors to this one in a single transaction, then deletes them. Their IDs keep resolving to this author.",
+		Tags:        []string{"authors"},
+		Parameters:  []*openapi.Parameter{authorID},
+		RequestBody: openapi.JSON("Authors to merge", doc.Schema(mergeRequest{})),
+		Responses: map[string]*openapi.Response{
+			"200": ok("Authors merged", author),
+			"400": failure("Invalid request body"),
+			"404": failure("Author not found"),
+			"500": failure("Authors could not be merged"),
+		},
+	})
+
+	// GraphQL
+	doc.Add(http.MethodPost, "/graphql", &openapi.Operation{
+		OperationID: "graphql",
+		Summary:     "Execute a GraphQL operation",
+		Description: "The schema is defined in `pkg/graph/schema.graphqls` and can be introspected.",
+		Tags:        []string{"graphql"},
+		RequestBody: openapi.JSON("GraphQL request", openapi.Object(map[string]*openapi.Schema{
+			"query":         openapi.String(),
+			"operationName": openapi.String(),
+			"variables":     {Type: "object"},
+		}, "query")),
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSONResponse("Operation executed; field errors are listed in errors", graphqlResponse),
+			"400": openapi.JSONResponse("Request body could not be decoded", graphqlResponse),
+			"422": openapi.JSONResponse("Operation is invalid or exceeds the query limits", graphqlResponse),
+		},
+	})
+
+	// Audit
+	doc.Add(http.MethodGet, "/audit", &openapi.Operation{
+		OperationID: "listAuditEntries",
+		Summary:     "Query the audit log",
+		Tags:        []string{"audit"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParam("entity", "Entity type, e.g. book", openapi.String()),
+			openapi.QueryParam("id", "Entity ID", openapi.String()),
+			openapi.QueryParam("actor", "Actor that made the change", openapi.String()),
+			openapi.QueryParam("limit", "Maximum number of entries", openapi.Integer()),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Audit entries retrieved", entries),
+			"400": failure("Invalid filter"),
+		},
+	})
+
+	// Webhooks
+	doc.Add(http.MethodGet, "/webhooks", &openapi.Operation{
+		OperationID: "listWebhooks",
+		Summary:     "List webhook subscriptions",
+		Tags:        []string{"webhooks"},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Webhooks retrieved", openapi.Array(webhook)),
+			"500": failure("Failed to retrieve webhooks"),
+		},
+	})
+	doc.Add(http.MethodPost, "/webhooks", &openapi.Operation{
+		OperationID: "createWebhook",
+		Summary:     "Create a subscription",
+		Description: "A secret is generated when none is supplied. It is only returned by this call.",
+		Tags:        []string{"webhooks"},
+		RequestBody: openapi.JSON("Subscription to create", webhook),
+		Responses: map[string]*openapi.Response{
+			"201": created(ok("Webhook created", webhook)),
+			"400": failure("Invalid request body"),
+		},
+	})
+	doc.Add(http.MethodGet, "/webhooks/:id", &openapi.Operation{
+		OperationID: "getWebhook",
+		Summary:     "Get a subscription",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{webhookID},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Webhook retrieved", webhook),
+			"404": failure("Webhook not found"),
+		},
+	})
+	doc.Add(http.MethodPut, "/webhooks/:id", &openapi.Operation{
+		OperationID: "updateWebhook",
+		Summary:     "Update a subscription",
+		Description: "Setting `active` to true re-enables a disabled subscription.",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{webhookID},
+		RequestBody: openapi.JSON("Fields to change", doc.Partial("WebhookSubscriptionUpdate", models.WebhookSubscription{})),
+		Responses: map[string]*openapi.Response{
+			"200": ok("Webhook updated", webhook),
+			"400": failure("Invalid request body"),
+			"404": failure("Webhook not found"),
+		},
+	})
+	doc.Add(http.MethodDelete, "/webhooks/:id", &openapi.Operation{
+		OperationID: "deleteWebhook",
+		Summary:     "Delete a subscription and its delivery log",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{webhookID},
+		Responses: map[string]*openapi.Response{
+			deletedStatus: deleted("Webhook deleted"),
+			"404":         failure("Webhook not found"),
+		},
+	})
+	doc.Add(http.MethodGet, "/webhooks/:id/deliveries", &openapi.Operation{
+		OperationID: "listWebhookDeliveries",
+		Summary:     "Recent deliveries with attempts and responses",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{webhookID},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Deliveries retrieved", openapi.Array(delivery)),
+			"404": failure("Webhook not found"),
+		},
+	})
+	doc.Add(http.MethodPost, "/webhooks/:id/deliveries/:deliveryId/replay", &openapi.Operation{
+		OperationID: "replayWebhookDelivery",
+		Summary:     "Send a delivery again",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{webhookID, openapi.PathParam("deliveryId", "Delivery ID")},
+		Responses: map[string]*openapi.Response{
+			"202": ok("Delivery queued for replay", delivery),
+			"404": failure("Webhook or delivery not found"),
+		},
+	})
+
+	// Tenants
+	tenantSchema := doc.Schema(models.Tenant{})
+	tenantID := openapi.PathParam("id", "Tenant ID")
+	doc.Add(http.MethodGet, "/tenant", &openapi.Operation{
+		OperationID: "getCurrentTenant",
+		Summary:     "The tenant of the request, with its currency and rate limit",
+		Tags:        []string{"tenants"},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Tenant retrieved", tenantSchema),
+		},
+	})
+	doc.Add(http.MethodGet, "/admin/tenants", &openapi.Operation{
+		OperationID: "listTenants",
+		Summary:     "List tenants",
+		Tags:        []string{"admin"},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Tenants retrieved", openapi.Array(tenantSchema)),
+			"500": failure("Failed to retrieve tenants"),
+		},
+	})
+	doc.Add(http.MethodPost, "/admin/tenants", &openapi.Operation{
+		OperationID: "createTenant",
+		Summary:     "Create a tenant",
+		Description: "The ID is used in requests, e.g. as `X-Tenant-ID` or subdomain: up to 63 lower case letters, digits and dashes. The currency defaults to USD.",
+		Tags:        []string{"admin"},
+		RequestBody: openapi.JSON("Tenant to create", tenantSchema),
+		Responses: map[string]*openapi.Response{
+			"201": created(ok("Tenant created", tenantSchema)),
+			"400": failure("Invalid request body"),
+			"409": failure("A tenant with this ID already exists"),
+		},
+	})
+	doc.Add(http.MethodGet, "/admin/tenants/:id", &openapi.Operation{
+		OperationID: "getTenant",
+		Summary:     "Get a tenant",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{tenantID},
+		Responses: map[string]*openapi.Response{
+			"200": ok("Tenant retrieved", tenantSchema),
+			"404": failure("Tenant not found"),
+		},
+	})
+	doc.Add(http.MethodPut, "/admin/tenants/:id", &openapi.Operation{
+		OperationID: "updateTenant",
+		Summary:     "Update a tenant",
+		Description: "Setting `active` to false refuses the tenant's requests until it is re-activated.",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{tenantID},
+		RequestBody: openapi.JSON("Fields to change", doc.Schema(models.TenantUpdate{})),
+		Responses: map[string]*openapi.Response{
+			"200": ok("Tenant updated", tenantSchema),
+			"400": failure("Invalid request body"),
+			"404": failure("Tenant not found"),
+		},
+	})
+	doc.Add(http.MethodDelete, "/admin/tenants/:id", &openapi.Operation{
+		OperationID: "deleteTenant",
+		Summary:     "Delete a tenant",
+		Description: "The tenant's data is kept but cannot be reached until a tenant with the same ID is created.",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{tenantID},
+		Responses: map[string]*openapi.Response{
+			deletedStatus: deleted("Tenant deleted"),
+			"404":         failure("Tenant not found"),
+		},
+	})
+
+	// POST requests can be retried with an Idempotency-Key (see middleware.Idempotency)
+	idempotencyKey := openapi.HeaderParam("Idempotency-Key", "Replay the stored response when the request is retried with the same key")
+	for _, item := range doc.Paths {
+		op := (*item)["post"]
+		if op == nil {
+			continue
+		}
+		op.Parameters = append(op.Parameters, idempotencyKey)
+		op.Responses["409"] = failure("A request with the same Idempotency-Key is in progress")
+		if response, ok := op.Responses["422"]; ok {
+			response.Description += ", or the Idempotency-Key was used with a different request"
+			response.Content["application/json"] = openapi.MediaType{Schema: &openapi.Schema{
+				AnyOf: []*openapi.Schema{response.Content["application/json"].Schema, errorBody},
+			}}
+		} else {
+			op.Responses["422"] = failure("The Idempotency-Key was used with a different request")
+		}
+	}
+
+	// Administration and webhook routes require the admin token (see
+	// middleware.AdminToken), and every route but health, administration and
+	// downloads is scoped to a tenant (see middleware.Tenant)
+	tenantHeader := openapi.HeaderParam(tenant.DefaultHeader, "Tenant of the request, when it is not resolved from a token or subdomain")
+	rateLimited := failure("Rate limit of the tenant exceeded")
+	rateLimited.Headers = map[string]*openapi.Header{
+		fiber.HeaderRetryAfter: {Description: "Seconds until the limit resets", Schema: openapi.Integer()},
+	}
+	for path, item := range doc.Paths {
+		for _, op := range *item {
+			switch {
+			case path == "/health":
+			case strings.HasPrefix(path, "/admin/"):
+				op.Responses["401"] = failure("Missing or invalid admin token")
+				op.Responses["403"] = failure("Administration is disabled")
+			case strings.HasPrefix(path, "/downloads/"):
+				// Download links carry their tenant
+			default:
+				if strings.HasPrefix(path, "/webhooks") {
+					op.Responses["401"] = failure("Missing or invalid admin token")
+					op.Responses["403"] = failure("Administration is disabled")
+				}
+				op.Parameters = append(op.Parameters, tenantHeader)
+				if response, ok := op.Responses["401"]; ok {
+					response.Description += ", or invalid bearer token"
+				} else {
+					op.Responses["401"] = failure("Invalid bearer token")
+				}
+				op.Responses["429"] = rateLimited
+				if response, ok := op.Responses["404"]; ok {
+					response.Description += ", or tenant not found"
+				} else {
+					op.Responses["404"] = failure("Tenant not found")
+				}
+			}
+		}
+	}
+
+	// Requests that break the contract are rejected by the Validation middleware
+	for _, item := range doc.Paths {
+		for _, op := range *item {
+			if _, ok := op.Responses["400"]; !ok && (len(op.Parameters) > 0 || op.RequestBody != nil) {
+				op.Responses["400"] = failure("Request does not match the API contract")
+			}
+		}
+	}
+
+	return doc
+}
+
+// graphqlResponse is the schema of a GraphQL response
+var graphqlResponse = openapi.Object(map[string]*openapi.Schema{
+	"data": openapi.Nullable(&openapi.Schema{Type: "object"}),
+	"errors": openapi.Array(openapi.Object(map[string]*openapi.Schema{
+		"message":    openapi.String(),
+		"path":       openapi.Array(openapi.Any()),
+		"extensions": openapi.Object(map[string]*openapi.Schema{"code": openapi.String()}),
+	}, "message")),
+})
+
+// DocsHandler serves the OpenAPI documents of the API versions and their UI
+type DocsHandler struct {
+	documents      map[string]*openapi.Document
+	defaultVersion string
+	page           string
+}
+
+// swaggerUIPath is where the Swagger UI assets are served, by version so
+// that they can be cached for good
+var swaggerUIPath = "/docs/swagger-ui/" + openapi.SwaggerUIVersion
+
+// NewDocsHandler creates a new DocsHandler serving the documents of the
+// versions by name, each at /openapi/<version>.json
+func NewDocsHandler(documents map[string]*openapi.Document, defaultVersion string) *DocsHandler {
+	var urls []openapi.SpecURL
+	for _, name := range slices.Sorted(maps.Keys(documents)) {
+		urls = append(urls, openapi.SpecURL{Name: name, URL: "/openapi/" + name + ".json"})
+	}
+
+	return &DocsHandler{
+		documents:      documents,
+		defaultVersion: defaultVersion,
+		page:           openapi.DocsPage(urls, defaultVersion, swaggerUIPath),
+	}
+}
+
+// Spec handles GET /openapi.json and GET /openapi/:version.json requests.
+// The former serves the document of the default version.
+func (h *DocsHandler) Spec(ctx *fiber.Ctx) error {
+	document, ok := h.documents[ctx.Params("version", h.defaultVersion)]
+	if !ok {
+		return fail(ctx, http.StatusNotFound, "API version not found")
+	}
+	return ctx.Status(http.StatusOK).JSON(document)
+}
+
+// UI handles GET /docs request
+func (h *DocsHandler) UI(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return ctx.Status(http.StatusOK).SendString(h.page)
+}
+
+// Asset handles GET /docs/swagger-ui/:version/:name request, serving the
+// Swagger UI assets the docs page loads
+func (h *DocsHandler) Asset(ctx *fiber.Ctx) error {
+	if ctx.Params("version") != openapi.SwaggerUIVersion {
+		return fail(ctx, http.StatusNotFound, "Asset not found")
+	}
+	name := ctx.Params("name")
+	data, err := fs.ReadFile(openapi.SwaggerUI, name)
+	if err != nil {
+		return fail(ctx, http.StatusNotFound, "Asset not found")
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	ctx.Type(strings.TrimPrefix(path.Ext(name), "."))
+	return ctx.Status(http.StatusOK).Send(data)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Names of the routes of single resources, used to build Location headers.
+// Each version's routes are named with the version as prefix, e.g. "v1.books.show".
+const (
+	RouteBook    = "books.show"
+	RouteWebhook = "webhooks.show"
+	RouteTenant  = "tenants.show"
+	// RouteDownload serves a download link by its token
+	RouteDownload = "downloads.show"
+)
+
+// Response is the envelope of every JSON response of the v1 REST API.
+// Successful responses carry a message and usually data; failed ones carry
+// an error and, for requests that break the API contract, the violations.
+type Response struct {
+	Message    string              `json:"message,omitempty"`
+	Data       any                 `json:"data,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Violations []openapi.Violation `json:"violations,omitempty"`
+}
+
+// respond sends a successful response in the envelope of the request's version
+func respond(ctx *fiber.Ctx, status int, message string, data any) error {
+	return versionOf(ctx).Serializer.Write(ctx, status, Reply{
+		Message: message,
+		Data:    data,
+	})
+}
+
+// fail sends a failed response in the envelope of the request's version
+func fail(ctx *fiber.Ctx, status int, message string) error {
+	return versionOf(ctx).Serializer.Write(ctx, status, Reply{
+		Error: message,
+	})
+}
+
+// Reject sends a failed response listing the violations of the API contract
+func Reject(ctx *fiber.Ctx, status int, message string, violations []openapi.Violation) error {
+	return versionOf(ctx).Serializer.Write(ctx, status, Reply{
+		Error:      message,
+		Violations: violations,
+	})
+}
+
+// setLocation points the Location header at the resource with the given ID,
+// served by the named route of the request's version
+func setLocation(ctx *fiber.Ctx, route string, id string) {
+	name := versionOf(ctx).Name + "." + route
+	if url, err := ctx.GetRouteURL(name, fiber.Map{"id": id}); err == nil && url != "" {
+		ctx.Location(url)
+	}
+}
+
+// ErrorHandler answers errors returned by handlers and middlewares, such as
+// recovered panics, with the response envelope. Requests for a method a
+// resource does not support get 405 with an Allow header listing those it
+// does, except OPTIONS requests, which get 204 with the same header.
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	status := http.StatusInternalServerError
+	message := http.StatusText(status)
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status = fiberErr.Code
+		message = fiberErr.Message
+	}
+
+	if status == http.StatusMethodNotAllowed {
+		ctx.Set(fiber.HeaderAllow, strings.Join(allowedMethods(ctx.App().GetRoutes(true), ctx.Path()), ", "))
+		if ctx.Method() == fiber.MethodOptions {
+			return ctx.SendStatus(http.StatusNoContent)
+		}
+		message = "Method " + ctx.Method() + " is not allowed"
+	}
+
+	return fail(ctx, status, message)
+}
+
+// allowedMethods returns the methods of the routes matching path, plus OPTIONS
+func allowedMethods(routes []fiber.Route, path string) []string {
+	segments := splitSegments(path)
+
+	methods := []string{fiber.MethodOptions}
+	for _, route := range routes {
+		if route.Method == "USE" || slices.Contains(methods, route.Method) {
+			continue
+		}
+		if matchSegments(splitSegments(route.Path), segments) {
+			methods = append(methods, route.Method)
+		}
+	}
+	slices.Sort(methods)
+	return methods
+}
+
+// splitSegments splits a path into segments, ignoring a trailing slash
+func splitSegments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// matchSegments matches request path segments against a route path, where
+// ":name" matches any segment and "*" the rest of the path
+func matchSegments(route []string, segments []string) bool {
+	for i, part := range route {
+		if part == "*" || part == "+" {
+			return true
+		}
+		if i >= len(segments) {
+			return strings.HasSuffix(part, "?") && i == len(route)-1
+		}
+		if strings.HasPrefix(part, ":") {
+			continue
+		}
+		if !strings.EqualFold(part, segments[i]) {
+			return false
+		}
+	}
+	return len(route) == len(segments)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/stream"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// StreamHandler streams book changes to clients as Server-Sent Events
+type StreamHandler struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new StreamHandler sending a heartbeat every heartbeat interval
+func NewStreamHandler(hub *stream.Hub, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// StreamBooks handles GET /books/stream request.
+//
+// Supported query parameters are author (ID or name) and publisher. Clients
+// resume with the Last-Event-ID header (or last_event_id query parameter);
+// when that is no longer possible a "reset" event tells them to reload.
+// Clients that cannot keep up receive an "overflow" event and are
+// disconnected, and should reconnect with their Last-Event-ID.
+func (h *StreamHandler) StreamBooks(ctx *fiber.Ctx) error {
+	lastEventID := ctx.Get("Last-Event-ID", ctx.Query("last_event_id"))
+	tenantID, _ := tenant.FromContext(ctx.UserContext())
+	filter := stream.Filter{
+		TenantID:  tenantID,
+		Author:    ctx.Query("author"),
+		Publisher: ctx.Query("publisher"),
+	}
+
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("Connection", "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	// HEAD only describes the stream
+	if ctx.Method() == fiber.MethodHead {
+		return nil
+	}
+
+	sub, backlog, resumed := h.hub.Subscribe(lastEventID, filter)
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.hub.Unsubscribe(sub)
+
+		// Tell the client how long to wait before reconnecting
+		fmt.Fprintf(w, "retry: %d\n\n", 3000)
+
+		if !resumed {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+
+		for _, event := range backlog {
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(h.heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case event, ok := <-sub.C:
+				if !ok {
+					if sub.Overflowed() {
+						fmt.Fprint(w, "event: overflow\ndata: {}\n\n")
+						w.Flush()
+					}
+					return
+				}
+				if err := writeStreamEvent(w, event); err != nil {
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeStreamEvent writes a single SSE frame for event
+func writeStreamEvent(w *bufio.Writer, event stream.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TenantHandler handles HTTP requests related to tenants
+type TenantHandler struct {
+	tenantService service.TenantService
+}
+
+// NewTenantHandler creates a new TenantHandler with the provided service
+func NewTenantHandler(service service.TenantService) *TenantHandler {
+	return &TenantHandler{
+		tenantService: service,
+	}
+}
+
+// GetCurrentTenant handles GET /tenant request, returning the tenant the
+// request was resolved to
+func (h *TenantHandler) GetCurrentTenant(ctx *fiber.Ctx) error {
+	id, _ := tenant.FromContext(ctx.UserContext())
+	current, err := h.tenantService.GetTenantByID(ctx.UserContext(), id)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Tenant retrieved successfully", current)
+}
+
+// GetAllTenants handles GET /admin/tenants request
+func (h *TenantHandler) GetAllTenants(ctx *fiber.Ctx) error {
+	tenants, err := h.tenantService.ListTenants(ctx.UserContext())
+	if err != nil {
+		return fail(ctx, http.StatusInternalServerError, "Failed to retrieve tenants")
+	}
+
+	return respond(ctx, http.StatusOK, "Tenants retrieved successfully", tenants)
+}
+
+// GetTenantById handles GET /admin/tenants/:id request
+func (h *TenantHandler) GetTenantById(ctx *fiber.Ctx) error {
+	t, err := h.tenantService.GetTenantByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Tenant retrieved successfully", t)
+}
+
+// CreateTenant handles POST /admin/tenants request
+func (h *TenantHandler) CreateTenant(ctx *fiber.Ctx) error {
+	body := new(models.Tenant)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.tenantService.CreateTenant(ctx.UserContext(), body); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	setLocation(ctx, RouteTenant, body.ID)
+	return respond(ctx, http.StatusCreated, "Tenant created successfully", body)
+}
+
+// UpdateTenant handles PUT /admin/tenants/:id request
+func (h *TenantHandler) UpdateTenant(ctx *fiber.Ctx) error {
+	body := new(models.TenantUpdate)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	t, err := h.tenantService.UpdateTenant(ctx.UserContext(), ctx.Params("id"), *body)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Tenant updated successfully", t)
+}
+
+// DeleteTenant handles DELETE /admin/tenants/:id request
+func (h *TenantHandler) DeleteTenant(ctx *fiber.Ctx) error {
+	if err := h.tenantService.DeleteTenant(ctx.UserContext(), ctx.Params("id")); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Tenant deleted successfully", nil)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionHeader is the response header naming the API version that answered
+const VersionHeader = "API-Version"
+
+// Version is a version of the REST API. Versions share the services and
+// differ in how responses are serialized and in a few handlers.
+type Version struct {
+	// Name is the path segment of the version, e.g. "v1"
+	Name       string
+	Serializer Serializer
+	// Paginated lists books a page at a time, with cursors
+	Paginated bool
+	// Legacy keeps the deprecated routes
+	Legacy bool
+}
+
+// Versions of the REST API
+var (
+	V1 = Version{Name: "v1", Serializer: serializerV1{}, Legacy: true}
+	V2 = Version{Name: "v2", Serializer: serializerV2{}, Paginated: true}
+)
+
+// Versions returns the known versions by name
+func Versions() map[string]Version {
+	return map[string]Version{V1.Name: V1, V2.Name: V2}
+}
+
+// Reply is what a handler answers, independently of the envelope of a version
+type Reply struct {
+	Message    string
+	Data       any
+	Error      string
+	Violations []openapi.Violation
+	// Page is set for a page of a paginated list
+	Page *Page
+}
+
+// Page describes a page of a paginated list
+type Page struct {
+	// NextCursor fetches the next page; it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Serializer writes replies in the envelope of a version and describes that
+// envelope in the OpenAPI document
+type Serializer interface {
+	Write(ctx *fiber.Ctx, status int, reply Reply) error
+	// SuccessSchema is the schema of a successful reply carrying data; a nil
+	// data is a reply without data
+	SuccessSchema(doc *openapi.Document, data *openapi.Schema, paginated bool) *openapi.Schema
+	// ErrorSchema is the schema of a failed reply; a non-nil data is the
+	// schema of the data it carries too
+	ErrorSchema(doc *openapi.Document, data *openapi.Schema) *openapi.Schema
+}
+
+// versionKey is the fiber.Ctx locals key of the version of a request
+const versionKey = "api.version"
+
+// UseVersion serves the requests of a version's route group with version
+func UseVersion(version Version) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		SetVersion(ctx, version)
+		return ctx.Next()
+	}
+}
+
+// SetVersion serves a request with version
+func SetVersion(ctx *fiber.Ctx, version Version) {
+	ctx.Locals(versionKey, version)
+	ctx.Set(VersionHeader, version.Name)
+}
+
+// versionOf returns the version serving a request; V1 outside of version groups
+func versionOf(ctx *fiber.Ctx) Version {
+	if version, ok := ctx.Locals(versionKey).(Version); ok {
+		return version
+	}
+	return V1
+}
+
+// serializerV1 writes the original Response envelope
+type serializerV1 struct{}
+
+func (serializerV1) Write(ctx *fiber.Ctx, status int, reply Reply) error {
+	return ctx.Status(status).JSON(Response{
+		Message:    reply.Message,
+		Data:       reply.Data,
+		Error:      reply.Error,
+		Violations: reply.Violations,
+	})
+}
+
+func (serializerV1) SuccessSchema(doc *openapi.Document, data *openapi.Schema, paginated bool) *openapi.Schema {
+	if data == nil {
+		return openapi.Object(map[string]*openapi.Schema{"message": openapi.String()}, "message")
+	}
+	return openapi.Object(map[string]*openapi.Schema{
+		"message": openapi.String(),
+		"data":    data,
+	}, "message", "data")
+}
+
+func (serializerV1) ErrorSchema(doc *openapi.Document, data *openapi.Schema) *openapi.Schema {
+	if data != nil {
+		return openapi.Object(map[string]*openapi.Schema{
+			"error": openapi.String(),
+			"data":  data,
+		}, "error", "data")
+	}
+	return doc.Define("Error", openapi.Object(map[string]*openapi.Schema{
+		"error":      openapi.String(),
+		"violations": openapi.Array(doc.Schema(openapi.Violation{})),
+	}, "error"))
+}
+
+// ResponseV2 is the envelope of the v2 API. Successful responses carry data
+// and, for lists, the page; failed ones carry a machine-readable error.
+type ResponseV2 struct {
+	Data  any      `json:"data,omitempty"`
+	Page  *Page    `json:"page,omitempty"`
+	Error *ErrorV2 `json:"error,omitempty"`
+}
+
+// ErrorV2 is the error of a failed v2 response
+type ErrorV2 struct {
+	// Code is the snake_case status text, e.g. not_found
+	Code       string              `json:"code" validate:"required"`
+	Message    string              `json:"message" validate:"required"`
+	Violations []openapi.Violation `json:"violations,omitempty"`
+}
+
+// serializerV2 writes the ResponseV2 envelope. Successful replies without
+// data are answered with 204.
+type serializerV2 struct{}
+
+func (serializerV2) Write(ctx *fiber.Ctx, status int, reply Reply) error {
+	if reply.Error != "" {
+		return ctx.Status(status).JSON(ResponseV2{
+			Data: reply.Data,
+			Error: &ErrorV2{
+				Code:       errorCode(status),
+				Message:    reply.Error,
+				Violations: reply.Violations,
+			},
+		})
+	}
+
+	if reply.Data == nil {
+		return ctx.SendStatus(http.StatusNoContent)
+	}
+	return ctx.Status(status).JSON(ResponseV2{
+		Data: reply.Data,
+		Page: reply.Page,
+	})
+}
+
+func (serializerV2) SuccessSchema(doc *openapi.Document, data *openapi.Schema, paginated bool) *openapi.Schema {
+	if data == nil {
+		return nil
+	}
+	if paginated {
+		return openapi.Object(map[string]*openapi.Schema{
+			"data": data,
+			"page": doc.Schema(Page{}),
+		}, "data", "page")
+	}
+	return openapi.Object(map[string]*openapi.Schema{"data": data}, "data")
+}
+
+func (serializerV2) ErrorSchema(doc *openapi.Document, data *openapi.Schema) *openapi.Schema {
+	if data != nil {
+		return openapi.Object(map[string]*openapi.Schema{
+			"error": doc.Schema(ErrorV2{}),
+			"data":  data,
+		}, "error", "data")
+	}
+	return doc.Define("ErrorResponse", openapi.Object(map[string]*openapi.Schema{
+		"error": doc.Schema(ErrorV2{}),
+	}, "error"))
+}
+
+// errorCode returns the snake_case text of an HTTP status
+func errorCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
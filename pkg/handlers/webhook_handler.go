@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookHandler handles HTTP requests related to webhook subscriptions
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler with the provided service
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: service,
+	}
+}
+
+// hideSecret returns a copy of the subscription without its signing secret
+func hideSecret(sub models.WebhookSubscription) models.WebhookSubscription {
+	sub.Secret = ""
+	return sub
+}
+
+// GetAllWebhooks handles GET /webhooks request
+func (h *WebhookHandler) GetAllWebhooks(ctx *fiber.Ctx) error {
+	subs, err := h.webhookService.ListWebhooks(ctx.UserContext())
+	if err != nil {
+		return fail(ctx, http.StatusInternalServerError, "Failed to retrieve webhooks")
+	}
+
+	for i := range subs {
+		subs[i] = hideSecret(subs[i])
+	}
+
+	return respond(ctx, http.StatusOK, "Webhooks retrieved successfully", subs)
+}
+
+// GetWebhookById handles GET /webhooks/:id request
+func (h *WebhookHandler) GetWebhookById(ctx *fiber.Ctx) error {
+	sub, err := h.webhookService.GetWebhookByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Webhook retrieved successfully", hideSecret(*sub))
+}
+
+// CreateWebhook handles POST /webhooks request.
+// The signing secret is only returned in this response.
+func (h *WebhookHandler) CreateWebhook(ctx *fiber.Ctx) error {
+	body := new(models.WebhookSubscription)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.webhookService.CreateWebhook(ctx.UserContext(), body); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	setLocation(ctx, RouteWebhook, body.ID)
+	return respond(ctx, http.StatusCreated, "Webhook created successfully", body)
+}
+
+// UpdateWebhook handles PUT /webhooks/:id request
+func (h *WebhookHandler) UpdateWebhook(ctx *fiber.Ctx) error {
+	body := new(models.WebhookSubscription)
+	if err := ctx.BodyParser(body); err != nil {
+		return fail(ctx, http.StatusBadRequest, "Invalid request body")
+	}
+
+	sub, err := h.webhookService.UpdateWebhook(ctx.UserContext(), ctx.Params("id"), body)
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Webhook updated successfully", hideSecret(*sub))
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id request
+func (h *WebhookHandler) DeleteWebhook(ctx *fiber.Ctx) error {
+	if err := h.webhookService.DeleteWebhook(ctx.UserContext(), ctx.Params("id")); err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// GetDeliveries handles GET /webhooks/:id/deliveries request
+func (h *WebhookHandler) GetDeliveries(ctx *fiber.Ctx) error {
+	deliveries, err := h.webhookService.ListDeliveries(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusOK, "Deliveries retrieved successfully", deliveries)
+}
+
+// ReplayDelivery handles POST /webhooks/:id/deliveries/:deliveryId/replay request
+func (h *WebhookHandler) ReplayDelivery(ctx *fiber.Ctx) error {
+	delivery, err := h.webhookService.ReplayDelivery(ctx.UserContext(), ctx.Params("id"), ctx.Params("deliveryId"))
+	if err != nil {
+		return fail(ctx, statusForError(err), err.Error())
+	}
+
+	return respond(ctx, http.StatusAccepted, "Delivery queued for replay", delivery)
+}
@@ -0,0 +1,112 @@
+// Package imaging decodes uploaded images and encodes their thumbnails
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"slices"
+
+	// Decoders of the accepted formats
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ContentTypes are the accepted image formats
+var ContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Extensions of the accepted formats, by content type
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ErrUnsupported is returned for data that is not an image in one of
+// ContentTypes
+var ErrUnsupported = errors.New("unsupported image format")
+
+// ErrTooLarge is returned for images with more pixels than allowed
+var ErrTooLarge = errors.New("image is too large")
+
+// Sniff returns the content type of data from its first bytes, whatever
+// the uploader claimed it to be
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(ContentTypes, contentType) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, contentType)
+	}
+	return contentType, nil
+}
+
+// Extension returns the file extension of an accepted content type
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
+
+// Decode decodes an image of at most maxPixels pixels. The dimensions are
+// read from the header first, so that oversized images are rejected before
+// they are allocated.
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("%w: image is empty", ErrUnsupported)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrTooLarge, config.Width, config.Height, maxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return img, nil
+}
+
+// Resize scales img to width, keeping its aspect ratio. Narrower images are
+// returned as they are: they are never enlarged.
+func Resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Src, nil)
+	return resized
+}
+
+// EncodeJPEG encodes img as a JPEG. JPEG has no transparency, so
+// transparent areas are flattened onto white.
+func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	if err := jpeg.Encode(w, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return nil
+}
+
+// EncodeWebP encodes img as a lossless WebP, keeping its transparency
+func EncodeWebP(w io.Writer, img image.Image) error {
+	if err := nativewebp.Encode(w, img, nil); err != nil {
+		return fmt.Errorf("failed to encode WebP: %w", err)
+	}
+	return nil
+}
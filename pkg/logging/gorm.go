@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GORMLogger writes GORM's logs through slog, using the request-scoped
+// logger from the query context when there is one. Failed queries are logged
+// at error level, slow ones at warn and everything else at debug.
+type GORMLogger struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	level         gormlogger.LogLevel
+}
+
+// NewGORMLogger creates a GORM logger writing to logger
+func NewGORMLogger(logger *slog.Logger, slowThreshold time.Duration) *GORMLogger {
+	return &GORMLogger{
+		logger:        logger,
+		slowThreshold: slowThreshold,
+		level:         gormlogger.Info,
+	}
+}
+
+// LogMode implements gormlogger.Interface
+func (l *GORMLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info implements gormlogger.Interface
+func (l *GORMLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Info {
+		FromContext(ctx, l.logger).InfoContext(ctx, msg, "args", args)
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *GORMLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Warn {
+		FromContext(ctx, l.logger).WarnContext(ctx, msg, "args", args)
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *GORMLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Error {
+		FromContext(ctx, l.logger).ErrorContext(ctx, msg, "args", args)
+	}
+}
+
+// Trace implements gormlogger.Interface
+func (l *GORMLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	logger := FromContext(ctx, l.logger)
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		logger.ErrorContext(ctx, "Query failed", "sql", sql, "rows", rows, "elapsed", elapsed, "error", err)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.WarnContext(ctx, "Slow query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	case l.level >= gormlogger.Info && logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		logger.DebugContext(ctx, "Query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/utils"
+)
+
+// Config describes how log records are formatted and where they are written
+type Config struct {
+	// Level is the minimum level logged: debug, info, warn or error
+	Level string
+	// Format is "json" or "text"
+	Format string
+	// Outputs lists the destinations: "stdout", "stderr" and/or "file"
+	Outputs []string
+	// AddSource includes the source file and line of each record
+	AddSource bool
+
+	// File is the path of the log file used by the "file" output
+	File string
+	// Rotate configures rotation of File
+	Rotate RotateOptions
+}
+
+// ConfigFromEnv reads the logging configuration from LOG_* environment variables
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Level:     utils.GetEnv("LOG_LEVEL", "info"),
+		Format:    utils.GetEnv("LOG_FORMAT", "text"),
+		Outputs:   strings.Split(utils.GetEnv("LOG_OUTPUTS", "stdout,file"), ","),
+		AddSource: utils.GetEnv("LOG_ADD_SOURCE", "true") == "true",
+		File:      utils.GetEnv("LOG_FILE", "logs/app.log"),
+	}
+
+	maxSizeMB, err := envInt("LOG_MAX_SIZE_MB", "100")
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Rotate.MaxSize = int64(maxSizeMB) << 20
+	if cfg.Rotate.Interval, err = envDuration("LOG_ROTATE_INTERVAL", "24h"); err != nil {
+		return Config{}, err
+	}
+	if cfg.Rotate.MaxBackups, err = envInt("LOG_MAX_BACKUPS", "7"); err != nil {
+		return Config{}, err
+	}
+	if cfg.Rotate.MaxAge, err = envDuration("LOG_MAX_AGE", "720h"); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// New builds a logger from cfg. Attributes with secret-looking keys are
+// redacted from every record. The returned closer releases the log file.
+func New(cfg Config) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var writers []io.Writer
+	var closer io.Closer = nopCloser{}
+
+	for _, output := range cfg.Outputs {
+		switch strings.TrimSpace(output) {
+		case "":
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		case "file":
+			if err := os.MkdirAll(filepath.Dir(cfg.File), 0o755); err != nil {
+				return nil, nil, fmt.Errorf("failed to create log directory: %w", err)
+			}
+			file, err := NewRotatingFile(cfg.File, cfg.Rotate)
+			if err != nil {
+				return nil, nil, err
+			}
+			writers = append(writers, file)
+			closer = file
+		default:
+			return nil, nil, fmt.Errorf("unknown log output %q", output)
+		}
+	}
+
+	if len(writers) == 0 {
+		writers = append(writers, io.Discard)
+	}
+
+	opts := &slog.HandlerOptions{
+		AddSource:   cfg.AddSource,
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case "json":
+		handler = slog.NewJSONHandler(io.MultiWriter(writers...), opts)
+	case "text", "":
+		handler = slog.NewTextHandler(io.MultiWriter(writers...), opts)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	return slog.New(handler), closer, nil
+}
+
+// ParseLevel converts a level name into a slog.Level
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
+// Discard returns a logger that drops every record, useful in tests
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or fallback
+// when there is none
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return fallback
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// envInt reads a non-negative integer environment variable
+func envInt(key, defaultValue string) (int, error) {
+	n, err := strconv.Atoi(utils.GetEnv(key, defaultValue))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: must be a non-negative integer", key)
+	}
+	return n, nil
+}
+
+// envDuration reads a non-negative duration environment variable
+func envDuration(key, defaultValue string) (time.Duration, error) {
+	d, err := time.ParseDuration(utils.GetEnv(key, defaultValue))
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s: must be a non-negative duration such as 24h", key)
+	}
+	return d, nil
+}
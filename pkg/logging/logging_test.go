@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "size", key: "LOG_MAX_SIZE_MB", value: "10"},
+		{name: "size in units", key: "LOG_MAX_SIZE_MB", value: "10MB", wantErr: true},
+		{name: "negative backups", key: "LOG_MAX_BACKUPS", value: "-1", wantErr: true},
+		{name: "interval without unit", key: "LOG_ROTATE_INTERVAL", value: "24", wantErr: true},
+		{name: "age in days", key: "LOG_MAX_AGE", value: "30d", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.key != "" {
+				t.Setenv(tt.key, tt.value)
+			}
+
+			cfg, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.key == "" && cfg.Rotate.MaxAge != 30*24*time.Hour {
+				t.Errorf("default MaxAge = %v, want 720h", cfg.Rotate.MaxAge)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secret values in logs
+const Redacted = "[REDACTED]"
+
+// secretKeys are lower-cased key fragments whose values are never logged
+var secretKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie",
+	"api_key", "apikey", "api-key", "private_key", "credit_card", "card_number", "cvv", "ssn",
+}
+
+// piiKeys are lower-cased keys whose values are masked rather than removed
+var piiKeys = map[string]bool{
+	"email": true, "phone": true, "phone_number": true, "address": true, "ip": true,
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// IsSecretKey reports whether values stored under key must be redacted
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range secretKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders returns a copy of headers with secret values replaced
+func RedactHeaders(headers map[string]string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if IsSecretKey(key) {
+			value = Redacted
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// RedactQuery returns a query string with every value replaced, keeping
+// the names of its parameters
+func RedactQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		if name, _, ok := strings.Cut(param, "="); ok {
+			params[i] = name + "=" + Redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// RedactBody returns body with secrets removed and PII masked. JSON bodies
+// are redacted field by field; other bodies only have e-mail addresses masked.
+// Bodies longer than limit bytes are truncated.
+func RedactBody(body []byte, limit int) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err == nil {
+		if data, err := json.Marshal(redactValue("", value)); err == nil {
+			body = data
+		}
+	} else {
+		body = emailPattern.ReplaceAllFunc(body, func(email []byte) []byte {
+			return []byte(maskPII(string(email)))
+		})
+	}
+
+	if limit > 0 && len(body) > limit {
+		return string(body[:limit]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// redactValue walks a decoded JSON value and redacts it in place
+func redactValue(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = redactValue(k, child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(key, child)
+		}
+		return v
+	case string:
+		if IsSecretKey(key) {
+			return Redacted
+		}
+		if piiKeys[strings.ToLower(key)] {
+			return maskPII(v)
+		}
+		return emailPattern.ReplaceAllStringFunc(v, maskPII)
+	default:
+		if IsSecretKey(key) {
+			return Redacted
+		}
+		return v
+	}
+}
+
+// maskPII keeps just enough of a value to tell entries apart
+func maskPII(value string) string {
+	if local, domain, ok := strings.Cut(value, "@"); ok && local != "" {
+		return local[:1] + "***@" + domain
+	}
+	if len(value) <= 4 {
+		return "***"
+	}
+	return "***" + value[len(value)-2:]
+}
+
+// redactAttr is a slog ReplaceAttr function redacting secret attributes
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() != slog.KindGroup && IsSecretKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
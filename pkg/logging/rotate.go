@@ -0,0 +1,174 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp appended to rotated files; it sorts chronologically
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateOptions configures when a RotatingFile rotates and which backups it keeps.
+// Zero values disable the corresponding limit.
+type RotateOptions struct {
+	// MaxSize rotates the file once it would grow beyond this many bytes
+	MaxSize int64
+	// Interval rotates the file when a new interval starts (e.g. 24h for daily)
+	Interval time.Duration
+	// MaxBackups is the number of rotated files kept
+	MaxBackups int
+	// MaxAge removes rotated files older than this
+	MaxAge time.Duration
+}
+
+// RotatingFile is an io.WriteCloser appending to a file that is rotated by
+// size and time. Rotated files are renamed to "<name>-<timestamp><ext>".
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	opts     RotateOptions
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// NewRotatingFile opens (or creates) path for appending
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	w := &RotatingFile{
+		path: path,
+		opts: opts,
+		now:  time.Now,
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write appends p, rotating first when a limit would be exceeded
+func (w *RotatingFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately
+func (w *RotatingFile) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+// Close closes the current file
+func (w *RotatingFile) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// shouldRotate reports whether writing n more bytes requires a rotation
+func (w *RotatingFile) shouldRotate(n int64) bool {
+	if w.opts.MaxSize > 0 && w.size > 0 && w.size+n > w.opts.MaxSize {
+		return true
+	}
+	if w.opts.Interval > 0 && !w.now().Truncate(w.opts.Interval).Equal(w.openedAt.Truncate(w.opts.Interval)) {
+		return true
+	}
+	return false
+}
+
+// open opens the log file for appending
+func (w *RotatingFile) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+	if w.size > 0 {
+		w.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// rotate renames the current file, opens a new one and prunes old backups
+func (w *RotatingFile) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		w.file = nil
+	}
+
+	ext := filepath.Ext(w.path)
+	backup := strings.TrimSuffix(w.path, ext) + "-" + w.now().Format(backupTimeFormat) + ext
+	if err := os.Rename(w.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	return w.prune()
+}
+
+// prune removes backups beyond MaxBackups or older than MaxAge
+func (w *RotatingFile) prune() error {
+	ext := filepath.Ext(w.path)
+	backups, err := filepath.Glob(strings.TrimSuffix(w.path, ext) + "-*" + ext)
+	if err != nil {
+		return err
+	}
+
+	// Newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, backup := range backups {
+		remove := w.opts.MaxBackups > 0 && i >= w.opts.MaxBackups
+		if !remove && w.opts.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && w.now().Sub(info.ModTime()) > w.opts.MaxAge {
+				remove = true
+			}
+		}
+		if remove {
+			if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old log file: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
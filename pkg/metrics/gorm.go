@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// repositoryPackage identifies the stack frames of repository methods
+const repositoryPackage = "/pkg/repository/impl."
+
+// startTimeKey is the statement setting holding the query start time
+const startTimeKey = "metrics:start"
+
+// GORMPlugin records query latency and errors for every GORM operation,
+// labelled by the repository method that issued it
+type GORMPlugin struct {
+	metrics *Metrics
+}
+
+// GORMPlugin returns a GORM plugin reporting to m
+func (m *Metrics) GORMPlugin() *GORMPlugin {
+	return &GORMPlugin{metrics: m}
+}
+
+// Name implements gorm.Plugin
+func (p *GORMPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin by registering before/after callbacks
+func (p *GORMPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, r := range register {
+		if err := r.before("metrics:before_"+r.operation, p.before); err != nil {
+			return err
+		}
+		if err := r.after("metrics:after_"+r.operation, p.after(r.operation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// before remembers when the statement started
+func (p *GORMPlugin) before(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+// after observes the statement duration and any error
+func (p *GORMPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		method := callerMethod()
+		p.metrics.dbDuration.WithLabelValues(method, operation).Observe(time.Since(start).Seconds())
+
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			p.metrics.dbErrors.WithLabelValues(method, operation).Inc()
+		}
+	}
+}
+
+// callerMethod walks the stack to find the repository method that issued
+// the current query, e.g. "BookRepositoryImpl.GetAllBooks"
+func callerMethod() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if i := strings.Index(frame.Function, repositoryPackage); i >= 0 {
+			name := frame.Function[i+len(repositoryPackage):]
+			// Closures passed to Transaction are reported as their enclosing method
+			if j := strings.Index(name, ".func"); j >= 0 {
+				name = name[:j]
+			}
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			// Unexported helpers are attributed to the exported method calling them
+			if method := name[strings.LastIndex(name, ".")+1:]; method != "" && method[0] >= 'a' && method[0] <= 'z' && more {
+				continue
+			}
+			return name
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
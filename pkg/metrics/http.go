@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Track is a route-level middleware recording request count, latency and
+// in-flight requests labelled by the matched route template.
+// Use Router to attach it to every route of a router.
+func (m *Metrics) Track(ctx *fiber.Ctx) error {
+	// Fiber strings point into reused buffers, so copy them before using them as labels
+	method := strings.Clone(ctx.Method())
+	route := strings.Clone(ctx.Route().Path)
+
+	inFlight := m.httpInFlight.WithLabelValues(method, route)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	start := time.Now()
+	err := ctx.Next()
+
+	status := ctx.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		}
+	}
+
+	labels := []string{method, route, strconv.Itoa(status)}
+	m.httpRequests.WithLabelValues(labels...).Inc()
+	m.httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+// Router wraps r so that every route registered through it is tracked.
+// Route-level tracking is what makes the route template available to the
+// in-flight gauge, which a global middleware runs too early to know.
+func (m *Metrics) Router(r fiber.Router) fiber.Router {
+	return &trackedRouter{Router: r, metrics: m}
+}
+
+// trackedRouter prepends Metrics.Track to the handlers of every route
+type trackedRouter struct {
+	fiber.Router
+	metrics *Metrics
+}
+
+func (r *trackedRouter) track(handlers []fiber.Handler) []fiber.Handler {
+	return append([]fiber.Handler{r.metrics.Track}, handlers...)
+}
+
+func (r *trackedRouter) Get(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Get(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Head(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Head(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Post(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Post(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Put(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Put(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Delete(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Delete(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Patch(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Patch(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Options(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Options(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) All(path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.All(path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Add(method string, path string, handlers ...fiber.Handler) fiber.Router {
+	r.Router.Add(method, path, r.track(handlers)...)
+	return r
+}
+
+func (r *trackedRouter) Group(prefix string, handlers ...fiber.Handler) fiber.Router {
+	return r.metrics.Router(r.Router.Group(prefix, handlers...))
+}
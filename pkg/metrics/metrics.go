@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the application's Prometheus collectors
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight *prometheus.GaugeVec
+
+	dbDuration *prometheus.HistogramVec
+	dbErrors   *prometheus.CounterVec
+
+	bookChanges *prometheus.CounterVec
+
+	cacheRequests *prometheus.CounterVec
+}
+
+// New creates a Metrics instance with its own registry, including the Go
+// runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests by route template, method and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route template, method and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		httpInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served by route template.",
+		}, []string{"method", "route"}),
+
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database query latency by repository method and GORM operation.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method", "operation"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Number of failed database queries by repository method and GORM operation.",
+		}, []string{"method", "operation"}),
+
+		bookChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bookstore_book_changes_total",
+			Help: "Number of committed book changes by type (created, updated, deleted).",
+		}, []string{"type"}),
+
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Number of cache lookups by cache and result (hit, miss, error).",
+		}, []string{"cache", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.dbDuration,
+		m.dbErrors,
+		m.bookChanges,
+		m.cacheRequests,
+	)
+
+	return m
+}
+
+// Registry returns the registry holding every collector
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RegisterDB exposes the connection pool statistics of db
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler returns an http.Handler serving the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// BookChanged implements service.BookChangeNotifier
+func (m *Metrics) BookChanged(ctx context.Context, change service.BookChange) {
+	switch change.Type {
+	case events.BookCreated:
+		m.bookChanges.WithLabelValues("created").Inc()
+	case events.BookUpdated:
+		m.bookChanges.WithLabelValues("updated").Inc()
+	case events.BookDeleted:
+		m.bookChanges.WithLabelValues("deleted").Inc()
+	}
+}
+
+// CacheHit implements cache.Recorder
+func (m *Metrics) CacheHit(cache string) {
+	m.cacheRequests.WithLabelValues(cache, "hit").Inc()
+}
+
+// CacheMiss implements cache.Recorder
+func (m *Metrics) CacheMiss(cache string) {
+	m.cacheRequests.WithLabelValues(cache, "miss").Inc()
+}
+
+// CacheError implements cache.Recorder
+func (m *Metrics) CacheError(cache string) {
+	m.cacheRequests.WithLabelValues(cache, "error").Inc()
+}
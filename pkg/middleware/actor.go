@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/netip"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ActorHeader is the request header naming the caller recorded in the audit
+// log, which is only read from trusted proxies
+const ActorHeader = "X-Actor"
+
+// Actor stores the caller identified by resolver (see audit.ActorResolver)
+// and the request ID in the request's user context so that repositories can
+// attribute changes in the audit log.
+// It must run after the requestid middleware.
+func Actor(resolver audit.ActorResolver) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		requestID, _ := ctx.Locals("requestid").(string)
+		peer, _ := netip.AddrFromSlice(ctx.Context().RemoteIP())
+
+		ctx.SetUserContext(audit.WithActor(ctx.UserContext(), audit.Actor{
+			ID: resolver.Resolve(audit.ActorRequest{
+				Authorization: ctx.Get(fiber.HeaderAuthorization),
+				Header:        ctx.Get(ActorHeader),
+				Peer:          peer,
+			}),
+			RequestID: requestID,
+		}))
+
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminToken protects administration routes with a static bearer token.
+// An empty token disables them: every request is answered with 403.
+func AdminToken(token string) fiber.Handler {
+	expected := []byte("Bearer " + token)
+
+	return func(ctx *fiber.Ctx) error {
+		if token == "" {
+			return handlers.Reject(ctx, http.StatusForbidden, "Administration is disabled", nil)
+		}
+		if subtle.ConstantTimeCompare([]byte(ctx.Get(fiber.HeaderAuthorization)), expected) != 1 {
+			ctx.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="admin"`)
+			return handlers.Reject(ctx, http.StatusUnauthorized, "Unauthorized", nil)
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimitConfig configures the BodyLimit middleware
+type BodyLimitConfig struct {
+	// Limit is the largest body read into memory, in bytes
+	Limit int
+	// Stream reports whether a request's handler reads its body as a
+	// stream instead, e.g. a large upload with its own limit
+	Stream func(ctx *fiber.Ctx) bool
+}
+
+// BodyLimit reads request bodies into memory up to a limit, answering
+// larger ones with 413, for apps that stream request bodies
+// (fiber.Config.StreamRequestBody). Without it, reading the body of such a
+// request reads all of it. Bodies of streaming requests are left to their
+// handler, and their connection is closed once answered, since the rest of
+// an unread body would otherwise be read as the next request.
+// It must run before any middleware reading the body.
+func BodyLimit(config BodyLimitConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if config.Stream != nil && config.Stream(ctx) {
+			ctx.Context().SetConnectionClose()
+			return ctx.Next()
+		}
+
+		request := ctx.Request()
+		if request.Header.ContentLength() > config.Limit {
+			ctx.Context().SetConnectionClose()
+			return handlers.Reject(ctx, http.StatusRequestEntityTooLarge, "Request body is too large", nil)
+		}
+		if stream := ctx.Context().RequestBodyStream(); stream != nil {
+			body, err := io.ReadAll(io.LimitReader(stream, int64(config.Limit)+1))
+			if err != nil {
+				ctx.Context().SetConnectionClose()
+				return handlers.Reject(ctx, http.StatusBadRequest, "Request body could not be read", nil)
+			}
+			if len(body) > config.Limit {
+				ctx.Context().SetConnectionClose()
+				return handlers.Reject(ctx, http.StatusRequestEntityTooLarge, "Request body is too large", nil)
+			}
+			request.SetBody(body)
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadYourWrites starts a database session for every request, so that the
+// reads following a write of the request go to the primary rather than a
+// lagging read replica
+func ReadYourWrites() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(database.WithSession(ctx.UserContext()))
+		return ctx.Next()
+	}
+}
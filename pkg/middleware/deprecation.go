@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeprecationConfig configures the Deprecated middleware
+type DeprecationConfig struct {
+	// Since is when the route was deprecated
+	Since time.Time
+	// Sunset is when the route will be removed
+	Sunset time.Time
+	// Successor is the path of the route that replaces it
+	Successor string
+	// Logger logs uses outside of the RequestLogger middleware
+	Logger *slog.Logger
+}
+
+// Deprecated marks the responses of a route as deprecated with the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers, links its successor
+// and logs every use so remaining clients can be found.
+// It must run after the RequestLogger middleware.
+func Deprecated(config DeprecationConfig) fiber.Handler {
+	deprecation := fmt.Sprintf("@%d", config.Since.Unix())
+	sunset := config.Sunset.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf(`<%s>; rel="successor-version"`, config.Successor)
+
+	return func(ctx *fiber.Ctx) error {
+		ctx.Set("Deprecation", deprecation)
+		ctx.Set("Sunset", sunset)
+		ctx.Append(fiber.HeaderLink, link)
+
+		logging.FromContext(ctx.UserContext(), config.Logger).WarnContext(ctx.UserContext(),
+			"Deprecated route used", "successor", config.Successor, "sunset", sunset)
+		return ctx.Next()
+	}
+}
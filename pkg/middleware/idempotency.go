@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Idempotency headers
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from a previous request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength is the longest accepted Idempotency-Key
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with a response and replayed
+var replayedHeaders = []string{
+	fiber.HeaderContentType,
+	fiber.HeaderLocation,
+	fiber.HeaderLink,
+	"Deprecation",
+	"Sunset",
+}
+
+// IdempotencyConfig configures the Idempotency middleware
+type IdempotencyConfig struct {
+	Repository repository.IdempotencyRepository
+	// TTL is how long responses are kept for retries
+	TTL time.Duration
+	// Lease is how long a request in flight holds its key. A retry after
+	// it runs again, e.g. once the first request was lost with its instance.
+	Lease time.Duration
+	// Logger logs failures outside of the RequestLogger middleware
+	Logger *slog.Logger
+}
+
+// Idempotency makes POST requests sent with an Idempotency-Key header safe to
+// retry. The first request with a key is executed and its response stored
+// for TTL; retries get the stored response again, with the
+// Idempotent-Replayed header. Reusing a key for a different request is
+// answered with 422, and retrying while the first request is in flight, for
+// up to Lease, with 409. Keys are scoped to the tenant and to the caller
+// verified by the Actor middleware, and failed requests (5xx) are not
+// stored, so they can be retried.
+// It must run after the Actor, RequestLogger and Tenant middlewares, and
+// after the authentication of the routes it covers, so that a response is
+// only replayed to a caller allowed to get it.
+func Idempotency(config IdempotencyConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(IdempotencyKeyHeader)
+		if key == "" || ctx.Method() != fiber.MethodPost {
+			return ctx.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return handlers.Reject(ctx, http.StatusBadRequest, "Idempotency-Key cannot be longer than 255 characters", nil)
+		}
+
+		logger := logging.FromContext(ctx.UserContext(), config.Logger)
+		actorID, _ := audit.IdentifiedActor(ctx.UserContext())
+		record := &models.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: hash(ctx.Method(), ctx.Path(), string(ctx.Request().URI().QueryString()), string(ctx.Body())),
+			Token:       uuid.NewString(),
+			ExpiresAt:   time.Now().UTC().Add(config.Lease),
+		}
+
+		existing, err := config.Repository.Reserve(ctx.UserContext(), record)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			switch {
+			case existing.Actor != actorID:
+				return handlers.Reject(ctx, http.StatusUnprocessableEntity, "Idempotency-Key was already used by another caller", nil)
+			case existing.Fingerprint != record.Fingerprint:
+				return handlers.Reject(ctx, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request", nil)
+			case !existing.Completed():
+				return handlers.Reject(ctx, http.StatusConflict, "A request with this Idempotency-Key is in progress", nil)
+			}
+
+			for name, value := range existing.Headers {
+				ctx.Set(name, value)
+			}
+			ctx.Set(IdempotentReplayedHeader, "true")
+			return ctx.Status(existing.StatusCode).SendString(existing.Body)
+		}
+
+		err = ctx.Next()
+		status := ctx.Response().StatusCode()
+		if err != nil || status >= http.StatusInternalServerError || ctx.Response().IsBodyStream() {
+			if releaseErr := config.Repository.Release(ctx.UserContext(), record); releaseErr != nil {
+				logger.ErrorContext(ctx.UserContext(), "Failed to release idempotency key", "error", releaseErr)
+			}
+			return err
+		}
+
+		record.StatusCode = status
+		record.ExpiresAt = time.Now().UTC().Add(config.TTL)
+		record.Body = string(ctx.Response().Body())
+		record.Headers = map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := ctx.GetRespHeader(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		// The response was produced; failing to store it only loses the replay
+		if err := config.Repository.Complete(ctx.UserContext(), record); err != nil {
+			logger.ErrorContext(ctx.UserContext(), "Failed to store idempotent response", "error", err)
+		}
+		return nil
+	}
+}
+
+// hash returns the hex SHA-256 of parts, separated so that they cannot run together
+func hash(parts ...string) string {
+	digest := sha256.New()
+	for _, part := range parts {
+		digest.Write([]byte(part))
+		digest.Write([]byte{0})
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIdempotency(t *testing.T) {
+	repo := impl.NewIdempotencyRepository(repotest.SQLite(t))
+	executed := 0
+
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		// Callers are identified by X-Actor, as the Actor middleware would
+		ctx.SetUserContext(audit.WithActor(tenant.WithID(ctx.UserContext(), "default"), audit.Actor{ID: strings.Clone(ctx.Get("X-Actor"))}))
+		return ctx.Next()
+	})
+	app.Use("/links", AdminToken("secret"))
+	app.Use(Idempotency(IdempotencyConfig{Repository: repo, TTL: time.Hour, Lease: time.Minute, Logger: logging.Discard()}))
+	app.Post("/books", func(ctx *fiber.Ctx) error {
+		executed++
+		return ctx.Status(http.StatusCreated).SendString("created")
+	})
+	app.Post("/links", func(ctx *fiber.Ctx) error {
+		actorID, _ := audit.IdentifiedActor(ctx.UserContext())
+		return ctx.Status(http.StatusCreated).SendString("link of " + actorID)
+	})
+
+	api := apitest.New(t, app).WithHeader(IdempotencyKeyHeader, "key")
+	book := map[string]string{"name": "Dune"}
+
+	t.Run("retries from the same actor are replayed", func(t *testing.T) {
+		api.WithHeader("X-Actor", "alice").Post("/books", book).ExpectStatus(http.StatusCreated)
+		replay := api.WithHeader("X-Actor", "alice").Post("/books", book).ExpectStatus(http.StatusCreated)
+		if replay.Header.Get(IdempotentReplayedHeader) != "true" || executed != 1 {
+			t.Fatalf("retry executed the request again; executed %d times", executed)
+		}
+	})
+
+	t.Run("keys of another actor are not replayed", func(t *testing.T) {
+		other := api.WithHeader("X-Actor", "bob").Post("/books", book).ExpectStatus(http.StatusCreated)
+		if other.Header.Get(IdempotentReplayedHeader) != "" || executed != 2 {
+			t.Fatalf("another actor got the stored response; executed %d times", executed)
+		}
+	})
+
+	t.Run("records of another actor are refused", func(t *testing.T) {
+		stolen := &models.IdempotencyRecord{
+			Fingerprint: hash(http.MethodPost, "/books", "", `{"name":"Dune"}`),
+			Actor:       "alice",
+			StatusCode:  http.StatusCreated,
+			Body:        "created",
+		}
+		app := fiber.New()
+		app.Use(Idempotency(IdempotencyConfig{Repository: storedRepository{stolen}, TTL: time.Hour, Lease: time.Minute, Logger: logging.Discard()}))
+		app.Post("/books", func(ctx *fiber.Ctx) error {
+			return ctx.SendStatus(http.StatusCreated)
+		})
+
+		apitest.New(t, app).WithHeader(IdempotencyKeyHeader, "stolen").
+			Post("/books", book).
+			ExpectStatus(http.StatusUnprocessableEntity)
+	})
+
+	t.Run("unauthenticated retries get no stored response", func(t *testing.T) {
+		api := api.WithHeader(IdempotencyKeyHeader, "link")
+		admin := api.WithHeader("X-Actor", "alice").WithHeader(fiber.HeaderAuthorization, "Bearer secret")
+		admin.Post("/links", book).ExpectStatus(http.StatusCreated)
+		replay := admin.Post("/links", book).ExpectStatus(http.StatusCreated)
+		if replay.Header.Get(IdempotentReplayedHeader) != "true" || string(replay.Body) != "link of alice" {
+			t.Fatalf("retry got %q, want the stored link", replay.Body)
+		}
+
+		api.WithHeader("X-Actor", "alice").Post("/links", book).ExpectStatus(http.StatusUnauthorized)
+	})
+
+	t.Run("in-flight keys are taken over after their lease", func(t *testing.T) {
+		ctx := tenant.WithID(t.Context(), "default")
+		lost := &models.IdempotencyRecord{
+			Key:         "lost",
+			Fingerprint: hash(http.MethodPost, "/books", "", `{"name":"Dune"}`),
+			Token:       "lost",
+			ExpiresAt:   time.Now().UTC().Add(-time.Second),
+		}
+		if _, err := repo.Reserve(ctx, lost); err != nil {
+			t.Fatal(err)
+		}
+
+		executed = 0
+		api := api.WithHeader(IdempotencyKeyHeader, "lost")
+		api.Post("/books", book).ExpectStatus(http.StatusCreated)
+		if executed != 1 {
+			t.Fatalf("retry after the lease executed %d times, want 1", executed)
+		}
+
+		// The lost request finishing late does not replace the response
+		lost.StatusCode = http.StatusConflict
+		lost.ExpiresAt = time.Now().UTC().Add(time.Hour)
+		if err := repo.Complete(ctx, lost); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Release(ctx, lost); err != nil {
+			t.Fatal(err)
+		}
+		api.Post("/books", book).ExpectStatus(http.StatusCreated)
+		if executed != 1 {
+			t.Fatalf("stored response was lost; executed %d times", executed)
+		}
+	})
+
+	t.Run("in-flight keys are held during their lease", func(t *testing.T) {
+		ctx := tenant.WithID(t.Context(), "default")
+		running := &models.IdempotencyRecord{
+			Key:         "running",
+			Fingerprint: hash(http.MethodPost, "/books", "", `{"name":"Dune"}`),
+			Token:       "running",
+			ExpiresAt:   time.Now().UTC().Add(time.Minute),
+		}
+		if _, err := repo.Reserve(ctx, running); err != nil {
+			t.Fatal(err)
+		}
+
+		api.WithHeader(IdempotencyKeyHeader, "running").Post("/books", book).ExpectStatus(http.StatusConflict)
+	})
+}
+
+// storedRepository is an IdempotencyRepository whose keys are all taken by
+// its record
+type storedRepository struct {
+	record *models.IdempotencyRecord
+}
+
+func (r storedRepository) Reserve(context.Context, *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	return r.record, nil
+}
+
+func (r storedRepository) Complete(context.Context, *models.IdempotencyRecord) error {
+	return nil
+}
+
+func (r storedRepository) Release(context.Context, *models.IdempotencyRecord) error {
+	return nil
+}
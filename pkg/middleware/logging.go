@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestLoggerConfig configures the RequestLogger middleware
+type RequestLoggerConfig struct {
+	// LogBodies includes the redacted request and response bodies
+	LogBodies bool
+	// MaxBodySize truncates logged bodies to this many bytes
+	MaxBodySize int
+}
+
+// RequestLogger stores a request-scoped logger, carrying the request ID,
+// actor and trace ID, in the request's user context and logs every completed
+// request. Requests are logged by route rather than path, since paths may
+// carry secrets such as download tokens, and query values are redacted.
+// Secret headers are redacted and bodies, when logged, have secrets and
+// personal data masked.
+// It must run after the requestid, Tracing and Actor middlewares.
+func RequestLogger(base *slog.Logger, config RequestLoggerConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		actor := audit.ActorFromContext(ctx.UserContext())
+
+		attrs := []any{
+			"request_id", actor.RequestID,
+			"actor", actor.ID,
+			"method", strings.Clone(ctx.Method()),
+		}
+		if spanCtx := trace.SpanContextFromContext(ctx.UserContext()); spanCtx.HasTraceID() {
+			attrs = append(attrs, "trace_id", spanCtx.TraceID().String())
+		}
+
+		logger := base.With(attrs...)
+		ctx.SetUserContext(logging.WithLogger(ctx.UserContext(), logger))
+
+		err := ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		headers := map[string]string{}
+		ctx.Request().Header.VisitAll(func(key, value []byte) {
+			headers[string(key)] = string(value)
+		})
+
+		fields := []any{
+			"route", strings.Clone(ctx.Route().Path),
+			"status", status,
+			"latency", time.Since(start),
+			"ip", ctx.IP(),
+			"headers", logging.RedactHeaders(headers),
+		}
+		if query := string(ctx.Request().URI().QueryString()); query != "" {
+			fields = append(fields, "query", logging.RedactQuery(query))
+		}
+		if config.LogBodies {
+			// Reading a streamed body (e.g. file uploads or server-sent
+			// events) would consume it
+			if !ctx.Request().IsBodyStream() {
+				if body := ctx.Body(); len(body) > 0 {
+					fields = append(fields, "request_body", logging.RedactBody(body, config.MaxBodySize))
+				}
+			}
+			if !ctx.Response().IsBodyStream() {
+				if body := ctx.Response().Body(); len(body) > 0 {
+					fields = append(fields, "response_body", logging.RedactBody(body, config.MaxBodySize))
+				}
+			}
+		}
+		if err != nil {
+			fields = append(fields, "error", err)
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx.UserContext(), level, "Request completed", fields...)
+
+		return err
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// tenantLocal is the Locals key of the request's tenant
+const tenantLocal = "tenant"
+
+// Rate limit headers
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+)
+
+// TenantConfig configures the Tenant middleware
+type TenantConfig struct {
+	Resolver tenant.Resolver
+	Tenants  repository.TenantRepository
+}
+
+// Tenant resolves the tenant of every request with the Resolver and scopes
+// the request's user context to it, so that repositories only see its data.
+// Requests naming no tenant are answered with 400, with an invalid bearer
+// token with 401, and for an unknown or inactive tenant with 404.
+// It must run after the Actor middleware.
+func Tenant(config TenantConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := config.Resolver.Resolve(tenant.Request{
+			Header: func(name string) string { return ctx.Get(name) },
+			Host:   ctx.Hostname(),
+		})
+		if errors.Is(err, tenant.ErrInvalidToken) {
+			ctx.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="bookstore"`)
+			return handlers.Reject(ctx, http.StatusUnauthorized, "Invalid bearer token", nil)
+		}
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return handlers.Reject(ctx, http.StatusBadRequest, fmt.Sprintf("A tenant is required, e.g. in the %s header", config.Resolver.Header), nil)
+		}
+
+		current, err := config.Tenants.GetTenantByID(ctx.UserContext(), id)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && !current.Active) {
+			return handlers.Reject(ctx, http.StatusNotFound, fmt.Sprintf("Tenant %s not found", id), nil)
+		}
+		if err != nil {
+			return err
+		}
+
+		ctx.Locals(tenantLocal, current)
+		ctx.SetUserContext(tenant.WithID(ctx.UserContext(), current.ID))
+		return ctx.Next()
+	}
+}
+
+// TenantRateLimit enforces the rate limit of each tenant: at most
+// Tenant.RateLimit requests per minute, counted per process. Requests over
+// the limit are answered with 429 and a Retry-After header.
+// It must run after the Tenant middleware.
+func TenantRateLimit() fiber.Handler {
+	limiter := &rateLimiter{windows: map[string]*rateWindow{}, now: time.Now}
+
+	return func(ctx *fiber.Ctx) error {
+		current, ok := ctx.Locals(tenantLocal).(*models.Tenant)
+		if !ok || current.RateLimit <= 0 {
+			return ctx.Next()
+		}
+
+		remaining, retryAfter := limiter.take(current.ID, current.RateLimit)
+		ctx.Set(RateLimitLimitHeader, strconv.Itoa(current.RateLimit))
+		ctx.Set(RateLimitRemainingHeader, strconv.Itoa(remaining))
+		if retryAfter > 0 {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return handlers.Reject(ctx, http.StatusTooManyRequests, "Rate limit exceeded", nil)
+		}
+		return ctx.Next()
+	}
+}
+
+// rateLimiter counts requests per key in fixed one-minute windows
+type rateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	now     func() time.Time
+}
+
+// rateWindow is the request count of a key since start
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// take counts a request of key and returns how many remain in the window.
+// retryAfter is positive when the request is over limit.
+func (l *rateLimiter) take(key string, limit int) (remaining int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	window, ok := l.windows[key]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &rateWindow{start: now}
+		l.windows[key] = window
+	}
+
+	if window.count >= limit {
+		return 0, window.start.Add(time.Minute).Sub(now)
+	}
+	window.count++
+	return limit - window.count, 0
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader is the response header carrying the request's trace ID
+const TraceIDHeader = "X-Trace-Id"
+
+// Tracing starts a server span for every request, continuing the trace from
+// an incoming W3C traceparent header, and stores it in the request's user
+// context so that services and repositories create child spans.
+// It must run after the requestid middleware so the span carries the request ID.
+func Tracing() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		carrier := propagation.MapCarrier{}
+		ctx.Request().Header.VisitAll(func(key, value []byte) {
+			carrier[strings.ToLower(string(key))] = string(value)
+		})
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), carrier)
+
+		method := strings.Clone(ctx.Method())
+		requestID, _ := ctx.Locals("requestid").(string)
+
+		spanCtx, span := tracing.Tracer().Start(parent, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				attribute.String("http.request_id", requestID),
+			),
+		)
+		defer span.End()
+
+		if span.SpanContext().HasTraceID() {
+			ctx.Set(TraceIDHeader, span.SpanContext().TraceID().String())
+		}
+
+		ctx.SetUserContext(spanCtx)
+		err := ctx.Next()
+
+		// The route template is only known once routing has happened
+		route := strings.Clone(ctx.Route().Path)
+		span.SetName(method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			span.RecordError(err)
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ValidationConfig configures the Validation middleware
+type ValidationConfig struct {
+	// Document is the API contract; its paths are relative to Prefix
+	Document *openapi.Document
+	Prefix   string
+	// Strict rejects body fields that the contract does not describe
+	Strict bool
+	// ValidateResponses replaces responses that break the contract with a
+	// 500 listing the violations. It is meant for development and tests.
+	ValidateResponses bool
+	// Logger logs broken responses outside of the RequestLogger middleware
+	Logger *slog.Logger
+}
+
+// Validation checks the path and query parameters and the JSON body of
+// every request to a documented route against the API contract. Requests
+// that do not match are answered with 400 and every violation, each with
+// the JSON pointer of the offending value.
+// It must run after the RequestLogger middleware.
+func Validation(config ValidationConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		path, ok := strings.CutPrefix(ctx.Path(), config.Prefix)
+		if !ok {
+			return ctx.Next()
+		}
+
+		op, params := config.Document.Match(ctx.Method(), path)
+		if op == nil {
+			return ctx.Next()
+		}
+
+		query, _ := url.ParseQuery(string(ctx.Request().URI().QueryString()))
+		request := openapi.Request{
+			Query:       query,
+			Header:      func(name string) string { return ctx.Get(name) },
+			ContentType: ctx.Get(fiber.HeaderContentType),
+		}
+		// Streamed bodies, such as file uploads, are left to their handler
+		if !ctx.Request().IsBodyStream() {
+			request.Body = ctx.Body()
+		}
+		violations := config.Document.ValidateRequest(op, params, request, config.Strict)
+		if len(violations) > 0 {
+			return handlers.Reject(ctx, fiber.StatusBadRequest, "Request does not match the API contract", violations)
+		}
+
+		if err := ctx.Next(); err != nil || !config.ValidateResponses {
+			return err
+		}
+
+		// Streamed bodies cannot be read without consuming them
+		if ctx.Response().IsBodyStream() {
+			return nil
+		}
+
+		violations = config.Document.ValidateResponse(op, ctx.Response().StatusCode(),
+			string(ctx.Response().Header.ContentType()), ctx.Response().Body())
+		if len(violations) > 0 {
+			logging.FromContext(ctx.UserContext(), config.Logger).ErrorContext(ctx.UserContext(),
+				"Response does not match the API contract", "violations", violations)
+			return handlers.Reject(ctx, fiber.StatusInternalServerError, "Response does not match the API contract", violations)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/apitest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newValidationApp serves stub book routes of version under /api/<version>,
+// validated against the version's API contract. GET /books/:id answers with
+// the JSON body named by its ID, or with 418 when it names none.
+func newValidationApp(t *testing.T, version handlers.Version, config ValidationConfig, bodies map[string]string) *fiber.App {
+	t.Helper()
+
+	base := "/api/" + version.Name
+	config.Document = handlers.APIDocument(base, version)
+	config.Prefix = base
+	config.Logger = logging.Discard()
+
+	app := fiber.New()
+	router := app.Group(base, handlers.UseVersion(version))
+	router.Use(Validation(config))
+	router.Get("/books", func(ctx *fiber.Ctx) error {
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.SendString(`{"data":[],"page":{"limit":20}}`)
+	})
+	router.Post("/books", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusCreated)
+	})
+	router.Get("/books/:id", func(ctx *fiber.Ctx) error {
+		body, ok := bodies[ctx.Params("id")]
+		if !ok {
+			return ctx.SendStatus(http.StatusTeapot)
+		}
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.SendString(body)
+	})
+	return app
+}
+
+// pointers returns the locations of violations, as in:pointer
+func pointers(violations []openapi.Violation) []string {
+	var pointers []string
+	for _, violation := range violations {
+		pointers = append(pointers, violation.In+":"+violation.Pointer)
+	}
+	slices.Sort(pointers)
+	return pointers
+}
+
+func TestValidationRejectsRequests(t *testing.T) {
+	api := apitest.New(t, newValidationApp(t, handlers.V2, ValidationConfig{}, nil))
+
+	tests := []struct {
+		name string
+		path string
+		body any
+		want []string
+	}{
+		{"missing required fields", "/api/v2/books", map[string]any{"price": 10}, []string{"body:/author", "body:/name"}},
+		{"wrong types", "/api/v2/books", map[string]any{"name": 1, "price": "cheap", "author": map[string]any{"name": "Frank Herbert"}}, []string{"body:/name", "body:/price"}},
+		{"nested field", "/api/v2/books", map[string]any{"name": "Dune", "author": map[string]any{"name": []string{}}}, []string{"body:/author/name"}},
+		{"fractional integer", "/api/v2/books", map[string]any{"name": "Dune", "pages": 1.5, "author": map[string]any{"name": "Frank Herbert"}}, []string{"body:/pages"}},
+		{"not JSON", "/api/v2/books", "{", []string{"body:"}},
+		{"no body", "/api/v2/books", nil, []string{"body:"}},
+		{"query below the minimum", "/api/v2/books?limit=0", nil, []string{"query:/limit"}},
+		{"query of the wrong type", "/api/v2/books?limit=ten&min_price=free", nil, []string{"query:/limit", "query:/min_price"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodPost
+			if tt.body == nil && tt.name != "no body" {
+				method = http.MethodGet
+			}
+
+			var response handlers.ResponseV2
+			body := tt.body
+			if s, ok := body.(string); ok {
+				api.Send(method, tt.path, fiber.MIMEApplicationJSON, []byte(s)).ExpectStatus(http.StatusBadRequest).Decode(&response)
+			} else {
+				api.Do(method, tt.path, body).ExpectStatus(http.StatusBadRequest).Decode(&response)
+			}
+
+			if response.Error == nil || response.Error.Code != "bad_request" || response.Error.Message != "Request does not match the API contract" {
+				t.Fatalf("error = %+v, want a bad_request about the contract", response.Error)
+			}
+			if got := pointers(response.Error.Violations); !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+			for _, violation := range response.Error.Violations {
+				if violation.Message == "" {
+					t.Errorf("violation %+v has no message", violation)
+				}
+			}
+		})
+	}
+
+	// Valid requests, and undocumented routes, reach their handler
+	api.Post("/api/v2/books", map[string]any{"name": "Dune", "price": 10, "author": map[string]any{"name": "Frank Herbert"}}).ExpectStatus(http.StatusCreated)
+	api.Get("/api/v2/books?limit=5&min_price=9.5").ExpectStatus(http.StatusOK)
+	api.Get("/api/v2/undocumented?limit=0").ExpectStatus(http.StatusNotFound)
+}
+
+func TestValidationStrictMode(t *testing.T) {
+	book := map[string]any{"name": "Dune", "isbn": "0441013597", "author": map[string]any{"name": "Frank Herbert", "born": 1920}}
+
+	lenient := apitest.New(t, newValidationApp(t, handlers.V2, ValidationConfig{}, nil))
+	lenient.Post("/api/v2/books", book).ExpectStatus(http.StatusCreated)
+
+	var response handlers.ResponseV2
+	strict := apitest.New(t, newValidationApp(t, handlers.V2, ValidationConfig{Strict: true}, nil))
+	strict.Post("/api/v2/books", book).ExpectStatus(http.StatusBadRequest).Decode(&response)
+	if got, want := pointers(response.Error.Violations), []string{"body:/author/born", "body:/isbn"}; !slices.Equal(got, want) {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+}
+
+func TestValidationErrorShapeOfV1(t *testing.T) {
+	api := apitest.New(t, newValidationApp(t, handlers.V1, ValidationConfig{}, nil))
+
+	var response handlers.Response
+	api.Post("/api/v1/books", map[string]any{"price": 10}).ExpectStatus(http.StatusBadRequest).Decode(&response)
+	if response.Error != "Request does not match the API contract" || response.Data != nil {
+		t.Errorf("response = %+v, want the v1 error envelope", response)
+	}
+	if got, want := pointers(response.Violations), []string{"body:/author", "body:/name"}; !slices.Equal(got, want) {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+}
+
+func TestValidationOfResponses(t *testing.T) {
+	bodies := map[string]string{
+		"valid": `{"data":{"id":"dune","name":"Dune","author":{"name":"Frank Herbert"}}}`,
+		"wrong": `{"data":{"id":1,"author":{"name":"Frank Herbert"}}}`,
+		"list":  `{"data":[]}`,
+	}
+
+	// Without response validation, broken responses are sent as they are
+	api := apitest.New(t, newValidationApp(t, handlers.V2, ValidationConfig{}, bodies))
+	api.Get("/api/v2/books/wrong").ExpectStatus(http.StatusOK)
+
+	api = apitest.New(t, newValidationApp(t, handlers.V2, ValidationConfig{ValidateResponses: true}, bodies))
+	api.Get("/api/v2/books/valid").ExpectStatus(http.StatusOK)
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"wrong", []string{"body:/data/id", "body:/data/name"}},
+		{"list", []string{"body:/data"}},
+		{"teapot", []string{"body:"}},
+	}
+	for _, tt := range tests {
+		var response handlers.ResponseV2
+		api.Get("/api/v2/books/" + tt.id).ExpectStatus(http.StatusInternalServerError).Decode(&response)
+		if response.Error == nil || response.Error.Message != "Response does not match the API contract" {
+			t.Fatalf("%s: error = %+v, want one about the contract", tt.id, response.Error)
+		}
+		if got := pointers(response.Error.Violations); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: violations = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
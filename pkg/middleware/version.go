@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/handlers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionConfig configures the VersionNegotiation middleware
+type VersionConfig struct {
+	// Prefix is the path the version route groups are mounted under, e.g. /api
+	Prefix string
+	// Versions are the mounted versions by name
+	Versions map[string]handlers.Version
+	// Default serves requests that do not ask for a version
+	Default handlers.Version
+}
+
+// VersionNegotiation routes requests under Prefix that do not name a version
+// in their path, e.g. /api/books, to a version's route group. The version is
+// taken from the version parameter of the Accept header, e.g.
+// "application/json; version=2", and is the default one otherwise. Requests
+// for a version that is not mounted are answered with 406. Middlewares
+// registered after it reply in the version of the request.
+// It must be registered before the version route groups.
+func VersionNegotiation(config VersionConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		path, ok := strings.CutPrefix(ctx.Path(), config.Prefix+"/")
+		if !ok {
+			return ctx.Next()
+		}
+
+		segment, _, _ := strings.Cut(path, "/")
+		if version, ok := config.Versions[segment]; ok {
+			// Middlewares before the route group reply in its version
+			handlers.SetVersion(ctx, version)
+			return ctx.Next()
+		}
+
+		ctx.Vary(fiber.HeaderAccept)
+		version := config.Default
+		if name := acceptedVersion(ctx.Get(fiber.HeaderAccept)); name != "" {
+			if version, ok = config.Versions[name]; !ok {
+				handlers.SetVersion(ctx, config.Default)
+				return handlers.Reject(ctx, http.StatusNotAcceptable, fmt.Sprintf("API version %s is not available", name), nil)
+			}
+		}
+
+		handlers.SetVersion(ctx, version)
+		ctx.Path(config.Prefix + "/" + version.Name + "/" + path)
+		return ctx.Next()
+	}
+}
+
+// acceptedVersion returns the version named by the first media range of an
+// Accept header with a version parameter, as a version name like "v2"
+func acceptedVersion(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		_, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		if version := strings.ToLower(strings.TrimSpace(params["version"])); version != "" {
+			return "v" + strings.TrimPrefix(version, "v")
+		}
+	}
+	return ""
+}
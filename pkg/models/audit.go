@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditAppendOnly is returned when an audit entry would be modified or removed
+var ErrAuditAppendOnly = errors.New("audit entries are append-only")
+
+// AuditEntry records a single change to an entity: who made it, in which
+// request, and the state of the entity before and after.
+// Entries are numbered per entity by Version and are never modified.
+type AuditEntry struct {
+	ID         uint64          `json:"id" gorm:"primaryKey;autoIncrement"`
+	EntityType string          `json:"entity_type" gorm:"type:varchar(64);not null;uniqueIndex:idx_audit_entity_version"`
+	EntityID   string          `json:"entity_id" gorm:"type:varchar(191);not null;uniqueIndex:idx_audit_entity_version"`
+	Version    int             `json:"version" gorm:"not null;uniqueIndex:idx_audit_entity_version"`
+	Action     string          `json:"action" gorm:"type:varchar(32);not null"`
+	Actor      string          `json:"actor" gorm:"type:varchar(191);index"`
+	RequestID  string          `json:"request_id" gorm:"type:varchar(191);index"`
+	Changes    json.RawMessage `json:"changes" gorm:"type:text"`
+	Before     json.RawMessage `json:"before" gorm:"type:text"`
+	After      json.RawMessage `json:"after" gorm:"type:text"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+	TenantID   string          `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// BeforeUpdate is a GORM hook that keeps audit entries immutable
+func (a *AuditEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// BeforeDelete is a GORM hook that keeps audit entries immutable
+func (a *AuditEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
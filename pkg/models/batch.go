@@ -0,0 +1,58 @@
+package models
+
+// BatchMode controls how a batch of book operations is applied
+type BatchMode string
+
+const (
+	// BatchModeAtomic applies every operation in a single transaction (all or nothing)
+	BatchModeAtomic BatchMode = "atomic"
+	// BatchModeBestEffort applies each operation independently
+	BatchModeBestEffort BatchMode = "best_effort"
+)
+
+// BatchOpType is the kind of change a batch operation performs
+type BatchOpType string
+
+const (
+	BatchOpCreate BatchOpType = "create"
+	BatchOpUpdate BatchOpType = "update"
+	BatchOpDelete BatchOpType = "delete"
+)
+
+// BatchStatus is the outcome of a single batch operation
+type BatchStatus string
+
+const (
+	BatchStatusCreated    BatchStatus = "created"
+	BatchStatusUpdated    BatchStatus = "updated"
+	BatchStatusDeleted    BatchStatus = "deleted"
+	BatchStatusFailed     BatchStatus = "failed"
+	BatchStatusRolledBack BatchStatus = "rolled_back"
+	BatchStatusSkipped    BatchStatus = "skipped"
+)
+
+// BatchOperation is a single create/update/delete in a batch request
+type BatchOperation struct {
+	Op   BatchOpType `json:"op"`
+	ID   string      `json:"id,omitempty"`
+	Book *Book       `json:"book,omitempty"`
+}
+
+// BatchRequest is the body of POST /books/batch
+type BatchRequest struct {
+	Mode       BatchMode        `json:"mode"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchResult reports the outcome of the operation at Index in a batch request
+type BatchResult struct {
+	Index  int         `json:"index"`
+	Op     BatchOpType `json:"op"`
+	ID     string      `json:"id,omitempty"`
+	Status BatchStatus `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Book   *Book       `json:"book,omitempty"`
+
+	// Err is the underlying error, kept for callers that need to classify it
+	Err error `json:"-"`
+}
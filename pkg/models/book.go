@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/dtg-lucifer/go-bookstore/pkg/similarity"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -13,14 +14,16 @@ type Book struct {
 	Name          string     `json:"name" validate:"required"`
 	AuthorID      string     `json:"author_id" gorm:"type:varchar(191);column:author_id;not null"`
 	Author        Author     `json:"author" validate:"required" gorm:"foreignKey:AuthorID;references:ID"`
-	Publisher     string     `json:"publisher" validate:"required"`
-	PublishedYear uint       `json:"published_year" validate:"required"`
-	Description   string     `json:"description" validate:"required" gorm:"size:255"`
-	Price         float64    `json:"price" validate:"required"`
-	Pages         int        `json:"pages" validate:"required"`
+	Publisher     string     `json:"publisher"`
+	PublishedYear uint       `json:"published_year"`
+	Description   string     `json:"description" gorm:"size:255"`
+	Price         float64    `json:"price"`
+	Pages         int        `json:"pages"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	DeletedAt     *time.Time `json:"deleted_at" gorm:"index"`
+	// TenantID is the store owning the book, set by tenant.GORMPlugin
+	TenantID string `json:"-" gorm:"type:varchar(64);not null;index"`
 }
 
 // BeforeCreate is a GORM hook to generate UUID before creating a record
@@ -35,11 +38,15 @@ func (b *Book) BeforeCreate(tx *gorm.DB) (err error) {
 type Author struct {
 	ID        string     `json:"id" gorm:"primaryKey;type:varchar(191);column:id;autoIncrement:false"`
 	Name      string     `json:"name" validate:"required"`
-	Bio       string     `json:"bio" validate:"required"`
+	Bio       string     `json:"bio"`
 	Books     []Book     `json:"books,omitempty" gorm:"foreignKey:AuthorID"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at" gorm:"index"`
+	// NormalizedName is the name folded by similarity.Normalize, to match
+	// authors by name
+	NormalizedName string `json:"-" gorm:"type:varchar(191);index"`
+	TenantID       string `json:"-" gorm:"type:varchar(64);not null;index"`
 }
 
 // BeforeCreate is a GORM hook to generate UUID before creating a record
@@ -49,3 +56,25 @@ func (a *Author) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+// BeforeSave is a GORM hook to keep the normalized name in sync with the name
+func (a *Author) BeforeSave(tx *gorm.DB) (err error) {
+	a.NormalizedName = similarity.Normalize(a.Name)
+	return
+}
+
+// AuthorRedirect points the ID of an author merged into another one at the
+// surviving author, so that the old ID still resolves
+type AuthorRedirect struct {
+	AuthorID  string    `json:"author_id" gorm:"primaryKey;type:varchar(191);autoIncrement:false"`
+	TargetID  string    `json:"target_id" gorm:"type:varchar(191);index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	TenantID  string    `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// AuthorDuplicates is a group of authors whose names are probably the same
+type AuthorDuplicates struct {
+	Authors []Author `json:"authors"`
+	// Similarity is the lowest name similarity that joined the group, from 0 to 1
+	Similarity float64 `json:"similarity"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Formats of cover renditions
+const (
+	CoverFormatJPEG = "jpeg"
+	CoverFormatWebP = "webp"
+)
+
+// BookCover describes the cover image of a book. The uploaded image and its
+// renditions are kept in blob storage, under a directory of their version,
+// which changes with every upload.
+type BookCover struct {
+	BookID      string           `json:"book_id" gorm:"primaryKey;type:varchar(191);column:book_id;autoIncrement:false"`
+	Version     string           `json:"version" gorm:"type:varchar(64);not null"`
+	ContentType string           `json:"content_type" gorm:"type:varchar(64);not null"`
+	Width       int              `json:"width"`
+	Height      int              `json:"height"`
+	Size        int64            `json:"size"`
+	Renditions  []CoverRendition `json:"renditions" gorm:"type:text;serializer:json"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	TenantID    string           `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// CoverRendition is a thumbnail of a cover, resized to one of the
+// configured widths, in one format
+type CoverRendition struct {
+	// Width is the requested width; Actual is smaller for smaller covers,
+	// which are never enlarged
+	Width  int    `json:"width"`
+	Actual int    `json:"actual_width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+	Size   int64  `json:"size"`
+}
+
+// Rendition returns the rendition of the given width and format, if any
+func (c *BookCover) Rendition(width int, format string) (CoverRendition, bool) {
+	for _, rendition := range c.Renditions {
+		if rendition.Width == width && rendition.Format == format {
+			return rendition, true
+		}
+	}
+	return CoverRendition{}, false
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Formats of ebook files
+const (
+	FileFormatEPUB = "epub"
+	FileFormatPDF  = "pdf"
+)
+
+// FileContentTypes are the content types of the ebook formats
+var FileContentTypes = map[string]string{
+	FileFormatEPUB: "application/epub+zip",
+	FileFormatPDF:  "application/pdf",
+}
+
+// BookFile describes the ebook file of a book in one format. The file is
+// kept in blob storage under its version, which changes with every upload.
+type BookFile struct {
+	BookID      string `json:"book_id" gorm:"primaryKey;type:varchar(191);column:book_id;autoIncrement:false"`
+	Format      string `json:"format" gorm:"primaryKey;type:varchar(16);autoIncrement:false"`
+	Version     string `json:"version" gorm:"type:varchar(64);not null"`
+	Filename    string `json:"filename" gorm:"type:varchar(191);not null"`
+	ContentType string `json:"content_type" gorm:"type:varchar(64);not null"`
+	Size        int64  `json:"size"`
+	// SHA256 is the hex-encoded SHA-256 checksum of the file
+	SHA256 string `json:"sha256" gorm:"type:char(64);not null"`
+	// Downloads counts the downloads of the file by every user, across
+	// uploads
+	Downloads int64     `json:"downloads" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	TenantID  string    `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// FileDownload counts the downloads of a book file by a user, which are
+// limited per user
+type FileDownload struct {
+	BookID string `json:"book_id" gorm:"primaryKey;type:varchar(191);column:book_id;autoIncrement:false"`
+	Format string `json:"format" gorm:"primaryKey;type:varchar(16);autoIncrement:false"`
+	UserID string `json:"user_id" gorm:"primaryKey;type:varchar(191);autoIncrement:false"`
+	// PurchaseID is the purchase of the link of the latest download
+	PurchaseID       string     `json:"purchase_id" gorm:"type:varchar(191)"`
+	Downloads        int        `json:"downloads" gorm:"not null;default:0"`
+	LastDownloadedAt *time.Time `json:"last_downloaded_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	TenantID         string     `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// FileDownloadLink records a download link once it has been counted, so
+// that its later requests, e.g. resumed downloads, are not counted again.
+// It is kept until the link expires.
+type FileDownloadLink struct {
+	LinkID    string    `json:"link_id" gorm:"primaryKey;type:varchar(64);autoIncrement:false"`
+	BookID    string    `json:"book_id" gorm:"type:varchar(191);column:book_id;not null;index:idx_file_download_links_file"`
+	Format    string    `json:"format" gorm:"type:varchar(16);not null;index:idx_file_download_links_file"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(191);not null;index:idx_file_download_links_file"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	TenantID  string    `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// DownloadLinkRequest asks for a download link of a book file for a user
+// who bought it. It is sent by the store, which vouches for the purchase.
+type DownloadLinkRequest struct {
+	// UserID is the user the link is for, whose downloads are limited
+	UserID string `json:"user_id" validate:"required"`
+	// PurchaseID is the purchase of the book by the user in the store
+	PurchaseID string `json:"purchase_id" validate:"required"`
+	// ExpiresIn is the lifetime of the link in seconds; zero for the default
+	ExpiresIn int `json:"expires_in"`
+}
+
+// DownloadLink is a signed link to download a book file until it expires
+type DownloadLink struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Downloads is how many times the user has downloaded the file
+	Downloads int `json:"downloads"`
+	// DownloadLimit is how many times the user may download the file; zero
+	// when unlimited
+	DownloadLimit int `json:"download_limit"`
+}
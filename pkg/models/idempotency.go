@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is the stored response of a request sent with an
+// Idempotency-Key header. A record without a status code is in flight, and
+// expires at the end of its lease.
+type IdempotencyRecord struct {
+	// Key is the Idempotency-Key of the request. It is stored scoped to
+	// the tenant and the verified caller; see repository.IdempotencyRepository
+	Key string `json:"key" gorm:"primaryKey;type:varchar(191);autoIncrement:false"`
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string `json:"fingerprint" gorm:"type:varchar(64);not null"`
+	// Actor is the verified caller of that request, or "" when it was not
+	// identified; it is set by the repository, and only the same caller
+	// gets its response again
+	Actor string `json:"actor" gorm:"type:varchar(191);not null;default:''"`
+	// Token identifies the reservation, so that a request whose lease was
+	// taken over neither completes nor releases the next one
+	Token      string            `json:"-" gorm:"type:varchar(36);not null"`
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers" gorm:"type:text;serializer:json"`
+	Body       string            `json:"body" gorm:"type:mediumtext"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ExpiresAt  time.Time         `json:"expires_at" gorm:"index"`
+}
+
+// Completed reports whether the response of the request was stored
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
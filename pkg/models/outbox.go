@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event stored in the transactional outbox.
+// Rows are written in the same transaction as the change they describe
+// and published asynchronously by the events relay.
+type OutboxEvent struct {
+	ID            uint64 `json:"id" gorm:"primaryKey;autoIncrement"`
+	EventID       string `json:"event_id" gorm:"type:varchar(191);uniqueIndex;not null"`
+	EventType     string `json:"event_type" gorm:"type:varchar(64);index;not null"`
+	AggregateType string `json:"aggregate_type" gorm:"type:varchar(64);index:idx_outbox_aggregate;not null"`
+	AggregateID   string `json:"aggregate_id" gorm:"type:varchar(191);index:idx_outbox_aggregate;not null"`
+	Payload       string `json:"payload" gorm:"type:text"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error" gorm:"type:text"`
+	// NextAttemptAt delays the retry of a failed event, and of the later
+	// events of its aggregate; nil until it fails
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PublishedAt   *time.Time `json:"published_at" gorm:"index"`
+	// TenantID is the store the event happened in
+	TenantID string `json:"tenant_id" gorm:"type:varchar(64);not null;index"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Tenant is a storefront sharing the deployment. Its catalog, audit log,
+// events and webhooks are only visible to requests resolved to it.
+type Tenant struct {
+	// ID names the tenant in requests, e.g. as X-Tenant-ID or subdomain
+	ID   string `json:"id" validate:"required" gorm:"primaryKey;type:varchar(64);autoIncrement:false"`
+	Name string `json:"name" validate:"required" gorm:"size:255;not null"`
+	// Currency is the ISO 4217 code of the prices of the tenant's books
+	Currency string `json:"currency" gorm:"type:varchar(3);not null"`
+	// RateLimit is the number of requests allowed per minute; 0 is unlimited
+	RateLimit int `json:"rate_limit"`
+	// Active tenants are served; requests for inactive ones are refused
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TenantUpdate holds the fields of a tenant to change; nil fields are kept
+type TenantUpdate struct {
+	Name      *string `json:"name"`
+	Currency  *string `json:"currency"`
+	RateLimit *int    `json:"rate_limit"`
+	Active    *bool   `json:"active"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is a partner endpoint that receives catalog events
+type WebhookSubscription struct {
+	ID                  string     `json:"id" gorm:"primaryKey;type:varchar(191);column:id;autoIncrement:false"`
+	URL                 string     `json:"url" validate:"required" gorm:"type:varchar(2048);not null"`
+	Description         string     `json:"description" gorm:"size:255"`
+	EventTypes          []string   `json:"event_types" gorm:"type:text;serializer:json"`
+	Secret              string     `json:"secret,omitempty" gorm:"type:varchar(191);not null"`
+	Active              bool       `json:"active" gorm:"index"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      string     `json:"disabled_reason,omitempty" gorm:"size:255"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	TenantID            string     `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// BeforeCreate is a GORM hook to generate UUID before creating a record
+func (w *WebhookSubscription) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return
+}
+
+// Matches reports whether the subscription wants events of the given type
+func (w *WebhookSubscription) Matches(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == "*" || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is the delivery log entry of one event to one subscription
+type WebhookDelivery struct {
+	ID             string                `json:"id" gorm:"primaryKey;type:varchar(191);column:id;autoIncrement:false"`
+	SubscriptionID string                `json:"subscription_id" gorm:"type:varchar(191);not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventID        string                `json:"event_id" gorm:"type:varchar(191);not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventType      string                `json:"event_type" gorm:"type:varchar(64);not null"`
+	Payload        string                `json:"payload" gorm:"type:text"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"type:varchar(32);index:idx_webhook_delivery_due"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body" gorm:"type:text"`
+	LastError      string                `json:"last_error" gorm:"type:text"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	TenantID       string                `json:"-" gorm:"type:varchar(64);not null;index"`
+}
+
+// BeforeCreate is a GORM hook to generate UUID before creating a record
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,178 @@
+package openapi
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.1.0"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// enums holds the allowed values of named types, keyed by component name
+	enums map[string][]any
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the paths are relative to
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Components holds the reusable schemas referenced by operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem holds the operations of a path, keyed by lower case method
+type PathItem map[string]*Operation
+
+// Operation describes a single route
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response describes a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]*Header   `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header describes a response header
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// New creates an empty document served under the given base URLs
+func New(title string, version string, servers ...string) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Version: version},
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+		enums:      map[string][]any{},
+	}
+	for _, url := range servers {
+		doc.Servers = append(doc.Servers, Server{URL: url})
+	}
+	return doc
+}
+
+// fiberParam matches a Fiber route parameter such as ":id" or ":id?"
+var fiberParam = regexp.MustCompile(`:(\w+)\??`)
+
+// Path converts a Fiber route path to an OpenAPI path
+func Path(route string) string {
+	return fiberParam.ReplaceAllString(route, "{$1}")
+}
+
+// Add documents the operation of method on a Fiber route path.
+// Its path parameters are added to the operation when it does not declare them.
+func (d *Document) Add(method string, route string, op *Operation) {
+	path := Path(route)
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+
+	declared := map[string]bool{}
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			declared[param.Name] = true
+		}
+	}
+	var params []*Parameter
+	for _, match := range fiberParam.FindAllStringSubmatch(route, -1) {
+		if !declared[match[1]] {
+			params = append(params, PathParam(match[1], ""))
+		}
+	}
+	op.Parameters = append(params, op.Parameters...)
+
+	(*item)[strings.ToLower(method)] = op
+}
+
+// Operation returns the operation of method on an OpenAPI path, if any
+func (d *Document) Operation(method string, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return (*item)[strings.ToLower(method)]
+}
+
+// PathParam creates a required string path parameter
+func PathParam(name string, description string) *Parameter {
+	return &Parameter{Name: name, In: "path", Description: description, Required: true, Schema: String()}
+}
+
+// QueryParam creates an optional query parameter
+func QueryParam(name string, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// HeaderParam creates an optional request header parameter
+func HeaderParam(name string, description string) *Parameter {
+	return &Parameter{Name: name, In: "header", Description: description, Schema: String()}
+}
+
+// JSON creates a JSON request body
+func JSON(description string, schema *Schema) *RequestBody {
+	return &RequestBody{
+		Description: description,
+		Required:    true,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// JSONResponse creates a response with a JSON body
+func JSONResponse(description string, schema *Schema) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
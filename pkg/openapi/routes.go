@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Check reports the routes registered under prefix that the document does
+// not describe, and the operations it describes that are not registered.
+// HEAD routes that Fiber adds for GET routes need not be documented.
+func (d *Document) Check(routes []fiber.Route, prefix string) error {
+	registered := map[string]bool{}
+	for _, route := range routes {
+		// Only whole segments match, so that /api/v1 leaves out /api/v10
+		path, ok := strings.CutPrefix(route.Path, prefix)
+		if route.Method == "USE" || !ok || (path != "" && !strings.HasPrefix(path, "/")) {
+			continue
+		}
+		registered[route.Method+" "+Path(path)] = true
+	}
+
+	var missing []string
+	for key := range registered {
+		method, path, _ := strings.Cut(key, " ")
+		if d.Operation(method, path) != nil {
+			continue
+		}
+		if method == http.MethodHead && registered[http.MethodGet+" "+path] {
+			continue
+		}
+		missing = append(missing, method+" "+prefix+path)
+	}
+
+	var stale []string
+	for path, item := range d.Paths {
+		for method := range *item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				stale = append(stale, strings.ToUpper(method)+" "+prefix+path)
+			}
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(stale)
+	switch {
+	case len(missing) > 0:
+		return fmt.Errorf("routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	case len(stale) > 0:
+		return fmt.Errorf("OpenAPI document describes unregistered routes: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
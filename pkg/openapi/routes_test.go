@@ -0,0 +1,59 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func noop(ctx *fiber.Ctx) error { return nil }
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		routes  map[string]string
+		wantErr string
+	}{
+		{
+			name:   "documented",
+			routes: map[string]string{"/api/v1/books": http.MethodGet, "/api/v1/books/:id": http.MethodDelete},
+		},
+		{
+			name:   "other prefixes",
+			routes: map[string]string{"/api/v1/books": http.MethodGet, "/api/v1/books/:id": http.MethodDelete, "/api/v10/authors": http.MethodGet, "/api/v1x": http.MethodGet},
+		},
+		{
+			name:    "undocumented",
+			routes:  map[string]string{"/api/v1/books": http.MethodGet, "/api/v1/books/:id": http.MethodDelete, "/api/v1/authors": http.MethodGet},
+			wantErr: "routes missing from the OpenAPI document: GET /api/v1/authors",
+		},
+		{
+			name:    "unregistered",
+			routes:  map[string]string{"/api/v1/books": http.MethodGet, "/api/v10/books/:id": http.MethodDelete},
+			wantErr: "OpenAPI document describes unregistered routes: DELETE /api/v1/books/{id}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := New("Test", "1")
+			doc.Add(http.MethodGet, "/books", &Operation{})
+			doc.Add(http.MethodDelete, "/books/:id", &Operation{})
+
+			app := fiber.New()
+			for path, method := range test.routes {
+				app.Add(method, path, noop)
+			}
+
+			err := doc.Check(app.GetRoutes(true), "/api/v1")
+			switch {
+			case test.wantErr == "" && err != nil:
+				t.Fatalf("Check() = %v, want nil", err)
+			case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+				t.Fatalf("Check() = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema is a JSON Schema (draft 2020-12, as used by OpenAPI 3.1)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+// String returns a string schema
+func String() *Schema {
+	return &Schema{Type: "string"}
+}
+
+// Integer returns an integer schema
+func Integer() *Schema {
+	return &Schema{Type: "integer"}
+}
+
+// Any returns a schema accepting any value
+func Any() *Schema {
+	return &Schema{}
+}
+
+// Array returns an array schema of items
+func Array(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// Object returns an object schema with the given properties
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// Nullable allows null in addition to schema
+func Nullable(schema *Schema) *Schema {
+	if typ, ok := schema.Type.(string); ok && schema.Ref == "" {
+		nullable := *schema
+		nullable.Type = []string{typ, "null"}
+		return &nullable
+	}
+	return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// Enum records the allowed values of the named type of v, which are used
+// whenever the type appears in a schema
+func (d *Document) Enum(v any, values ...any) {
+	d.enums[componentName(reflect.TypeOf(v))] = values
+}
+
+// Schema returns the schema of the Go value v as encoded by encoding/json.
+// Named struct types are added to the components and referenced. Fields
+// tagged `validate:"required"` are required.
+func (d *Document) Schema(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return Any()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return Nullable(d.schemaOf(t.Elem()))
+	case reflect.String:
+		schema := String()
+		if values, ok := d.enums[componentName(t)]; ok {
+			schema.Enum = values
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Integer()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return Array(d.schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return Any()
+	}
+}
+
+// structSchema returns the object schema of the fields of struct type t
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := Object(map[string]*Schema{})
+	d.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the JSON fields of t, including those of embedded structs, to schema
+func (d *Document) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			d.addFields(schema, field.Type)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldType := field.Type
+		// An omitted pointer is left out rather than sent as null
+		if fieldType.Kind() == reflect.Pointer && strings.Contains(opts, "omitempty") {
+			fieldType = fieldType.Elem()
+		}
+		property := d.schemaOf(fieldType)
+		// A nil slice is encoded as null
+		if fieldType.Kind() == reflect.Slice && fieldType != rawType && !strings.Contains(opts, "omitempty") {
+			property = Nullable(property)
+		}
+		schema.Properties[name] = property
+
+		if strings.Contains(field.Tag.Get("validate"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// componentName returns the component name of a named type
+func componentName(t reflect.Type) string {
+	name := []rune(t.Name())
+	if len(name) > 0 {
+		name[0] = unicode.ToUpper(name[0])
+	}
+	return string(name)
+}
+
+// Partial adds a component named name with the fields of the struct value v,
+// none of them required, and references it. It describes partial updates.
+func (d *Document) Partial(name string, v any) *Schema {
+	if _, ok := d.Components.Schemas[name]; !ok {
+		schema := d.structSchema(reflect.TypeOf(v))
+		schema.Required = nil
+		d.Components.Schemas[name] = schema
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Define adds schema to the components as name and references it
+func (d *Document) Define(name string, schema *Schema) *Schema {
+	d.Components.Schemas[name] = schema
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"strings"
+)
+
+//go:embed docs.html
+var docsPage string
+
+// SwaggerUIVersion is the version of swagger-ui-dist vendored in swagger-ui,
+// from which the docs page loads Swagger UI rather than from a CDN
+const SwaggerUIVersion = "5.18.2"
+
+//go:embed swagger-ui/*.css swagger-ui/*.js
+var swaggerUI embed.FS
+
+// SwaggerUI holds the Swagger UI assets the docs page loads, by file name
+var SwaggerUI, _ = fs.Sub(swaggerUI, "swagger-ui")
+
+// SpecURL is a document listed by the docs page
+type SpecURL struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// DocsPage returns the HTML page rendering the documents at urls with
+// Swagger UI, showing the one named primary first. The Swagger UI assets
+// are loaded from assets, where SwaggerUI is served.
+func DocsPage(urls []SpecURL, primary string, assets string) string {
+	encodedURLs, _ := json.Marshal(urls)
+	encodedPrimary, _ := json.Marshal(primary)
+	return strings.NewReplacer(
+		"{{SPEC_URLS}}", string(encodedURLs),
+		"{{PRIMARY}}", string(encodedPrimary),
+		"{{ASSETS}}", strings.TrimSuffix(assets, "/"),
+	).Replace(docsPage)
+}
@@ -0,0 +1,329 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Violation is a part of a request or response that does not match the document
+type Violation struct {
+	// In is where the value is: path, query, header or body
+	In string `json:"in"`
+	// Pointer is the JSON pointer of the value, e.g. /author/name
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// Request is the part of an HTTP request that is validated
+type Request struct {
+	Query       url.Values
+	Header      func(name string) string
+	ContentType string
+	Body        []byte
+}
+
+// Match returns the operation of method whose path matches requestPath, and
+// the values of its path parameters. HEAD requests match GET operations.
+// Static segments take precedence over parameters, like in the router.
+func (d *Document) Match(method string, requestPath string) (*Operation, map[string]string) {
+	if method == "HEAD" {
+		method = "GET"
+	}
+	segments := splitPath(requestPath)
+
+	var best *Operation
+	var bestParams map[string]string
+	for path := range d.Paths {
+		op := d.Operation(method, path)
+		if op == nil {
+			continue
+		}
+		params, ok := matchPath(splitPath(path), segments)
+		if ok && (best == nil || len(params) < len(bestParams)) {
+			best, bestParams = op, params
+		}
+	}
+	return best, bestParams
+}
+
+// matchPath matches request path segments against a path template
+func matchPath(template []string, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, part := range template {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			value, err := url.PathUnescape(segments[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = value
+			continue
+		}
+		if !strings.EqualFold(part, segments[i]) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// splitPath splits a path into segments, ignoring a trailing slash
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// ValidateRequest checks the parameters and JSON body of req against op.
+// In strict mode body fields that the schema does not describe are rejected.
+func (d *Document) ValidateRequest(op *Operation, params map[string]string, req Request, strict bool) []Violation {
+	v := &validator{doc: d, strict: strict}
+
+	for _, param := range op.Parameters {
+		var raw string
+		var present bool
+		switch param.In {
+		case "path":
+			raw, present = params[param.Name]
+		case "query":
+			present = req.Query.Has(param.Name)
+			raw = req.Query.Get(param.Name)
+		case "header":
+			raw = req.Header(param.Name)
+			present = raw != ""
+		}
+
+		v.in = param.In
+		pointer := "/" + escapePointer(param.Name)
+		if !present {
+			if param.Required {
+				v.fail(pointer, "is required")
+			}
+			continue
+		}
+		v.validate(param.Schema, parseParam(param.Schema, raw), pointer)
+	}
+
+	if op.RequestBody != nil {
+		v.in = "body"
+		v.validateBody(op.RequestBody.Content, op.RequestBody.Required, req.ContentType, req.Body)
+	}
+
+	return v.violations
+}
+
+// ValidateResponse checks a response to op against the document. Responses
+// with a status the operation does not describe are violations too.
+func (d *Document) ValidateResponse(op *Operation, status int, contentType string, body []byte) []Violation {
+	v := &validator{doc: d, in: "body"}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		v.fail("", fmt.Sprintf("status %d is not documented", status))
+		return v.violations
+	}
+	if len(response.Content) > 0 {
+		v.validateBody(response.Content, false, contentType, body)
+	}
+	return v.violations
+}
+
+// validator collects the violations of a request or response
+type validator struct {
+	doc        *Document
+	strict     bool
+	in         string
+	violations []Violation
+}
+
+func (v *validator) fail(pointer string, message string) {
+	v.violations = append(v.violations, Violation{In: v.in, Pointer: pointer, Message: message})
+}
+
+// validateBody validates a JSON body; other media types are not checked
+func (v *validator) validateBody(content map[string]MediaType, required bool, contentType string, body []byte) {
+	media, ok := content["application/json"]
+	if !ok || media.Schema == nil {
+		return
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required {
+			v.fail("", "a JSON body is required")
+		}
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "" && mediaType != "application/json" {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		v.fail("", "is not valid JSON")
+		return
+	}
+	v.validate(media.Schema, value, "")
+}
+
+// validate checks a decoded JSON value against schema
+func (v *validator) validate(schema *Schema, value any, pointer string) {
+	if schema == nil {
+		return
+	}
+	if schema.Ref != "" {
+		v.validate(v.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], value, pointer)
+		return
+	}
+
+	if len(schema.AnyOf) > 0 {
+		for _, option := range schema.AnyOf {
+			nested := &validator{doc: v.doc, strict: v.strict, in: v.in}
+			nested.validate(option, value, pointer)
+			if len(nested.violations) == 0 {
+				return
+			}
+		}
+		if value == nil {
+			v.fail(pointer, "cannot be null")
+			return
+		}
+		// Report why the first non-null option did not match
+		for _, option := range schema.AnyOf {
+			if option.Type != "null" {
+				v.validate(option, value, pointer)
+				return
+			}
+		}
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 && !slices.Contains(types, jsonType(value, types)) {
+		if value == nil {
+			v.fail(pointer, "cannot be null")
+		} else {
+			v.fail(pointer, "must be "+strings.Join(types, " or "))
+		}
+		return
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(allowed any) bool {
+		return fmt.Sprint(allowed) == fmt.Sprint(value)
+	}) {
+		allowed := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			allowed[i] = fmt.Sprintf("%q", fmt.Sprint(value))
+		}
+		v.fail(pointer, "must be one of "+strings.Join(allowed, ", "))
+	}
+
+	switch value := value.(type) {
+	case string:
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				v.fail(pointer, "must be an RFC 3339 date-time")
+			}
+		}
+	case json.Number:
+		if schema.Minimum != nil {
+			if n, err := value.Float64(); err == nil && n < *schema.Minimum {
+				v.fail(pointer, fmt.Sprintf("must be at least %v", *schema.Minimum))
+			}
+		}
+	case []any:
+		for i, item := range value {
+			v.validate(schema.Items, item, pointer+"/"+strconv.Itoa(i))
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				v.fail(pointer+"/"+escapePointer(name), "is required")
+			}
+		}
+
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fieldPointer := pointer + "/" + escapePointer(name)
+			if property, ok := schema.Properties[name]; ok {
+				v.validate(property, value[name], fieldPointer)
+			} else if schema.AdditionalProperties != nil {
+				v.validate(schema.AdditionalProperties, value[name], fieldPointer)
+			} else if v.strict && schema.Properties != nil {
+				v.fail(fieldPointer, "is not a known field")
+			}
+		}
+	}
+}
+
+// schemaTypes returns the JSON types allowed by schema
+func schemaTypes(schema *Schema) []string {
+	switch typ := schema.Type.(type) {
+	case string:
+		return []string{typ}
+	case []string:
+		return typ
+	}
+	return nil
+}
+
+// jsonType returns the JSON type of a decoded value. Integral numbers are
+// integers when allowed is looking for one.
+func jsonType(value any, allowed []string) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if slices.Contains(allowed, "integer") {
+			if n, err := value.Float64(); err == nil && n == math.Trunc(n) {
+				return "integer"
+			}
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return ""
+}
+
+// parseParam converts a raw parameter to the JSON value its schema expects.
+// Values that cannot be converted are kept as strings and fail validation.
+func parseParam(schema *Schema, raw string) any {
+	types := schemaTypes(schema)
+	switch {
+	case slices.Contains(types, "integer"), slices.Contains(types, "number"):
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case slices.Contains(types, "boolean"):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// escapePointer escapes a JSON pointer reference token
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
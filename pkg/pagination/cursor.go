@@ -0,0 +1,43 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by EncodeCursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Key is the position of an item in a list ordered by name, then ID. A page
+// continues after the key of the last item of the previous one, so that
+// items added or removed meanwhile neither repeat nor skip items.
+type Key struct {
+	Name string `json:"n"`
+	ID   string `json:"i"`
+}
+
+// EncodeCursor builds the opaque cursor pointing after the item at key
+func EncodeCursor(key Key) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the key a cursor points after; an empty cursor is the
+// first page, with no key
+func DecodeCursor(cursor string) (*Key, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var key Key
+	if err := json.Unmarshal(data, &key); err != nil || key.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &key, nil
+}
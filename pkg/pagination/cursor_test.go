@@ -0,0 +1,38 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, key := range []Key{
+		{Name: "Dune", ID: "6efd7999-a0cc-4e05-a764-9cd271dd42d3"},
+		{Name: "", ID: "1"},
+		{Name: "Guards! Guards! / ?&=+ ü", ID: "2"},
+	} {
+		got, err := DecodeCursor(EncodeCursor(key))
+		if err != nil {
+			t.Fatalf("DecodeCursor(EncodeCursor(%+v)) error = %v", key, err)
+		}
+		if *got != key {
+			t.Fatalf("DecodeCursor(EncodeCursor(%+v)) = %+v", key, *got)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	if key, err := DecodeCursor(""); key != nil || err != nil {
+		t.Fatalf(`DecodeCursor("") = %v, %v; want the first page`, key, err)
+	}
+
+	for _, cursor := range []string{
+		"not base64!",
+		"b2Zmc2V0OjIw", // offset:20, the former offset cursors
+		EncodeCursor(Key{Name: "Dune"}),
+	} {
+		if _, err := DecodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// AuditFilter selects audit entries. Empty fields match everything.
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	Limit      int
+}
+
+// AuditRepository defines the interface for reading the audit log.
+// Entries are written by the other repositories in the same transaction as
+// the change they describe, and can never be modified.
+type AuditRepository interface {
+	ListEntries(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error)
+	GetEntry(ctx context.Context, entityType string, entityID string, version int) (*models.AuditEntry, error)
+}
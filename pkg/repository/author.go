@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+)
+
+// AuthorRepository defines the interface for author-related database operations
+type AuthorRepository interface {
+	GetAllAuthors(ctx context.Context) ([]models.Author, error)
+	// GetAuthorByID retrieves an author; IDs of merged authors resolve to the surviving one
+	GetAuthorByID(ctx context.Context, id string) (*models.Author, error)
+	// GetAuthorsByIDs retrieves several authors; unknown IDs are skipped
+	GetAuthorsByIDs(ctx context.Context, ids []string) ([]models.Author, error)
+	// ListAuthors retrieves a page of authors ordered by name, then ID,
+	// starting after the author at after unless it is nil
+	ListAuthors(ctx context.Context, limit int, after *pagination.Key) ([]models.Author, error)
+	// FindAuthorByName retrieves the oldest author whose name normalizes like name
+	FindAuthorByName(ctx context.Context, name string) (*models.Author, error)
+	// CreateAuthor creates an author, generating its ID when empty
+	CreateAuthor(ctx context.Context, author *models.Author) error
+	// UpdateAuthor changes the name and bio of an existing author
+	UpdateAuthor(ctx context.Context, author *models.Author) error
+}
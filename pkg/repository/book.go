@@ -2,15 +2,54 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
 )
 
+// ErrNotFound is wrapped by repository errors when the requested record does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is wrapped by repository errors when a record with the same key already exists
+var ErrConflict = errors.New("already exists")
+
+// BookSearch selects books. Empty fields match everything.
+type BookSearch struct {
+	// Query is matched against the book name and description
+	Query string
+	// Author is matched against the author name
+	Author    string
+	Publisher string
+	MinPrice  float64
+	// MaxPrice is ignored when zero
+	MaxPrice float64
+	Limit    int
+	// After starts the results after the book at this key, in their order
+	After *pagination.Key
+}
+
 // BookRepository defines the interface for book-related database operations
 type BookRepository interface {
 	GetAllBooks(ctx context.Context) ([]models.Book, error)
 	GetBookByID(ctx context.Context, id string) (*models.Book, error)
+	SearchBooks(ctx context.Context, search BookSearch) ([]models.Book, error)
+	// GetBooksByAuthorIDs retrieves the books of several authors, ordered by name
+	GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) ([]models.Book, error)
+	// CountBooksByAuthorIDs counts the books of several authors; authors without books are omitted
+	CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (map[string]int, error)
+	// CreateBook creates a book of the existing author book.Author.ID
 	CreateBook(ctx context.Context, book *models.Book) error
+	// UpdateBook updates a book; a non-empty book.Author.ID, which must exist, becomes its author
 	UpdateBook(ctx context.Context, id string, book *models.Book) error
 	DeleteBook(ctx context.Context, id string) error
+	// RestoreBook writes every field of book, re-creating it if it was deleted
+	RestoreBook(ctx context.Context, book *models.Book) error
+	// LastModified returns when a book or author was last changed, including deletions
+	LastModified(ctx context.Context) (time.Time, error)
+	// MergeAuthors moves the books of the duplicate authors to the surviving
+	// one, deletes the duplicates and leaves redirects from their IDs to it.
+	// It returns the surviving author and the moved books.
+	MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, []models.Book, error)
 }
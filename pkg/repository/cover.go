@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// CoverRepository defines the interface for the metadata of book covers,
+// whose images are kept in a storage.BlobStore
+type CoverRepository interface {
+	GetCover(ctx context.Context, bookID string) (*models.BookCover, error)
+	// SaveCover creates the cover of its book or replaces the existing one
+	SaveCover(ctx context.Context, cover *models.BookCover) error
+	DeleteCover(ctx context.Context, bookID string) error
+}
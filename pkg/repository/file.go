@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// FileRepository defines the interface for the metadata and download counts
+// of ebook files, whose content is kept in a storage.BlobStore
+type FileRepository interface {
+	ListFiles(ctx context.Context, bookID string) ([]models.BookFile, error)
+	GetFile(ctx context.Context, bookID, format string) (*models.BookFile, error)
+	// SaveFile creates the file of its book and format, or replaces the
+	// content of the existing one while keeping its download count
+	SaveFile(ctx context.Context, file *models.BookFile) error
+	DeleteFile(ctx context.Context, bookID, format string) error
+	// DeleteFiles deletes every file of a book and their download counts
+	DeleteFiles(ctx context.Context, bookID string) error
+
+	// GetDownloads returns the downloads of a file by a user
+	GetDownloads(ctx context.Context, bookID, format, userID string) (*models.FileDownload, error)
+	// CountDownload counts a download of a file by the user of download
+	// through link, unless the user has downloaded it limit times already, a
+	// zero limit being none. A link is only counted on its first download,
+	// whose later requests are allowed whatever the limit. It reports
+	// whether the download is allowed.
+	CountDownload(ctx context.Context, download *models.FileDownload, link *models.FileDownloadLink, limit int) (bool, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// IdempotencyRepository defines the interface for storing the responses of idempotent requests.
+// Records are scoped to the tenant and the verified actor of the context: a
+// key used by another tenant or caller is another record, which their
+// reservations neither return, complete nor release.
+type IdempotencyRepository interface {
+	// Reserve stores a new in-flight record, first removing expired ones.
+	// When a record with the same key exists, it is returned and nothing is stored.
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	// Complete stores the response and expiry of a reserved record, unless
+	// its reservation was taken over
+	Complete(ctx context.Context, record *models.IdempotencyRecord) error
+	// Release removes an in-flight record so that the request can be retried
+	Release(ctx context.Context, record *models.IdempotencyRecord) error
+}
@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"gorm.io/gorm"
+)
+
+// AuditRepositoryImpl implements the AuditRepository interface using GORM
+type AuditRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewAuditRepository creates a new AuditRepository instance
+func NewAuditRepository(db *gorm.DB) repository.AuditRepository {
+	return &AuditRepositoryImpl{
+		DB: db,
+	}
+}
+
+// ListEntries retrieves audit entries matching filter, oldest first
+func (r *AuditRepositoryImpl) ListEntries(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, error) {
+	query := database.FromContext(ctx, r.DB).Order("id ASC")
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var entries []models.AuditEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetEntry retrieves a single version of an entity's audit trail
+func (r *AuditRepositoryImpl) GetEntry(ctx context.Context, entityType string, entityID string, version int) (*models.AuditEntry, error) {
+	var entry models.AuditEntry
+	result := database.FromContext(ctx, r.DB).First(&entry,
+		"entity_type = ? AND entity_id = ? AND version = ?", entityType, entityID, version)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("version %d of %s %s %w", version, entityType, entityID, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve audit entry: %w", result.Error)
+	}
+	return &entry, nil
+}
+
+// recordAudit appends an audit entry for a change using the given transaction.
+// The actor and request ID are taken from ctx. Updates that change nothing
+// are not recorded, nor are seed data; see repository.WithSeed.
+func recordAudit(ctx context.Context, tx *gorm.DB, action string, entityType string, entityID string, before any, after any) error {
+	if repository.IsSeed(ctx) {
+		return nil
+	}
+	changes, err := audit.Diff(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s: %w", entityType, err)
+	}
+	if action == audit.ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	entry := models.AuditEntry{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+	}
+
+	actor := audit.ActorFromContext(ctx)
+	entry.Actor = actor.ID
+	entry.RequestID = actor.RequestID
+
+	if entry.Changes, err = json.Marshal(changes); err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	if entry.Before, err = marshalSnapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalSnapshot(after); err != nil {
+		return err
+	}
+
+	var latest int
+	if err := tx.Model(&models.AuditEntry{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return fmt.Errorf("failed to read audit version: %w", err)
+	}
+	entry.Version = latest + 1
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// marshalSnapshot encodes a snapshot, using JSON null for a missing one
+func marshalSnapshot(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// bookSnapshot returns the book without its preloaded author, as stored in the audit log
+func bookSnapshot(book models.Book) *models.Book {
+	book.Author = models.Author{}
+	return &book
+}
+
+// authorSnapshot returns the author without its preloaded books, as stored in the audit log
+func authorSnapshot(author models.Author) *models.Author {
+	author.Books = nil
+	return &author
+}
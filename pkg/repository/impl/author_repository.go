@@ -0,0 +1,152 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/similarity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuthorRepositoryImpl implements the AuthorRepository interface using GORM
+type AuthorRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewAuthorRepository creates a new AuthorRepository instance
+func NewAuthorRepository(db *gorm.DB) repository.AuthorRepository {
+	return &AuthorRepositoryImpl{
+		DB: db,
+	}
+}
+
+// GetAllAuthors retrieves all authors from the database
+func (r *AuthorRepositoryImpl) GetAllAuthors(ctx context.Context) ([]models.Author, error) {
+	var authors []models.Author
+	result := database.FromContext(ctx, r.DB).Order("name ASC").Find(&authors)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve authors: %w", result.Error)
+	}
+	return authors, nil
+}
+
+// GetAuthorByID retrieves an author by its ID, following the redirect of a merged author
+func (r *AuthorRepositoryImpl) GetAuthorByID(ctx context.Context, id string) (*models.Author, error) {
+	db := database.FromContext(ctx, r.DB)
+	resolved, err := resolveAuthorID(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var author models.Author
+	result := db.First(&author, "id = ?", resolved)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("author with ID %s %w", id, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve author: %w", result.Error)
+	}
+	return &author, nil
+}
+
+// GetAuthorsByIDs retrieves several authors by their IDs
+func (r *AuthorRepositoryImpl) GetAuthorsByIDs(ctx context.Context, ids []string) ([]models.Author, error) {
+	var authors []models.Author
+	result := database.FromContext(ctx, r.DB).Where("id IN ?", ids).Find(&authors)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve authors: %w", result.Error)
+	}
+	return authors, nil
+}
+
+// ListAuthors retrieves a page of authors ordered by name, then ID
+func (r *AuthorRepositoryImpl) ListAuthors(ctx context.Context, limit int, after *pagination.Key) ([]models.Author, error) {
+	query := database.FromContext(ctx, r.DB).Order("name ASC").Order("id ASC").Limit(limit)
+	if after != nil {
+		query = query.Where("(name, id) > (?, ?)", after.Name, after.ID)
+	}
+
+	var authors []models.Author
+	result := query.Find(&authors)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve authors: %w", result.Error)
+	}
+	return authors, nil
+}
+
+// FindAuthorByName retrieves the oldest author with the same normalized name
+func (r *AuthorRepositoryImpl) FindAuthorByName(ctx context.Context, name string) (*models.Author, error) {
+	normalized := similarity.Normalize(name)
+
+	var author models.Author
+	result := database.FromContext(ctx, r.DB).
+		Where("normalized_name = ? AND normalized_name <> ''", normalized).
+		Order("created_at ASC").
+		Order("id ASC").
+		First(&author)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("author named %s %w", name, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to find author: %w", result.Error)
+	}
+	return &author, nil
+}
+
+// CreateAuthor inserts an author and records its audit entry and AuthorCreated event
+func (r *AuthorRepositoryImpl) CreateAuthor(ctx context.Context, author *models.Author) error {
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if author.ID == "" {
+			authorID, err := uuid.NewRandom()
+			if err != nil {
+				return fmt.Errorf("failed to generate author UUID: %w", err)
+			}
+			author.ID = authorID.String()
+		}
+
+		if err := tx.Omit(clause.Associations).Create(author).Error; err != nil {
+			return fmt.Errorf("failed to create author: %w", err)
+		}
+
+		if err := recordAudit(ctx, tx, audit.ActionCreate, audit.EntityAuthor, author.ID, nil, authorSnapshot(*author)); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, events.AuthorCreated, events.AggregateAuthor, author.ID, events.AuthorCreatedPayload{
+			Author: *author,
+		})
+	})
+}
+
+// UpdateAuthor saves the name and bio of an author and records the change in the audit log
+func (r *AuthorRepositoryImpl) UpdateAuthor(ctx context.Context, author *models.Author) error {
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		var existing models.Author
+		if err := tx.First(&existing, "id = ?", author.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("author with ID %s %w", author.ID, repository.ErrNotFound)
+			}
+			return fmt.Errorf("failed to check existing author: %w", err)
+		}
+
+		previous := existing
+		existing.Name = author.Name
+		existing.Bio = author.Bio
+		if err := tx.Omit(clause.Associations).Save(&existing).Error; err != nil {
+			return fmt.Errorf("failed to update author: %w", err)
+		}
+		*author = existing
+
+		return recordAudit(ctx, tx, audit.ActionUpdate, audit.EntityAuthor, existing.ID,
+			authorSnapshot(previous), authorSnapshot(existing))
+	})
+}
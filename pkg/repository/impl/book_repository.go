@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
 	"github.com/dtg-lucifer/go-bookstore/pkg/models"
 	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // BookRepositoryImpl implements the BookRepository interface using GORM
@@ -23,163 +30,407 @@ func NewBookRepository(db *gorm.DB) repository.BookRepository {
 	}
 }
 
-// GetAllBooks retrieves all books from the database
+// GetAllBooks retrieves all books from the database, or a read replica
 func (r *BookRepositoryImpl) GetAllBooks(ctx context.Context) ([]models.Book, error) {
 	var books []models.Book
-	result := r.DB.WithContext(ctx).Preload("Author").Find(&books)
+	result := database.FromContext(ctx, r.DB).Scopes(database.Replica).Preload("Author").Find(&books)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to retrieve books: %w", result.Error)
 	}
 	return books, nil
 }
 
-// GetBookByID retrieves a book by its ID
+// GetBookByID retrieves a book by its ID, from the database or a read replica
 func (r *BookRepositoryImpl) GetBookByID(ctx context.Context, id string) (*models.Book, error) {
 	var book models.Book
-	result := r.DB.WithContext(ctx).Preload("Author").First(&book, "id = ?", id)
+	result := database.FromContext(ctx, r.DB).Scopes(database.Replica).Preload("Author").First(&book, "id = ?", id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("book with ID %s not found", id)
+			return nil, fmt.Errorf("book with ID %s %w", id, repository.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to retrieve book: %w", result.Error)
 	}
 	return &book, nil
 }
 
-// CreateBook creates a new book in the database
-func (r *BookRepositoryImpl) CreateBook(ctx context.Context, book *models.Book) error {
-	// Begin a transaction
-	tx := r.DB.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
+// SearchBooks retrieves the books matching search, ordered by name
+func (r *BookRepositoryImpl) SearchBooks(ctx context.Context, search repository.BookSearch) ([]models.Book, error) {
+	query := database.FromContext(ctx, r.DB).Joins("Author").Order("books.name ASC").Order("books.id ASC")
+	if search.Query != "" {
+		pattern := containsPattern(search.Query)
+		query = query.Where("(books.name LIKE ? ESCAPE '!' OR books.description LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+	if search.Author != "" {
+		query = query.Where("Author.name LIKE ? ESCAPE '!'", containsPattern(search.Author))
+	}
+	if search.Publisher != "" {
+		query = query.Where("books.publisher LIKE ? ESCAPE '!'", containsPattern(search.Publisher))
+	}
+	if search.MinPrice > 0 {
+		query = query.Where("books.price >= ?", search.MinPrice)
+	}
+	if search.MaxPrice > 0 {
+		query = query.Where("books.price <= ?", search.MaxPrice)
+	}
+	if search.Limit > 0 {
+		query = query.Limit(search.Limit)
+	}
+	if search.After != nil {
+		query = query.Where("(books.name, books.id) > (?, ?)", search.After.Name, search.After.ID)
 	}
 
-	// Generate UUIDs if they're empty
-	if book.ID == "" {
-		bookID, err := uuid.NewRandom()
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to generate book UUID: %w", err)
-		}
-		book.ID = bookID.String()
+	var books []models.Book
+	if err := query.Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to search books: %w", err)
 	}
+	return books, nil
+}
 
-	// Check if we need to create a new author
-	if book.Author.ID == "" {
-		// Generate a new author ID
-		authorID, err := uuid.NewRandom()
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to generate author UUID: %w", err)
+// GetBooksByAuthorIDs retrieves the books of several authors, ordered by name
+func (r *BookRepositoryImpl) GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) ([]models.Book, error) {
+	var books []models.Book
+	result := database.FromContext(ctx, r.DB).Preload("Author").
+		Where("author_id IN ?", authorIDs).
+		Order("name ASC").
+		Find(&books)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve books by author: %w", result.Error)
+	}
+	return books, nil
+}
+
+// CountBooksByAuthorIDs counts the books of several authors
+func (r *BookRepositoryImpl) CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (map[string]int, error) {
+	var rows []struct {
+		AuthorID string
+		Count    int
+	}
+	result := database.FromContext(ctx, r.DB).Model(&models.Book{}).
+		Select("author_id, COUNT(*) AS count").
+		Where("author_id IN ?", authorIDs).
+		Group("author_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to count books by author: %w", result.Error)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.AuthorID] = row.Count
+	}
+	return counts, nil
+}
+
+// CreateBook creates a new book of an existing author in the database
+func (r *BookRepositoryImpl) CreateBook(ctx context.Context, book *models.Book) error {
+	// Run inside a transaction (a savepoint when already inside one)
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		// Generate UUIDs if they're empty
+		if book.ID == "" {
+			bookID, err := uuid.NewRandom()
+			if err != nil {
+				return fmt.Errorf("failed to generate book UUID: %w", err)
+			}
+			book.ID = bookID.String()
 		}
-		book.Author.ID = authorID.String()
 
-		// Create the author
-		if err := tx.Create(&book.Author).Error; err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to create author: %w", err)
+		// Set the author ID in the book
+		if err := checkAuthor(tx, book.Author.ID); err != nil {
+			return err
 		}
-	} else {
-		// Author ID exists, check if we need to update author info
-		var existingAuthor models.Author
-		if err := tx.First(&existingAuthor, "id = ?", book.Author.ID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				// Author doesn't exist, create it
-				if err := tx.Create(&book.Author).Error; err != nil {
-					tx.Rollback()
-					return fmt.Errorf("failed to create author: %w", err)
-				}
-			} else {
-				tx.Rollback()
-				return fmt.Errorf("failed to check existing author: %w", err)
-			}
+		book.AuthorID = book.Author.ID
+
+		// Create the book; its author is written by the AuthorRepository
+		if err := tx.Omit(clause.Associations).Create(book).Error; err != nil {
+			return fmt.Errorf("failed to create book: %w", err)
 		}
-	}
 
-	// Set the author ID in the book
-	book.AuthorID = book.Author.ID
+		if err := recordAudit(ctx, tx, audit.ActionCreate, audit.EntityBook, book.ID, nil, bookSnapshot(*book)); err != nil {
+			return err
+		}
 
-	// Create the book
-	if err := tx.Create(book).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to create book: %w", err)
-	}
+		return recordEvent(tx, events.BookCreated, events.AggregateBook, book.ID, events.BookCreatedPayload{
+			Book: *book,
+		})
+	})
+}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// checkAuthor fails unless the author with ID id exists in the tenant. The
+// foreign key alone would accept an author of another tenant.
+func checkAuthor(tx *gorm.DB, id string) error {
+	var author models.Author
+	if err := tx.Select("id").First(&author, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("author with ID %s %w", id, repository.ErrNotFound)
+		}
+		return fmt.Errorf("failed to check existing author: %w", err)
 	}
-
 	return nil
 }
 
 // UpdateBook updates an existing book in the database
 func (r *BookRepositoryImpl) UpdateBook(ctx context.Context, id string, book *models.Book) error {
-	// Begin a transaction
-	tx := r.DB.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
-	}
+	// Run inside a transaction (a savepoint when already inside one)
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		// Check if the book exists
+		var existingBook models.Book
+		if err := tx.First(&existingBook, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("book with ID %s %w", id, repository.ErrNotFound)
+			}
+			return fmt.Errorf("failed to check existing book: %w", err)
+		}
 
-	// Check if the book exists
-	var existingBook models.Book
-	if err := tx.First(&existingBook, "id = ?", id).Error; err != nil {
-		tx.Rollback()
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("book with ID %s not found", id)
+		// Work out what is about to change before the update is applied
+		book.ID = id // Ensure the ID is not changed
+		previousBook := existingBook
+		update := *book
+		if update.Author.ID != "" {
+			if err := checkAuthor(tx, update.Author.ID); err != nil {
+				return err
+			}
+			update.AuthorID = update.Author.ID
 		}
-		return fmt.Errorf("failed to check existing book: %w", err)
-	}
+		changes := events.BookChanges(existingBook, update)
 
-	// Update the author if needed
-	if book.Author.ID != "" {
-		// Check if the author exists
-		var existingAuthor models.Author
-		if err := tx.First(&existingAuthor, "id = ?", book.Author.ID).Error; err != nil {
+		// Update book fields; the author is written by the AuthorRepository
+		if err := tx.Model(&existingBook).Omit(clause.Associations).Updates(&update).Error; err != nil {
+			return fmt.Errorf("failed to update book: %w", err)
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+
+		var updatedBook models.Book
+		if err := tx.Preload("Author").First(&updatedBook, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to reload updated book: %w", err)
+		}
+
+		if err := recordAudit(ctx, tx, audit.ActionUpdate, audit.EntityBook, id,
+			bookSnapshot(previousBook), bookSnapshot(updatedBook)); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, events.BookUpdated, events.AggregateBook, id, events.BookUpdatedPayload{
+			Book:    updatedBook,
+			Changes: changes,
+		})
+	})
+}
+
+// DeleteBook deletes a book from the database
+func (r *BookRepositoryImpl) DeleteBook(ctx context.Context, id string) error {
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		// Keep a snapshot of the book for the BookDeleted event
+		var book models.Book
+		if err := tx.Preload("Author").First(&book, "id = ?", id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				// Create the author
-				if err := tx.Create(&book.Author).Error; err != nil {
-					tx.Rollback()
-					return fmt.Errorf("failed to create author: %w", err)
-				}
-			} else {
-				tx.Rollback()
-				return fmt.Errorf("failed to check existing author: %w", err)
+				return fmt.Errorf("book with ID %s %w", id, repository.ErrNotFound)
+			}
+			return fmt.Errorf("failed to retrieve book: %w", err)
+		}
+
+		result := tx.Delete(&models.Book{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete book: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("book with ID %s %w", id, repository.ErrNotFound)
+		}
+
+		if err := recordAudit(ctx, tx, audit.ActionDelete, audit.EntityBook, id, bookSnapshot(book), nil); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, events.BookDeleted, events.AggregateBook, id, events.BookDeletedPayload{
+			Book: book,
+		})
+	})
+}
+
+// RestoreBook writes every field of a previously captured book snapshot,
+// re-creating the book if it was deleted. The change is audited as a revert.
+func (r *BookRepositoryImpl) RestoreBook(ctx context.Context, book *models.Book) error {
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkAuthor(tx, book.AuthorID); err != nil {
+			return err
+		}
+
+		var existingBook models.Book
+		err := tx.First(&existingBook, "id = ?", book.ID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check existing book: %w", err)
+		}
+		exists := err == nil
+		previousBook := existingBook
+
+		restored := *bookSnapshot(*book)
+		restored.DeletedAt = nil
+		if exists {
+			restored.CreatedAt = existingBook.CreatedAt
+			// Select every column so that zero values are restored too; the
+			// book stays in its tenant, which snapshots do not carry
+			if err := tx.Model(&existingBook).Select("*").Omit("id", "created_at", "tenant_id").Updates(&restored).Error; err != nil {
+				return fmt.Errorf("failed to restore book: %w", err)
 			}
 		} else {
-			// Update author fields if they exist
-			existingAuthor.Name = book.Author.Name
-			existingAuthor.Bio = book.Author.Bio
-			if err := tx.Save(&existingAuthor).Error; err != nil {
-				tx.Rollback()
-				return fmt.Errorf("failed to update author: %w", err)
+			if err := tx.Omit("Author").Create(&restored).Error; err != nil {
+				return fmt.Errorf("failed to restore book: %w", err)
 			}
 		}
-	}
 
-	// Update book fields
-	book.ID = id // Ensure the ID is not changed
-	if err := tx.Model(&existingBook).Updates(book).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update book: %w", err)
-	}
+		var restoredBook models.Book
+		if err := tx.Preload("Author").First(&restoredBook, "id = ?", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to reload restored book: %w", err)
+		}
+		*book = restoredBook
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
+		var before *models.Book
+		if exists {
+			before = bookSnapshot(previousBook)
+		}
+		if err := recordAudit(ctx, tx, audit.ActionRevert, audit.EntityBook, book.ID, before, bookSnapshot(restoredBook)); err != nil {
+			return err
+		}
 
-	return nil
+		if !exists {
+			return recordEvent(tx, events.BookCreated, events.AggregateBook, book.ID, events.BookCreatedPayload{
+				Book: restoredBook,
+			})
+		}
+
+		changes, err := audit.Diff(bookSnapshot(previousBook), bookSnapshot(restoredBook))
+		if err != nil {
+			return fmt.Errorf("failed to diff book: %w", err)
+		}
+
+		return recordEvent(tx, events.BookUpdated, events.AggregateBook, book.ID, events.BookUpdatedPayload{
+			Book:    restoredBook,
+			Changes: changes,
+		})
+	})
 }
 
-// DeleteBook deletes a book from the database
-func (r *BookRepositoryImpl) DeleteBook(ctx context.Context, id string) error {
-	result := r.DB.WithContext(ctx).Delete(&models.Book{}, "id = ?", id)
+// LastModified returns the time of the latest audited book or author change
+func (r *BookRepositoryImpl) LastModified(ctx context.Context) (time.Time, error) {
+	var entry models.AuditEntry
+	result := database.FromContext(ctx, r.DB).
+		Select("created_at").
+		Where("entity_type IN ?", []string{audit.EntityBook, audit.EntityAuthor}).
+		Order("id DESC").
+		Limit(1).
+		Find(&entry)
 	if result.Error != nil {
-		return fmt.Errorf("failed to delete book: %w", result.Error)
+		return time.Time{}, fmt.Errorf("failed to retrieve last modification time: %w", result.Error)
 	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("book with ID %s not found", id)
+	return entry.CreatedAt, nil
+}
+
+// MergeAuthors moves the books of the duplicate authors to the surviving one
+// in a single transaction. Every moved book is audited and announced like an
+// update, and the duplicates are replaced by redirects.
+func (r *BookRepositoryImpl) MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, []models.Book, error) {
+	var survivor models.Author
+	var moved []models.Book
+
+	err := database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&survivor, "id = ?", survivorID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("author with ID %s %w", survivorID, repository.ErrNotFound)
+			}
+			return fmt.Errorf("failed to retrieve author: %w", err)
+		}
+
+		var duplicates []models.Author
+		if err := tx.Where("id IN ?", duplicateIDs).Find(&duplicates).Error; err != nil {
+			return fmt.Errorf("failed to retrieve authors: %w", err)
+		}
+		if len(duplicates) != len(duplicateIDs) {
+			for _, id := range duplicateIDs {
+				if !slices.ContainsFunc(duplicates, func(author models.Author) bool { return author.ID == id }) {
+					return fmt.Errorf("author with ID %s %w", id, repository.ErrNotFound)
+				}
+			}
+		}
+
+		var books []models.Book
+		if err := tx.Where("author_id IN ?", duplicateIDs).Order("id ASC").Find(&books).Error; err != nil {
+			return fmt.Errorf("failed to retrieve books: %w", err)
+		}
+		if len(books) > 0 {
+			if err := tx.Model(&models.Book{}).
+				Where("author_id IN ?", duplicateIDs).
+				Updates(map[string]any{"author_id": survivor.ID, "updated_at": time.Now()}).Error; err != nil {
+				return fmt.Errorf("failed to move books: %w", err)
+			}
+		}
+
+		for _, previous := range books {
+			var book models.Book
+			if err := tx.Preload("Author").First(&book, "id = ?", previous.ID).Error; err != nil {
+				return fmt.Errorf("failed to reload moved book: %w", err)
+			}
+
+			if err := recordAudit(ctx, tx, audit.ActionUpdate, audit.EntityBook, book.ID,
+				bookSnapshot(previous), bookSnapshot(book)); err != nil {
+				return err
+			}
+			if err := recordEvent(tx, events.BookUpdated, events.AggregateBook, book.ID, events.BookUpdatedPayload{
+				Book:    book,
+				Changes: map[string]events.FieldChange{"author_id": {Old: previous.AuthorID, New: book.AuthorID}},
+			}); err != nil {
+				return err
+			}
+			moved = append(moved, book)
+		}
+
+		// Earlier redirects to the duplicates now lead to the survivor
+		if err := tx.Model(&models.AuthorRedirect{}).
+			Where("target_id IN ?", duplicateIDs).
+			Update("target_id", survivor.ID).Error; err != nil {
+			return fmt.Errorf("failed to update author redirects: %w", err)
+		}
+
+		for _, duplicate := range duplicates {
+			if err := tx.Delete(&models.Author{}, "id = ?", duplicate.ID).Error; err != nil {
+				return fmt.Errorf("failed to delete merged author: %w", err)
+			}
+			if err := tx.Create(&models.AuthorRedirect{AuthorID: duplicate.ID, TargetID: survivor.ID}).Error; err != nil {
+				return fmt.Errorf("failed to create author redirect: %w", err)
+			}
+			if err := recordAudit(ctx, tx, audit.ActionMerge, audit.EntityAuthor, duplicate.ID,
+				authorSnapshot(duplicate), nil); err != nil {
+				return err
+			}
+		}
+
+		return recordEvent(tx, events.AuthorMerged, events.AggregateAuthor, survivor.ID, events.AuthorMergedPayload{
+			Author:    survivor,
+			MergedIDs: duplicateIDs,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
+	return &survivor, moved, nil
+}
+
+// resolveAuthorID returns the ID of the author that the author with ID id
+// was merged into, or id itself when it was not merged
+func resolveAuthorID(tx *gorm.DB, id string) (string, error) {
+	var redirect models.AuthorRedirect
+	if err := tx.Limit(1).Find(&redirect, "author_id = ?", id).Error; err != nil {
+		return "", fmt.Errorf("failed to resolve author redirect: %w", err)
+	}
+	if redirect.TargetID != "" {
+		return redirect.TargetID, nil
+	}
+	return id, nil
+}
+
+// containsPattern builds a LIKE pattern (escaped with '!') matching values containing s
+func containsPattern(s string) string {
+	s = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+	return "%" + s + "%"
 }
@@ -0,0 +1,234 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/cache"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// Cache keys and tags of the book catalog.
+// Every entry is tagged with the books and authors it contains, so a change
+// only evicts the entries that include the changed book or author. Keys and
+// the catalog-wide tags are prefixed with the tenant (see tenantKey).
+const (
+	bookListKey     = "books:list"
+	lastModifiedKey = "books:last_modified"
+	bookListTag     = "books:list"
+	catalogTag      = "books:catalog"
+	bookTagKind     = "book"
+	authorTagKind   = "author"
+	bookKeyPrefix   = "book:"
+)
+
+// CachedBookRepository caches the reads of a BookRepository and invalidates
+// the affected entries after every committed write. Reads inside a
+// transaction bypass the cache, and entries are loaded from the primary,
+// never from a replica that may not have the latest writes yet.
+type CachedBookRepository struct {
+	Repo  repository.BookRepository
+	Cache *cache.Cache
+}
+
+// NewCachedBookRepository creates a new caching BookRepository around repo
+func NewCachedBookRepository(repo repository.BookRepository, cache *cache.Cache) repository.BookRepository {
+	return &CachedBookRepository{
+		Repo:  repo,
+		Cache: cache,
+	}
+}
+
+// GetAllBooks retrieves all books, from the cache when possible
+func (r *CachedBookRepository) GetAllBooks(ctx context.Context) ([]models.Book, error) {
+	if database.InTransaction(ctx) {
+		return r.Repo.GetAllBooks(ctx)
+	}
+
+	data, err := r.Cache.GetOrLoad(ctx, tenantKey(ctx, bookListKey), func(ctx context.Context) ([]byte, []string, error) {
+		books, err := r.Repo.GetAllBooks(database.WithPrimary(ctx))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tags := []string{tenantKey(ctx, bookListTag)}
+		for _, book := range books {
+			tags = append(tags, bookTags(book)...)
+		}
+		return encodeCached(books, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var books []models.Book
+	if err := json.Unmarshal(data, &books); err != nil {
+		return nil, fmt.Errorf("failed to decode cached books: %w", err)
+	}
+	return books, nil
+}
+
+// GetBookByID retrieves a book by its ID, from the cache when possible
+func (r *CachedBookRepository) GetBookByID(ctx context.Context, id string) (*models.Book, error) {
+	if database.InTransaction(ctx) {
+		return r.Repo.GetBookByID(ctx, id)
+	}
+
+	data, err := r.Cache.GetOrLoad(ctx, tenantKey(ctx, bookKeyPrefix+id), func(ctx context.Context) ([]byte, []string, error) {
+		book, err := r.Repo.GetBookByID(database.WithPrimary(ctx), id)
+		if err != nil {
+			return nil, nil, err
+		}
+		return encodeCached(book, bookTags(*book))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var book models.Book
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, fmt.Errorf("failed to decode cached book: %w", err)
+	}
+	return &book, nil
+}
+
+// SearchBooks retrieves the books matching search; searches are not cached
+func (r *CachedBookRepository) SearchBooks(ctx context.Context, search repository.BookSearch) ([]models.Book, error) {
+	return r.Repo.SearchBooks(ctx, search)
+}
+
+// GetBooksByAuthorIDs retrieves the books of several authors; not cached
+func (r *CachedBookRepository) GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) ([]models.Book, error) {
+	return r.Repo.GetBooksByAuthorIDs(ctx, authorIDs)
+}
+
+// CountBooksByAuthorIDs counts the books of several authors; not cached
+func (r *CachedBookRepository) CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (map[string]int, error) {
+	return r.Repo.CountBooksByAuthorIDs(ctx, authorIDs)
+}
+
+// CreateBook creates a new book and evicts the book lists
+func (r *CachedBookRepository) CreateBook(ctx context.Context, book *models.Book) error {
+	if err := r.Repo.CreateBook(ctx, book); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, tenantKey(ctx, bookListTag), tenantKey(ctx, catalogTag))
+	return nil
+}
+
+// UpdateBook updates a book and evicts every entry containing it or its author
+func (r *CachedBookRepository) UpdateBook(ctx context.Context, id string, book *models.Book) error {
+	if err := r.Repo.UpdateBook(ctx, id, book); err != nil {
+		return err
+	}
+
+	tags := []string{cache.Tag(bookTagKind, id), tenantKey(ctx, catalogTag)}
+	if book.Author.ID != "" {
+		// The author is updated along with the book
+		tags = append(tags, cache.Tag(authorTagKind, book.Author.ID))
+	}
+	r.invalidate(ctx, tags...)
+	return nil
+}
+
+// DeleteBook deletes a book and evicts every entry containing it
+func (r *CachedBookRepository) DeleteBook(ctx context.Context, id string) error {
+	if err := r.Repo.DeleteBook(ctx, id); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, cache.Tag(bookTagKind, id), tenantKey(ctx, catalogTag))
+	return nil
+}
+
+// RestoreBook restores a book and evicts every entry containing it, and the
+// book lists in case it was re-created
+func (r *CachedBookRepository) RestoreBook(ctx context.Context, book *models.Book) error {
+	if err := r.Repo.RestoreBook(ctx, book); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, cache.Tag(bookTagKind, book.ID), tenantKey(ctx, bookListTag), tenantKey(ctx, catalogTag))
+	return nil
+}
+
+// LastModified returns when the catalog last changed, from the cache when possible
+func (r *CachedBookRepository) LastModified(ctx context.Context) (time.Time, error) {
+	if database.InTransaction(ctx) {
+		return r.Repo.LastModified(ctx)
+	}
+
+	data, err := r.Cache.GetOrLoad(ctx, tenantKey(ctx, lastModifiedKey), func(ctx context.Context) ([]byte, []string, error) {
+		lastModified, err := r.Repo.LastModified(database.WithPrimary(ctx))
+		if err != nil {
+			return nil, nil, err
+		}
+		return encodeCached(lastModified, []string{tenantKey(ctx, catalogTag)})
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var lastModified time.Time
+	if err := json.Unmarshal(data, &lastModified); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode cached modification time: %w", err)
+	}
+	return lastModified, nil
+}
+
+// MergeAuthors merges authors and evicts every entry containing them or
+// their books
+func (r *CachedBookRepository) MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, []models.Book, error) {
+	survivor, moved, err := r.Repo.MergeAuthors(ctx, survivorID, duplicateIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags := []string{cache.Tag(authorTagKind, survivorID), tenantKey(ctx, catalogTag)}
+	for _, id := range duplicateIDs {
+		tags = append(tags, cache.Tag(authorTagKind, id))
+	}
+	for _, book := range moved {
+		tags = append(tags, cache.Tag(bookTagKind, book.ID))
+	}
+	r.invalidate(ctx, tags...)
+	return survivor, moved, nil
+}
+
+// invalidate evicts the entries carrying tags once the surrounding
+// transaction, if any, commits
+func (r *CachedBookRepository) invalidate(ctx context.Context, tags ...string) {
+	database.AfterCommit(ctx, func() {
+		r.Cache.Invalidate(ctx, tags...)
+	})
+}
+
+// bookTags returns the tags of a cache entry containing book
+func bookTags(book models.Book) []string {
+	return []string{
+		cache.Tag(bookTagKind, book.ID),
+		cache.Tag(authorTagKind, book.AuthorID),
+	}
+}
+
+// tenantKey prefixes a cache key or tag with the tenant of ctx, so that
+// tenants never share entries
+func tenantKey(ctx context.Context, key string) string {
+	id, _ := tenant.FromContext(ctx)
+	return id + ":" + key
+}
+
+// encodeCached encodes a value loaded for the cache
+func encodeCached(value any, tags []string) ([]byte, []string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	return data, tags, nil
+}
@@ -0,0 +1,64 @@
+package impl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/cache"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+func TestCachedBookRepositoryLoadsFromThePrimary(t *testing.T) {
+	primary, replica := repotest.SQLite(t), repotest.SQLite(t)
+	ctx := tenant.WithID(t.Context(), tenant.DefaultID)
+
+	// The replica lags: it has the book as it was before its last update
+	var book models.Book
+	for _, db := range []*gorm.DB{primary, replica} {
+		author := models.Author{ID: "5f0c9c43-5a3e-4a55-9a8c-54a5a3a0e0a1", Name: "Ted Chiang"}
+		if err := impl.NewAuthorRepository(db).CreateAuthor(ctx, &author); err != nil {
+			t.Fatal(err)
+		}
+		book = models.Book{ID: "0b8f3c1e-2f6d-4a8e-8d4b-1c2d3e4f5a6b", Name: "Exhalation", Price: 15, Author: author}
+		if err := impl.NewBookRepository(db).CreateBook(ctx, &book); err != nil {
+			t.Fatal(err)
+		}
+	}
+	replicaDB, err := replica.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.Use(database.NewReplicaRouter(replicaDB)); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := impl.NewBookRepository(primary)
+	books := impl.NewCachedBookRepository(repo, cache.New("books", cache.NewLRU(100), time.Hour, logging.Discard(), nil))
+	if err := books.UpdateBook(database.WithSession(ctx), book.ID, &models.Book{Price: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later request, which has not written, reads from the replica
+	request := database.WithSession(ctx)
+	if stale, err := repo.GetBookByID(request, book.ID); err != nil || stale.Price != 15 {
+		t.Fatalf("GetBookByID from the replica = %+v, %v, want the stale price", stale, err)
+	}
+
+	// but the cache is filled from the primary
+	for range 2 {
+		cached, err := books.GetBookByID(request, book.ID)
+		if err != nil || cached.Price != 20 {
+			t.Fatalf("cached GetBookByID = %+v, %v, want the updated price", cached, err)
+		}
+		all, err := books.GetAllBooks(request)
+		if err != nil || len(all) != 1 || all[0].Price != 20 {
+			t.Fatalf("cached GetAllBooks = %+v, %v, want the updated book", all, err)
+		}
+	}
+}
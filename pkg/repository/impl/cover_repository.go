@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CoverRepositoryImpl implements the CoverRepository interface using GORM
+type CoverRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewCoverRepository creates a new CoverRepository instance
+func NewCoverRepository(db *gorm.DB) repository.CoverRepository {
+	return &CoverRepositoryImpl{
+		DB: db,
+	}
+}
+
+// GetCover retrieves the cover of a book
+func (r *CoverRepositoryImpl) GetCover(ctx context.Context, bookID string) (*models.BookCover, error) {
+	var cover models.BookCover
+	result := database.FromContext(ctx, r.DB).First(&cover, "book_id = ?", bookID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("cover of book %s %w", bookID, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve cover: %w", result.Error)
+	}
+	return &cover, nil
+}
+
+// SaveCover creates or replaces the cover of a book. The cover of a book of
+// another tenant is never replaced.
+func (r *CoverRepositoryImpl) SaveCover(ctx context.Context, cover *models.BookCover) error {
+	db := database.FromContext(ctx, r.DB)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(cover)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save cover: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	result = db.Model(cover).Select("*").Omit("book_id", "created_at").Updates(cover)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save cover: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cover of book %s %w", cover.BookID, repository.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteCover deletes the cover of a book
+func (r *CoverRepositoryImpl) DeleteCover(ctx context.Context, bookID string) error {
+	result := database.FromContext(ctx, r.DB).Delete(&models.BookCover{}, "book_id = ?", bookID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete cover: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cover of book %s %w", bookID, repository.ErrNotFound)
+	}
+	return nil
+}
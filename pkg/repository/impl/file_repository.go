@@ -0,0 +1,189 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileRepositoryImpl implements the FileRepository interface using GORM
+type FileRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewFileRepository creates a new FileRepository instance
+func NewFileRepository(db *gorm.DB) repository.FileRepository {
+	return &FileRepositoryImpl{
+		DB: db,
+	}
+}
+
+// ListFiles retrieves the files of a book, ordered by format
+func (r *FileRepositoryImpl) ListFiles(ctx context.Context, bookID string) ([]models.BookFile, error) {
+	var files []models.BookFile
+	result := database.FromContext(ctx, r.DB).Where("book_id = ?", bookID).Order("format").Find(&files)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve files: %w", result.Error)
+	}
+	return files, nil
+}
+
+// GetFile retrieves the file of a book in a format
+func (r *FileRepositoryImpl) GetFile(ctx context.Context, bookID, format string) (*models.BookFile, error) {
+	var file models.BookFile
+	result := database.FromContext(ctx, r.DB).First(&file, "book_id = ? AND format = ?", bookID, format)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s file of book %s %w", format, bookID, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve file: %w", result.Error)
+	}
+	return &file, nil
+}
+
+// SaveFile upserts the file, leaving the download count of an existing one
+// untouched. The file of a book of another tenant is never replaced.
+func (r *FileRepositoryImpl) SaveFile(ctx context.Context, file *models.BookFile) error {
+	db := database.FromContext(ctx, r.DB)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(file)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save file: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	result = db.Model(file).Select("version", "filename", "content_type", "size", "sha256", "updated_at").Updates(file)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save file: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s file of book %s %w", file.Format, file.BookID, repository.ErrNotFound)
+	}
+	return nil
+}
+
+// errLimitReached rolls back the link of a download over the limit
+var errLimitReached = errors.New("download limit reached")
+
+// DeleteFile deletes the file of a book in a format and its download counts
+func (r *FileRepositoryImpl) DeleteFile(ctx context.Context, bookID, format string) error {
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.BookFile{}, "book_id = ? AND format = ?", bookID, format)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete file: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%s file of book %s %w", format, bookID, repository.ErrNotFound)
+		}
+		if err := tx.Delete(&models.FileDownload{}, "book_id = ? AND format = ?", bookID, format).Error; err != nil {
+			return fmt.Errorf("failed to delete download counts: %w", err)
+		}
+		if err := tx.Delete(&models.FileDownloadLink{}, "book_id = ? AND format = ?", bookID, format).Error; err != nil {
+			return fmt.Errorf("failed to delete download links: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteFiles deletes the files of a book and their download counts
+func (r *FileRepositoryImpl) DeleteFiles(ctx context.Context, bookID string) error {
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.BookFile{}, "book_id = ?", bookID).Error; err != nil {
+			return fmt.Errorf("failed to delete files: %w", err)
+		}
+		if err := tx.Delete(&models.FileDownload{}, "book_id = ?", bookID).Error; err != nil {
+			return fmt.Errorf("failed to delete download counts: %w", err)
+		}
+		if err := tx.Delete(&models.FileDownloadLink{}, "book_id = ?", bookID).Error; err != nil {
+			return fmt.Errorf("failed to delete download links: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetDownloads retrieves the download count of a file by a user
+func (r *FileRepositoryImpl) GetDownloads(ctx context.Context, bookID, format, userID string) (*models.FileDownload, error) {
+	var download models.FileDownload
+	result := database.FromContext(ctx, r.DB).First(&download, "book_id = ? AND format = ? AND user_id = ?", bookID, format, userID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("downloads of the %s file of book %s by user %s %w", format, bookID, userID, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve downloads: %w", result.Error)
+	}
+	return &download, nil
+}
+
+// CountDownload increments the download counts of the user and of the file
+// in a transaction, the first time a link is used. The link is recorded in
+// the same transaction, and its expired predecessors removed. The user's
+// count is only incremented below the limit, in a single statement, so that
+// concurrent downloads cannot exceed it.
+func (r *FileRepositoryImpl) CountDownload(ctx context.Context, download *models.FileDownload, link *models.FileDownloadLink, limit int) (bool, error) {
+	now := time.Now()
+	err := database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.FileDownloadLink{}, "book_id = ? AND format = ? AND user_id = ? AND expires_at <= ?",
+			download.BookID, download.Format, download.UserID, now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete expired download links: %w", result.Error)
+		}
+
+		used := models.FileDownloadLink{
+			LinkID:    link.LinkID,
+			BookID:    download.BookID,
+			Format:    download.Format,
+			UserID:    download.UserID,
+			ExpiresAt: link.ExpiresAt,
+		}
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&used)
+		if result.Error != nil {
+			return fmt.Errorf("failed to count download: %w", result.Error)
+		}
+		// The link was counted by an earlier request
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		first := models.FileDownload{BookID: download.BookID, Format: download.Format, UserID: download.UserID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&first).Error; err != nil {
+			return fmt.Errorf("failed to count download: %w", err)
+		}
+
+		query := tx.Model(&models.FileDownload{}).
+			Where("book_id = ? AND format = ? AND user_id = ?", download.BookID, download.Format, download.UserID)
+		if limit > 0 {
+			query = query.Where("downloads < ?", limit)
+		}
+		result = query.Updates(map[string]any{
+			"downloads":          gorm.Expr("downloads + 1"),
+			"purchase_id":        download.PurchaseID,
+			"last_downloaded_at": now,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to count download: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errLimitReached
+		}
+
+		result = tx.Model(&models.BookFile{}).
+			Where("book_id = ? AND format = ?", download.BookID, download.Format).
+			UpdateColumn("downloads", gorm.Expr("downloads + 1"))
+		if result.Error != nil {
+			return fmt.Errorf("failed to count download: %w", result.Error)
+		}
+		return nil
+	})
+	if errors.Is(err, errLimitReached) {
+		return false, nil
+	}
+	return err == nil, err
+}
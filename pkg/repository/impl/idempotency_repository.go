@@ -0,0 +1,104 @@
+package impl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepositoryImpl implements the IdempotencyRepository interface using GORM
+type IdempotencyRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepository instance
+func NewIdempotencyRepository(db *gorm.DB) repository.IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		DB: db,
+	}
+}
+
+// Reserve inserts an in-flight record unless its key is taken by a live one
+// of the same tenant and caller
+func (r *IdempotencyRepositoryImpl) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	db := database.FromContext(ctx, r.DB)
+
+	if err := db.Where("expires_at <= ?", time.Now().UTC()).Delete(&models.IdempotencyRecord{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove expired idempotency records: %w", err)
+	}
+
+	// The primary key makes concurrent reservations of the same key exclusive
+	stored := *record
+	stored.Key, stored.Actor = scopedKey(ctx, record.Key)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&stored)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		record.Actor, record.CreatedAt = stored.Actor, stored.CreatedAt
+		return nil, nil
+	}
+
+	var existing models.IdempotencyRecord
+	if err := db.Where(&models.IdempotencyRecord{Key: stored.Key}).First(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve idempotency record: %w", err)
+	}
+	existing.Key = record.Key
+	return &existing, nil
+}
+
+// Complete saves the response of a reserved record
+func (r *IdempotencyRepositoryImpl) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	key, _ := scopedKey(ctx, record.Key)
+	result := database.FromContext(ctx, r.DB).
+		Model(&models.IdempotencyRecord{}).
+		Where(&models.IdempotencyRecord{Key: key, Token: record.Token}).
+		Select("status_code", "headers", "body", "expires_at").
+		Updates(&models.IdempotencyRecord{
+			StatusCode: record.StatusCode,
+			Headers:    record.Headers,
+			Body:       record.Body,
+			ExpiresAt:  record.ExpiresAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", result.Error)
+	}
+	return nil
+}
+
+// Release deletes a record that is still in flight
+func (r *IdempotencyRepositoryImpl) Release(ctx context.Context, record *models.IdempotencyRecord) error {
+	key, _ := scopedKey(ctx, record.Key)
+	result := database.FromContext(ctx, r.DB).
+		Where(&models.IdempotencyRecord{Key: key, Token: record.Token}).
+		Where("status_code = 0").
+		Delete(&models.IdempotencyRecord{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", result.Error)
+	}
+	return nil
+}
+
+// scopedKey returns the stored key of a caller's key, the SHA-256 of the
+// key with the tenant and the verified actor of ctx, and that actor
+func scopedKey(ctx context.Context, key string) (string, string) {
+	tenantID, _ := tenant.FromContext(ctx)
+	actorID, _ := audit.IdentifiedActor(ctx)
+
+	digest := sha256.New()
+	for _, part := range []string{tenantID, actorID, key} {
+		digest.Write([]byte(part))
+		digest.Write([]byte{0})
+	}
+	return hex.EncodeToString(digest.Sum(nil)), actorID
+}
@@ -0,0 +1,96 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"gorm.io/gorm"
+)
+
+// OutboxRepositoryImpl implements the OutboxRepository interface using GORM
+type OutboxRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewOutboxRepository creates a new OutboxRepository instance
+func NewOutboxRepository(db *gorm.DB) repository.OutboxRepository {
+	return &OutboxRepositoryImpl{
+		DB: db,
+	}
+}
+
+// FetchPending retrieves unpublished events ordered by their outbox position,
+// leaving out those of aggregates whose earliest failed event is not due yet
+func (r *OutboxRepositoryImpl) FetchPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var rows []models.OutboxEvent
+	result := database.FromContext(ctx, r.DB).
+		Where("published_at IS NULL").
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events AS waiting
+			WHERE waiting.published_at IS NULL AND waiting.next_attempt_at > ?
+			AND waiting.aggregate_type = outbox_events.aggregate_type
+			AND waiting.aggregate_id = outbox_events.aggregate_id
+			AND waiting.id <= outbox_events.id)`, now.UTC()).
+		Order("id ASC").
+		Limit(limit).
+		Find(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve outbox events: %w", result.Error)
+	}
+	return rows, nil
+}
+
+// MarkPublished records that an event was delivered to every sink
+func (r *OutboxRepositoryImpl) MarkPublished(ctx context.Context, id uint64) error {
+	result := database.FromContext(ctx, r.DB).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"published_at": time.Now().UTC(),
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark outbox event as published: %w", result.Error)
+	}
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt and when to retry it
+func (r *OutboxRepositoryImpl) MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error {
+	result := database.FromContext(ctx, r.DB).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      reason,
+			"next_attempt_at": nextAttemptAt.UTC(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark outbox event as failed: %w", result.Error)
+	}
+	return nil
+}
+
+// recordEvent writes a domain event to the outbox using the given
+// transaction, unless it writes seed data; see repository.WithSeed
+func recordEvent(tx *gorm.DB, eventType events.Type, aggregateType string, aggregateID string, payload any) error {
+	if repository.IsSeed(tx.Statement.Context) {
+		return nil
+	}
+
+	event, err := events.New(eventType, aggregateType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(event.ToOutbox()).Error; err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+
+	return nil
+}
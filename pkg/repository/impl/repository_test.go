@@ -0,0 +1,15 @@
+package impl_test
+
+import (
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+)
+
+func TestGORMRepositories(t *testing.T) {
+	repotest.TestRepositories(t, repotest.GORM(repotest.SQLite(t)))
+}
+
+func TestMySQLRepositories(t *testing.T) {
+	repotest.TestRepositories(t, repotest.GORM(repotest.MySQL(t)))
+}
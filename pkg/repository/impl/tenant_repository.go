@@ -0,0 +1,80 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantRepositoryImpl implements the TenantRepository interface using GORM
+type TenantRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewTenantRepository creates a new TenantRepository instance
+func NewTenantRepository(db *gorm.DB) repository.TenantRepository {
+	return &TenantRepositoryImpl{
+		DB: db,
+	}
+}
+
+// ListTenants retrieves all tenants ordered by ID
+func (r *TenantRepositoryImpl) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	result := database.FromContext(ctx, r.DB).Order("id ASC").Find(&tenants)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve tenants: %w", result.Error)
+	}
+	return tenants, nil
+}
+
+// GetTenantByID retrieves a tenant by its ID
+func (r *TenantRepositoryImpl) GetTenantByID(ctx context.Context, id string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	result := database.FromContext(ctx, r.DB).First(&tenant, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tenant with ID %s %w", id, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve tenant: %w", result.Error)
+	}
+	return &tenant, nil
+}
+
+// CreateTenant creates a new tenant
+func (r *TenantRepositoryImpl) CreateTenant(ctx context.Context, tenant *models.Tenant) error {
+	result := database.FromContext(ctx, r.DB).Clauses(clause.OnConflict{DoNothing: true}).Create(tenant)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create tenant: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tenant with ID %s %w", tenant.ID, repository.ErrConflict)
+	}
+	return nil
+}
+
+// UpdateTenant saves every field of an existing tenant
+func (r *TenantRepositoryImpl) UpdateTenant(ctx context.Context, tenant *models.Tenant) error {
+	if err := database.FromContext(ctx, r.DB).Save(tenant).Error; err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+	return nil
+}
+
+// DeleteTenant deletes a tenant. Its data is kept, but no request can reach it.
+func (r *TenantRepositoryImpl) DeleteTenant(ctx context.Context, id string) error {
+	result := database.FromContext(ctx, r.DB).Delete(&models.Tenant{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete tenant: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tenant with ID %s %w", id, repository.ErrNotFound)
+	}
+	return nil
+}
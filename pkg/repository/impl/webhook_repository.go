@@ -0,0 +1,161 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/database"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepositoryImpl implements the WebhookRepository interface using GORM
+type WebhookRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewWebhookRepository creates a new WebhookRepository instance
+func NewWebhookRepository(db *gorm.DB) repository.WebhookRepository {
+	return &WebhookRepositoryImpl{
+		DB: db,
+	}
+}
+
+// ListSubscriptions retrieves all webhook subscriptions
+func (r *WebhookRepositoryImpl) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	result := database.FromContext(ctx, r.DB).Order("created_at ASC").Find(&subs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve webhook subscriptions: %w", result.Error)
+	}
+	return subs, nil
+}
+
+// ListActiveSubscriptions retrieves all enabled webhook subscriptions
+func (r *WebhookRepositoryImpl) ListActiveSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	result := database.FromContext(ctx, r.DB).Where("active = ?", true).Find(&subs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve webhook subscriptions: %w", result.Error)
+	}
+	return subs, nil
+}
+
+// GetSubscriptionByID retrieves a webhook subscription by its ID
+func (r *WebhookRepositoryImpl) GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	result := database.FromContext(ctx, r.DB).First(&sub, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook with ID %s %w", id, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve webhook: %w", result.Error)
+	}
+	return &sub, nil
+}
+
+// CreateSubscription creates a new webhook subscription
+func (r *WebhookRepositoryImpl) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	if err := database.FromContext(ctx, r.DB).Create(sub).Error; err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubscription saves every field of an existing webhook subscription
+func (r *WebhookRepositoryImpl) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	result := database.FromContext(ctx, r.DB).Model(sub).Select("*").Omit("id", "created_at").Updates(sub)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook with ID %s %w", sub.ID, repository.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteSubscription deletes a webhook subscription and its delivery log
+func (r *WebhookRepositoryImpl) DeleteSubscription(ctx context.Context, id string) error {
+	return database.FromContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.WebhookDelivery{}, "subscription_id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+
+		result := tx.Delete(&models.WebhookSubscription{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete webhook: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("webhook with ID %s %w", id, repository.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+// CreateDelivery stores a new delivery, ignoring duplicates of the same event
+func (r *WebhookRepositoryImpl) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	result := database.FromContext(ctx, r.DB).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(delivery)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", result.Error)
+	}
+	return nil
+}
+
+// UpdateDelivery saves every field of an existing delivery
+func (r *WebhookRepositoryImpl) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	result := database.FromContext(ctx, r.DB).Model(delivery).Select("*").Omit("id", "created_at").Updates(delivery)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook delivery with ID %s %w", delivery.ID, repository.ErrNotFound)
+	}
+	return nil
+}
+
+// GetDeliveryByID retrieves a delivery by its ID
+func (r *WebhookRepositoryImpl) GetDeliveryByID(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	result := database.FromContext(ctx, r.DB).First(&delivery, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook delivery with ID %s %w", id, repository.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to retrieve webhook delivery: %w", result.Error)
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries retrieves the most recent deliveries of a subscription
+func (r *WebhookRepositoryImpl) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	result := database.FromContext(ctx, r.DB).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve webhook deliveries: %w", result.Error)
+	}
+	return deliveries, nil
+}
+
+// ListDueDeliveries retrieves pending deliveries that are ready to be attempted
+func (r *WebhookRepositoryImpl) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	result := database.FromContext(ctx, r.DB).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retrieve due webhook deliveries: %w", result.Error)
+	}
+	return deliveries, nil
+}
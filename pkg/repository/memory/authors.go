@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/similarity"
+	"github.com/google/uuid"
+)
+
+// Authors returns the Store as an AuthorRepository
+func (s *Store) Authors() repository.AuthorRepository {
+	return s
+}
+
+// authorsByName returns the authors seen by sc ordered by name; the caller holds s.mu
+func (s *Store) authorsByName(sc scope) []models.Author {
+	authors := []models.Author{}
+	for k, author := range s.data.authors {
+		if sc.has(k.tenant) {
+			authors = append(authors, author)
+		}
+	}
+	slices.SortFunc(authors, func(a, b models.Author) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return authors
+}
+
+// GetAllAuthors retrieves the authors of the tenant, ordered by name
+func (s *Store) GetAllAuthors(ctx context.Context) ([]models.Author, error) {
+	sc, err := scopeOf(ctx, "authors")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorsByName(sc), nil
+}
+
+// GetAuthorByID retrieves an author by its ID, following the redirect of a merged author
+func (s *Store) GetAuthorByID(ctx context.Context, id string) (*models.Author, error) {
+	sc, err := scopeOf(ctx, "authors")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tenantID := range s.tenants(sc) {
+		resolved := id
+		if target, ok := s.data.redirects[key{tenantID, id}]; ok {
+			resolved = target
+		}
+		if author, ok := s.data.authors[key{tenantID, resolved}]; ok {
+			return &author, nil
+		}
+	}
+	return nil, fmt.Errorf("author with ID %s %w", id, repository.ErrNotFound)
+}
+
+// GetAuthorsByIDs retrieves several authors by their IDs
+func (s *Store) GetAuthorsByIDs(ctx context.Context, ids []string) ([]models.Author, error) {
+	sc, err := scopeOf(ctx, "authors")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	authors := []models.Author{}
+	for _, id := range ids {
+		for _, tenantID := range s.tenants(sc) {
+			if author, ok := s.data.authors[key{tenantID, id}]; ok {
+				authors = append(authors, author)
+			}
+		}
+	}
+	return authors, nil
+}
+
+// ListAuthors retrieves a page of authors ordered by name, then ID
+func (s *Store) ListAuthors(ctx context.Context, limit int, after *pagination.Key) ([]models.Author, error) {
+	sc, err := scopeOf(ctx, "authors")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	authors := s.authorsByName(sc)
+	s.mu.Unlock()
+
+	if after != nil {
+		authors = slices.DeleteFunc(authors, func(author models.Author) bool {
+			return !isAfter(author.Name, author.ID, after)
+		})
+	}
+	if limit >= 0 && limit < len(authors) {
+		authors = authors[:limit]
+	}
+	return authors, nil
+}
+
+// FindAuthorByName retrieves the oldest author with the same normalized name
+func (s *Store) FindAuthorByName(ctx context.Context, name string) (*models.Author, error) {
+	sc, err := scopeOf(ctx, "authors")
+	if err != nil {
+		return nil, err
+	}
+	normalized := similarity.Normalize(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *models.Author
+	for k, author := range s.data.authors {
+		if !sc.has(k.tenant) || normalized == "" || author.NormalizedName != normalized {
+			continue
+		}
+		if found == nil || cmp.Or(author.CreatedAt.Compare(found.CreatedAt), cmp.Compare(author.ID, found.ID)) < 0 {
+			found = &author
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("author named %s %w", name, repository.ErrNotFound)
+	}
+	return found, nil
+}
+
+// CreateAuthor stores a new author, generating its ID when empty
+func (s *Store) CreateAuthor(ctx context.Context, author *models.Author) error {
+	tenantID, err := tenantOf(ctx, "authors")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if author.ID == "" {
+		author.ID = uuid.New().String()
+	}
+	if _, ok := s.data.authors[key{tenantID, author.ID}]; ok {
+		return fmt.Errorf("author with ID %s %w", author.ID, repository.ErrConflict)
+	}
+
+	now := time.Now()
+	author.NormalizedName = similarity.Normalize(author.Name)
+	author.TenantID = tenantID
+	author.CreatedAt, author.UpdatedAt = now, now
+
+	stored := *author
+	stored.Books = nil
+	s.data.authors[key{tenantID, author.ID}] = stored
+	s.touch(tenantID)
+	return nil
+}
+
+// UpdateAuthor changes the name and bio of an existing author
+func (s *Store) UpdateAuthor(ctx context.Context, author *models.Author) error {
+	tenantID, err := tenantOf(ctx, "authors")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.data.authors[key{tenantID, author.ID}]
+	if !ok {
+		return fmt.Errorf("author with ID %s %w", author.ID, repository.ErrNotFound)
+	}
+	existing.Name = author.Name
+	existing.Bio = author.Bio
+	existing.NormalizedName = similarity.Normalize(author.Name)
+	existing.UpdatedAt = time.Now()
+
+	s.data.authors[key{tenantID, author.ID}] = existing
+	s.touch(tenantID)
+	*author = existing
+	return nil
+}
@@ -0,0 +1,343 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/google/uuid"
+)
+
+// Books returns the Store as a BookRepository
+func (s *Store) Books() repository.BookRepository {
+	return s
+}
+
+// book returns a stored book with its author; the caller holds s.mu
+func (s *Store) book(tenantID, id string) (models.Book, bool) {
+	book, ok := s.data.books[key{tenantID, id}]
+	if ok {
+		book.Author = s.data.authors[key{tenantID, book.AuthorID}]
+	}
+	return book, ok
+}
+
+// bookIn returns a book seen by sc with its author; the caller holds s.mu
+func (s *Store) bookIn(sc scope, id string) (models.Book, bool) {
+	for _, tenantID := range s.tenants(sc) {
+		if book, ok := s.book(tenantID, id); ok {
+			return book, true
+		}
+	}
+	return models.Book{}, false
+}
+
+// booksWhere returns the books seen by sc matching match, with their
+// authors, ordered by name; the caller holds s.mu
+func (s *Store) booksWhere(sc scope, match func(models.Book) bool) []models.Book {
+	books := []models.Book{}
+	for k := range s.data.books {
+		if !sc.has(k.tenant) {
+			continue
+		}
+		if book, _ := s.book(k.tenant, k.id); match(book) {
+			books = append(books, book)
+		}
+	}
+	slices.SortFunc(books, func(a, b models.Book) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return books
+}
+
+// GetAllBooks retrieves the books of the tenant, ordered by name
+func (s *Store) GetAllBooks(ctx context.Context) ([]models.Book, error) {
+	sc, err := scopeOf(ctx, "books")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.booksWhere(sc, func(models.Book) bool { return true }), nil
+}
+
+// GetBookByID retrieves a book by its ID
+func (s *Store) GetBookByID(ctx context.Context, id string) (*models.Book, error) {
+	sc, err := scopeOf(ctx, "books")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	book, ok := s.bookIn(sc, id)
+	if !ok {
+		return nil, fmt.Errorf("book with ID %s %w", id, repository.ErrNotFound)
+	}
+	return &book, nil
+}
+
+// SearchBooks retrieves the books matching search, ordered by name. Text is
+// matched case-insensitively, like MySQL's default collation.
+func (s *Store) SearchBooks(ctx context.Context, search repository.BookSearch) ([]models.Book, error) {
+	sc, err := scopeOf(ctx, "books")
+	if err != nil {
+		return nil, err
+	}
+
+	contains := func(value, part string) bool {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(part))
+	}
+
+	s.mu.Lock()
+	books := s.booksWhere(sc, func(book models.Book) bool {
+		return (search.Query == "" || contains(book.Name, search.Query) || contains(book.Description, search.Query)) &&
+			(search.Author == "" || contains(book.Author.Name, search.Author)) &&
+			(search.Publisher == "" || contains(book.Publisher, search.Publisher)) &&
+			(search.MinPrice <= 0 || book.Price >= search.MinPrice) &&
+			(search.MaxPrice <= 0 || book.Price <= search.MaxPrice)
+	})
+	s.mu.Unlock()
+
+	if search.After != nil {
+		books = slices.DeleteFunc(books, func(book models.Book) bool {
+			return !isAfter(book.Name, book.ID, search.After)
+		})
+	}
+	if search.Limit > 0 && search.Limit < len(books) {
+		books = books[:search.Limit]
+	}
+	return books, nil
+}
+
+// GetBooksByAuthorIDs retrieves the books of several authors, ordered by name
+func (s *Store) GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) ([]models.Book, error) {
+	sc, err := scopeOf(ctx, "books")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.booksWhere(sc, func(book models.Book) bool {
+		return slices.Contains(authorIDs, book.AuthorID)
+	}), nil
+}
+
+// CountBooksByAuthorIDs counts the books of several authors
+func (s *Store) CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (map[string]int, error) {
+	sc, err := scopeOf(ctx, "books")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[string]int{}
+	for k, book := range s.data.books {
+		if sc.has(k.tenant) && slices.Contains(authorIDs, book.AuthorID) {
+			counts[book.AuthorID]++
+		}
+	}
+	return counts, nil
+}
+
+// CreateBook stores a new book of an existing author
+func (s *Store) CreateBook(ctx context.Context, book *models.Book) error {
+	tenantID, err := tenantOf(ctx, "books")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	author, ok := s.data.authors[key{tenantID, book.Author.ID}]
+	if !ok {
+		return fmt.Errorf("author with ID %s %w", book.Author.ID, repository.ErrNotFound)
+	}
+	if book.ID == "" {
+		book.ID = uuid.New().String()
+	}
+	if _, ok := s.data.books[key{tenantID, book.ID}]; ok {
+		return fmt.Errorf("book with ID %s %w", book.ID, repository.ErrConflict)
+	}
+
+	now := time.Now()
+	book.AuthorID = author.ID
+	book.Author = author
+	book.TenantID = tenantID
+	book.CreatedAt, book.UpdatedAt = now, now
+
+	stored := *book
+	stored.Author = models.Author{}
+	s.data.books[key{tenantID, book.ID}] = stored
+	s.touch(tenantID)
+	return nil
+}
+
+// UpdateBook changes the non-zero fields of a book, like GORM's Updates
+func (s *Store) UpdateBook(ctx context.Context, id string, book *models.Book) error {
+	tenantID, err := tenantOf(ctx, "books")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.data.books[key{tenantID, id}]
+	if !ok {
+		return fmt.Errorf("book with ID %s %w", id, repository.ErrNotFound)
+	}
+	book.ID = id
+
+	if book.Author.ID != "" {
+		if _, ok := s.data.authors[key{tenantID, book.Author.ID}]; !ok {
+			return fmt.Errorf("author with ID %s %w", book.Author.ID, repository.ErrNotFound)
+		}
+		existing.AuthorID = book.Author.ID
+	}
+	if book.Name != "" {
+		existing.Name = book.Name
+	}
+	if book.Publisher != "" {
+		existing.Publisher = book.Publisher
+	}
+	if book.PublishedYear != 0 {
+		existing.PublishedYear = book.PublishedYear
+	}
+	if book.Description != "" {
+		existing.Description = book.Description
+	}
+	if book.Price != 0 {
+		existing.Price = book.Price
+	}
+	if book.Pages != 0 {
+		existing.Pages = book.Pages
+	}
+	existing.UpdatedAt = time.Now()
+
+	s.data.books[key{tenantID, id}] = existing
+	s.touch(tenantID)
+	return nil
+}
+
+// DeleteBook deletes a book
+func (s *Store) DeleteBook(ctx context.Context, id string) error {
+	tenantID, err := tenantOf(ctx, "books")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.books[key{tenantID, id}]; !ok {
+		return fmt.Errorf("book with ID %s %w", id, repository.ErrNotFound)
+	}
+	delete(s.data.books, key{tenantID, id})
+	s.touch(tenantID)
+	return nil
+}
+
+// RestoreBook writes every field of book, re-creating it if it was deleted
+func (s *Store) RestoreBook(ctx context.Context, book *models.Book) error {
+	tenantID, err := tenantOf(ctx, "books")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.authors[key{tenantID, book.AuthorID}]; !ok {
+		return fmt.Errorf("author with ID %s %w", book.AuthorID, repository.ErrNotFound)
+	}
+
+	restored := *book
+	restored.Author = models.Author{}
+	restored.TenantID = tenantID
+	restored.DeletedAt = nil
+	if existing, ok := s.data.books[key{tenantID, book.ID}]; ok {
+		restored.CreatedAt = existing.CreatedAt
+	}
+	s.data.books[key{tenantID, book.ID}] = restored
+	s.touch(tenantID)
+
+	*book, _ = s.book(tenantID, book.ID)
+	return nil
+}
+
+// LastModified returns when a book or author of the tenant was last changed
+func (s *Store) LastModified(ctx context.Context) (time.Time, error) {
+	sc, err := scopeOf(ctx, "books")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lastModified time.Time
+	for _, tenantID := range s.tenants(sc) {
+		if modified := s.data.modified[tenantID]; modified.After(lastModified) {
+			lastModified = modified
+		}
+	}
+	return lastModified, nil
+}
+
+// MergeAuthors moves the books of the duplicate authors to the surviving one
+// and replaces the duplicates by redirects
+func (s *Store) MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, []models.Book, error) {
+	tenantID, err := tenantOf(ctx, "authors")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	survivor, ok := s.data.authors[key{tenantID, survivorID}]
+	if !ok {
+		return nil, nil, fmt.Errorf("author with ID %s %w", survivorID, repository.ErrNotFound)
+	}
+	for _, id := range duplicateIDs {
+		if _, ok := s.data.authors[key{tenantID, id}]; !ok {
+			return nil, nil, fmt.Errorf("author with ID %s %w", id, repository.ErrNotFound)
+		}
+	}
+
+	var moved []models.Book
+	now := time.Now()
+	for k, book := range s.data.books {
+		if k.tenant == tenantID && slices.Contains(duplicateIDs, book.AuthorID) {
+			book.AuthorID = survivor.ID
+			book.UpdatedAt = now
+			s.data.books[k] = book
+			book.Author = survivor
+			moved = append(moved, book)
+		}
+	}
+	slices.SortFunc(moved, func(a, b models.Book) int { return cmp.Compare(a.ID, b.ID) })
+
+	// Earlier redirects to the duplicates now lead to the survivor
+	for k, target := range s.data.redirects {
+		if k.tenant == tenantID && slices.Contains(duplicateIDs, target) {
+			s.data.redirects[k] = survivor.ID
+		}
+	}
+	for _, id := range duplicateIDs {
+		delete(s.data.authors, key{tenantID, id})
+		s.data.redirects[key{tenantID, id}] = survivor.ID
+	}
+	s.touch(tenantID)
+
+	return &survivor, moved, nil
+}
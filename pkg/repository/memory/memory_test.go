@@ -0,0 +1,11 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	repotest.TestRepositories(t, repotest.Memory())
+}
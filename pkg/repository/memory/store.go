@@ -0,0 +1,178 @@
+// Package memory provides an in-memory catalog for tests, implementing the
+// book and author repositories and the service's TransactionManager.
+// Reads with tenant.WithAllTenants see every tenant; writes need a tenant.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// key identifies a record of a tenant
+type key struct {
+	tenant string
+	id     string
+}
+
+// data is the content of a Store, copied to roll transactions back
+type data struct {
+	books   map[key]models.Book
+	authors map[key]models.Author
+	// redirects lead the IDs of merged authors to the surviving ones
+	redirects map[key]string
+	// modified is when each tenant's catalog last changed
+	modified map[string]time.Time
+}
+
+// clone returns a copy of d that can be changed independently
+func (d data) clone() data {
+	return data{
+		books:     maps.Clone(d.books),
+		authors:   maps.Clone(d.authors),
+		redirects: maps.Clone(d.redirects),
+		modified:  maps.Clone(d.modified),
+	}
+}
+
+// Store is an in-memory catalog, scoped to the tenant of each context like
+// the GORM repositories. It keeps no audit log and publishes no events.
+//
+// Transactions are serialized and roll back by restoring a copy of the
+// store, so writes made outside of them while one runs can be lost.
+type Store struct {
+	mu   sync.Mutex
+	data data
+
+	// txMu serializes the outermost transactions
+	txMu sync.Mutex
+}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{data: data{
+		books:     map[key]models.Book{},
+		authors:   map[key]models.Author{},
+		redirects: map[key]string{},
+		modified:  map[string]time.Time{},
+	}}
+}
+
+// tenantOf returns the tenant of ctx, which every write requires
+func tenantOf(ctx context.Context, table string) (string, error) {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("%s: %w", table, tenant.ErrMissing)
+	}
+	return id, nil
+}
+
+// scope is the tenants a read sees: the tenant of its context, or every
+// tenant for a context created by tenant.WithAllTenants
+type scope struct {
+	tenant string
+	all    bool
+}
+
+// scopeOf returns the tenants a read with ctx sees
+func scopeOf(ctx context.Context, table string) (scope, error) {
+	if tenant.AllTenants(ctx) {
+		return scope{all: true}, nil
+	}
+	id, err := tenantOf(ctx, table)
+	return scope{tenant: id}, err
+}
+
+// has reports whether the scope sees the records of tenantID
+func (sc scope) has(tenantID string) bool {
+	return sc.all || tenantID == sc.tenant
+}
+
+// tenants returns the tenants the scope sees; the caller holds s.mu
+func (s *Store) tenants(sc scope) []string {
+	if !sc.all {
+		return []string{sc.tenant}
+	}
+	// Every tenant with records has changed its catalog
+	return slices.Collect(maps.Keys(s.data.modified))
+}
+
+// isAfter reports whether the item named name with id comes after key, in
+// the order of names, then IDs, that lists are kept in
+func isAfter(name, id string, key *pagination.Key) bool {
+	return cmp.Or(cmp.Compare(name, key.Name), cmp.Compare(id, key.ID)) > 0
+}
+
+// touch records a change of the tenant's catalog; the caller holds s.mu
+func (s *Store) touch(tenantID string) {
+	s.data.modified[tenantID] = time.Now()
+}
+
+// txKey is the context key of the current transaction
+type txKey struct{}
+
+// txState is a transaction (or savepoint) carried by a context
+type txState struct {
+	// hooks run once the outermost transaction commits
+	hooks []func()
+}
+
+// WithinTransaction runs fn, restoring the store as it was when fn returns
+// an error or panics (the panic is returned as an error). Nested calls roll
+// back on their own, leaving the outer transaction usable.
+func (s *Store) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	parent, nested := ctx.Value(txKey{}).(*txState)
+	if !nested {
+		s.txMu.Lock()
+		defer s.txMu.Unlock()
+	}
+
+	s.mu.Lock()
+	saved := s.data.clone()
+	s.mu.Unlock()
+
+	state := &txState{}
+	if err := run(context.WithValue(ctx, txKey{}, state), fn); err != nil {
+		s.mu.Lock()
+		s.data = saved
+		s.mu.Unlock()
+		return err
+	}
+
+	if nested {
+		parent.hooks = append(parent.hooks, state.hooks...)
+		return nil
+	}
+	for _, hook := range state.hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction of ctx has committed, or right
+// away outside of a transaction
+func (s *Store) AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.hooks = append(state.hooks, fn)
+		return
+	}
+	fn()
+}
+
+// run calls fn, turning a panic into an error
+func run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in transaction: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
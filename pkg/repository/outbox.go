@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// OutboxRepository defines the interface for reading and acknowledging outbox events
+type OutboxRepository interface {
+	// FetchPending returns up to limit unpublished events that are due at
+	// now, in the order they were written. Events of an aggregate are not
+	// due while an earlier one of it waits to be retried.
+	FetchPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uint64) error
+	// MarkFailed records a failed attempt, to be retried at nextAttemptAt
+	MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error
+}
@@ -0,0 +1,760 @@
+// Package repotest holds the contract that every implementation of the book
+// and author repositories must honour, and the databases to run it against.
+//
+// A test runs the contract by passing a Factory to TestRepositories:
+//
+//	func TestGORMRepositories(t *testing.T) {
+//		repotest.TestRepositories(t, repotest.GORM(repotest.SQLite(t)))
+//	}
+package repotest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+// Repositories are the implementations under test, sharing their storage.
+// Only Books and Authors are required: the cases of the others are skipped
+// when they are nil.
+type Repositories struct {
+	Books       repository.BookRepository
+	Authors     repository.AuthorRepository
+	Audit       repository.AuditRepository
+	Covers      repository.CoverRepository
+	Files       repository.FileRepository
+	Idempotency repository.IdempotencyRepository
+	Outbox      repository.OutboxRepository
+	Webhooks    repository.WebhookRepository
+}
+
+// Factory returns the repositories to run a subtest against. They may be
+// shared by every subtest: each one works in a tenant of its own.
+type Factory func(t *testing.T) Repositories
+
+// TestRepositories runs the repository contract as subtests of t
+func TestRepositories(t *testing.T, newRepos Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repos Repositories)
+	}{
+		{"CreateAndGetBook", testCreateAndGetBook},
+		{"UpdateBook", testUpdateBook},
+		{"DeleteBook", testDeleteBook},
+		{"RestoreBook", testRestoreBook},
+		{"SearchBooks", testSearchBooks},
+		{"BookPages", testBookPages},
+		{"BooksByAuthor", testBooksByAuthor},
+		{"Authors", testAuthors},
+		{"FindAuthorByName", testFindAuthorByName},
+		{"LastModified", testLastModified},
+		{"MergeAuthors", testMergeAuthors},
+		{"TenantIsolation", testTenantIsolation},
+		{"AuditTenantIsolation", testAuditTenantIsolation},
+		{"CoverTenantIsolation", testCoverTenantIsolation},
+		{"FileTenantIsolation", testFileTenantIsolation},
+		{"IdempotencyIsolation", testIdempotencyIsolation},
+		{"OutboxTenantIsolation", testOutboxTenantIsolation},
+		{"WebhookTenantIsolation", testWebhookTenantIsolation},
+		{"AllTenants", testAllTenants},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, NewTenantContext(t), newRepos(t))
+		})
+	}
+}
+
+// NewTenantContext returns a context scoped to a new tenant, so that tests
+// sharing a database do not see each other's records
+func NewTenantContext(t *testing.T) context.Context {
+	return tenant.WithID(t.Context(), "test-"+uuid.NewString())
+}
+
+// createAuthor stores an author named name
+func createAuthor(t *testing.T, ctx context.Context, repos Repositories, name string) models.Author {
+	t.Helper()
+	author := models.Author{Name: name}
+	if err := repos.Authors.CreateAuthor(ctx, &author); err != nil {
+		t.Fatalf("CreateAuthor(%q): %v", name, err)
+	}
+	if author.ID == "" {
+		t.Fatalf("CreateAuthor(%q) did not set the ID", name)
+	}
+	return author
+}
+
+// createBook stores book, written by author
+func createBook(t *testing.T, ctx context.Context, repos Repositories, author models.Author, book models.Book) models.Book {
+	t.Helper()
+	book.Author = models.Author{ID: author.ID}
+	if err := repos.Books.CreateBook(ctx, &book); err != nil {
+		t.Fatalf("CreateBook(%q): %v", book.Name, err)
+	}
+	if book.ID == "" {
+		t.Fatalf("CreateBook(%q) did not set the ID", book.Name)
+	}
+	return book
+}
+
+// getBook retrieves the book with ID id, which must exist
+func getBook(t *testing.T, ctx context.Context, repos Repositories, id string) models.Book {
+	t.Helper()
+	book, err := repos.Books.GetBookByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetBookByID(%s): %v", id, err)
+	}
+	return *book
+}
+
+// expectNotFound fails t unless err wraps repository.ErrNotFound
+func expectNotFound(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("%s: got error %v, want one wrapping repository.ErrNotFound", op, err)
+	}
+}
+
+// bookNames returns the names of books, in order
+func bookNames(books []models.Book) []string {
+	names := make([]string, len(books))
+	for i, book := range books {
+		names[i] = book.Name
+	}
+	return names
+}
+
+// authorNames returns the names of authors, in order
+func authorNames(authors []models.Author) []string {
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = author.Name
+	}
+	return names
+}
+
+// expectNames fails t unless got equals want
+func expectNames(t *testing.T, op string, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) && (len(got) != 0 || len(want) != 0) {
+		t.Fatalf("%s: got %q, want %q", op, got, want)
+	}
+}
+
+func testCreateAndGetBook(t *testing.T, ctx context.Context, repos Repositories) {
+	author := createAuthor(t, ctx, repos, "Ursula K. Le Guin")
+	created := createBook(t, ctx, repos, author, models.Book{
+		Name:          "The Dispossessed",
+		Publisher:     "Harper & Row",
+		PublishedYear: 1974,
+		Price:         12.5,
+		Pages:         387,
+	})
+	if created.AuthorID != author.ID {
+		t.Fatalf("CreateBook set author_id %q, want %q", created.AuthorID, author.ID)
+	}
+
+	book := getBook(t, ctx, repos, created.ID)
+	if book.Name != "The Dispossessed" || book.Publisher != "Harper & Row" || book.PublishedYear != 1974 ||
+		book.Price != 12.5 || book.Pages != 387 {
+		t.Fatalf("GetBookByID returned %+v, which differs from the created book", book)
+	}
+	if book.Author.ID != author.ID || book.Author.Name != author.Name {
+		t.Fatalf("GetBookByID returned author %+v, want %q", book.Author, author.Name)
+	}
+
+	all, err := repos.Books.GetAllBooks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllBooks: %v", err)
+	}
+	expectNames(t, "GetAllBooks", bookNames(all), "The Dispossessed")
+	if all[0].Author.Name != author.Name {
+		t.Fatalf("GetAllBooks returned author %+v, want %q", all[0].Author, author.Name)
+	}
+
+	_, err = repos.Books.GetBookByID(ctx, uuid.NewString())
+	expectNotFound(t, "GetBookByID of an unknown book", err)
+}
+
+func testUpdateBook(t *testing.T, ctx context.Context, repos Repositories) {
+	first := createAuthor(t, ctx, repos, "Octavia E. Butler")
+	second := createAuthor(t, ctx, repos, "Samuel R. Delany")
+	created := createBook(t, ctx, repos, first, models.Book{Name: "Kindred", Publisher: "Doubleday", Price: 9})
+
+	// Zero fields are left as they are
+	if err := repos.Books.UpdateBook(ctx, created.ID, &models.Book{Price: 11, Pages: 264}); err != nil {
+		t.Fatalf("UpdateBook: %v", err)
+	}
+	book := getBook(t, ctx, repos, created.ID)
+	if book.Price != 11 || book.Pages != 264 || book.Name != "Kindred" || book.Publisher != "Doubleday" {
+		t.Fatalf("UpdateBook left %+v, want price 11, 264 pages and the other fields unchanged", book)
+	}
+
+	// A non-empty author ID moves the book to that author
+	if err := repos.Books.UpdateBook(ctx, created.ID, &models.Book{Author: models.Author{ID: second.ID}}); err != nil {
+		t.Fatalf("UpdateBook of the author: %v", err)
+	}
+	book = getBook(t, ctx, repos, created.ID)
+	if book.AuthorID != second.ID || book.Author.Name != second.Name {
+		t.Fatalf("UpdateBook left author %q (%q), want %q", book.AuthorID, book.Author.Name, second.ID)
+	}
+
+	err := repos.Books.UpdateBook(ctx, uuid.NewString(), &models.Book{Price: 1})
+	expectNotFound(t, "UpdateBook of an unknown book", err)
+}
+
+func testDeleteBook(t *testing.T, ctx context.Context, repos Repositories) {
+	author := createAuthor(t, ctx, repos, "Iain M. Banks")
+	kept := createBook(t, ctx, repos, author, models.Book{Name: "Excession"})
+	deleted := createBook(t, ctx, repos, author, models.Book{Name: "Use of Weapons"})
+
+	if err := repos.Books.DeleteBook(ctx, deleted.ID); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+	_, err := repos.Books.GetBookByID(ctx, deleted.ID)
+	expectNotFound(t, "GetBookByID of a deleted book", err)
+	expectNotFound(t, "DeleteBook of a deleted book", repos.Books.DeleteBook(ctx, deleted.ID))
+
+	getBook(t, ctx, repos, kept.ID)
+	if _, err := repos.Authors.GetAuthorByID(ctx, author.ID); err != nil {
+		t.Fatalf("GetAuthorByID after deleting one of the author's books: %v", err)
+	}
+}
+
+func testRestoreBook(t *testing.T, ctx context.Context, repos Repositories) {
+	author := createAuthor(t, ctx, repos, "Ted Chiang")
+	created := createBook(t, ctx, repos, author, models.Book{Name: "Exhalation", Publisher: "Knopf", Price: 15})
+	snapshot := getBook(t, ctx, repos, created.ID)
+
+	// Restoring an existing book writes zero values too. Snapshots of the
+	// audit log do not carry the tenant, which the book keeps.
+	restored := snapshot
+	restored.TenantID = ""
+	restored.Publisher = ""
+	restored.Price = 20
+	if err := repos.Books.RestoreBook(ctx, &restored); err != nil {
+		t.Fatalf("RestoreBook: %v", err)
+	}
+	if restored.Publisher != "" || restored.Price != 20 || restored.Author.ID != author.ID {
+		t.Fatalf("RestoreBook returned %+v, want no publisher, price 20 and the author", restored)
+	}
+	book := getBook(t, ctx, repos, created.ID)
+	if book.Publisher != "" || book.Price != 20 {
+		t.Fatalf("RestoreBook left %+v, want no publisher and price 20", book)
+	}
+
+	// Restoring a deleted book re-creates it with its ID
+	if err := repos.Books.DeleteBook(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+	restored = snapshot
+	if err := repos.Books.RestoreBook(ctx, &restored); err != nil {
+		t.Fatalf("RestoreBook of a deleted book: %v", err)
+	}
+	book = getBook(t, ctx, repos, created.ID)
+	if book.Name != "Exhalation" || book.Publisher != "Knopf" || book.Price != 15 {
+		t.Fatalf("RestoreBook of a deleted book left %+v, want the snapshot", book)
+	}
+
+	orphan := snapshot
+	orphan.ID = uuid.NewString()
+	orphan.AuthorID = uuid.NewString()
+	expectNotFound(t, "RestoreBook with an unknown author", repos.Books.RestoreBook(ctx, &orphan))
+}
+
+func testSearchBooks(t *testing.T, ctx context.Context, repos Repositories) {
+	tolkien := createAuthor(t, ctx, repos, "J. R. R. Tolkien")
+	pratchett := createAuthor(t, ctx, repos, "Terry Pratchett")
+	createBook(t, ctx, repos, tolkien, models.Book{Name: "The Hobbit", Publisher: "Allen & Unwin", Price: 10})
+	createBook(t, ctx, repos, tolkien, models.Book{Name: "The Silmarillion", Description: "Tales of the Elder Days", Price: 25})
+	createBook(t, ctx, repos, pratchett, models.Book{Name: "Guards! Guards!", Publisher: "Gollancz", Price: 8})
+	createBook(t, ctx, repos, pratchett, models.Book{Name: "Mort", Description: "100% Death", Price: 7})
+
+	tests := []struct {
+		name   string
+		search repository.BookSearch
+		want   []string
+	}{
+		{"everything", repository.BookSearch{}, []string{"Guards! Guards!", "Mort", "The Hobbit", "The Silmarillion"}},
+		{"name ignoring case", repository.BookSearch{Query: "hobbit"}, []string{"The Hobbit"}},
+		{"description", repository.BookSearch{Query: "elder"}, []string{"The Silmarillion"}},
+		{"wildcards taken literally", repository.BookSearch{Query: "%"}, []string{"Mort"}},
+		{"no wildcard match", repository.BookSearch{Query: "1_0"}, nil},
+		{"author", repository.BookSearch{Author: "pratchett"}, []string{"Guards! Guards!", "Mort"}},
+		{"publisher", repository.BookSearch{Publisher: "unwin"}, []string{"The Hobbit"}},
+		{"price range", repository.BookSearch{MinPrice: 8, MaxPrice: 10}, []string{"Guards! Guards!", "The Hobbit"}},
+		{"combined", repository.BookSearch{Query: "the", Author: "tolkien", MaxPrice: 20}, []string{"The Hobbit"}},
+		{"limit", repository.BookSearch{Limit: 2}, []string{"Guards! Guards!", "Mort"}},
+	}
+
+	for _, tt := range tests {
+		books, err := repos.Books.SearchBooks(ctx, tt.search)
+		if err != nil {
+			t.Fatalf("SearchBooks (%s): %v", tt.name, err)
+		}
+		expectNames(t, "SearchBooks ("+tt.name+")", bookNames(books), tt.want...)
+	}
+}
+
+func testBookPages(t *testing.T, ctx context.Context, repos Repositories) {
+	author := createAuthor(t, ctx, repos, "Frank Herbert")
+	var want []models.Book
+	for _, name := range []string{"Dune", "Dune", "Dune", "Chapterhouse", "The Eyes of Heisenberg"} {
+		want = append(want, createBook(t, ctx, repos, author, models.Book{Name: name}))
+	}
+	slices.SortFunc(want, func(a, b models.Book) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+
+	// Pages continue after the last book of the previous one, through books
+	// of the same name, and are not shifted by books added before them
+	var got []models.Book
+	search := repository.BookSearch{Limit: 2}
+	for {
+		page, err := repos.Books.SearchBooks(ctx, search)
+		if err != nil {
+			t.Fatalf("SearchBooks after %v: %v", search.After, err)
+		}
+		got = append(got, page...)
+		if len(page) < search.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		search.After = &pagination.Key{Name: last.Name, ID: last.ID}
+		if len(got) == 2 {
+			createBook(t, ctx, repos, author, models.Book{Name: "Children of Dune"})
+		}
+	}
+
+	gotIDs := make([]string, len(got))
+	for i, book := range got {
+		gotIDs[i] = book.ID
+	}
+	wantIDs := make([]string, len(want))
+	for i, book := range want {
+		wantIDs[i] = book.ID
+	}
+	if !slices.Equal(gotIDs, wantIDs) {
+		t.Fatalf("SearchBooks pages: got %q (%q), want %q (%q)", bookNames(got), gotIDs, bookNames(want), wantIDs)
+	}
+}
+
+func testBooksByAuthor(t *testing.T, ctx context.Context, repos Repositories) {
+	herbert := createAuthor(t, ctx, repos, "Frank Herbert")
+	asimov := createAuthor(t, ctx, repos, "Isaac Asimov")
+	idle := createAuthor(t, ctx, repos, "Idle Author")
+	createBook(t, ctx, repos, herbert, models.Book{Name: "Dune Messiah"})
+	createBook(t, ctx, repos, herbert, models.Book{Name: "Dune"})
+	createBook(t, ctx, repos, asimov, models.Book{Name: "Foundation"})
+
+	books, err := repos.Books.GetBooksByAuthorIDs(ctx, []string{herbert.ID, idle.ID})
+	if err != nil {
+		t.Fatalf("GetBooksByAuthorIDs: %v", err)
+	}
+	expectNames(t, "GetBooksByAuthorIDs", bookNames(books), "Dune", "Dune Messiah")
+
+	counts, err := repos.Books.CountBooksByAuthorIDs(ctx, []string{herbert.ID, asimov.ID, idle.ID})
+	if err != nil {
+		t.Fatalf("CountBooksByAuthorIDs: %v", err)
+	}
+	if len(counts) != 2 || counts[herbert.ID] != 2 || counts[asimov.ID] != 1 {
+		t.Fatalf("CountBooksByAuthorIDs returned %v, want 2 for %s and 1 for %s only", counts, herbert.ID, asimov.ID)
+	}
+}
+
+func testAuthors(t *testing.T, ctx context.Context, repos Repositories) {
+	for _, name := range []string{"Mary Shelley", "Arthur C. Clarke", "Philip K. Dick"} {
+		createAuthor(t, ctx, repos, name)
+	}
+
+	all, err := repos.Authors.GetAllAuthors(ctx)
+	if err != nil {
+		t.Fatalf("GetAllAuthors: %v", err)
+	}
+	expectNames(t, "GetAllAuthors", authorNames(all), "Arthur C. Clarke", "Mary Shelley", "Philip K. Dick")
+
+	page, err := repos.Authors.ListAuthors(ctx, 2, nil)
+	if err != nil {
+		t.Fatalf("ListAuthors: %v", err)
+	}
+	expectNames(t, "ListAuthors", authorNames(page), "Arthur C. Clarke", "Mary Shelley")
+	page, err = repos.Authors.ListAuthors(ctx, 2, &pagination.Key{Name: page[1].Name, ID: page[1].ID})
+	if err != nil {
+		t.Fatalf("ListAuthors: %v", err)
+	}
+	expectNames(t, "ListAuthors after a key", authorNames(page), "Philip K. Dick")
+
+	some, err := repos.Authors.GetAuthorsByIDs(ctx, []string{all[0].ID, uuid.NewString()})
+	if err != nil {
+		t.Fatalf("GetAuthorsByIDs: %v", err)
+	}
+	expectNames(t, "GetAuthorsByIDs", authorNames(some), "Arthur C. Clarke")
+
+	_, err = repos.Authors.GetAuthorByID(ctx, uuid.NewString())
+	expectNotFound(t, "GetAuthorByID of an unknown author", err)
+
+	update := models.Author{ID: all[1].ID, Name: "Mary Wollstonecraft Shelley", Bio: "Frankenstein"}
+	if err := repos.Authors.UpdateAuthor(ctx, &update); err != nil {
+		t.Fatalf("UpdateAuthor: %v", err)
+	}
+	author, err := repos.Authors.GetAuthorByID(ctx, all[1].ID)
+	if err != nil {
+		t.Fatalf("GetAuthorByID: %v", err)
+	}
+	if author.Name != "Mary Wollstonecraft Shelley" || author.Bio != "Frankenstein" {
+		t.Fatalf("UpdateAuthor left %+v, want the new name and bio", author)
+	}
+
+	err = repos.Authors.UpdateAuthor(ctx, &models.Author{ID: uuid.NewString(), Name: "Nobody"})
+	expectNotFound(t, "UpdateAuthor of an unknown author", err)
+}
+
+func testFindAuthorByName(t *testing.T, ctx context.Context, repos Repositories) {
+	rowling := createAuthor(t, ctx, repos, "J.K. Rowling")
+	createAuthor(t, ctx, repos, "J. R. R. Tolkien")
+
+	for _, name := range []string{"J.K. Rowling", "J. K. Rowling", "jk rowling"} {
+		author, err := repos.Authors.FindAuthorByName(ctx, name)
+		if err != nil {
+			t.Fatalf("FindAuthorByName(%q): %v", name, err)
+		}
+		if author.ID != rowling.ID {
+			t.Fatalf("FindAuthorByName(%q) returned %q, want %q", name, author.Name, rowling.Name)
+		}
+	}
+
+	_, err := repos.Authors.FindAuthorByName(ctx, "Robert Galbraith")
+	expectNotFound(t, "FindAuthorByName of an unknown name", err)
+	_, err = repos.Authors.FindAuthorByName(ctx, "...")
+	expectNotFound(t, "FindAuthorByName of a name without letters", err)
+}
+
+func testLastModified(t *testing.T, ctx context.Context, repos Repositories) {
+	modified, err := repos.Books.LastModified(ctx)
+	if err != nil {
+		t.Fatalf("LastModified: %v", err)
+	}
+	if !modified.IsZero() {
+		t.Fatalf("LastModified of an empty catalog returned %v, want the zero time", modified)
+	}
+
+	// Databases may store times with a precision of a second
+	start := time.Now().Add(-time.Second)
+	author := createAuthor(t, ctx, repos, "Le Guin")
+	book := createBook(t, ctx, repos, author, models.Book{Name: "Lavinia"})
+	created, err := repos.Books.LastModified(ctx)
+	if err != nil {
+		t.Fatalf("LastModified: %v", err)
+	}
+	if created.Before(start) {
+		t.Fatalf("LastModified after a create returned %v, want %v or later", created, start)
+	}
+
+	if err := repos.Books.DeleteBook(ctx, book.ID); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+	deleted, err := repos.Books.LastModified(ctx)
+	if err != nil {
+		t.Fatalf("LastModified: %v", err)
+	}
+	if deleted.Before(created) {
+		t.Fatalf("LastModified after a delete returned %v, before %v", deleted, created)
+	}
+}
+
+func testMergeAuthors(t *testing.T, ctx context.Context, repos Repositories) {
+	survivor := createAuthor(t, ctx, repos, "Stanisław Lem")
+	duplicate := createAuthor(t, ctx, repos, "Stanislaw Lem")
+	older := createAuthor(t, ctx, repos, "S. Lem")
+	createBook(t, ctx, repos, survivor, models.Book{Name: "Solaris"})
+	moved := createBook(t, ctx, repos, duplicate, models.Book{Name: "The Cyberiad"})
+
+	// An earlier merge leaves a redirect that the next merge must follow
+	if _, _, err := repos.Books.MergeAuthors(ctx, duplicate.ID, []string{older.ID}); err != nil {
+		t.Fatalf("MergeAuthors: %v", err)
+	}
+
+	merged, books, err := repos.Books.MergeAuthors(ctx, survivor.ID, []string{duplicate.ID})
+	if err != nil {
+		t.Fatalf("MergeAuthors: %v", err)
+	}
+	if merged.ID != survivor.ID {
+		t.Fatalf("MergeAuthors returned author %q, want %q", merged.ID, survivor.ID)
+	}
+	expectNames(t, "MergeAuthors", bookNames(books), "The Cyberiad")
+	if book := getBook(t, ctx, repos, moved.ID); book.AuthorID != survivor.ID {
+		t.Fatalf("MergeAuthors left the book with author %q, want %q", book.AuthorID, survivor.ID)
+	}
+
+	for _, id := range []string{duplicate.ID, older.ID} {
+		author, err := repos.Authors.GetAuthorByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetAuthorByID of merged author %s: %v", id, err)
+		}
+		if author.ID != survivor.ID {
+			t.Fatalf("GetAuthorByID of merged author %s returned %q, want %q", id, author.ID, survivor.ID)
+		}
+	}
+	all, err := repos.Authors.GetAllAuthors(ctx)
+	if err != nil {
+		t.Fatalf("GetAllAuthors: %v", err)
+	}
+	expectNames(t, "GetAllAuthors after merging", authorNames(all), "Stanisław Lem")
+
+	_, _, err = repos.Books.MergeAuthors(ctx, uuid.NewString(), []string{survivor.ID})
+	expectNotFound(t, "MergeAuthors into an unknown author", err)
+	_, _, err = repos.Books.MergeAuthors(ctx, survivor.ID, []string{uuid.NewString()})
+	expectNotFound(t, "MergeAuthors of an unknown author", err)
+}
+
+// testTenantIsolation checks that no method reads or changes the records of
+// another tenant, here, with the same names
+func testTenantIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	author := createAuthor(t, ctx, repos, "Ann Leckie")
+	book := createBook(t, ctx, repos, author, models.Book{Name: "Ancillary Justice", Price: 10})
+
+	other := NewTenantContext(t)
+	otherAuthor := createAuthor(t, other, repos, "Ann Leckie")
+	otherBook := createBook(t, other, repos, otherAuthor, models.Book{Name: "Ancillary Sword", Price: 10})
+	lastModified, err := repos.Books.LastModified(other)
+	if err != nil {
+		t.Fatalf("LastModified: %v", err)
+	}
+
+	// Reads
+	_, err = repos.Books.GetBookByID(other, book.ID)
+	expectNotFound(t, "GetBookByID in another tenant", err)
+
+	books, err := repos.Books.GetAllBooks(other)
+	if err != nil {
+		t.Fatalf("GetAllBooks in another tenant: %v", err)
+	}
+	expectNames(t, "GetAllBooks in another tenant", bookNames(books), "Ancillary Sword")
+
+	books, err = repos.Books.SearchBooks(other, repository.BookSearch{Query: "Ancillary", Author: "Leckie"})
+	if err != nil {
+		t.Fatalf("SearchBooks in another tenant: %v", err)
+	}
+	expectNames(t, "SearchBooks in another tenant", bookNames(books), "Ancillary Sword")
+
+	books, err = repos.Books.GetBooksByAuthorIDs(other, []string{author.ID, otherAuthor.ID})
+	if err != nil {
+		t.Fatalf("GetBooksByAuthorIDs in another tenant: %v", err)
+	}
+	expectNames(t, "GetBooksByAuthorIDs in another tenant", bookNames(books), "Ancillary Sword")
+
+	counts, err := repos.Books.CountBooksByAuthorIDs(other, []string{author.ID, otherAuthor.ID})
+	if err != nil {
+		t.Fatalf("CountBooksByAuthorIDs in another tenant: %v", err)
+	}
+	if len(counts) != 1 || counts[otherAuthor.ID] != 1 {
+		t.Fatalf("CountBooksByAuthorIDs in another tenant: got %v, want one book of the tenant's author", counts)
+	}
+
+	_, err = repos.Authors.GetAuthorByID(other, author.ID)
+	expectNotFound(t, "GetAuthorByID in another tenant", err)
+
+	authors, err := repos.Authors.GetAllAuthors(other)
+	if err != nil {
+		t.Fatalf("GetAllAuthors in another tenant: %v", err)
+	}
+	expectAuthors(t, "GetAllAuthors in another tenant", authors, otherAuthor.ID)
+
+	authors, err = repos.Authors.GetAuthorsByIDs(other, []string{author.ID, otherAuthor.ID})
+	if err != nil {
+		t.Fatalf("GetAuthorsByIDs in another tenant: %v", err)
+	}
+	expectAuthors(t, "GetAuthorsByIDs in another tenant", authors, otherAuthor.ID)
+
+	authors, err = repos.Authors.ListAuthors(other, 10, nil)
+	if err != nil {
+		t.Fatalf("ListAuthors in another tenant: %v", err)
+	}
+	expectAuthors(t, "ListAuthors in another tenant", authors, otherAuthor.ID)
+
+	found, err := repos.Authors.FindAuthorByName(other, "Ann Leckie")
+	if err != nil {
+		t.Fatalf("FindAuthorByName in another tenant: %v", err)
+	}
+	if found.ID != otherAuthor.ID {
+		t.Fatalf("FindAuthorByName in another tenant: got author %s, want the tenant's %s", found.ID, otherAuthor.ID)
+	}
+
+	// Writes
+	stolen := models.Book{Name: "Ancillary Mercy", Author: models.Author{ID: author.ID}}
+	expectNotFound(t, "CreateBook of an author of another tenant", repos.Books.CreateBook(other, &stolen))
+	expectNotFound(t, "UpdateBook in another tenant", repos.Books.UpdateBook(other, book.ID, &models.Book{Price: 1}))
+	expectNotFound(t, "UpdateBook to an author of another tenant",
+		repos.Books.UpdateBook(other, otherBook.ID, &models.Book{Author: models.Author{ID: author.ID}}))
+	expectNotFound(t, "DeleteBook in another tenant", repos.Books.DeleteBook(other, book.ID))
+
+	restored := getBook(t, ctx, repos, book.ID)
+	restored.Author = models.Author{}
+	expectNotFound(t, "RestoreBook of an author of another tenant", repos.Books.RestoreBook(other, &restored))
+
+	_, _, err = repos.Books.MergeAuthors(other, otherAuthor.ID, []string{author.ID})
+	expectNotFound(t, "MergeAuthors of an author of another tenant", err)
+	_, _, err = repos.Books.MergeAuthors(other, author.ID, []string{otherAuthor.ID})
+	expectNotFound(t, "MergeAuthors into an author of another tenant", err)
+
+	renamed := models.Author{ID: author.ID, Name: "Breq"}
+	expectNotFound(t, "UpdateAuthor in another tenant", repos.Authors.UpdateAuthor(other, &renamed))
+
+	// The tenant's records and modification time are untouched
+	if current, err := repos.Books.LastModified(other); err != nil || !current.Equal(lastModified) {
+		t.Fatalf("LastModified of another tenant after failed writes: got %v, %v, want %v", current, err, lastModified)
+	}
+	current := getBook(t, ctx, repos, book.ID)
+	if current.Name != "Ancillary Justice" || current.Price != 10 || current.AuthorID != author.ID {
+		t.Fatalf("book after writes in another tenant: got %+v", current)
+	}
+	if current, err := repos.Authors.GetAuthorByID(ctx, author.ID); err != nil || current.Name != "Ann Leckie" {
+		t.Fatalf("author after writes in another tenant: got %v, %v", current, err)
+	}
+	books, err = repos.Books.GetAllBooks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllBooks: %v", err)
+	}
+	expectNames(t, "GetAllBooks after writes in another tenant", bookNames(books), "Ancillary Justice")
+	found, err = repos.Authors.FindAuthorByName(ctx, "Ann Leckie")
+	if err != nil || found.ID != author.ID {
+		t.Fatalf("FindAuthorByName after writes in another tenant: got %v, %v, want %s", found, err, author.ID)
+	}
+
+	if _, err := repos.Books.GetAllBooks(context.Background()); !errors.Is(err, tenant.ErrMissing) {
+		t.Fatalf("GetAllBooks without a tenant: got error %v, want one wrapping tenant.ErrMissing", err)
+	}
+	if _, err := repos.Authors.GetAllAuthors(context.Background()); !errors.Is(err, tenant.ErrMissing) {
+		t.Fatalf("GetAllAuthors without a tenant: got error %v, want one wrapping tenant.ErrMissing", err)
+	}
+}
+
+// testAllTenants checks that reads with tenant.WithAllTenants, as background
+// work does, see the records of every tenant
+func testAllTenants(t *testing.T, ctx context.Context, repos Repositories) {
+	author := createAuthor(t, ctx, repos, "Becky Chambers")
+	book := createBook(t, ctx, repos, author, models.Book{Name: "Record of a Spaceborn Few"})
+	other := NewTenantContext(t)
+	otherAuthor := createAuthor(t, other, repos, "Becky Chambers")
+	otherBook := createBook(t, other, repos, otherAuthor, models.Book{Name: "Record of a Spaceborn Few"})
+
+	all := tenant.WithAllTenants(t.Context())
+	authorIDs := []string{author.ID, otherAuthor.ID}
+
+	for _, id := range []string{book.ID, otherBook.ID} {
+		getBook(t, all, repos, id)
+	}
+	for _, id := range authorIDs {
+		if _, err := repos.Authors.GetAuthorByID(all, id); err != nil {
+			t.Fatalf("GetAuthorByID(%s) in every tenant: %v", id, err)
+		}
+	}
+
+	books, err := repos.Books.GetAllBooks(all)
+	if err != nil {
+		t.Fatalf("GetAllBooks in every tenant: %v", err)
+	}
+	expectBooksInclude(t, "GetAllBooks in every tenant", books, book.ID, otherBook.ID)
+
+	books, err = repos.Books.SearchBooks(all, repository.BookSearch{Query: "Spaceborn"})
+	if err != nil {
+		t.Fatalf("SearchBooks in every tenant: %v", err)
+	}
+	expectBooksInclude(t, "SearchBooks in every tenant", books, book.ID, otherBook.ID)
+
+	books, err = repos.Books.GetBooksByAuthorIDs(all, authorIDs)
+	if err != nil {
+		t.Fatalf("GetBooksByAuthorIDs in every tenant: %v", err)
+	}
+	expectBooksInclude(t, "GetBooksByAuthorIDs in every tenant", books, book.ID, otherBook.ID)
+
+	counts, err := repos.Books.CountBooksByAuthorIDs(all, authorIDs)
+	if err != nil {
+		t.Fatalf("CountBooksByAuthorIDs in every tenant: %v", err)
+	}
+	if len(counts) != 2 || counts[author.ID] != 1 || counts[otherAuthor.ID] != 1 {
+		t.Fatalf("CountBooksByAuthorIDs in every tenant: got %v, want a book of each author", counts)
+	}
+
+	authors, err := repos.Authors.GetAllAuthors(all)
+	if err != nil {
+		t.Fatalf("GetAllAuthors in every tenant: %v", err)
+	}
+	expectAuthorsInclude(t, "GetAllAuthors in every tenant", authors, authorIDs...)
+
+	authors, err = repos.Authors.GetAuthorsByIDs(all, authorIDs)
+	if err != nil {
+		t.Fatalf("GetAuthorsByIDs in every tenant: %v", err)
+	}
+	expectAuthorsInclude(t, "GetAuthorsByIDs in every tenant", authors, authorIDs...)
+
+	authors, err = repos.Authors.ListAuthors(all, 1000, &pagination.Key{Name: "Becky Chambers"})
+	if err != nil {
+		t.Fatalf("ListAuthors in every tenant: %v", err)
+	}
+	expectAuthorsInclude(t, "ListAuthors in every tenant", authors, authorIDs...)
+
+	found, err := repos.Authors.FindAuthorByName(all, "Becky Chambers")
+	if err != nil {
+		t.Fatalf("FindAuthorByName in every tenant: %v", err)
+	}
+	if !slices.Contains(authorIDs, found.ID) {
+		t.Fatalf("FindAuthorByName in every tenant: got author %s, want one of %q", found.ID, authorIDs)
+	}
+
+	lastModified, err := repos.Books.LastModified(all)
+	if err != nil {
+		t.Fatalf("LastModified in every tenant: %v", err)
+	}
+	for _, tenantCtx := range []context.Context{ctx, other} {
+		modified, err := repos.Books.LastModified(tenantCtx)
+		if err != nil {
+			t.Fatalf("LastModified: %v", err)
+		}
+		if lastModified.Before(modified) {
+			t.Fatalf("LastModified in every tenant: got %v, before a tenant's %v", lastModified, modified)
+		}
+	}
+}
+
+// expectAuthors fails t unless authors are exactly those with ids, in order
+func expectAuthors(t *testing.T, op string, authors []models.Author, ids ...string) {
+	t.Helper()
+	got := make([]string, len(authors))
+	for i, author := range authors {
+		got[i] = author.ID
+	}
+	if !slices.Equal(got, ids) {
+		t.Fatalf("%s: got authors %q, want %q", op, got, ids)
+	}
+}
+
+// expectAuthorsInclude fails t unless authors include those with ids
+func expectAuthorsInclude(t *testing.T, op string, authors []models.Author, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if !slices.ContainsFunc(authors, func(author models.Author) bool { return author.ID == id }) {
+			t.Fatalf("%s: author %s is missing", op, id)
+		}
+	}
+}
+
+// expectBooksInclude fails t unless books include those with ids
+func expectBooksInclude(t *testing.T, op string, books []models.Book, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if !slices.ContainsFunc(books, func(book models.Book) bool { return book.ID == id }) {
+			t.Fatalf("%s: book %s is missing", op, id)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/config"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GORM returns a Factory of the GORM repositories on db
+func GORM(db *gorm.DB) Factory {
+	return func(t *testing.T) Repositories {
+		return Repositories{
+			Books:       impl.NewBookRepository(db),
+			Authors:     impl.NewAuthorRepository(db),
+			Audit:       impl.NewAuditRepository(db),
+			Covers:      impl.NewCoverRepository(db),
+			Files:       impl.NewFileRepository(db),
+			Idempotency: impl.NewIdempotencyRepository(db),
+			Outbox:      impl.NewOutboxRepository(db),
+			Webhooks:    impl.NewWebhookRepository(db),
+		}
+	}
+}
+
+// open connects to a test database, scopes it to tenants like the server
+// does and migrates it
+func open(t *testing.T, dialector gorm.Dialector) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := db.Use(tenant.NewGORMPlugin()); err != nil {
+		t.Fatalf("failed to register tenant plugin: %v", err)
+	}
+	if err := config.MigrateDB(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
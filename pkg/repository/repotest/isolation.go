@@ -0,0 +1,345 @@
+package repotest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/google/uuid"
+)
+
+// testAuditTenantIsolation checks that the audit log of a tenant is neither
+// read nor written by another
+func testAuditTenantIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	if repos.Audit == nil {
+		t.Skip("no audit repository")
+	}
+	ctx = audit.WithActor(ctx, audit.Actor{ID: "alice"})
+	author := createAuthor(t, ctx, repos, "Ann Leckie")
+	book := createBook(t, ctx, repos, author, models.Book{Name: "Ancillary Justice", Price: 10})
+
+	other := audit.WithActor(NewTenantContext(t), audit.Actor{ID: "alice"})
+	createAuthor(t, other, repos, "Ann Leckie")
+
+	// Reads
+	for _, filter := range []repository.AuditFilter{
+		{EntityType: "book", EntityID: book.ID},
+		{EntityID: author.ID},
+		{Actor: "alice"},
+	} {
+		entries, err := repos.Audit.ListEntries(other, filter)
+		if err != nil {
+			t.Fatalf("ListEntries(%+v) in another tenant: %v", filter, err)
+		}
+		for _, entry := range entries {
+			if entry.EntityID == book.ID || entry.EntityID == author.ID {
+				t.Fatalf("ListEntries(%+v) in another tenant: got entry %d of %s", filter, entry.ID, entry.EntityID)
+			}
+		}
+	}
+	_, err := repos.Audit.GetEntry(other, "book", book.ID, 1)
+	expectNotFound(t, "GetEntry in another tenant", err)
+
+	// Failed writes in another tenant are not recorded
+	expectNotFound(t, "UpdateBook in another tenant", repos.Books.UpdateBook(other, book.ID, &models.Book{Price: 1}))
+	expectNotFound(t, "DeleteBook in another tenant", repos.Books.DeleteBook(other, book.ID))
+	entries, err := repos.Audit.ListEntries(ctx, repository.AuditFilter{EntityID: book.ID})
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != audit.ActionCreate || entries[0].Actor != "alice" {
+		t.Fatalf("entries after writes in another tenant: got %+v, want the creation by alice", entries)
+	}
+}
+
+// testCoverTenantIsolation checks that the cover of a book is neither read,
+// replaced nor deleted by another tenant
+func testCoverTenantIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	if repos.Covers == nil {
+		t.Skip("no cover repository")
+	}
+	bookID := uuid.NewString()
+	cover := models.BookCover{BookID: bookID, Version: "v1", ContentType: "image/jpeg", Width: 600, Height: 900, Size: 1000}
+	if err := repos.Covers.SaveCover(ctx, &cover); err != nil {
+		t.Fatalf("SaveCover: %v", err)
+	}
+	other := NewTenantContext(t)
+
+	_, err := repos.Covers.GetCover(other, bookID)
+	expectNotFound(t, "GetCover in another tenant", err)
+
+	replaced := models.BookCover{BookID: bookID, Version: "v2", ContentType: "image/png", Width: 1, Height: 1, Size: 1}
+	expectNotFound(t, "SaveCover of a book of another tenant", repos.Covers.SaveCover(other, &replaced))
+	expectNotFound(t, "DeleteCover in another tenant", repos.Covers.DeleteCover(other, bookID))
+
+	current, err := repos.Covers.GetCover(ctx, bookID)
+	if err != nil || current.Version != "v1" || current.ContentType != "image/jpeg" {
+		t.Fatalf("cover after writes in another tenant: got %+v, %v", current, err)
+	}
+
+	// The tenant still replaces its own cover
+	cover.Version = "v2"
+	if err := repos.Covers.SaveCover(ctx, &cover); err != nil {
+		t.Fatalf("SaveCover of an existing cover: %v", err)
+	}
+	if current, err := repos.Covers.GetCover(ctx, bookID); err != nil || current.Version != "v2" {
+		t.Fatalf("cover after replacing it: got %+v, %v", current, err)
+	}
+}
+
+// testFileTenantIsolation checks that the files of a book and their
+// downloads are neither read, replaced, counted nor deleted by another tenant
+func testFileTenantIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	if repos.Files == nil {
+		t.Skip("no file repository")
+	}
+	bookID := uuid.NewString()
+	file := models.BookFile{BookID: bookID, Format: models.FileFormatPDF, Version: "v1", Filename: "book.pdf",
+		ContentType: "application/pdf", Size: 10, SHA256: "sha"}
+	if err := repos.Files.SaveFile(ctx, &file); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	download := models.FileDownload{BookID: bookID, Format: models.FileFormatPDF, UserID: "alice", PurchaseID: "order-1"}
+	if ok, err := repos.Files.CountDownload(ctx, &download, &models.FileDownloadLink{LinkID: uuid.NewString(), ExpiresAt: expires}, 2); !ok || err != nil {
+		t.Fatalf("CountDownload: got %v, %v, want the download allowed", ok, err)
+	}
+	other := NewTenantContext(t)
+
+	// Reads
+	_, err := repos.Files.GetFile(other, bookID, models.FileFormatPDF)
+	expectNotFound(t, "GetFile in another tenant", err)
+	files, err := repos.Files.ListFiles(other, bookID)
+	if err != nil || len(files) != 0 {
+		t.Fatalf("ListFiles in another tenant: got %+v, %v, want none", files, err)
+	}
+	_, err = repos.Files.GetDownloads(other, bookID, models.FileFormatPDF, "alice")
+	expectNotFound(t, "GetDownloads in another tenant", err)
+
+	// Writes
+	replaced := file
+	replaced.Version, replaced.Filename = "v2", "stolen.pdf"
+	expectNotFound(t, "SaveFile of a book of another tenant", repos.Files.SaveFile(other, &replaced))
+	link := &models.FileDownloadLink{LinkID: uuid.NewString(), ExpiresAt: expires}
+	if ok, err := repos.Files.CountDownload(other, &download, link, 2); ok || err != nil {
+		t.Fatalf("CountDownload in another tenant: got %v, %v, want the download refused", ok, err)
+	}
+	expectNotFound(t, "DeleteFile in another tenant", repos.Files.DeleteFile(other, bookID, models.FileFormatPDF))
+	if err := repos.Files.DeleteFiles(other, bookID); err != nil {
+		t.Fatalf("DeleteFiles in another tenant: %v", err)
+	}
+
+	current, err := repos.Files.GetFile(ctx, bookID, models.FileFormatPDF)
+	if err != nil || current.Version != "v1" || current.Filename != "book.pdf" || current.Downloads != 1 {
+		t.Fatalf("file after writes in another tenant: got %+v, %v", current, err)
+	}
+	downloads, err := repos.Files.GetDownloads(ctx, bookID, models.FileFormatPDF, "alice")
+	if err != nil || downloads.Downloads != 1 {
+		t.Fatalf("downloads after writes in another tenant: got %+v, %v, want 1", downloads, err)
+	}
+
+	// The tenant still replaces its own file, keeping its downloads
+	file.Version = "v2"
+	if err := repos.Files.SaveFile(ctx, &file); err != nil {
+		t.Fatalf("SaveFile of an existing file: %v", err)
+	}
+	if current, err := repos.Files.GetFile(ctx, bookID, models.FileFormatPDF); err != nil || current.Version != "v2" || current.Downloads != 1 {
+		t.Fatalf("file after replacing it: got %+v, %v", current, err)
+	}
+}
+
+// testIdempotencyIsolation checks that an Idempotency-Key is a key of its
+// tenant and verified caller: the same key sent by another tenant or caller
+// neither gets nor changes the stored record
+func testIdempotencyIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	if repos.Idempotency == nil {
+		t.Skip("no idempotency repository")
+	}
+	key := uuid.NewString()
+	// reserve reserves key in ctx, returning the record and the existing one
+	reserve := func(ctx context.Context) (*models.IdempotencyRecord, *models.IdempotencyRecord) {
+		t.Helper()
+		record := &models.IdempotencyRecord{Key: key, Fingerprint: "request", Token: uuid.NewString(), ExpiresAt: time.Now().UTC().Add(time.Hour)}
+		existing, err := repos.Idempotency.Reserve(ctx, record)
+		if err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		return record, existing
+	}
+
+	alice := audit.WithActor(ctx, audit.Actor{ID: "alice"})
+	record, existing := reserve(alice)
+	if existing != nil || record.Actor != "alice" {
+		t.Fatalf("first Reserve: got existing %+v and actor %q, want a reservation by alice", existing, record.Actor)
+	}
+	if _, existing := reserve(alice); existing == nil || existing.Token != record.Token || existing.Actor != "alice" {
+		t.Fatalf("Reserve again: got %+v, want the reservation by alice", existing)
+	}
+
+	others := map[string]context.Context{
+		"another tenant":        audit.WithActor(NewTenantContext(t), audit.Actor{ID: "alice"}),
+		"another actor":         audit.WithActor(ctx, audit.Actor{ID: "bob"}),
+		"an unidentified actor": ctx,
+	}
+	for name, other := range others {
+		if _, existing := reserve(other); existing != nil {
+			t.Fatalf("Reserve by %s: got the record of %q, want a reservation of its own", name, existing.Actor)
+		}
+
+		// The reservation of alice is neither completed nor released
+		stolen := *record
+		stolen.StatusCode, stolen.Body = 200, "stolen"
+		if err := repos.Idempotency.Complete(other, &stolen); err != nil {
+			t.Fatalf("Complete by %s: %v", name, err)
+		}
+		if err := repos.Idempotency.Release(other, record); err != nil {
+			t.Fatalf("Release by %s: %v", name, err)
+		}
+		if _, existing := reserve(alice); existing == nil || existing.Completed() {
+			t.Fatalf("reservation after Complete and Release by %s: got %+v, want it in flight", name, existing)
+		}
+	}
+
+	record.StatusCode, record.Body = 201, "created"
+	if err := repos.Idempotency.Complete(alice, record); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, existing := reserve(alice); existing == nil || existing.StatusCode != 201 || existing.Body != "created" {
+		t.Fatalf("Reserve after Complete: got %+v, want the response", existing)
+	}
+	for name, other := range others {
+		if _, existing := reserve(other); existing != nil && existing.Body == "created" {
+			t.Fatalf("Reserve by %s: got the response of alice", name)
+		}
+	}
+}
+
+// testOutboxTenantIsolation checks that the outbox events of a tenant are
+// neither fetched nor acknowledged by another
+func testOutboxTenantIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	if repos.Outbox == nil {
+		t.Skip("no outbox repository")
+	}
+	author := createAuthor(t, ctx, repos, "Ann Leckie")
+	book := createBook(t, ctx, repos, author, models.Book{Name: "Ancillary Justice", Price: 10})
+	other := NewTenantContext(t)
+
+	// pending returns the pending events of the book in ctx
+	pending := func(ctx context.Context) []models.OutboxEvent {
+		t.Helper()
+		events, err := repos.Outbox.FetchPending(ctx, time.Now().Add(time.Hour), 100000)
+		if err != nil {
+			t.Fatalf("FetchPending: %v", err)
+		}
+		return slices.DeleteFunc(events, func(event models.OutboxEvent) bool { return event.AggregateID != book.ID })
+	}
+
+	events := pending(ctx)
+	if len(events) != 1 {
+		t.Fatalf("FetchPending: got %d events of the book, want 1", len(events))
+	}
+	if events := pending(other); len(events) != 0 {
+		t.Fatalf("FetchPending in another tenant: got %d events of the book, want none", len(events))
+	}
+
+	event := events[0]
+	if err := repos.Outbox.MarkFailed(other, event.ID, "stolen", time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatalf("MarkFailed in another tenant: %v", err)
+	}
+	if err := repos.Outbox.MarkPublished(other, event.ID); err != nil {
+		t.Fatalf("MarkPublished in another tenant: %v", err)
+	}
+	events = pending(ctx)
+	if len(events) != 1 || events[0].Attempts != 0 || events[0].LastError != "" {
+		t.Fatalf("events after writes in another tenant: got %+v, want the event untouched", events)
+	}
+
+	if err := repos.Outbox.MarkPublished(ctx, event.ID); err != nil {
+		t.Fatalf("MarkPublished: %v", err)
+	}
+	if events := pending(ctx); len(events) != 0 {
+		t.Fatalf("FetchPending after MarkPublished: got %+v, want none", events)
+	}
+}
+
+// testWebhookTenantIsolation checks that the webhook subscriptions of a
+// tenant and their deliveries are neither read, changed nor deleted by another
+func testWebhookTenantIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	if repos.Webhooks == nil {
+		t.Skip("no webhook repository")
+	}
+	sub := models.WebhookSubscription{URL: "https://example.com/hook", Secret: "whsec_test", Active: true}
+	if err := repos.Webhooks.CreateSubscription(ctx, &sub); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	due := time.Now().Add(-time.Minute)
+	delivery := models.WebhookDelivery{SubscriptionID: sub.ID, EventID: uuid.NewString(), EventType: "book.created",
+		Status: models.WebhookDeliveryPending, NextAttemptAt: &due}
+	if err := repos.Webhooks.CreateDelivery(ctx, &delivery); err != nil {
+		t.Fatalf("CreateDelivery: %v", err)
+	}
+	other := NewTenantContext(t)
+
+	// Reads
+	_, err := repos.Webhooks.GetSubscriptionByID(other, sub.ID)
+	expectNotFound(t, "GetSubscriptionByID in another tenant", err)
+	for name, list := range map[string]func(context.Context) ([]models.WebhookSubscription, error){
+		"ListSubscriptions":       repos.Webhooks.ListSubscriptions,
+		"ListActiveSubscriptions": repos.Webhooks.ListActiveSubscriptions,
+	} {
+		subs, err := list(other)
+		if err != nil {
+			t.Fatalf("%s in another tenant: %v", name, err)
+		}
+		if slices.ContainsFunc(subs, func(s models.WebhookSubscription) bool { return s.ID == sub.ID }) {
+			t.Fatalf("%s in another tenant: got the tenant's subscription", name)
+		}
+	}
+	_, err = repos.Webhooks.GetDeliveryByID(other, delivery.ID)
+	expectNotFound(t, "GetDeliveryByID in another tenant", err)
+	if deliveries, err := repos.Webhooks.ListDeliveries(other, sub.ID, 100); err != nil || len(deliveries) != 0 {
+		t.Fatalf("ListDeliveries in another tenant: got %+v, %v, want none", deliveries, err)
+	}
+	dueDeliveries, err := repos.Webhooks.ListDueDeliveries(other, time.Now(), 100000)
+	if err != nil {
+		t.Fatalf("ListDueDeliveries in another tenant: %v", err)
+	}
+	if slices.ContainsFunc(dueDeliveries, func(d models.WebhookDelivery) bool { return d.ID == delivery.ID }) {
+		t.Fatal("ListDueDeliveries in another tenant: got the tenant's delivery")
+	}
+
+	// Writes
+	changed := sub
+	changed.URL, changed.Active = "https://attacker.example/hook", false
+	expectNotFound(t, "UpdateSubscription in another tenant", repos.Webhooks.UpdateSubscription(other, &changed))
+	failed := delivery
+	failed.Status = models.WebhookDeliveryFailed
+	expectNotFound(t, "UpdateDelivery in another tenant", repos.Webhooks.UpdateDelivery(other, &failed))
+	expectNotFound(t, "DeleteSubscription in another tenant", repos.Webhooks.DeleteSubscription(other, sub.ID))
+
+	current, err := repos.Webhooks.GetSubscriptionByID(ctx, sub.ID)
+	if err != nil || current.URL != sub.URL || !current.Active {
+		t.Fatalf("subscription after writes in another tenant: got %+v, %v", current, err)
+	}
+	deliveries, err := repos.Webhooks.ListDeliveries(ctx, sub.ID, 100)
+	if err != nil || len(deliveries) != 1 || deliveries[0].Status != models.WebhookDeliveryPending {
+		t.Fatalf("deliveries after writes in another tenant: got %+v, %v, want the pending delivery", deliveries, err)
+	}
+
+	// The tenant still changes its own
+	current.Active = false
+	if err := repos.Webhooks.UpdateSubscription(ctx, current); err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+	deliveries[0].Status = models.WebhookDeliverySucceeded
+	if err := repos.Webhooks.UpdateDelivery(ctx, &deliveries[0]); err != nil {
+		t.Fatalf("UpdateDelivery: %v", err)
+	}
+	if err := repos.Webhooks.DeleteSubscription(ctx, sub.ID); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+}
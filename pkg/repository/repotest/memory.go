@@ -0,0 +1,15 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/memory"
+)
+
+// Memory returns a Factory of in-memory repositories, each sharing a new store
+func Memory() Factory {
+	return func(t *testing.T) Repositories {
+		store := memory.NewStore()
+		return Repositories{Books: store.Books(), Authors: store.Authors()}
+	}
+}
@@ -0,0 +1,40 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+	tcmysql "github.com/testcontainers/testcontainers-go/modules/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlImage is the image of docker-compose.yml's database
+const mysqlImage = "mysql:8.0"
+
+// MySQL starts a MySQL container with testcontainers and returns its
+// migrated database; the container is removed when t ends. t is skipped in
+// -short mode and when Docker is not available.
+func MySQL(t *testing.T) *gorm.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping MySQL integration test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	container, err := tcmysql.Run(t.Context(), mysqlImage,
+		tcmysql.WithDatabase("book_store"),
+		tcmysql.WithUsername("demo"),
+		tcmysql.WithPassword("password"),
+	)
+	testcontainers.CleanupContainer(t, container)
+	if err != nil {
+		t.Fatalf("failed to start MySQL container: %v", err)
+	}
+
+	dsn, err := container.ConnectionString(t.Context(), "charset=utf8mb4", "parseTime=True", "loc=Local")
+	if err != nil {
+		t.Fatalf("failed to get MySQL connection string: %v", err)
+	}
+	return open(t, mysql.Open(dsn))
+}
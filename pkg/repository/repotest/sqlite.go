@@ -0,0 +1,17 @@
+package repotest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLite returns a migrated SQLite database in a temporary directory,
+// removed when t ends. The driver is pure Go, so it needs neither cgo nor
+// a server.
+func SQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	return open(t, sqlite.Open(filepath.Join(t.TempDir(), "bookstore.db")))
+}
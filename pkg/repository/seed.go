@@ -0,0 +1,18 @@
+package repository
+
+import "context"
+
+type seedKey struct{}
+
+// WithSeed returns a copy of ctx whose writes load seed data. They are
+// recorded neither in the audit log nor in the outbox, so loading a data
+// set is not reported to event sinks and webhooks as changes to the catalog.
+func WithSeed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, seedKey{}, true)
+}
+
+// IsSeed reports whether ctx was created by WithSeed
+func IsSeed(ctx context.Context) bool {
+	seed, _ := ctx.Value(seedKey{}).(bool)
+	return seed
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// TenantRepository defines the interface for tenant storage
+type TenantRepository interface {
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+	GetTenantByID(ctx context.Context, id string) (*models.Tenant, error)
+	// CreateTenant stores a new tenant; an existing ID is an ErrConflict
+	CreateTenant(ctx context.Context, tenant *models.Tenant) error
+	UpdateTenant(ctx context.Context, tenant *models.Tenant) error
+	DeleteTenant(ctx context.Context, id string) error
+}
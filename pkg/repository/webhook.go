@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// WebhookRepository defines the interface for webhook subscription and delivery storage
+type WebhookRepository interface {
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	ListActiveSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// CreateDelivery stores a new delivery. Creating a second delivery of the
+	// same event to the same subscription is a no-op.
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveryByID(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error)
+	// ListDueDeliveries returns pending deliveries whose next attempt is at or before now
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+}
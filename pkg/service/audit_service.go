@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/audit"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+)
+
+// AuditService defines the interface for querying the audit log and reverting changes
+type AuditService interface {
+	ListEntries(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, error)
+	BookHistory(ctx context.Context, id string) ([]models.AuditEntry, error)
+	RevertBook(ctx context.Context, id string, version int) (*models.Book, error)
+}
+
+// AuditServiceImpl implements the AuditService interface
+type AuditServiceImpl struct {
+	repo        repository.AuditRepository
+	bookService BookService
+	logger      *slog.Logger
+}
+
+// NewAuditService creates a new AuditService instance
+func NewAuditService(repo repository.AuditRepository, bookService BookService, logger *slog.Logger) AuditService {
+	return &AuditServiceImpl{
+		repo:        repo,
+		bookService: bookService,
+		logger:      logger,
+	}
+}
+
+// maxAuditEntries caps the number of entries returned by a single query
+const maxAuditEntries = 1000
+
+// ListEntries retrieves audit entries matching filter
+func (s *AuditServiceImpl) ListEntries(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, error) {
+	switch filter.EntityType {
+	case "", audit.EntityBook, audit.EntityAuthor:
+	default:
+		return nil, newValidationError(fmt.Sprintf("unknown entity type %q", filter.EntityType))
+	}
+
+	if filter.EntityID != "" && filter.EntityType == "" {
+		return nil, newValidationError("entity is required when filtering by id")
+	}
+
+	if filter.Limit <= 0 || filter.Limit > maxAuditEntries {
+		filter.Limit = maxAuditEntries
+	}
+
+	entries, err := s.repo.ListEntries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return []models.AuditEntry{}, nil
+	}
+
+	return entries, nil
+}
+
+// BookHistory retrieves every recorded version of a book, oldest first
+func (s *AuditServiceImpl) BookHistory(ctx context.Context, id string) ([]models.AuditEntry, error) {
+	if id == "" {
+		return nil, newValidationError("book ID cannot be empty")
+	}
+
+	return s.ListEntries(ctx, repository.AuditFilter{
+		EntityType: audit.EntityBook,
+		EntityID:   id,
+	})
+}
+
+// RevertBook restores a book to the state it had right after the given version
+func (s *AuditServiceImpl) RevertBook(ctx context.Context, id string, version int) (*models.Book, error) {
+	if id == "" {
+		return nil, newValidationError("book ID cannot be empty")
+	}
+
+	if version <= 0 {
+		return nil, newValidationError("version must be a positive number")
+	}
+
+	entry, err := s.repo.GetEntry(ctx, audit.EntityBook, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot *models.Book
+	if err := json.Unmarshal(entry.After, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode book snapshot: %w", err)
+	}
+
+	if snapshot == nil {
+		return nil, newValidationError(fmt.Sprintf("version %d deleted the book; revert to an earlier version", version))
+	}
+
+	if err := s.bookService.RestoreBook(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Book reverted", "book_id", id, "version", version)
+
+	return snapshot, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/similarity"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AuthorService defines the interface for author-related business logic
+type AuthorService interface {
+	GetAllAuthors(ctx context.Context) ([]models.Author, error)
+	GetAuthorByID(ctx context.Context, id string) (*models.Author, error)
+	GetAuthorsByIDs(ctx context.Context, ids []string) ([]models.Author, error)
+	// ListAuthors retrieves a page of authors ordered by name, then ID,
+	// starting after the author at after unless it is nil
+	ListAuthors(ctx context.Context, limit int, after *pagination.Key) ([]models.Author, error)
+	// FindDuplicates groups the authors whose names are at least threshold similar
+	FindDuplicates(ctx context.Context, threshold float64) ([]models.AuthorDuplicates, error)
+}
+
+// AuthorServiceImpl implements the AuthorService interface
+type AuthorServiceImpl struct {
+	repo repository.AuthorRepository
+}
+
+// NewAuthorService creates a new AuthorService instance
+func NewAuthorService(repo repository.AuthorRepository) AuthorService {
+	return &AuthorServiceImpl{
+		repo: repo,
+	}
+}
+
+// GetAllAuthors retrieves all authors
+func (s *AuthorServiceImpl) GetAllAuthors(ctx context.Context) (authors []models.Author, err error) {
+	ctx, span := tracing.Start(ctx, "AuthorService.GetAllAuthors")
+	defer func() { tracing.End(span, err) }()
+
+	authors, err = s.repo.GetAllAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authors) == 0 {
+		return []models.Author{}, nil
+	}
+
+	return authors, nil
+}
+
+// GetAuthorByID retrieves an author by its ID
+func (s *AuthorServiceImpl) GetAuthorByID(ctx context.Context, id string) (author *models.Author, err error) {
+	ctx, span := tracing.Start(ctx, "AuthorService.GetAuthorByID", attribute.String("author.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	if id == "" {
+		return nil, newValidationError("author ID cannot be empty")
+	}
+
+	return s.repo.GetAuthorByID(ctx, id)
+}
+
+// GetAuthorsByIDs retrieves several authors by their IDs
+func (s *AuthorServiceImpl) GetAuthorsByIDs(ctx context.Context, ids []string) (authors []models.Author, err error) {
+	ctx, span := tracing.Start(ctx, "AuthorService.GetAuthorsByIDs", attribute.Int("author.count", len(ids)))
+	defer func() { tracing.End(span, err) }()
+
+	if len(ids) == 0 {
+		return []models.Author{}, nil
+	}
+
+	return s.repo.GetAuthorsByIDs(ctx, ids)
+}
+
+// maxAuthorPage caps the number of authors returned by ListAuthors
+const maxAuthorPage = 1000
+
+// ListAuthors retrieves a page of authors ordered by name
+func (s *AuthorServiceImpl) ListAuthors(ctx context.Context, limit int, after *pagination.Key) (authors []models.Author, err error) {
+	ctx, span := tracing.Start(ctx, "AuthorService.ListAuthors")
+	defer func() { tracing.End(span, err) }()
+
+	if limit <= 0 || limit > maxAuthorPage {
+		return nil, newValidationError(fmt.Sprintf("limit must be between 1 and %d", maxAuthorPage))
+	}
+
+	authors, err = s.repo.ListAuthors(ctx, limit, after)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authors) == 0 {
+		return []models.Author{}, nil
+	}
+
+	return authors, nil
+}
+
+// Bounds of the similarity threshold of FindDuplicates
+const (
+	DefaultDuplicateThreshold = 0.92
+	minDuplicateThreshold     = 0.7
+)
+
+// FindDuplicates groups the authors whose normalized names have a
+// Jaro-Winkler similarity of at least threshold. Authors are ordered by
+// creation in each group, so the first one is usually the one to keep.
+func (s *AuthorServiceImpl) FindDuplicates(ctx context.Context, threshold float64) (groups []models.AuthorDuplicates, err error) {
+	ctx, span := tracing.Start(ctx, "AuthorService.FindDuplicates")
+	defer func() { tracing.End(span, err) }()
+
+	if threshold < minDuplicateThreshold || threshold > 1 {
+		return nil, newValidationError(fmt.Sprintf("threshold must be between %v and 1", minDuplicateThreshold))
+	}
+
+	authors, err := s.repo.GetAllAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(authors, func(i, j int) bool {
+		return authors[i].CreatedAt.Before(authors[j].CreatedAt)
+	})
+
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = similarity.Normalize(author.Name)
+	}
+
+	groups = []models.AuthorDuplicates{}
+	for _, cluster := range similarity.Clusters(names, threshold) {
+		group := models.AuthorDuplicates{Similarity: math.Round(cluster.Similarity*1000) / 1000}
+		for _, i := range cluster.Members {
+			group.Authors = append(group.Authors, authors[i])
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
@@ -3,35 +3,72 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
 	"github.com/dtg-lucifer/go-bookstore/pkg/models"
 	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // BookService defines the interface for book-related business logic
 type BookService interface {
 	GetAllBooks(ctx context.Context) ([]models.Book, error)
 	GetBookByID(ctx context.Context, id string) (*models.Book, error)
+	SearchBooks(ctx context.Context, search repository.BookSearch) ([]models.Book, error)
+	GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) ([]models.Book, error)
+	CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (map[string]int, error)
 	CreateBook(ctx context.Context, book *models.Book) error
 	UpdateBook(ctx context.Context, id string, book *models.Book) error
 	DeleteBook(ctx context.Context, id string) error
+	RestoreBook(ctx context.Context, book *models.Book) error
+	BatchBooks(ctx context.Context, mode models.BatchMode, ops []models.BatchOperation) ([]models.BatchResult, error)
+	LastModified(ctx context.Context) (time.Time, error)
+	// MergeAuthors moves the books of duplicate authors to a surviving one
+	MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, error)
+}
+
+// BookServiceOptions configures a BookService
+type BookServiceOptions struct {
+	// MatchAuthorsByName gives books created without an author ID the oldest
+	// existing author with the same normalized name, instead of a new author
+	MatchAuthorsByName bool
 }
 
 // BookServiceImpl implements the BookService interface
 type BookServiceImpl struct {
-	repo repository.BookRepository
+	repo      repository.BookRepository
+	authors   repository.AuthorRepository
+	tx        TransactionManager
+	logger    *slog.Logger
+	options   BookServiceOptions
+	notifiers []BookChangeNotifier
 }
 
-// NewBookService creates a new BookService instance
-func NewBookService(repo repository.BookRepository) BookService {
+// NewBookService creates a new BookService instance. Books and their
+// authors are written together in transactions of tx.
+// The notifiers are told about every book change once it is committed.
+func NewBookService(repo repository.BookRepository, authors repository.AuthorRepository, tx TransactionManager, logger *slog.Logger, options BookServiceOptions, notifiers ...BookChangeNotifier) BookService {
 	return &BookServiceImpl{
-		repo: repo,
+		repo:      repo,
+		authors:   authors,
+		tx:        tx,
+		logger:    logger,
+		options:   options,
+		notifiers: notifiers,
 	}
 }
 
 // GetAllBooks retrieves all books
-func (s *BookServiceImpl) GetAllBooks(ctx context.Context) ([]models.Book, error) {
-	books, err := s.repo.GetAllBooks(ctx)
+func (s *BookServiceImpl) GetAllBooks(ctx context.Context) (books []models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetAllBooks")
+	defer func() { tracing.End(span, err) }()
+
+	books, err = s.repo.GetAllBooks(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -44,61 +81,462 @@ func (s *BookServiceImpl) GetAllBooks(ctx context.Context) ([]models.Book, error
 }
 
 // GetBookByID retrieves a book by its ID
-func (s *BookServiceImpl) GetBookByID(ctx context.Context, id string) (*models.Book, error) {
+func (s *BookServiceImpl) GetBookByID(ctx context.Context, id string) (book *models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBookByID", attribute.String("book.id", id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == "" {
-		return nil, errors.New("book ID cannot be empty")
+		return nil, newValidationError("book ID cannot be empty")
 	}
 
 	return s.repo.GetBookByID(ctx, id)
 }
 
+// Search result limits
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 1000
+)
+
+// SearchBooks retrieves the books matching search
+func (s *BookServiceImpl) SearchBooks(ctx context.Context, search repository.BookSearch) (books []models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.SearchBooks")
+	defer func() { tracing.End(span, err) }()
+
+	if search.MinPrice < 0 || search.MaxPrice < 0 {
+		return nil, newValidationError("prices cannot be negative")
+	}
+
+	if search.MaxPrice > 0 && search.MinPrice > search.MaxPrice {
+		return nil, newValidationError("minimum price cannot exceed maximum price")
+	}
+
+	if search.Limit < 0 || search.Limit > maxSearchLimit {
+		return nil, newValidationError(fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit))
+	}
+
+	if search.Limit == 0 {
+		search.Limit = defaultSearchLimit
+	}
+
+	books, err = s.repo.SearchBooks(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(books) == 0 {
+		return []models.Book{}, nil
+	}
+
+	return books, nil
+}
+
+// GetBooksByAuthorIDs retrieves the books of several authors
+func (s *BookServiceImpl) GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) (books []models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBooksByAuthorIDs", attribute.Int("author.count", len(authorIDs)))
+	defer func() { tracing.End(span, err) }()
+
+	if len(authorIDs) == 0 {
+		return []models.Book{}, nil
+	}
+
+	return s.repo.GetBooksByAuthorIDs(ctx, authorIDs)
+}
+
+// CountBooksByAuthorIDs counts the books of several authors
+func (s *BookServiceImpl) CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (counts map[string]int, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.CountBooksByAuthorIDs", attribute.Int("author.count", len(authorIDs)))
+	defer func() { tracing.End(span, err) }()
+
+	if len(authorIDs) == 0 {
+		return map[string]int{}, nil
+	}
+
+	return s.repo.CountBooksByAuthorIDs(ctx, authorIDs)
+}
+
 // CreateBook creates a new book
-func (s *BookServiceImpl) CreateBook(ctx context.Context, book *models.Book) error {
+func (s *BookServiceImpl) CreateBook(ctx context.Context, book *models.Book) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.CreateBook")
+	defer func() { tracing.End(span, err) }()
+
 	if book == nil {
-		return errors.New("book cannot be nil")
+		return newValidationError("book cannot be nil")
 	}
 
 	if book.Name == "" {
-		return errors.New("book name cannot be empty")
+		return newValidationError("book name cannot be empty")
 	}
 
 	if book.Author.Name == "" {
-		return errors.New("author name cannot be empty")
+		return newValidationError("author name cannot be empty")
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.createBookAuthor(ctx, &book.Author); err != nil {
+			return err
+		}
+		return s.repo.CreateBook(ctx, book)
+	})
+	if err != nil {
+		return err
 	}
 
-	return s.repo.CreateBook(ctx, book)
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Book created", "book_id", book.ID, "author_id", book.AuthorID)
+	s.notify(ctx, events.BookCreated, *book)
+	return nil
 }
 
 // UpdateBook updates an existing book
-func (s *BookServiceImpl) UpdateBook(ctx context.Context, id string, book *models.Book) error {
+func (s *BookServiceImpl) UpdateBook(ctx context.Context, id string, book *models.Book) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.UpdateBook", attribute.String("book.id", id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == "" {
-		return errors.New("book ID cannot be empty")
+		return newValidationError("book ID cannot be empty")
 	}
 
 	if book == nil {
-		return errors.New("book cannot be nil")
+		return newValidationError("book cannot be nil")
 	}
 
-	// First check if the book exists
-	_, err := s.repo.GetBookByID(ctx, id)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// First check if the book exists
+		if _, err := s.repo.GetBookByID(ctx, id); err != nil {
+			return err
+		}
+
+		if book.Author.ID != "" {
+			if err := s.updateBookAuthor(ctx, &book.Author); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.UpdateBook(ctx, id, book); err != nil {
+			return err
+		}
+
+		if len(s.notifiers) > 0 {
+			updated, err := s.repo.GetBookByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			s.notify(ctx, events.BookUpdated, *updated)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	return s.repo.UpdateBook(ctx, id, book)
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Book updated", "book_id", id)
+	return nil
+}
+
+// createBookAuthor gives a new book its author: the existing author with the
+// ID of author (or the author it was merged into), the oldest author with the
+// same name when matching by name, or else a new author
+func (s *BookServiceImpl) createBookAuthor(ctx context.Context, author *models.Author) error {
+	var existing *models.Author
+	var err error
+	switch {
+	case author.ID != "":
+		existing, err = s.authors.GetAuthorByID(ctx, author.ID)
+	case s.options.MatchAuthorsByName:
+		existing, err = s.authors.FindAuthorByName(ctx, author.Name)
+	default:
+		return s.authors.CreateAuthor(ctx, author)
+	}
+
+	switch {
+	case err == nil:
+		*author = *existing
+		return nil
+	case errors.Is(err, repository.ErrNotFound):
+		return s.authors.CreateAuthor(ctx, author)
+	default:
+		return err
+	}
+}
+
+// updateBookAuthor saves the author of an updated book, creating it when no
+// author has its ID
+func (s *BookServiceImpl) updateBookAuthor(ctx context.Context, author *models.Author) error {
+	existing, err := s.authors.GetAuthorByID(ctx, author.ID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return s.authors.CreateAuthor(ctx, author)
+	case err != nil:
+		return err
+	}
+
+	// IDs of merged authors stand for the author they were merged into
+	author.ID = existing.ID
+	return s.authors.UpdateAuthor(ctx, author)
 }
 
 // DeleteBook deletes a book
-func (s *BookServiceImpl) DeleteBook(ctx context.Context, id string) error {
+func (s *BookServiceImpl) DeleteBook(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.DeleteBook", attribute.String("book.id", id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == "" {
-		return errors.New("book ID cannot be empty")
+		return newValidationError("book ID cannot be empty")
 	}
 
-	// First check if the book exists
-	_, err := s.repo.GetBookByID(ctx, id)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// First check if the book exists
+		existing, err := s.repo.GetBookByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.DeleteBook(ctx, id); err != nil {
+			return err
+		}
+
+		s.notify(ctx, events.BookDeleted, *existing)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	return s.repo.DeleteBook(ctx, id)
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Book deleted", "book_id", id)
+	return nil
+}
+
+// RestoreBook overwrites a book with a previously captured snapshot,
+// re-creating it if it has been deleted
+func (s *BookServiceImpl) RestoreBook(ctx context.Context, book *models.Book) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.RestoreBook")
+	defer func() { tracing.End(span, err) }()
+
+	if book == nil {
+		return newValidationError("book cannot be nil")
+	}
+
+	if book.ID == "" {
+		return newValidationError("book ID cannot be empty")
+	}
+
+	if book.Name == "" {
+		return newValidationError("book name cannot be empty")
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		eventType := events.BookUpdated
+		if _, err := s.repo.GetBookByID(ctx, book.ID); err != nil {
+			if !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			eventType = events.BookCreated
+		}
+
+		if err := s.repo.RestoreBook(ctx, book); err != nil {
+			return err
+		}
+
+		s.notify(ctx, eventType, *book)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Book restored", "book_id", book.ID)
+	return nil
+}
+
+// LastModified returns when a book or author was last changed
+func (s *BookServiceImpl) LastModified(ctx context.Context) (lastModified time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.LastModified")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.LastModified(ctx)
+}
+
+// MaxBatchSize is the maximum number of operations accepted in a single batch
+const MaxBatchSize = 1000
+
+// maxMergedAuthors caps the number of authors merged at once
+const maxMergedAuthors = 100
+
+// MergeAuthors merges duplicate authors into a surviving one. Their books
+// are moved to it and their IDs keep resolving to it.
+func (s *BookServiceImpl) MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (author *models.Author, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.MergeAuthors",
+		attribute.String("author.id", survivorID),
+		attribute.Int("author.count", len(duplicateIDs)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if survivorID == "" {
+		return nil, newValidationError("author ID cannot be empty")
+	}
+
+	if len(duplicateIDs) == 0 {
+		return nil, newValidationError("at least one author to merge is required")
+	}
+
+	if len(duplicateIDs) > maxMergedAuthors {
+		return nil, newValidationError(fmt.Sprintf("cannot merge more than %d authors at once", maxMergedAuthors))
+	}
+
+	seen := map[string]bool{}
+	for _, id := range duplicateIDs {
+		switch {
+		case id == "":
+			return nil, newValidationError("author IDs to merge cannot be empty")
+		case id == survivorID:
+			return nil, newValidationError("an author cannot be merged into itself")
+		case seen[id]:
+			return nil, newValidationError(fmt.Sprintf("author %s is listed twice", id))
+		}
+		seen[id] = true
+	}
+
+	author, moved, err := s.repo.MergeAuthors(ctx, survivorID, duplicateIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Authors merged",
+		"author_id", survivorID, "merged_ids", duplicateIDs, "books", len(moved))
+	for _, book := range moved {
+		s.notify(ctx, events.BookUpdated, book)
+	}
+	return author, nil
+}
+
+// errBatchAborted aborts an atomic batch transaction after an operation fails
+var errBatchAborted = errors.New("batch aborted")
+
+// BatchBooks applies a list of create/update/delete operations.
+// In atomic mode all operations share one transaction and the first failure
+// rolls back the whole batch; in best-effort mode every operation is applied
+// on its own. The returned results are indexed like ops.
+func (s *BookServiceImpl) BatchBooks(ctx context.Context, mode models.BatchMode, ops []models.BatchOperation) (results []models.BatchResult, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.BatchBooks",
+		attribute.String("batch.mode", string(mode)),
+		attribute.Int("batch.size", len(ops)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if len(ops) == 0 {
+		return nil, newValidationError("batch must contain at least one operation")
+	}
+
+	if len(ops) > MaxBatchSize {
+		return nil, newValidationError(fmt.Sprintf("batch cannot contain more than %d operations", MaxBatchSize))
+	}
+
+	results = make([]models.BatchResult, len(ops))
+	reset := func() {
+		for i, op := range ops {
+			results[i] = models.BatchResult{Index: i, Op: op.Op, ID: op.ID}
+		}
+	}
+	reset()
+
+	switch mode {
+	case models.BatchModeBestEffort:
+		for i, op := range ops {
+			s.applyBatchOperation(ctx, op, &results[i])
+		}
+		return results, nil
+
+	case models.BatchModeAtomic, "":
+		failed := -1
+		// Every operation runs in a savepoint of the batch's transaction, and
+		// notifications are held back until it commits
+		err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			// Start over when the transaction is retried
+			failed = -1
+			reset()
+			for i, op := range ops {
+				if !s.applyBatchOperation(ctx, op, &results[i]) {
+					failed = i
+					return fmt.Errorf("%w: %w", errBatchAborted, results[i].Err)
+				}
+			}
+			return nil
+		})
+
+		if err == nil {
+			logging.FromContext(ctx, s.logger).InfoContext(ctx, "Batch committed", "operations", len(ops))
+			return results, nil
+		}
+
+		logging.FromContext(ctx, s.logger).WarnContext(ctx, "Batch rolled back", "operations", len(ops), "failed_index", failed, "error", err)
+
+		// Every operation is undone, so report what happened to each of them
+		for i := range results {
+			switch {
+			case i < failed:
+				results[i].Status = models.BatchStatusRolledBack
+				results[i].ID = ops[i].ID
+				results[i].Book = nil
+			case i > failed:
+				results[i].Status = models.BatchStatusSkipped
+			}
+		}
+
+		if failed < 0 {
+			// The transaction itself failed (e.g. on commit)
+			for i := range results {
+				results[i].Status = models.BatchStatusFailed
+				results[i].Error = err.Error()
+				results[i].Err = err
+				results[i].Book = nil
+			}
+		}
+
+		return results, nil
+
+	default:
+		return nil, newValidationError(fmt.Sprintf("unknown batch mode %q", mode))
+	}
+}
+
+// applyBatchOperation runs a single batch operation through the regular
+// service methods and records its outcome in result. It reports whether the
+// operation succeeded.
+func (s *BookServiceImpl) applyBatchOperation(ctx context.Context, op models.BatchOperation, result *models.BatchResult) bool {
+	var err error
+
+	switch op.Op {
+	case models.BatchOpCreate:
+		if err = s.CreateBook(ctx, op.Book); err == nil {
+			result.ID = op.Book.ID
+			result.Book = op.Book
+			result.Status = models.BatchStatusCreated
+		}
+
+	case models.BatchOpUpdate:
+		if err = s.UpdateBook(ctx, op.ID, op.Book); err == nil {
+			var book *models.Book
+			if book, err = s.repo.GetBookByID(ctx, op.ID); err == nil {
+				result.Book = book
+				result.Status = models.BatchStatusUpdated
+			}
+		}
+
+	case models.BatchOpDelete:
+		if err = s.DeleteBook(ctx, op.ID); err == nil {
+			result.Status = models.BatchStatusDeleted
+		}
+
+	default:
+		err = newValidationError(fmt.Sprintf("unknown batch operation %q", op.Op))
+	}
+
+	if err != nil {
+		result.Status = models.BatchStatusFailed
+		result.Error = err.Error()
+		result.Err = err
+		return false
+	}
+
+	return true
 }
@@ -0,0 +1,350 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// bookMocks are the dependencies of a BookServiceImpl under test
+type bookMocks struct {
+	books    *mocks.MockBookRepository
+	authors  *mocks.MockAuthorRepository
+	notifier *mocks.MockBookChangeNotifier
+}
+
+// newBookService returns a BookServiceImpl on mocks. Transactions run their
+// function right away and notifications are sent at once, as if committed.
+func newBookService(t *testing.T, options service.BookServiceOptions) (service.BookService, bookMocks) {
+	ctrl := gomock.NewController(t)
+	m := bookMocks{
+		books:    mocks.NewMockBookRepository(ctrl),
+		authors:  mocks.NewMockAuthorRepository(ctrl),
+		notifier: mocks.NewMockBookChangeNotifier(ctrl),
+	}
+
+	tx := mocks.NewMockTransactionManager(ctrl)
+	tx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }).
+		AnyTimes()
+	tx.EXPECT().AfterCommit(gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, fn func()) { fn() }).
+		AnyTimes()
+
+	return service.NewBookService(m.books, m.authors, tx, logging.Discard(), options, m.notifier), m
+}
+
+// changed matches a BookChange of a type and book ID
+func changed(eventType events.Type, id string) gomock.Matcher {
+	return gomock.Cond(func(change service.BookChange) bool {
+		return change.Type == eventType && change.Book.ID == id
+	})
+}
+
+// errCheck reports whether an error is the expected one
+type errCheck func(err error) bool
+
+var (
+	noError  errCheck = func(err error) bool { return err == nil }
+	invalid  errCheck = service.IsValidationError
+	notFound errCheck = func(err error) bool { return errors.Is(err, repository.ErrNotFound) }
+	failed   errCheck = func(err error) bool { return errors.Is(err, errDatabase) }
+)
+
+// Fixtures returned by the mocks
+var (
+	errDatabase  = errors.New("database is down")
+	errNotFound  = fmt.Errorf("book: %w", repository.ErrNotFound)
+	dune         = models.Book{Name: "Dune", Author: models.Author{Name: "Frank Herbert"}}
+	existingDune = models.Book{ID: "book-1", Name: "Dune", AuthorID: "author-1"}
+)
+
+func TestBookServiceCreateBook(t *testing.T) {
+	tests := []struct {
+		name    string
+		book    *models.Book
+		options service.BookServiceOptions
+		expect  func(m bookMocks)
+		wantErr errCheck
+		// wantAuthorID is the author ID the book ends up with
+		wantAuthorID string
+	}{
+		{name: "nil book", wantErr: invalid},
+		{name: "no name", book: &models.Book{Author: models.Author{Name: "Frank Herbert"}}, wantErr: invalid},
+		{name: "no author name", book: &models.Book{Name: "Dune"}, wantErr: invalid},
+		{
+			name: "new author",
+			book: &dune,
+			expect: func(m bookMocks) {
+				m.authors.EXPECT().CreateAuthor(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, author *models.Author) { author.ID = "author-1" })
+				m.books.EXPECT().CreateBook(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, book *models.Book) { book.ID, book.AuthorID = "book-1", book.Author.ID })
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookCreated, "book-1"))
+			},
+			wantErr:      noError,
+			wantAuthorID: "author-1",
+		},
+		{
+			name: "existing author by ID",
+			book: &models.Book{Name: "Dune", Author: models.Author{ID: "merged", Name: "Frank Herbert"}},
+			expect: func(m bookMocks) {
+				m.authors.EXPECT().GetAuthorByID(gomock.Any(), "merged").Return(&models.Author{ID: "author-1", Name: "Frank Herbert"}, nil)
+				m.books.EXPECT().CreateBook(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, book *models.Book) { book.ID, book.AuthorID = "book-1", book.Author.ID })
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookCreated, "book-1"))
+			},
+			wantErr:      noError,
+			wantAuthorID: "author-1",
+		},
+		{
+			name:    "existing author by name",
+			book:    &models.Book{Name: "Dune", Author: models.Author{Name: "frank herbert"}},
+			options: service.BookServiceOptions{MatchAuthorsByName: true},
+			expect: func(m bookMocks) {
+				m.authors.EXPECT().FindAuthorByName(gomock.Any(), "frank herbert").Return(&models.Author{ID: "author-1", Name: "Frank Herbert"}, nil)
+				m.books.EXPECT().CreateBook(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, book *models.Book) { book.ID, book.AuthorID = "book-1", book.Author.ID })
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookCreated, "book-1"))
+			},
+			wantErr:      noError,
+			wantAuthorID: "author-1",
+		},
+		{
+			name:    "no author with the name",
+			book:    &models.Book{Name: "Dune", Author: models.Author{Name: "Frank Herbert"}},
+			options: service.BookServiceOptions{MatchAuthorsByName: true},
+			expect: func(m bookMocks) {
+				m.authors.EXPECT().FindAuthorByName(gomock.Any(), "Frank Herbert").Return(nil, errNotFound)
+				m.authors.EXPECT().CreateAuthor(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, author *models.Author) { author.ID = "author-2" })
+				m.books.EXPECT().CreateBook(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, book *models.Book) { book.ID, book.AuthorID = "book-1", book.Author.ID })
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookCreated, "book-1"))
+			},
+			wantErr:      noError,
+			wantAuthorID: "author-2",
+		},
+		{
+			name: "author lookup fails",
+			book: &models.Book{Name: "Dune", Author: models.Author{ID: "author-1", Name: "Frank Herbert"}},
+			expect: func(m bookMocks) {
+				m.authors.EXPECT().GetAuthorByID(gomock.Any(), "author-1").Return(nil, errDatabase)
+			},
+			wantErr: failed,
+		},
+		{
+			name: "book is not stored",
+			book: &dune,
+			expect: func(m bookMocks) {
+				m.authors.EXPECT().CreateAuthor(gomock.Any(), gomock.Any())
+				m.books.EXPECT().CreateBook(gomock.Any(), gomock.Any()).Return(errDatabase)
+			},
+			wantErr: failed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, m := newBookService(t, tt.options)
+			if tt.expect != nil {
+				tt.expect(m)
+			}
+
+			var book *models.Book
+			if tt.book != nil {
+				copied := *tt.book
+				book = &copied
+			}
+			err := s.CreateBook(t.Context(), book)
+			if !tt.wantErr(err) {
+				t.Fatalf("CreateBook() error = %v", err)
+			}
+			if tt.wantAuthorID != "" && book.AuthorID != tt.wantAuthorID {
+				t.Errorf("CreateBook() author ID = %q, want %q", book.AuthorID, tt.wantAuthorID)
+			}
+		})
+	}
+}
+
+func TestBookServiceUpdateBook(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		book    *models.Book
+		expect  func(m bookMocks)
+		wantErr errCheck
+	}{
+		{name: "no ID", book: &models.Book{Price: 10}, wantErr: invalid},
+		{name: "nil book", id: "book-1", wantErr: invalid},
+		{
+			name: "unknown book",
+			id:   "book-1",
+			book: &models.Book{Price: 10},
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(nil, errNotFound)
+			},
+			wantErr: notFound,
+		},
+		{
+			name: "book fields",
+			id:   "book-1",
+			book: &models.Book{Price: 10},
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(&existingDune, nil).Times(2)
+				m.books.EXPECT().UpdateBook(gomock.Any(), "book-1", gomock.Any())
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookUpdated, "book-1"))
+			},
+			wantErr: noError,
+		},
+		{
+			name: "merged author",
+			id:   "book-1",
+			book: &models.Book{Author: models.Author{ID: "merged", Name: "Frank Herbert"}},
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(&existingDune, nil).Times(2)
+				m.authors.EXPECT().GetAuthorByID(gomock.Any(), "merged").Return(&models.Author{ID: "author-1"}, nil)
+				m.authors.EXPECT().UpdateAuthor(gomock.Any(), gomock.Cond(func(author *models.Author) bool {
+					return author.ID == "author-1"
+				}))
+				m.books.EXPECT().UpdateBook(gomock.Any(), "book-1", gomock.Any())
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookUpdated, "book-1"))
+			},
+			wantErr: noError,
+		},
+		{
+			name: "unknown author",
+			id:   "book-1",
+			book: &models.Book{Author: models.Author{ID: "author-2", Name: "Brian Herbert"}},
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(&existingDune, nil).Times(2)
+				m.authors.EXPECT().GetAuthorByID(gomock.Any(), "author-2").Return(nil, errNotFound)
+				m.authors.EXPECT().CreateAuthor(gomock.Any(), gomock.Any())
+				m.books.EXPECT().UpdateBook(gomock.Any(), "book-1", gomock.Any())
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookUpdated, "book-1"))
+			},
+			wantErr: noError,
+		},
+		{
+			name: "update fails",
+			id:   "book-1",
+			book: &models.Book{Price: 10},
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(&existingDune, nil)
+				m.books.EXPECT().UpdateBook(gomock.Any(), "book-1", gomock.Any()).Return(errDatabase)
+			},
+			wantErr: failed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, m := newBookService(t, service.BookServiceOptions{})
+			if tt.expect != nil {
+				tt.expect(m)
+			}
+
+			if err := s.UpdateBook(t.Context(), tt.id, tt.book); !tt.wantErr(err) {
+				t.Fatalf("UpdateBook() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestBookServiceDeleteBook(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		expect  func(m bookMocks)
+		wantErr errCheck
+	}{
+		{name: "no ID", wantErr: invalid},
+		{
+			name: "unknown book",
+			id:   "book-1",
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(nil, errNotFound)
+			},
+			wantErr: notFound,
+		},
+		{
+			name: "deleted",
+			id:   "book-1",
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(&existingDune, nil)
+				m.books.EXPECT().DeleteBook(gomock.Any(), "book-1")
+				m.notifier.EXPECT().BookChanged(gomock.Any(), changed(events.BookDeleted, "book-1"))
+			},
+			wantErr: noError,
+		},
+		{
+			name: "delete fails",
+			id:   "book-1",
+			expect: func(m bookMocks) {
+				m.books.EXPECT().GetBookByID(gomock.Any(), "book-1").Return(&existingDune, nil)
+				m.books.EXPECT().DeleteBook(gomock.Any(), "book-1").Return(errDatabase)
+			},
+			wantErr: failed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, m := newBookService(t, service.BookServiceOptions{})
+			if tt.expect != nil {
+				tt.expect(m)
+			}
+
+			if err := s.DeleteBook(t.Context(), tt.id); !tt.wantErr(err) {
+				t.Fatalf("DeleteBook() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestBookServiceSearchBooks(t *testing.T) {
+	tests := []struct {
+		name   string
+		search repository.BookSearch
+		// wantLimit is the limit passed to the repository; 0 when it is not called
+		wantLimit int
+		wantErr   errCheck
+	}{
+		{name: "default limit", search: repository.BookSearch{Query: "dune"}, wantLimit: 50, wantErr: noError},
+		{name: "limit", search: repository.BookSearch{Limit: 10}, wantLimit: 10, wantErr: noError},
+		{name: "limit too large", search: repository.BookSearch{Limit: 1001}, wantErr: invalid},
+		{name: "negative limit", search: repository.BookSearch{Limit: -1}, wantErr: invalid},
+		{name: "negative price", search: repository.BookSearch{MinPrice: -1}, wantErr: invalid},
+		{name: "inverted prices", search: repository.BookSearch{MinPrice: 20, MaxPrice: 10}, wantErr: invalid},
+		{name: "minimum price only", search: repository.BookSearch{MinPrice: 20}, wantLimit: 50, wantErr: noError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, m := newBookService(t, service.BookServiceOptions{})
+			if tt.wantLimit != 0 {
+				m.books.EXPECT().SearchBooks(gomock.Any(), gomock.Cond(func(search repository.BookSearch) bool {
+					return search.Limit == tt.wantLimit
+				}))
+			}
+
+			books, err := s.SearchBooks(t.Context(), tt.search)
+			if !tt.wantErr(err) {
+				t.Fatalf("SearchBooks() error = %v", err)
+			}
+			// No match is an empty list, not nil, so that it encodes as []
+			if err == nil && books == nil {
+				t.Errorf("SearchBooks() = nil, want an empty list")
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/imaging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/storage"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrCoverTooLarge is returned for covers over the size or pixel limit
+var ErrCoverTooLarge = errors.New("cover is too large")
+
+// ErrUnsupportedCover is returned for covers that are not JPEG, PNG, GIF or
+// WebP images
+var ErrUnsupportedCover = errors.New("cover must be a JPEG, PNG, GIF or WebP image")
+
+// CoverService defines the interface for the cover images of books
+type CoverService interface {
+	GetCover(ctx context.Context, bookID string) (*models.BookCover, error)
+	// UploadCover stores the image in data as the cover of a book,
+	// replacing any previous one, and generates its renditions
+	UploadCover(ctx context.Context, bookID string, data []byte) (*models.BookCover, error)
+	// OpenCover opens the uploaded image of a cover, for a zero width, or
+	// its rendition of the given width, in WebP when acceptWebP is set and
+	// a WebP rendition exists
+	OpenCover(ctx context.Context, bookID string, width int, acceptWebP bool) (*CoverImage, error)
+	DeleteCover(ctx context.Context, bookID string) error
+}
+
+// CoverImage is an open image of a cover, which the caller must close
+type CoverImage struct {
+	io.ReadCloser
+	Cover       *models.BookCover
+	ContentType string
+	Size        int64
+	// ETag identifies the image; it changes with every upload
+	ETag string
+}
+
+// CoverServiceOptions configures a CoverService
+type CoverServiceOptions struct {
+	// MaxSize is the largest accepted upload, in bytes
+	MaxSize int64
+	// MaxPixels is the largest accepted width times height, which bounds
+	// the memory taken by decoding
+	MaxPixels int
+	// Widths are the widths renditions are generated at
+	Widths []int
+	// JPEGQuality is the quality of JPEG renditions, from 1 to 100
+	JPEGQuality int
+}
+
+// DefaultCoverServiceOptions returns the default limits and renditions
+func DefaultCoverServiceOptions() CoverServiceOptions {
+	return CoverServiceOptions{
+		MaxSize:     5 << 20,
+		MaxPixels:   40_000_000,
+		Widths:      []int{160, 320, 640},
+		JPEGQuality: 82,
+	}
+}
+
+// CoverServiceImpl implements the CoverService interface. Images are kept in
+// the blob store under <tenant>/covers/<book ID>/<version>/.
+type CoverServiceImpl struct {
+	covers  repository.CoverRepository
+	books   repository.BookRepository
+	blobs   storage.BlobStore
+	logger  *slog.Logger
+	options CoverServiceOptions
+	// encoders bounds the number of covers decoded and resized at once
+	encoders chan struct{}
+}
+
+// NewCoverService creates a new CoverService instance. It is also a
+// BookChangeNotifier, which removes the cover of deleted books.
+func NewCoverService(covers repository.CoverRepository, books repository.BookRepository, blobs storage.BlobStore, logger *slog.Logger, options CoverServiceOptions) *CoverServiceImpl {
+	return &CoverServiceImpl{
+		covers:   covers,
+		books:    books,
+		blobs:    blobs,
+		logger:   logger,
+		options:  options,
+		encoders: make(chan struct{}, runtime.GOMAXPROCS(0)),
+	}
+}
+
+// bookDir returns the directory of the images of a book in the blob store
+func bookDir(ctx context.Context, bookID string) (string, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return "", tenant.ErrMissing
+	}
+	return tenantID + "/covers/" + bookID, nil
+}
+
+// imageName returns the file name of the image of a cover for a width,
+// where zero is the uploaded image
+func imageName(cover *models.BookCover, width int, format string) string {
+	if width == 0 {
+		return "original" + imaging.Extension(cover.ContentType)
+	}
+	extension := ".jpg"
+	if format == models.CoverFormatWebP {
+		extension = ".webp"
+	}
+	return "w" + strconv.Itoa(width) + extension
+}
+
+// GetCover retrieves the cover of a book
+func (s *CoverServiceImpl) GetCover(ctx context.Context, bookID string) (cover *models.BookCover, err error) {
+	ctx, span := tracing.Start(ctx, "CoverService.GetCover", attribute.String("book.id", bookID))
+	defer func() { tracing.End(span, err) }()
+
+	return s.covers.GetCover(ctx, bookID)
+}
+
+// UploadCover checks and decodes the image, then stores it and its
+// renditions under a new version before pointing the cover at them. The
+// previous version is removed once the new one is saved.
+func (s *CoverServiceImpl) UploadCover(ctx context.Context, bookID string, data []byte) (cover *models.BookCover, err error) {
+	ctx, span := tracing.Start(ctx, "CoverService.UploadCover", attribute.String("book.id", bookID))
+	defer func() { tracing.End(span, err) }()
+
+	if int64(len(data)) > s.options.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrCoverTooLarge, len(data), s.options.MaxSize)
+	}
+	if len(data) == 0 {
+		return nil, newValidationError("cover is empty")
+	}
+	if _, err := s.books.GetBookByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	dir, err := bookDir(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, err := imaging.Sniff(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w, not %v", ErrUnsupportedCover, err)
+	}
+	// Decoded images take up to MaxPixels of memory each, so decoding
+	// holds an encoder, like resizing
+	s.encoders <- struct{}{}
+	release := sync.OnceFunc(func() { <-s.encoders })
+	defer release()
+
+	img, err := imaging.Decode(data, s.options.MaxPixels)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return nil, fmt.Errorf("%w: %v", ErrCoverTooLarge, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedCover, err)
+	}
+
+	cover = &models.BookCover{
+		BookID:      bookID,
+		Version:     uuid.NewString(),
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Size:        int64(len(data)),
+	}
+	versionDir := dir + "/" + cover.Version
+	if err := s.blobs.Put(ctx, versionDir+"/"+imageName(cover, 0, ""), bytes.NewReader(data), cover.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store cover: %w", err)
+	}
+	// Nothing points at the new version until it is saved
+	discard := func() {
+		if err := s.blobs.DeleteDir(context.WithoutCancel(ctx), versionDir); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete unsaved cover", "book_id", bookID, "error", err)
+		}
+	}
+
+	renditions, err := s.render(ctx, cover, img, versionDir)
+	release()
+	if err != nil {
+		discard()
+		return nil, err
+	}
+	cover.Renditions = renditions
+
+	previous, err := s.covers.GetCover(ctx, bookID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		discard()
+		return nil, err
+	}
+	if previous != nil {
+		cover.CreatedAt = previous.CreatedAt
+	}
+	if err := s.covers.SaveCover(ctx, cover); err != nil {
+		discard()
+		return nil, err
+	}
+
+	if previous != nil {
+		if err := s.blobs.DeleteDir(ctx, dir+"/"+previous.Version); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete previous cover", "book_id", bookID, "version", previous.Version, "error", err)
+		}
+	}
+	return cover, nil
+}
+
+// encodedRendition is a rendition waiting to be stored
+type encodedRendition struct {
+	format      string
+	contentType string
+	data        *bytes.Buffer
+}
+
+// render encodes and stores the renditions of a cover. WebP renditions are
+// lossless, so they are only kept when smaller than the JPEG ones.
+func (s *CoverServiceImpl) render(ctx context.Context, cover *models.BookCover, img image.Image, dir string) ([]models.CoverRendition, error) {
+	var renditions []models.CoverRendition
+	for _, width := range s.options.Widths {
+		resized := imaging.Resize(img, width)
+		bounds := resized.Bounds()
+
+		var jpeg, webp bytes.Buffer
+		if err := imaging.EncodeJPEG(&jpeg, resized, s.options.JPEGQuality); err != nil {
+			return nil, err
+		}
+		if err := imaging.EncodeWebP(&webp, resized); err != nil {
+			return nil, err
+		}
+
+		encoded := []encodedRendition{{models.CoverFormatJPEG, "image/jpeg", &jpeg}}
+		if webp.Len() < jpeg.Len() {
+			encoded = append(encoded, encodedRendition{models.CoverFormatWebP, "image/webp", &webp})
+		}
+
+		for _, e := range encoded {
+			size := int64(e.data.Len())
+			if err := s.blobs.Put(ctx, dir+"/"+imageName(cover, width, e.format), e.data, size, e.contentType); err != nil {
+				return nil, fmt.Errorf("failed to store cover rendition: %w", err)
+			}
+			renditions = append(renditions, models.CoverRendition{
+				Width:  width,
+				Actual: bounds.Dx(),
+				Height: bounds.Dy(),
+				Format: e.format,
+				Size:   size,
+			})
+		}
+	}
+	return renditions, nil
+}
+
+// OpenCover opens an image of a cover from the blob store
+func (s *CoverServiceImpl) OpenCover(ctx context.Context, bookID string, width int, acceptWebP bool) (opened *CoverImage, err error) {
+	ctx, span := tracing.Start(ctx, "CoverService.OpenCover", attribute.String("book.id", bookID), attribute.Int("cover.width", width))
+	defer func() { tracing.End(span, err) }()
+
+	if width != 0 && !slices.Contains(s.options.Widths, width) {
+		return nil, newValidationError(fmt.Sprintf("size must be original or one of %v", s.options.Widths))
+	}
+	cover, err := s.covers.GetCover(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := bookDir(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, format := cover.ContentType, ""
+	if width != 0 {
+		contentType, format = "image/jpeg", models.CoverFormatJPEG
+		if _, ok := cover.Rendition(width, models.CoverFormatWebP); ok && acceptWebP {
+			contentType, format = "image/webp", models.CoverFormatWebP
+		} else if _, ok := cover.Rendition(width, models.CoverFormatJPEG); !ok {
+			// Covers uploaded before the width was configured
+			return nil, fmt.Errorf("cover of book %s at width %d %w", bookID, width, repository.ErrNotFound)
+		}
+	}
+
+	name := imageName(cover, width, format)
+	reader, info, err := s.blobs.Get(ctx, dir+"/"+cover.Version+"/"+name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("image %s of the cover of book %s %w", name, bookID, repository.ErrNotFound)
+		}
+		return nil, err
+	}
+	return &CoverImage{
+		ReadCloser:  reader,
+		Cover:       cover,
+		ContentType: contentType,
+		Size:        info.Size,
+		ETag:        fmt.Sprintf(`"%s-%s"`, cover.Version, name),
+	}, nil
+}
+
+// DeleteCover deletes the cover of a book and every image of it
+func (s *CoverServiceImpl) DeleteCover(ctx context.Context, bookID string) (err error) {
+	ctx, span := tracing.Start(ctx, "CoverService.DeleteCover", attribute.String("book.id", bookID))
+	defer func() { tracing.End(span, err) }()
+
+	dir, err := bookDir(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if err := s.covers.DeleteCover(ctx, bookID); err != nil {
+		return err
+	}
+	// The whole directory also holds versions left over by failed uploads
+	if err := s.blobs.DeleteDir(ctx, dir); err != nil {
+		return fmt.Errorf("failed to delete cover images: %w", err)
+	}
+	return nil
+}
+
+// BookChanged removes the cover of deleted books. Deleting blobs may be
+// slow, so it happens in the background, outliving the request.
+func (s *CoverServiceImpl) BookChanged(ctx context.Context, change BookChange) {
+	if change.Type != events.BookDeleted {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		err := s.DeleteCover(ctx, change.Book.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			s.logger.ErrorContext(ctx, "Failed to delete cover of deleted book", "book_id", change.Book.ID, "error", err)
+		}
+	}()
+}
+
+var (
+	_ CoverService       = (*CoverServiceImpl)(nil)
+	_ BookChangeNotifier = (*CoverServiceImpl)(nil)
+)
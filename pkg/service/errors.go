@@ -0,0 +1,24 @@
+package service
+
+import "errors"
+
+// ValidationError reports input that was rejected by the service's business rules
+type ValidationError struct {
+	msg string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return e.msg
+}
+
+// newValidationError creates a new ValidationError with the given message
+func newValidationError(msg string) error {
+	return &ValidationError{msg: msg}
+}
+
+// IsValidationError reports whether err (or any error it wraps) is a ValidationError
+func IsValidationError(err error) bool {
+	var v *ValidationError
+	return errors.As(err, &v)
+}
@@ -0,0 +1,494 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/storage"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrFileTooLarge is returned for ebook files over the size limit
+var ErrFileTooLarge = errors.New("file is too large")
+
+// ErrUnsupportedFile is returned for files that are not in the format they
+// are uploaded as
+var ErrUnsupportedFile = errors.New("file does not match its format")
+
+// ErrInvalidLink is returned for download links that were not signed by
+// the service, or were altered
+var ErrInvalidLink = errors.New("invalid download link")
+
+// ErrLinkExpired is returned for download links past their expiry
+var ErrLinkExpired = errors.New("download link has expired")
+
+// ErrDownloadLimit is returned once a user has downloaded a file as many
+// times as allowed
+var ErrDownloadLimit = errors.New("download limit reached")
+
+// FileService defines the interface for the ebook files of books and their
+// downloads
+type FileService interface {
+	ListFiles(ctx context.Context, bookID string) ([]models.BookFile, error)
+	GetFile(ctx context.Context, bookID, format string) (*models.BookFile, error)
+	// UploadFile stores the size bytes read from body as the file of a book
+	// in a format, replacing any previous one. A non-empty checksum is the
+	// hex SHA-256 that the file must have.
+	UploadFile(ctx context.Context, bookID, format, filename string, body io.Reader, size int64, checksum string) (*models.BookFile, error)
+	DeleteFile(ctx context.Context, bookID, format string) error
+	// CreateLink signs a link to download the file of a book in a format,
+	// for the user and purchase of request, which the caller must have
+	// verified. The URL of the link is left to the caller.
+	CreateLink(ctx context.Context, bookID, format string, request models.DownloadLinkRequest) (*models.DownloadLink, error)
+	// VerifyLink checks the token of a download link and returns what it
+	// grants, provided its tenant is still active
+	VerifyLink(ctx context.Context, token string) (*DownloadGrant, error)
+	// OpenDownload opens length bytes from offset of the file a grant is
+	// for. The first request of a link counts as a download, whatever its
+	// range, and is refused once the user has reached the limit; later
+	// requests of the link, e.g. resuming the download, are not counted.
+	OpenDownload(ctx context.Context, grant *DownloadGrant, file *models.BookFile, offset, length int64) (io.ReadCloser, error)
+}
+
+// FileServiceOptions configures a FileService
+type FileServiceOptions struct {
+	// MaxSize is the largest accepted upload, in bytes
+	MaxSize int64
+	// LinkTTL is the lifetime of links, unless a shorter or longer one up
+	// to MaxLinkTTL is asked for
+	LinkTTL    time.Duration
+	MaxLinkTTL time.Duration
+	// DownloadLimit is how many times a user may download a file; zero for
+	// no limit
+	DownloadLimit int
+	// SigningKey signs the download links
+	SigningKey []byte
+}
+
+// DefaultFileServiceOptions returns the default limits of files and links,
+// without a signing key
+func DefaultFileServiceOptions() FileServiceOptions {
+	return FileServiceOptions{
+		MaxSize:       100 << 20,
+		LinkTTL:       15 * time.Minute,
+		MaxLinkTTL:    24 * time.Hour,
+		DownloadLimit: 5,
+	}
+}
+
+// FileServiceImpl implements the FileService interface. Files are kept in
+// the blob store under <tenant>/files/<book ID>/<format>/.
+type FileServiceImpl struct {
+	files   repository.FileRepository
+	books   repository.BookRepository
+	tenants repository.TenantRepository
+	blobs   storage.BlobStore
+	logger  *slog.Logger
+	options FileServiceOptions
+	now     func() time.Time
+}
+
+// NewFileService creates a new FileService instance. It is also a
+// BookChangeNotifier, which removes the files of deleted books.
+func NewFileService(files repository.FileRepository, books repository.BookRepository, tenants repository.TenantRepository, blobs storage.BlobStore, logger *slog.Logger, options FileServiceOptions) *FileServiceImpl {
+	return &FileServiceImpl{
+		files:   files,
+		books:   books,
+		tenants: tenants,
+		blobs:   blobs,
+		logger:  logger,
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// filesDir returns the directory of the files of a book in the blob store
+func filesDir(ctx context.Context, bookID string) (string, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return "", tenant.ErrMissing
+	}
+	return tenantID + "/files/" + bookID, nil
+}
+
+// fileKey returns the key of the content of a file
+func fileKey(dir string, file *models.BookFile) string {
+	return dir + "/" + file.Format + "/" + file.Version + "." + file.Format
+}
+
+// checkFormat returns a ValidationError for unknown formats
+func checkFormat(format string) error {
+	if _, ok := models.FileContentTypes[format]; !ok {
+		return newValidationError(fmt.Sprintf("format must be %s or %s", models.FileFormatEPUB, models.FileFormatPDF))
+	}
+	return nil
+}
+
+// ListFiles retrieves the files of a book
+func (s *FileServiceImpl) ListFiles(ctx context.Context, bookID string) (files []models.BookFile, err error) {
+	ctx, span := tracing.Start(ctx, "FileService.ListFiles", attribute.String("book.id", bookID))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.books.GetBookByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return s.files.ListFiles(ctx, bookID)
+}
+
+// GetFile retrieves the file of a book in a format
+func (s *FileServiceImpl) GetFile(ctx context.Context, bookID, format string) (file *models.BookFile, err error) {
+	ctx, span := tracing.Start(ctx, "FileService.GetFile", attribute.String("book.id", bookID), attribute.String("file.format", format))
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+	return s.files.GetFile(ctx, bookID, format)
+}
+
+// UploadFile checks the size and format of the file, then streams it to
+// storage under a new version, checking its checksum on the way, before
+// pointing the file at it. The previous version is removed once the new one
+// is saved.
+func (s *FileServiceImpl) UploadFile(ctx context.Context, bookID, format, filename string, body io.Reader, size int64, checksum string) (file *models.BookFile, err error) {
+	ctx, span := tracing.Start(ctx, "FileService.UploadFile", attribute.String("book.id", bookID), attribute.String("file.format", format))
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+	if size > s.options.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrFileTooLarge, size, s.options.MaxSize)
+	}
+	if size <= 0 {
+		return nil, newValidationError("file is empty")
+	}
+
+	book, err := s.books.GetBookByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if filename == "" {
+		filename = book.Name
+	}
+	dir, err := filesDir(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReaderSize(body, sniffSize)
+	head, err := reader.Peek(int(min(size, sniffSize)))
+	if err != nil && len(head) < int(min(size, sniffSize)) {
+		return nil, newValidationError("file is shorter than its size")
+	}
+	if !sniffFormat(head, format) {
+		return nil, fmt.Errorf("%w: not a valid %s file", ErrUnsupportedFile, strings.ToUpper(format))
+	}
+
+	file = &models.BookFile{
+		BookID:      bookID,
+		Format:      format,
+		Version:     uuid.NewString(),
+		Filename:    cleanFilename(filename, format),
+		ContentType: models.FileContentTypes[format],
+		Size:        size,
+	}
+	key := fileKey(dir, file)
+	hash := sha256.New()
+	if err := s.blobs.Put(ctx, key, io.TeeReader(reader, hash), file.Size, file.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	// Nothing points at the new version until it is saved
+	discard := func() {
+		if err := s.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete unsaved file", "book_id", bookID, "format", format, "error", err)
+		}
+	}
+
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if checksum != "" && !strings.EqualFold(checksum, file.SHA256) {
+		discard()
+		return nil, newValidationError(fmt.Sprintf("file checksum %s does not match the expected %s", file.SHA256, strings.ToLower(checksum)))
+	}
+
+	previous, err := s.files.GetFile(ctx, bookID, format)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		discard()
+		return nil, err
+	}
+	if err := s.files.SaveFile(ctx, file); err != nil {
+		discard()
+		return nil, err
+	}
+
+	if previous != nil {
+		if err := s.blobs.Delete(ctx, fileKey(dir, previous)); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete previous file", "book_id", bookID, "format", format, "version", previous.Version, "error", err)
+		}
+	}
+	// Read back the download count and creation time the upload kept
+	return s.files.GetFile(ctx, bookID, format)
+}
+
+// sniffSize is how much of a file sniffFormat is given
+const sniffSize = 4096
+
+// sniffFormat reports whether data starts like a file of format. EPUB files
+// are ZIP archives whose first entry is an uncompressed "mimetype" file
+// holding their content type.
+func sniffFormat(data []byte, format string) bool {
+	switch format {
+	case models.FileFormatEPUB:
+		const header = 30
+		if len(data) < header || !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+			return false
+		}
+		nameLength := int(binary.LittleEndian.Uint16(data[26:]))
+		extraLength := int(binary.LittleEndian.Uint16(data[28:]))
+		content := header + nameLength + extraLength
+		return len(data) >= content &&
+			string(data[header:header+nameLength]) == "mimetype" &&
+			bytes.HasPrefix(data[content:], []byte(models.FileContentTypes[models.FileFormatEPUB]))
+	case models.FileFormatPDF:
+		// Readers accept the header anywhere in the first kilobyte
+		return bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-"))
+	default:
+		return false
+	}
+}
+
+// cleanFilename turns the name of an uploaded file, or a book, into the
+// name offered to downloaders: without directories or control characters,
+// and with the extension of its format
+func cleanFilename(name string, format string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '/' {
+			return -1
+		}
+		return r
+	}, name)
+
+	extension := "." + format
+	name = strings.TrimSuffix(name, extension)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if name == "" {
+		name = "book"
+	}
+	// The column holds 191 characters
+	if runes := []rune(name); len(runes) > 180 {
+		name = strings.TrimSpace(string(runes[:180]))
+	}
+	return name + extension
+}
+
+// DeleteFile deletes the file of a book in a format and its download counts
+func (s *FileServiceImpl) DeleteFile(ctx context.Context, bookID, format string) (err error) {
+	ctx, span := tracing.Start(ctx, "FileService.DeleteFile", attribute.String("book.id", bookID), attribute.String("file.format", format))
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	dir, err := filesDir(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if err := s.files.DeleteFile(ctx, bookID, format); err != nil {
+		return err
+	}
+	// The directory also holds versions left over by failed uploads
+	if err := s.blobs.DeleteDir(ctx, dir+"/"+format); err != nil {
+		return fmt.Errorf("failed to delete file content: %w", err)
+	}
+	return nil
+}
+
+// CreateLink checks the user and purchase, that the file exists and that
+// the user may still download it, then signs a link for them
+func (s *FileServiceImpl) CreateLink(ctx context.Context, bookID, format string, request models.DownloadLinkRequest) (link *models.DownloadLink, err error) {
+	ctx, span := tracing.Start(ctx, "FileService.CreateLink", attribute.String("book.id", bookID), attribute.String("file.format", format))
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := linkIdentifier("user_id", request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if request.PurchaseID, err = linkIdentifier("purchase_id", request.PurchaseID); err != nil {
+		return nil, err
+	}
+	ttl := s.options.LinkTTL
+	if request.ExpiresIn != 0 {
+		ttl = time.Duration(request.ExpiresIn) * time.Second
+	}
+	if ttl <= 0 || ttl > s.options.MaxLinkTTL {
+		return nil, newValidationError(fmt.Sprintf("expires_in must be between 1 and %d seconds", int(s.options.MaxLinkTTL.Seconds())))
+	}
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+	if _, err := s.GetFile(ctx, bookID, format); err != nil {
+		return nil, err
+	}
+
+	downloads := 0
+	previous, err := s.files.GetDownloads(ctx, bookID, format, userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if previous != nil {
+		downloads = previous.Downloads
+	}
+	if s.options.DownloadLimit > 0 && downloads >= s.options.DownloadLimit {
+		return nil, fmt.Errorf("%w: user %s has downloaded the %s file %d times", ErrDownloadLimit, userID, format, downloads)
+	}
+
+	grant := DownloadGrant{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		BookID:     bookID,
+		Format:     format,
+		UserID:     userID,
+		PurchaseID: request.PurchaseID,
+		Expires:    s.now().Add(ttl).Unix(),
+	}
+	return &models.DownloadLink{
+		Token:         signGrant(s.options.SigningKey, grant),
+		ExpiresAt:     grant.ExpiresAt().UTC(),
+		Downloads:     downloads,
+		DownloadLimit: s.options.DownloadLimit,
+	}, nil
+}
+
+// maxLinkIdentifierLength is the longest user or purchase ID of a link,
+// which their columns hold
+const maxLinkIdentifierLength = 191
+
+// linkIdentifier returns the user or purchase ID value named field without
+// surrounding spaces, or a ValidationError when it is empty, too long or
+// holds control characters
+func linkIdentifier(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return "", newValidationError(field + " is required")
+	case len(value) > maxLinkIdentifierLength:
+		return "", newValidationError(fmt.Sprintf("%s cannot be longer than %d bytes", field, maxLinkIdentifierLength))
+	case strings.ContainsFunc(value, unicode.IsControl):
+		return "", newValidationError(field + " cannot contain control characters")
+	}
+	return value, nil
+}
+
+// VerifyLink checks the signature and expiry of a token, then its tenant
+func (s *FileServiceImpl) VerifyLink(ctx context.Context, token string) (grant *DownloadGrant, err error) {
+	ctx, span := tracing.Start(ctx, "FileService.VerifyLink")
+	defer func() { tracing.End(span, err) }()
+
+	grant, err = verifyGrant(s.options.SigningKey, token, s.now())
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.tenants.GetTenantByID(ctx, grant.TenantID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !current.Active) {
+		return nil, fmt.Errorf("tenant %s of the link %w", grant.TenantID, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// OpenDownload opens the content of the file, then counts the download of
+// the link before any of it is sent, so that content that cannot be read is
+// not counted
+func (s *FileServiceImpl) OpenDownload(ctx context.Context, grant *DownloadGrant, file *models.BookFile, offset, length int64) (reader io.ReadCloser, err error) {
+	ctx, span := tracing.Start(ctx, "FileService.OpenDownload",
+		attribute.String("book.id", file.BookID), attribute.String("file.format", file.Format), attribute.Int64("download.offset", offset))
+	defer func() { tracing.End(span, err) }()
+
+	dir, err := filesDir(ctx, file.BookID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, _, err = s.blobs.GetRange(ctx, fileKey(dir, file), offset, length)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("content of the %s file of book %s %w", file.Format, file.BookID, repository.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	counted, err := s.files.CountDownload(ctx, &models.FileDownload{
+		BookID:     file.BookID,
+		Format:     file.Format,
+		UserID:     grant.UserID,
+		PurchaseID: grant.PurchaseID,
+	}, &models.FileDownloadLink{
+		LinkID:    grant.ID,
+		ExpiresAt: grant.ExpiresAt(),
+	}, s.options.DownloadLimit)
+	if err == nil && !counted {
+		err = fmt.Errorf("%w: user %s may download the %s file %d times", ErrDownloadLimit, grant.UserID, file.Format, s.options.DownloadLimit)
+	}
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// BookChanged removes the files of deleted books, in the background like
+// their covers
+func (s *FileServiceImpl) BookChanged(ctx context.Context, change BookChange) {
+	if change.Type != events.BookDeleted {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.deleteFiles(ctx, change.Book.ID); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete files of deleted book", "book_id", change.Book.ID, "error", err)
+		}
+	}()
+}
+
+// deleteFiles deletes every file of a book and its download counts
+func (s *FileServiceImpl) deleteFiles(ctx context.Context, bookID string) error {
+	dir, err := filesDir(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if err := s.files.DeleteFiles(ctx, bookID); err != nil {
+		return err
+	}
+	if err := s.blobs.DeleteDir(ctx, dir); err != nil {
+		return fmt.Errorf("failed to delete file content: %w", err)
+	}
+	return nil
+}
+
+var (
+	_ FileService        = (*FileServiceImpl)(nil)
+	_ BookChangeNotifier = (*FileServiceImpl)(nil)
+)
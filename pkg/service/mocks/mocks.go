@@ -0,0 +1,7 @@
+// Package mocks holds gomock mocks of the interfaces the services depend on,
+// for table-driven service tests. Regenerate them with go generate.
+package mocks
+
+//go:generate go tool mockgen -destination=repository.go -package=mocks github.com/dtg-lucifer/go-bookstore/pkg/repository BookRepository,AuthorRepository,CoverRepository,FileRepository
+//go:generate go tool mockgen -destination=service.go -package=mocks github.com/dtg-lucifer/go-bookstore/pkg/service TransactionManager,BookChangeNotifier
+//go:generate go tool mockgen -destination=storage.go -package=mocks github.com/dtg-lucifer/go-bookstore/pkg/storage BlobStore
@@ -0,0 +1,527 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/dtg-lucifer/go-bookstore/pkg/repository (interfaces: BookRepository,AuthorRepository,CoverRepository,FileRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=repository.go -package=mocks github.com/dtg-lucifer/go-bookstore/pkg/repository BookRepository,AuthorRepository,CoverRepository,FileRepository
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/dtg-lucifer/go-bookstore/pkg/models"
+	pagination "github.com/dtg-lucifer/go-bookstore/pkg/pagination"
+	repository "github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBookRepository is a mock of BookRepository interface.
+type MockBookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBookRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockBookRepositoryMockRecorder is the mock recorder for MockBookRepository.
+type MockBookRepositoryMockRecorder struct {
+	mock *MockBookRepository
+}
+
+// NewMockBookRepository creates a new mock instance.
+func NewMockBookRepository(ctrl *gomock.Controller) *MockBookRepository {
+	mock := &MockBookRepository{ctrl: ctrl}
+	mock.recorder = &MockBookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBookRepository) EXPECT() *MockBookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountBooksByAuthorIDs mocks base method.
+func (m *MockBookRepository) CountBooksByAuthorIDs(ctx context.Context, authorIDs []string) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountBooksByAuthorIDs", ctx, authorIDs)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountBooksByAuthorIDs indicates an expected call of CountBooksByAuthorIDs.
+func (mr *MockBookRepositoryMockRecorder) CountBooksByAuthorIDs(ctx, authorIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountBooksByAuthorIDs", reflect.TypeOf((*MockBookRepository)(nil).CountBooksByAuthorIDs), ctx, authorIDs)
+}
+
+// CreateBook mocks base method.
+func (m *MockBookRepository) CreateBook(ctx context.Context, book *models.Book) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBook", ctx, book)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBook indicates an expected call of CreateBook.
+func (mr *MockBookRepositoryMockRecorder) CreateBook(ctx, book any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBook", reflect.TypeOf((*MockBookRepository)(nil).CreateBook), ctx, book)
+}
+
+// DeleteBook mocks base method.
+func (m *MockBookRepository) DeleteBook(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBook indicates an expected call of DeleteBook.
+func (mr *MockBookRepositoryMockRecorder) DeleteBook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockBookRepository)(nil).DeleteBook), ctx, id)
+}
+
+// GetAllBooks mocks base method.
+func (m *MockBookRepository) GetAllBooks(ctx context.Context) ([]models.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllBooks", ctx)
+	ret0, _ := ret[0].([]models.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllBooks indicates an expected call of GetAllBooks.
+func (mr *MockBookRepositoryMockRecorder) GetAllBooks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllBooks", reflect.TypeOf((*MockBookRepository)(nil).GetAllBooks), ctx)
+}
+
+// GetBookByID mocks base method.
+func (m *MockBookRepository) GetBookByID(ctx context.Context, id string) (*models.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByID", ctx, id)
+	ret0, _ := ret[0].(*models.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByID indicates an expected call of GetBookByID.
+func (mr *MockBookRepositoryMockRecorder) GetBookByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByID", reflect.TypeOf((*MockBookRepository)(nil).GetBookByID), ctx, id)
+}
+
+// GetBooksByAuthorIDs mocks base method.
+func (m *MockBookRepository) GetBooksByAuthorIDs(ctx context.Context, authorIDs []string) ([]models.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBooksByAuthorIDs", ctx, authorIDs)
+	ret0, _ := ret[0].([]models.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBooksByAuthorIDs indicates an expected call of GetBooksByAuthorIDs.
+func (mr *MockBookRepositoryMockRecorder) GetBooksByAuthorIDs(ctx, authorIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooksByAuthorIDs", reflect.TypeOf((*MockBookRepository)(nil).GetBooksByAuthorIDs), ctx, authorIDs)
+}
+
+// LastModified mocks base method.
+func (m *MockBookRepository) LastModified(ctx context.Context) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastModified", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastModified indicates an expected call of LastModified.
+func (mr *MockBookRepositoryMockRecorder) LastModified(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastModified", reflect.TypeOf((*MockBookRepository)(nil).LastModified), ctx)
+}
+
+// MergeAuthors mocks base method.
+func (m *MockBookRepository) MergeAuthors(ctx context.Context, survivorID string, duplicateIDs []string) (*models.Author, []models.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeAuthors", ctx, survivorID, duplicateIDs)
+	ret0, _ := ret[0].(*models.Author)
+	ret1, _ := ret[1].([]models.Book)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MergeAuthors indicates an expected call of MergeAuthors.
+func (mr *MockBookRepositoryMockRecorder) MergeAuthors(ctx, survivorID, duplicateIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeAuthors", reflect.TypeOf((*MockBookRepository)(nil).MergeAuthors), ctx, survivorID, duplicateIDs)
+}
+
+// RestoreBook mocks base method.
+func (m *MockBookRepository) RestoreBook(ctx context.Context, book *models.Book) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBook", ctx, book)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreBook indicates an expected call of RestoreBook.
+func (mr *MockBookRepositoryMockRecorder) RestoreBook(ctx, book any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBook", reflect.TypeOf((*MockBookRepository)(nil).RestoreBook), ctx, book)
+}
+
+// SearchBooks mocks base method.
+func (m *MockBookRepository) SearchBooks(ctx context.Context, search repository.BookSearch) ([]models.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchBooks", ctx, search)
+	ret0, _ := ret[0].([]models.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchBooks indicates an expected call of SearchBooks.
+func (mr *MockBookRepositoryMockRecorder) SearchBooks(ctx, search any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchBooks", reflect.TypeOf((*MockBookRepository)(nil).SearchBooks), ctx, search)
+}
+
+// UpdateBook mocks base method.
+func (m *MockBookRepository) UpdateBook(ctx context.Context, id string, book *models.Book) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, id, book)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockBookRepositoryMockRecorder) UpdateBook(ctx, id, book any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockBookRepository)(nil).UpdateBook), ctx, id, book)
+}
+
+// MockAuthorRepository is a mock of AuthorRepository interface.
+type MockAuthorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthorRepositoryMockRecorder is the mock recorder for MockAuthorRepository.
+type MockAuthorRepositoryMockRecorder struct {
+	mock *MockAuthorRepository
+}
+
+// NewMockAuthorRepository creates a new mock instance.
+func NewMockAuthorRepository(ctrl *gomock.Controller) *MockAuthorRepository {
+	mock := &MockAuthorRepository{ctrl: ctrl}
+	mock.recorder = &MockAuthorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorRepository) EXPECT() *MockAuthorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAuthor mocks base method.
+func (m *MockAuthorRepository) CreateAuthor(ctx context.Context, author *models.Author) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuthor", ctx, author)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuthor indicates an expected call of CreateAuthor.
+func (mr *MockAuthorRepositoryMockRecorder) CreateAuthor(ctx, author any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuthor", reflect.TypeOf((*MockAuthorRepository)(nil).CreateAuthor), ctx, author)
+}
+
+// FindAuthorByName mocks base method.
+func (m *MockAuthorRepository) FindAuthorByName(ctx context.Context, name string) (*models.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAuthorByName", ctx, name)
+	ret0, _ := ret[0].(*models.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAuthorByName indicates an expected call of FindAuthorByName.
+func (mr *MockAuthorRepositoryMockRecorder) FindAuthorByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAuthorByName", reflect.TypeOf((*MockAuthorRepository)(nil).FindAuthorByName), ctx, name)
+}
+
+// GetAllAuthors mocks base method.
+func (m *MockAuthorRepository) GetAllAuthors(ctx context.Context) ([]models.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAuthors", ctx)
+	ret0, _ := ret[0].([]models.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAuthors indicates an expected call of GetAllAuthors.
+func (mr *MockAuthorRepositoryMockRecorder) GetAllAuthors(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAuthors", reflect.TypeOf((*MockAuthorRepository)(nil).GetAllAuthors), ctx)
+}
+
+// GetAuthorByID mocks base method.
+func (m *MockAuthorRepository) GetAuthorByID(ctx context.Context, id string) (*models.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthorByID", ctx, id)
+	ret0, _ := ret[0].(*models.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthorByID indicates an expected call of GetAuthorByID.
+func (mr *MockAuthorRepositoryMockRecorder) GetAuthorByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthorByID", reflect.TypeOf((*MockAuthorRepository)(nil).GetAuthorByID), ctx, id)
+}
+
+// GetAuthorsByIDs mocks base method.
+func (m *MockAuthorRepository) GetAuthorsByIDs(ctx context.Context, ids []string) ([]models.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthorsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]models.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthorsByIDs indicates an expected call of GetAuthorsByIDs.
+func (mr *MockAuthorRepositoryMockRecorder) GetAuthorsByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthorsByIDs", reflect.TypeOf((*MockAuthorRepository)(nil).GetAuthorsByIDs), ctx, ids)
+}
+
+// ListAuthors mocks base method.
+func (m *MockAuthorRepository) ListAuthors(ctx context.Context, limit int, after *pagination.Key) ([]models.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuthors", ctx, limit, after)
+	ret0, _ := ret[0].([]models.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuthors indicates an expected call of ListAuthors.
+func (mr *MockAuthorRepositoryMockRecorder) ListAuthors(ctx, limit, after any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuthors", reflect.TypeOf((*MockAuthorRepository)(nil).ListAuthors), ctx, limit, after)
+}
+
+// UpdateAuthor mocks base method.
+func (m *MockAuthorRepository) UpdateAuthor(ctx context.Context, author *models.Author) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAuthor", ctx, author)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAuthor indicates an expected call of UpdateAuthor.
+func (mr *MockAuthorRepositoryMockRecorder) UpdateAuthor(ctx, author any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAuthor", reflect.TypeOf((*MockAuthorRepository)(nil).UpdateAuthor), ctx, author)
+}
+
+// MockCoverRepository is a mock of CoverRepository interface.
+type MockCoverRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCoverRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCoverRepositoryMockRecorder is the mock recorder for MockCoverRepository.
+type MockCoverRepositoryMockRecorder struct {
+	mock *MockCoverRepository
+}
+
+// NewMockCoverRepository creates a new mock instance.
+func NewMockCoverRepository(ctrl *gomock.Controller) *MockCoverRepository {
+	mock := &MockCoverRepository{ctrl: ctrl}
+	mock.recorder = &MockCoverRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCoverRepository) EXPECT() *MockCoverRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteCover mocks base method.
+func (m *MockCoverRepository) DeleteCover(ctx context.Context, bookID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCover", ctx, bookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCover indicates an expected call of DeleteCover.
+func (mr *MockCoverRepositoryMockRecorder) DeleteCover(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCover", reflect.TypeOf((*MockCoverRepository)(nil).DeleteCover), ctx, bookID)
+}
+
+// GetCover mocks base method.
+func (m *MockCoverRepository) GetCover(ctx context.Context, bookID string) (*models.BookCover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCover", ctx, bookID)
+	ret0, _ := ret[0].(*models.BookCover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCover indicates an expected call of GetCover.
+func (mr *MockCoverRepositoryMockRecorder) GetCover(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCover", reflect.TypeOf((*MockCoverRepository)(nil).GetCover), ctx, bookID)
+}
+
+// SaveCover mocks base method.
+func (m *MockCoverRepository) SaveCover(ctx context.Context, cover *models.BookCover) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCover", ctx, cover)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCover indicates an expected call of SaveCover.
+func (mr *MockCoverRepositoryMockRecorder) SaveCover(ctx, cover any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCover", reflect.TypeOf((*MockCoverRepository)(nil).SaveCover), ctx, cover)
+}
+
+// MockFileRepository is a mock of FileRepository interface.
+type MockFileRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockFileRepositoryMockRecorder is the mock recorder for MockFileRepository.
+type MockFileRepositoryMockRecorder struct {
+	mock *MockFileRepository
+}
+
+// NewMockFileRepository creates a new mock instance.
+func NewMockFileRepository(ctrl *gomock.Controller) *MockFileRepository {
+	mock := &MockFileRepository{ctrl: ctrl}
+	mock.recorder = &MockFileRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileRepository) EXPECT() *MockFileRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountDownload mocks base method.
+func (m *MockFileRepository) CountDownload(ctx context.Context, download *models.FileDownload, link *models.FileDownloadLink, limit int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDownload", ctx, download, link, limit)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDownload indicates an expected call of CountDownload.
+func (mr *MockFileRepositoryMockRecorder) CountDownload(ctx, download, link, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDownload", reflect.TypeOf((*MockFileRepository)(nil).CountDownload), ctx, download, link, limit)
+}
+
+// DeleteFile mocks base method.
+func (m *MockFileRepository) DeleteFile(ctx context.Context, bookID, format string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", ctx, bookID, format)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockFileRepositoryMockRecorder) DeleteFile(ctx, bookID, format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockFileRepository)(nil).DeleteFile), ctx, bookID, format)
+}
+
+// DeleteFiles mocks base method.
+func (m *MockFileRepository) DeleteFiles(ctx context.Context, bookID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFiles", ctx, bookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFiles indicates an expected call of DeleteFiles.
+func (mr *MockFileRepositoryMockRecorder) DeleteFiles(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFiles", reflect.TypeOf((*MockFileRepository)(nil).DeleteFiles), ctx, bookID)
+}
+
+// GetDownloads mocks base method.
+func (m *MockFileRepository) GetDownloads(ctx context.Context, bookID, format, userID string) (*models.FileDownload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDownloads", ctx, bookID, format, userID)
+	ret0, _ := ret[0].(*models.FileDownload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDownloads indicates an expected call of GetDownloads.
+func (mr *MockFileRepositoryMockRecorder) GetDownloads(ctx, bookID, format, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDownloads", reflect.TypeOf((*MockFileRepository)(nil).GetDownloads), ctx, bookID, format, userID)
+}
+
+// GetFile mocks base method.
+func (m *MockFileRepository) GetFile(ctx context.Context, bookID, format string) (*models.BookFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", ctx, bookID, format)
+	ret0, _ := ret[0].(*models.BookFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFile indicates an expected call of GetFile.
+func (mr *MockFileRepositoryMockRecorder) GetFile(ctx, bookID, format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockFileRepository)(nil).GetFile), ctx, bookID, format)
+}
+
+// ListFiles mocks base method.
+func (m *MockFileRepository) ListFiles(ctx context.Context, bookID string) ([]models.BookFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles", ctx, bookID)
+	ret0, _ := ret[0].([]models.BookFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFiles indicates an expected call of ListFiles.
+func (mr *MockFileRepositoryMockRecorder) ListFiles(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockFileRepository)(nil).ListFiles), ctx, bookID)
+}
+
+// SaveFile mocks base method.
+func (m *MockFileRepository) SaveFile(ctx context.Context, file *models.BookFile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFile", ctx, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFile indicates an expected call of SaveFile.
+func (mr *MockFileRepositoryMockRecorder) SaveFile(ctx, file any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFile", reflect.TypeOf((*MockFileRepository)(nil).SaveFile), ctx, file)
+}
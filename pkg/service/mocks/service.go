@@ -0,0 +1,104 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/dtg-lucifer/go-bookstore/pkg/service (interfaces: TransactionManager,BookChangeNotifier)
+//
+// Generated by this command:
+//
+//	mockgen -destination=service.go -package=mocks github.com/dtg-lucifer/go-bookstore/pkg/service TransactionManager,BookChangeNotifier
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/dtg-lucifer/go-bookstore/pkg/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTransactionManager is a mock of TransactionManager interface.
+type MockTransactionManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockTransactionManagerMockRecorder is the mock recorder for MockTransactionManager.
+type MockTransactionManagerMockRecorder struct {
+	mock *MockTransactionManager
+}
+
+// NewMockTransactionManager creates a new mock instance.
+func NewMockTransactionManager(ctrl *gomock.Controller) *MockTransactionManager {
+	mock := &MockTransactionManager{ctrl: ctrl}
+	mock.recorder = &MockTransactionManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionManager) EXPECT() *MockTransactionManagerMockRecorder {
+	return m.recorder
+}
+
+// AfterCommit mocks base method.
+func (m *MockTransactionManager) AfterCommit(ctx context.Context, fn func()) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AfterCommit", ctx, fn)
+}
+
+// AfterCommit indicates an expected call of AfterCommit.
+func (mr *MockTransactionManagerMockRecorder) AfterCommit(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterCommit", reflect.TypeOf((*MockTransactionManager)(nil).AfterCommit), ctx, fn)
+}
+
+// WithinTransaction mocks base method.
+func (m *MockTransactionManager) WithinTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockTransactionManagerMockRecorder) WithinTransaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockTransactionManager)(nil).WithinTransaction), ctx, fn)
+}
+
+// MockBookChangeNotifier is a mock of BookChangeNotifier interface.
+type MockBookChangeNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockBookChangeNotifierMockRecorder
+	isgomock struct{}
+}
+
+// MockBookChangeNotifierMockRecorder is the mock recorder for MockBookChangeNotifier.
+type MockBookChangeNotifierMockRecorder struct {
+	mock *MockBookChangeNotifier
+}
+
+// NewMockBookChangeNotifier creates a new mock instance.
+func NewMockBookChangeNotifier(ctrl *gomock.Controller) *MockBookChangeNotifier {
+	mock := &MockBookChangeNotifier{ctrl: ctrl}
+	mock.recorder = &MockBookChangeNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBookChangeNotifier) EXPECT() *MockBookChangeNotifierMockRecorder {
+	return m.recorder
+}
+
+// BookChanged mocks base method.
+func (m *MockBookChangeNotifier) BookChanged(ctx context.Context, change service.BookChange) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BookChanged", ctx, change)
+}
+
+// BookChanged indicates an expected call of BookChanged.
+func (mr *MockBookChangeNotifierMockRecorder) BookChanged(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BookChanged", reflect.TypeOf((*MockBookChangeNotifier)(nil).BookChanged), ctx, change)
+}
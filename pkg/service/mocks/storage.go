@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/dtg-lucifer/go-bookstore/pkg/storage (interfaces: BlobStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=storage.go -package=mocks github.com/dtg-lucifer/go-bookstore/pkg/storage BlobStore
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	storage "github.com/dtg-lucifer/go-bookstore/pkg/storage"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBlobStore is a mock of BlobStore interface.
+type MockBlobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlobStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockBlobStoreMockRecorder is the mock recorder for MockBlobStore.
+type MockBlobStoreMockRecorder struct {
+	mock *MockBlobStore
+}
+
+// NewMockBlobStore creates a new mock instance.
+func NewMockBlobStore(ctrl *gomock.Controller) *MockBlobStore {
+	mock := &MockBlobStore{ctrl: ctrl}
+	mock.recorder = &MockBlobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlobStore) EXPECT() *MockBlobStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBlobStoreMockRecorder) Delete(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBlobStore)(nil).Delete), ctx, key)
+}
+
+// DeleteDir mocks base method.
+func (m *MockBlobStore) DeleteDir(ctx context.Context, dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDir", ctx, dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDir indicates an expected call of DeleteDir.
+func (mr *MockBlobStoreMockRecorder) DeleteDir(ctx, dir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDir", reflect.TypeOf((*MockBlobStore)(nil).DeleteDir), ctx, dir)
+}
+
+// Get mocks base method.
+func (m *MockBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, *storage.Info, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(*storage.Info)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBlobStoreMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBlobStore)(nil).Get), ctx, key)
+}
+
+// GetRange mocks base method.
+func (m *MockBlobStore) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *storage.Info, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRange", ctx, key, offset, length)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(*storage.Info)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRange indicates an expected call of GetRange.
+func (mr *MockBlobStoreMockRecorder) GetRange(ctx, key, offset, length any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRange", reflect.TypeOf((*MockBlobStore)(nil).GetRange), ctx, key, offset, length)
+}
+
+// Put mocks base method.
+func (m *MockBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, key, r, size, contentType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockBlobStoreMockRecorder) Put(ctx, key, r, size, contentType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockBlobStore)(nil).Put), ctx, key, r, size, contentType)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+)
+
+// BookChange describes a committed change to a book
+type BookChange struct {
+	Type events.Type
+	Book models.Book
+}
+
+// BookChangeNotifier is told about every committed book change.
+// BookChanged is called synchronously on the write path and must not block.
+type BookChangeNotifier interface {
+	BookChanged(ctx context.Context, change BookChange)
+}
+
+// notify hands a book change to every registered notifier once the
+// surrounding transaction, if any, commits
+func (s *BookServiceImpl) notify(ctx context.Context, eventType events.Type, book models.Book) {
+	s.tx.AfterCommit(ctx, func() {
+		for _, n := range s.notifiers {
+			n.BookChanged(ctx, BookChange{Type: eventType, Book: book})
+		}
+	})
+}
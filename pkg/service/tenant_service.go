@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// DefaultCurrency is the currency of tenants created without one
+const DefaultCurrency = "USD"
+
+// currencyPattern matches ISO 4217 currency codes
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// TenantService defines the interface for tenant administration
+type TenantService interface {
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+	GetTenantByID(ctx context.Context, id string) (*models.Tenant, error)
+	CreateTenant(ctx context.Context, tenant *models.Tenant) error
+	UpdateTenant(ctx context.Context, id string, update models.TenantUpdate) (*models.Tenant, error)
+	DeleteTenant(ctx context.Context, id string) error
+}
+
+// TenantServiceImpl implements the TenantService interface
+type TenantServiceImpl struct {
+	repo   repository.TenantRepository
+	logger *slog.Logger
+}
+
+// NewTenantService creates a new TenantService instance
+func NewTenantService(repo repository.TenantRepository, logger *slog.Logger) TenantService {
+	return &TenantServiceImpl{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ListTenants retrieves all tenants
+func (s *TenantServiceImpl) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	tenants, err := s.repo.ListTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenants) == 0 {
+		return []models.Tenant{}, nil
+	}
+
+	return tenants, nil
+}
+
+// GetTenantByID retrieves a tenant by its ID
+func (s *TenantServiceImpl) GetTenantByID(ctx context.Context, id string) (*models.Tenant, error) {
+	if id == "" {
+		return nil, newValidationError("tenant ID cannot be empty")
+	}
+
+	return s.repo.GetTenantByID(ctx, id)
+}
+
+// CreateTenant creates a new, active tenant
+func (s *TenantServiceImpl) CreateTenant(ctx context.Context, t *models.Tenant) error {
+	if t == nil {
+		return newValidationError("tenant cannot be nil")
+	}
+
+	if !tenant.ValidID(t.ID) {
+		return newValidationError("tenant ID must be up to 63 lower case letters, digits and dashes")
+	}
+	if t.Currency == "" {
+		t.Currency = DefaultCurrency
+	}
+	t.Active = true
+
+	if err := validateTenant(t); err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateTenant(ctx, t); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Tenant created", "tenant_id", t.ID)
+	return nil
+}
+
+// UpdateTenant changes the fields of a tenant set in update
+func (s *TenantServiceImpl) UpdateTenant(ctx context.Context, id string, update models.TenantUpdate) (*models.Tenant, error) {
+	existing, err := s.GetTenantByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		existing.Name = *update.Name
+	}
+	if update.Currency != nil {
+		existing.Currency = *update.Currency
+	}
+	if update.RateLimit != nil {
+		existing.RateLimit = *update.RateLimit
+	}
+	if update.Active != nil {
+		existing.Active = *update.Active
+	}
+
+	if err := validateTenant(existing); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTenant(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Tenant updated", "tenant_id", id, "active", existing.Active)
+	return existing, nil
+}
+
+// DeleteTenant deletes a tenant
+func (s *TenantServiceImpl) DeleteTenant(ctx context.Context, id string) error {
+	if id == "" {
+		return newValidationError("tenant ID cannot be empty")
+	}
+
+	if err := s.repo.DeleteTenant(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Tenant deleted", "tenant_id", id)
+	return nil
+}
+
+// validateTenant checks the name, currency and rate limit of a tenant
+func validateTenant(t *models.Tenant) error {
+	if t.Name == "" {
+		return newValidationError("tenant name cannot be empty")
+	}
+	if !currencyPattern.MatchString(t.Currency) {
+		return newValidationError(fmt.Sprintf("invalid currency %q: must be an ISO 4217 code such as USD", t.Currency))
+	}
+	if t.RateLimit < 0 {
+		return newValidationError("rate limit cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package service
+
+import "context"
+
+// TransactionManager runs units of work spanning several repositories
+type TransactionManager interface {
+	// WithinTransaction runs fn in a transaction, which the repositories
+	// called with the context given to fn join. It commits when fn returns
+	// nil; nested calls run in savepoints.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// AfterCommit runs fn once the transaction of ctx has committed, or
+	// right away outside of a transaction
+	AfterCommit(ctx context.Context, fn func())
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/webhooks"
+)
+
+// WebhookService defines the interface for webhook subscription management
+type WebhookService interface {
+	ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetWebhookByID(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	CreateWebhook(ctx context.Context, sub *models.WebhookSubscription) error
+	UpdateWebhook(ctx context.Context, id string, sub *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, id string) ([]models.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id string, deliveryID string) (*models.WebhookDelivery, error)
+}
+
+// WebhookServiceImpl implements the WebhookService interface
+type WebhookServiceImpl struct {
+	repo   repository.WebhookRepository
+	logger *slog.Logger
+}
+
+// NewWebhookService creates a new WebhookService instance
+func NewWebhookService(repo repository.WebhookRepository, logger *slog.Logger) WebhookService {
+	return &WebhookServiceImpl{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// deliveryLogLimit is the number of deliveries returned by ListDeliveries
+const deliveryLogLimit = 100
+
+// ListWebhooks retrieves all webhook subscriptions
+func (s *WebhookServiceImpl) ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subs) == 0 {
+		return []models.WebhookSubscription{}, nil
+	}
+
+	return subs, nil
+}
+
+// GetWebhookByID retrieves a webhook subscription by its ID
+func (s *WebhookServiceImpl) GetWebhookByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	if id == "" {
+		return nil, newValidationError("webhook ID cannot be empty")
+	}
+
+	return s.repo.GetSubscriptionByID(ctx, id)
+}
+
+// CreateWebhook creates a new webhook subscription, generating a secret when none is given
+func (s *WebhookServiceImpl) CreateWebhook(ctx context.Context, sub *models.WebhookSubscription) error {
+	if sub == nil {
+		return newValidationError("webhook cannot be nil")
+	}
+
+	if err := validateWebhook(sub); err != nil {
+		return err
+	}
+
+	if sub.Secret == "" {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			return err
+		}
+		sub.Secret = secret
+	}
+
+	sub.Active = true
+	sub.ConsecutiveFailures = 0
+	sub.DisabledAt = nil
+	sub.DisabledReason = ""
+
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Webhook created", "webhook_id", sub.ID, "url", sub.URL)
+	return nil
+}
+
+// UpdateWebhook updates an existing webhook subscription.
+// Re-activating a disabled subscription resets its failure counter.
+func (s *WebhookServiceImpl) UpdateWebhook(ctx context.Context, id string, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if id == "" {
+		return nil, newValidationError("webhook ID cannot be empty")
+	}
+
+	if sub == nil {
+		return nil, newValidationError("webhook cannot be nil")
+	}
+
+	existing, err := s.repo.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.URL != "" {
+		existing.URL = sub.URL
+	}
+	if sub.Description != "" {
+		existing.Description = sub.Description
+	}
+	if sub.EventTypes != nil {
+		existing.EventTypes = sub.EventTypes
+	}
+	if sub.Secret != "" {
+		existing.Secret = sub.Secret
+	}
+	if sub.Active && !existing.Active {
+		existing.Active = true
+		existing.ConsecutiveFailures = 0
+		existing.DisabledAt = nil
+		existing.DisabledReason = ""
+	}
+
+	if err := validateWebhook(existing); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Webhook updated", "webhook_id", id, "active", existing.Active)
+	return existing, nil
+}
+
+// DeleteWebhook deletes a webhook subscription
+func (s *WebhookServiceImpl) DeleteWebhook(ctx context.Context, id string) error {
+	if id == "" {
+		return newValidationError("webhook ID cannot be empty")
+	}
+
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Webhook deleted", "webhook_id", id)
+	return nil
+}
+
+// ListDeliveries retrieves the recent delivery log of a webhook subscription
+func (s *WebhookServiceImpl) ListDeliveries(ctx context.Context, id string) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhookByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, id, deliveryLogLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return []models.WebhookDelivery{}, nil
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDelivery queues a delivery to be sent again as soon as possible
+func (s *WebhookServiceImpl) ReplayDelivery(ctx context.Context, id string, deliveryID string) (*models.WebhookDelivery, error) {
+	if deliveryID == "" {
+		return nil, newValidationError("delivery ID cannot be empty")
+	}
+
+	sub, err := s.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sub.Active {
+		return nil, newValidationError("cannot replay a delivery of a disabled webhook")
+	}
+
+	delivery, err := s.repo.GetDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.SubscriptionID != id {
+		return nil, fmt.Errorf("webhook delivery with ID %s %w", deliveryID, repository.ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, s.logger).InfoContext(ctx, "Webhook delivery replayed", "webhook_id", id, "delivery_id", deliveryID)
+	return delivery, nil
+}
+
+// validateWebhook checks the subscription URL and event type filters
+func validateWebhook(sub *models.WebhookSubscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return newValidationError("webhook URL must be an absolute http(s) URL")
+	}
+
+	for _, t := range sub.EventTypes {
+		if t == "*" {
+			continue
+		}
+		if !isKnownEventType(t) {
+			return newValidationError(fmt.Sprintf("unknown event type %q", t))
+		}
+	}
+
+	return nil
+}
+
+// isKnownEventType reports whether t is an event type emitted by the catalog
+func isKnownEventType(t string) bool {
+	for _, known := range events.AllTypes {
+		if string(known) == t {
+			return true
+		}
+	}
+	return false
+}
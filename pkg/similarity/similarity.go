@@ -0,0 +1,177 @@
+package similarity
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize folds a name for comparison: it is lower-cased, accents are
+// removed and everything but letters and digits is dropped, so that
+// "J.K. Rowling", "J. K. Rowling" and "jk rowling" are equal.
+func Normalize(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity of two strings, from 0
+// (nothing in common) to 1 (equal). Common prefixes of up to 4 characters
+// weigh more, which suits names.
+func JaroWinkler(a string, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 && len(t) == 0 {
+		return 1
+	}
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	window := max(len(s), len(t))/2 - 1
+	window = max(window, 0)
+
+	sMatched := make([]bool, len(s))
+	tMatched := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		for j := max(0, i-window); j < min(len(t), i+window+1); j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Matched characters that are out of order; a transposition counts twice
+	transpositions := 0
+	j := 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[j] {
+			j++
+		}
+		if s[i] != t[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Trigrams returns the distinct 3-character substrings of s, padded so that
+// short strings have some too
+func Trigrams(s string) []string {
+	runes := []rune("  " + s + " ")
+	seen := map[string]bool{}
+	var trigrams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		trigram := string(runes[i : i+3])
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return trigrams
+}
+
+// Cluster is a group of similar strings
+type Cluster struct {
+	// Members are the indexes of the strings, in ascending order
+	Members []int
+	// Similarity is the lowest similarity of the pairs that joined the cluster
+	Similarity float64
+}
+
+// Clusters groups the strings whose Jaro-Winkler similarity is at least
+// threshold, transitively. Only pairs sharing a trigram are compared, which
+// keeps large inputs fast. Strings are expected to be normalized; clusters
+// are ordered by their first member and singletons are left out.
+func Clusters(values []string, threshold float64) []Cluster {
+	parent := make([]int, len(values))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	byTrigram := map[string][]int{}
+	for i, value := range values {
+		for _, trigram := range Trigrams(value) {
+			byTrigram[trigram] = append(byTrigram[trigram], i)
+		}
+	}
+
+	lowest := map[int]float64{}
+	compared := map[[2]int]bool{}
+	for i, value := range values {
+		for _, trigram := range Trigrams(value) {
+			for _, j := range byTrigram[trigram] {
+				if j <= i || compared[[2]int{i, j}] {
+					continue
+				}
+				compared[[2]int{i, j}] = true
+
+				score := JaroWinkler(value, values[j])
+				if score < threshold {
+					continue
+				}
+
+				ri, rj := find(i), find(j)
+				low := score
+				if l, ok := lowest[ri]; ok {
+					low = min(low, l)
+				}
+				if l, ok := lowest[rj]; ok && ri != rj {
+					low = min(low, l)
+				}
+				parent[rj] = ri
+				if ri != rj {
+					delete(lowest, rj)
+				}
+				lowest[ri] = low
+			}
+		}
+	}
+
+	members := map[int][]int{}
+	for i := range values {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+
+	var clusters []Cluster
+	for root, group := range members {
+		if len(group) > 1 {
+			clusters = append(clusters, Cluster{Members: group, Similarity: lowest[root]})
+		}
+	}
+	sort.Slice(clusters, func(a, b int) bool {
+		return clusters[a].Members[0] < clusters[b].Members[0]
+	})
+	return clusters
+}
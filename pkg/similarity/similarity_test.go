@@ -0,0 +1,124 @@
+package similarity_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/similarity"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"J.K. Rowling", "jkrowling"},
+		{"J. K. Rowling", "jkrowling"},
+		{"jk rowling", "jkrowling"},
+		{"Gabriel García Márquez", "gabrielgarciamarquez"},
+		{"Ngũgĩ wa Thiong'o", "ngugiwathiongo"},
+		{"Ｆｕｌｌ　Ｗｉｄｔｈ", "fullwidth"},
+		{"Stanisław Lem", "stanisławlem"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := similarity.Normalize(tt.name); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		// Reference values of Winkler's paper
+		{"martha", "marhta", 0.961},
+		{"dwayne", "duane", 0.840},
+		{"dixon", "dicksonx", 0.813},
+		{"same", "same", 1},
+		{"", "", 1},
+		{"abc", "", 0},
+		{"abc", "xyz", 0},
+	}
+	for _, tt := range tests {
+		got := similarity.JaroWinkler(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+		if reverse := similarity.JaroWinkler(tt.b, tt.a); math.Abs(got-reverse) > 1e-9 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, not symmetric", tt.b, tt.a, reverse)
+		}
+	}
+}
+
+// TestDuplicateThresholds checks the names that the default duplicate
+// threshold of 0.92 and the lowest allowed one of 0.7 tell apart
+func TestDuplicateThresholds(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		atDefault bool
+		atLowest  bool
+	}{
+		{"J.K. Rowling", "JK Rowling", true, true},
+		{"Fyodor Dostoevsky", "Fyodor Dostoyevsky", true, true},
+		{"Arthur Conan Doyle", "Arthur Conan Doyl", true, true},
+		{"Isaac Asimov", "Isaak Asimov", true, true},
+		{"Terry Pratchett", "Terry Brooks", false, true},
+		{"Ursula K. Le Guin", "Ursula Le Guin", true, true},
+		{"Frank Herbert", "Brian Herbert", false, true},
+		{"Ted Chiang", "Neal Stephenson", false, false},
+	}
+	for _, tt := range tests {
+		score := similarity.JaroWinkler(similarity.Normalize(tt.a), similarity.Normalize(tt.b))
+		if got := score >= 0.92; got != tt.atDefault {
+			t.Errorf("%q and %q score %.3f, duplicates at 0.92 = %v, want %v", tt.a, tt.b, score, got, tt.atDefault)
+		}
+		if got := score >= 0.7; got != tt.atLowest {
+			t.Errorf("%q and %q score %.3f, duplicates at 0.7 = %v, want %v", tt.a, tt.b, score, got, tt.atLowest)
+		}
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	if got, want := similarity.Trigrams("lem"), []string{"  l", " le", "lem", "em "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Trigrams(lem) = %q, want %q", got, want)
+	}
+	if got, want := similarity.Trigrams("aaaa"), []string{"  a", " aa", "aaa", "aa "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Trigrams(aaaa) = %q, want the distinct %q", got, want)
+	}
+}
+
+func TestClusters(t *testing.T) {
+	names := []string{"jkrowling", "tedchiang", "jkrowlin", "tedchiang", "nealstephenson", "jkrowlng"}
+
+	got := similarity.Clusters(names, 0.92)
+	if len(got) != 2 {
+		t.Fatalf("Clusters() = %+v, want two clusters", got)
+	}
+	if want := []int{0, 2, 5}; !reflect.DeepEqual(got[0].Members, want) {
+		t.Errorf("first cluster = %v, want %v", got[0].Members, want)
+	}
+	if want := []int{1, 3}; !reflect.DeepEqual(got[1].Members, want) || got[1].Similarity != 1 {
+		t.Errorf("second cluster = %+v, want %v with similarity 1", got[1], want)
+	}
+	if s := got[0].Similarity; s < 0.92 || s >= 1 {
+		t.Errorf("first cluster similarity = %v, want the lowest joining pair", s)
+	}
+
+	// Clusters are transitive: c joins a through b even though a and c are
+	// less similar than the threshold
+	chain := []string{"abcdefgh", "abcdefgx", "abcdefyx"}
+	if similarity.JaroWinkler(chain[0], chain[2]) >= 0.95 {
+		t.Fatal("the ends of the chain are too similar for the test")
+	}
+	if got := similarity.Clusters(chain, 0.95); len(got) != 1 || len(got[0].Members) != 3 {
+		t.Errorf("Clusters(chain) = %+v, want one cluster of three", got)
+	}
+
+	if got := similarity.Clusters(names, 1.01); len(got) != 0 {
+		t.Errorf("Clusters() above 1 = %+v, want none", got)
+	}
+}
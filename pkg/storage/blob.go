@@ -0,0 +1,70 @@
+// Package storage keeps binary objects, such as book covers, outside the
+// database: on the local filesystem or in an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for keys that hold no blob
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidRange is returned for ranges that do not lie within their blob
+var ErrInvalidRange = errors.New("invalid blob range")
+
+// ErrInvalidKey is returned for keys that are not relative slash-separated
+// paths
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Info describes a stored blob
+type Info struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobStore keeps blobs by key. Keys are slash-separated paths, e.g.
+// "acme/covers/<book ID>/<version>/w320.jpg"; every blob under a directory
+// can be deleted at once.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any blob there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob under key, which the caller must close
+	Get(ctx context.Context, key string) (io.ReadCloser, *Info, error)
+	// GetRange opens length bytes of the blob under key from offset, which
+	// must lie within it; Info still describes the whole blob
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *Info, error)
+	// Delete removes the blob under key; a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	// DeleteDir removes every blob whose key starts with dir + "/"
+	DeleteDir(ctx context.Context, dir string) error
+}
+
+// CheckKey returns ErrInvalidKey unless key is a relative slash-separated
+// path without empty, "." or ".." segments
+func CheckKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty key", ErrInvalidKey)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, "\\\x00") {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// checkRange returns ErrInvalidRange unless length bytes from offset lie
+// within a blob of size bytes
+func checkRange(offset, length, size int64) error {
+	if offset < 0 || length <= 0 || offset > size-length {
+		return fmt.Errorf("%w: %d bytes from %d of %d", ErrInvalidRange, length, offset, size)
+	}
+	return nil
+}
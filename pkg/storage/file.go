@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FileStore keeps blobs as files under a root directory. The filesystem has
+// no room for metadata, so the content type of a blob is derived from the
+// extension of its key.
+type FileStore struct {
+	root string
+}
+
+// NewFileStore creates a FileStore under root. Directories are created as
+// blobs are stored in them.
+func NewFileStore(root string) *FileStore {
+	return &FileStore{root: root}
+}
+
+// path returns the file of key
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+// Put writes the blob to a temporary file, which is renamed into place once
+// complete so that readers never see a partial blob
+func (s *FileStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	name := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if written != size {
+		return fmt.Errorf("failed to write blob: read %d bytes, expected %d", written, size)
+	}
+
+	if err := os.Rename(file.Name(), name); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+// Get opens the file of key
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, *Info, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return file, &Info{Key: key, Size: stat.Size(), ContentType: contentType, ModTime: stat.ModTime()}, nil
+}
+
+// GetRange opens the file of key and seeks to offset
+func (s *FileStore) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *Info, error) {
+	reader, info, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file := reader.(*os.File)
+	if err := checkRange(offset, length, info.Size); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return limitedReadCloser{io.LimitReader(file, length), file}, info, nil
+}
+
+// limitedReadCloser reads part of a blob and closes the whole of it
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Delete removes the file of key, and the directories it leaves empty
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	s.prune(path.Dir(key))
+	return nil
+}
+
+// DeleteDir removes the directory of dir and everything in it
+func (s *FileStore) DeleteDir(ctx context.Context, dir string) error {
+	if err := CheckKey(dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(s.path(dir)); err != nil {
+		return fmt.Errorf("failed to delete blobs: %w", err)
+	}
+	s.prune(path.Dir(dir))
+	return nil
+}
+
+// prune removes dir and its parents below the root while they are empty
+func (s *FileStore) prune(dir string) {
+	for dir != "." && dir != "/" {
+		if os.Remove(s.path(dir)) != nil {
+			return
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+var _ BlobStore = (*FileStore)(nil)
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures the connection of an S3Store
+type S3Config struct {
+	// Endpoint is the host and port of the service, e.g. s3.amazonaws.com
+	// or localhost:9000 for MinIO
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// UseSSL connects over HTTPS
+	UseSSL bool
+	// PathStyle addresses the bucket in the path rather than as a
+	// subdomain, which most self-hosted services require
+	PathStyle bool
+}
+
+// S3Store keeps blobs as objects of a bucket of S3 or a compatible service
+// such as MinIO
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store connects to the service of cfg and checks that the bucket
+// exists
+func NewS3Store(ctx context.Context, cfg S3Config) (*S3Store, error) {
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach S3 bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("S3 bucket %s does not exist", cfg.Bucket)
+	}
+	return &S3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Put uploads the blob as an object
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	return nil
+}
+
+// Get opens the object of key. Objects are read lazily, so it is looked up
+// first to report missing ones.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *Info, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, nil, err
+	}
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		if isNotFound(err) {
+			return nil, nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return object, &Info{Key: key, Size: stat.Size, ContentType: stat.ContentType, ModTime: stat.LastModified}, nil
+}
+
+// GetRange opens a range of the object of key. The object is looked up
+// first, to check the range against its size.
+func (s *S3Store) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *Info, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, nil, err
+	}
+	stat, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	if err := checkRange(offset, length, stat.Size); err != nil {
+		return nil, nil, err
+	}
+
+	options := minio.GetObjectOptions{}
+	if err := options.SetRange(offset, offset+length-1); err != nil {
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	// A replaced object must not be read halfway through
+	if err := options.SetMatchETag(stat.ETag); err != nil {
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	object, err := s.client.GetObject(ctx, s.bucket, key, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return object, &Info{Key: key, Size: stat.Size, ContentType: stat.ContentType, ModTime: stat.LastModified}, nil
+}
+
+// Delete removes the object of key
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// DeleteDir lists the objects under dir and removes them in bulk
+func (s *S3Store) DeleteDir(ctx context.Context, dir string) error {
+	if err := CheckKey(dir); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: dir + "/", Recursive: true})
+
+	// Listing errors stop the removal, which then reports them
+	var listErr error
+	listed := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(listed)
+		for object := range objects {
+			if object.Err != nil {
+				listErr = object.Err
+				return
+			}
+			select {
+			case listed <- object:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for removeErr := range s.client.RemoveObjects(ctx, s.bucket, listed, minio.RemoveObjectsOptions{}) {
+		return fmt.Errorf("failed to delete blob %s: %w", removeErr.ObjectName, removeErr.Err)
+	}
+	if listErr != nil {
+		return fmt.Errorf("failed to list blobs: %w", listErr)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is the response to a missing object
+func isNotFound(err error) bool {
+	response := minio.ToErrorResponse(err)
+	return response.StatusCode == http.StatusNotFound || response.Code == "NoSuchKey"
+}
+
+var _ BlobStore = (*S3Store)(nil)
@@ -0,0 +1,15 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/storage/storagetest"
+)
+
+func TestFileStore(t *testing.T) {
+	storagetest.TestBlobStore(t, storagetest.Files(t))
+}
+
+func TestS3Store(t *testing.T) {
+	storagetest.TestBlobStore(t, storagetest.S3(t))
+}
@@ -0,0 +1,220 @@
+// Package storagetest holds the contract that every BlobStore must honour,
+// and the stores to run it against.
+//
+// A test runs the contract by passing a store to TestBlobStore:
+//
+//	func TestS3Store(t *testing.T) {
+//		storagetest.TestBlobStore(t, storagetest.S3(t))
+//	}
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// TestBlobStore runs the BlobStore contract as subtests of t. Each subtest
+// works under a directory of its own, so store may hold other blobs.
+func TestBlobStore(t *testing.T, store storage.BlobStore) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, store storage.BlobStore, dir string)
+	}{
+		{"PutAndGet", testPutAndGet},
+		{"Replace", testReplace},
+		{"GetMissing", testGetMissing},
+		{"GetRange", testGetRange},
+		{"Delete", testDelete},
+		{"DeleteDir", testDeleteDir},
+		{"InvalidKeys", testInvalidKeys},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, context.Background(), store, "contract/"+uuid.NewString())
+		})
+	}
+}
+
+// put stores content under key, which must succeed
+func put(t *testing.T, ctx context.Context, store storage.BlobStore, key string, content string, contentType string) {
+	t.Helper()
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), contentType); err != nil {
+		t.Fatalf("Put %s: %v", key, err)
+	}
+}
+
+// get reads the blob under key, which must exist
+func get(t *testing.T, ctx context.Context, store storage.BlobStore, key string) (string, *storage.Info) {
+	t.Helper()
+	reader, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get %s: %v", key, err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading %s: %v", key, err)
+	}
+	return string(content), info
+}
+
+// expectNotFound fails t unless the blob under key is missing
+func expectNotFound(t *testing.T, ctx context.Context, store storage.BlobStore, key string) {
+	t.Helper()
+	reader, _, err := store.Get(ctx, key)
+	if err == nil {
+		reader.Close()
+		t.Fatalf("Get %s succeeded, want storage.ErrNotFound", key)
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Get %s returned %v, want storage.ErrNotFound", key, err)
+	}
+}
+
+func testPutAndGet(t *testing.T, ctx context.Context, store storage.BlobStore, dir string) {
+	key := dir + "/covers/w320.jpg"
+	put(t, ctx, store, key, "jpeg bytes", "image/jpeg")
+
+	content, info := get(t, ctx, store, key)
+	if content != "jpeg bytes" {
+		t.Fatalf("Get returned %q, want %q", content, "jpeg bytes")
+	}
+	if info.Key != key || info.Size != int64(len(content)) || info.ContentType != "image/jpeg" {
+		t.Fatalf("Get returned %+v, which does not describe the blob", info)
+	}
+	if info.ModTime.IsZero() {
+		t.Fatalf("Get returned no modification time")
+	}
+
+	// Binary content survives unchanged
+	binary := bytes.Repeat([]byte{0, 0xff, 0x10, '\n'}, 64<<10)
+	if err := store.Put(ctx, dir+"/large.webp", bytes.NewReader(binary), int64(len(binary)), "image/webp"); err != nil {
+		t.Fatalf("Put of a large blob: %v", err)
+	}
+	content, _ = get(t, ctx, store, dir+"/large.webp")
+	if content != string(binary) {
+		t.Fatalf("Get of a large blob returned %d different bytes", len(content))
+	}
+}
+
+func testReplace(t *testing.T, ctx context.Context, store storage.BlobStore, dir string) {
+	key := dir + "/cover.png"
+	put(t, ctx, store, key, "first", "image/png")
+	put(t, ctx, store, key, "second version", "image/png")
+
+	content, info := get(t, ctx, store, key)
+	if content != "second version" || info.Size != int64(len("second version")) {
+		t.Fatalf("Get after a second Put returned %q (%d bytes), want the second blob", content, info.Size)
+	}
+}
+
+func testGetMissing(t *testing.T, ctx context.Context, store storage.BlobStore, dir string) {
+	expectNotFound(t, ctx, store, dir+"/missing.jpg")
+}
+
+func testGetRange(t *testing.T, ctx context.Context, store storage.BlobStore, dir string) {
+	key := dir + "/book.epub"
+	put(t, ctx, store, key, "0123456789", "application/epub+zip")
+
+	for _, tt := range []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, 10, "0123456789"},
+		{0, 4, "0123"},
+		{3, 4, "3456"},
+		{9, 1, "9"},
+	} {
+		reader, info, err := store.GetRange(ctx, key, tt.offset, tt.length)
+		if err != nil {
+			t.Fatalf("GetRange %d+%d: %v", tt.offset, tt.length, err)
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("reading range %d+%d: %v", tt.offset, tt.length, err)
+		}
+		if string(content) != tt.want {
+			t.Fatalf("GetRange %d+%d returned %q, want %q", tt.offset, tt.length, content, tt.want)
+		}
+		if info.Size != 10 {
+			t.Fatalf("GetRange returned a size of %d, want the size of the whole blob", info.Size)
+		}
+	}
+
+	for _, r := range [][2]int64{{-1, 2}, {0, 0}, {8, 3}, {10, 1}} {
+		_, _, err := store.GetRange(ctx, key, r[0], r[1])
+		if !errors.Is(err, storage.ErrInvalidRange) {
+			t.Fatalf("GetRange %d+%d returned %v, want storage.ErrInvalidRange", r[0], r[1], err)
+		}
+	}
+
+	_, _, err := store.GetRange(ctx, dir+"/missing.pdf", 0, 1)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetRange of a missing blob returned %v, want storage.ErrNotFound", err)
+	}
+}
+
+func testDelete(t *testing.T, ctx context.Context, store storage.BlobStore, dir string) {
+	put(t, ctx, store, dir+"/a.jpg", "a", "image/jpeg")
+	put(t, ctx, store, dir+"/b.jpg", "b", "image/jpeg")
+
+	if err := store.Delete(ctx, dir+"/a.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	expectNotFound(t, ctx, store, dir+"/a.jpg")
+	if content, _ := get(t, ctx, store, dir+"/b.jpg"); content != "b" {
+		t.Fatalf("Delete removed another blob")
+	}
+
+	if err := store.Delete(ctx, dir+"/a.jpg"); err != nil {
+		t.Fatalf("Delete of a missing blob: %v", err)
+	}
+}
+
+func testDeleteDir(t *testing.T, ctx context.Context, store storage.BlobStore, dir string) {
+	put(t, ctx, store, dir+"/book/v1/original.jpg", "original", "image/jpeg")
+	put(t, ctx, store, dir+"/book/v1/w160.jpg", "thumbnail", "image/jpeg")
+	put(t, ctx, store, dir+"/book/v2/original.jpg", "newer", "image/jpeg")
+	// Sharing a prefix without being in the directory
+	put(t, ctx, store, dir+"/book/v10.jpg", "sibling", "image/jpeg")
+
+	if err := store.DeleteDir(ctx, dir+"/book/v1"); err != nil {
+		t.Fatalf("DeleteDir: %v", err)
+	}
+	expectNotFound(t, ctx, store, dir+"/book/v1/original.jpg")
+	expectNotFound(t, ctx, store, dir+"/book/v1/w160.jpg")
+	if content, _ := get(t, ctx, store, dir+"/book/v2/original.jpg"); content != "newer" {
+		t.Fatalf("DeleteDir removed a blob of another directory")
+	}
+	if content, _ := get(t, ctx, store, dir+"/book/v10.jpg"); content != "sibling" {
+		t.Fatalf("DeleteDir removed a blob outside the directory")
+	}
+
+	if err := store.DeleteDir(ctx, dir+"/book"); err != nil {
+		t.Fatalf("DeleteDir: %v", err)
+	}
+	expectNotFound(t, ctx, store, dir+"/book/v2/original.jpg")
+	expectNotFound(t, ctx, store, dir+"/book/v10.jpg")
+
+	if err := store.DeleteDir(ctx, dir+"/missing"); err != nil {
+		t.Fatalf("DeleteDir of a missing directory: %v", err)
+	}
+}
+
+func testInvalidKeys(t *testing.T, ctx context.Context, store storage.BlobStore, dir string) {
+	for _, key := range []string{"", "/absolute.jpg", dir + "/../escape.jpg", dir + "//double.jpg", dir + "/trailing/"} {
+		err := store.Put(ctx, key, strings.NewReader("x"), 1, "image/jpeg")
+		if !errors.Is(err, storage.ErrInvalidKey) {
+			t.Fatalf("Put %q returned %v, want storage.ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package storagetest
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/storage"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+// Files returns a FileStore in a temporary directory, removed when t ends
+func Files(t *testing.T) *storage.FileStore {
+	return storage.NewFileStore(t.TempDir())
+}
+
+// S3 returns an S3Store on an in-memory stand-in for S3, served over HTTP
+// until t ends. It speaks the S3 protocol, so the store runs its real
+// client code without credentials or a network.
+func S3(t *testing.T) *storage.S3Store {
+	t.Helper()
+
+	const bucket = "bookstore-test"
+	backend := s3mem.New()
+	if err := backend.CreateBucket(bucket); err != nil {
+		t.Fatalf("creating bucket: %v", err)
+	}
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	store, err := storage.NewS3Store(context.Background(), storage.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Bucket:    bucket,
+		Region:    "us-east-1",
+		AccessKey: "test",
+		SecretKey: "test",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("connecting to the S3 stand-in: %v", err)
+	}
+	return store
+}
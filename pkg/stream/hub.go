@@ -0,0 +1,200 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// Event is a book change as delivered to stream subscribers
+type Event struct {
+	ID         string      `json:"id"`
+	Type       events.Type `json:"type"`
+	Book       models.Book `json:"book"`
+	OccurredAt time.Time   `json:"occurred_at"`
+
+	tenantID string
+	seq      uint64
+}
+
+// Filter selects the events a subscriber receives. Empty fields match
+// everything, except TenantID: only the events of that tenant are received.
+type Filter struct {
+	TenantID string
+	// Author matches the author ID or, case-insensitively, the author name
+	Author    string
+	Publisher string
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(e Event) bool {
+	if f.TenantID != e.tenantID {
+		return false
+	}
+	if f.Author != "" && f.Author != e.Book.AuthorID && !strings.EqualFold(f.Author, e.Book.Author.Name) {
+		return false
+	}
+	if f.Publisher != "" && !strings.EqualFold(f.Publisher, e.Book.Publisher) {
+		return false
+	}
+	return true
+}
+
+// Subscriber receives live events on C. C is closed when the subscriber is
+// removed, either by Unsubscribe or because it fell too far behind.
+type Subscriber struct {
+	C <-chan Event
+
+	ch         chan Event
+	filter     Filter
+	overflowed bool
+}
+
+// Overflowed reports whether the subscriber was dropped for being too slow.
+// It is only meaningful once C has been closed.
+func (s *Subscriber) Overflowed() bool {
+	return s.overflowed
+}
+
+// Hub fans book changes out to stream subscribers and keeps a bounded
+// buffer of recent events so that reconnecting clients can resume.
+//
+// Event IDs have the form "<epoch>-<sequence>", where the epoch identifies
+// this process; IDs from another process cannot be resumed from.
+type Hub struct {
+	mu         sync.Mutex
+	epoch      string
+	seq        uint64
+	buffer     []Event
+	bufferSize int
+	subBuffer  int
+	subs       map[*Subscriber]struct{}
+}
+
+// NewHub creates a new Hub keeping the last bufferSize events.
+// Each subscriber may lag up to subscriberBuffer events before it is dropped.
+func NewHub(bufferSize int, subscriberBuffer int) *Hub {
+	return &Hub{
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		bufferSize: bufferSize,
+		subBuffer:  subscriberBuffer,
+		subs:       map[*Subscriber]struct{}{},
+	}
+}
+
+// BookChanged implements service.BookChangeNotifier
+func (h *Hub) BookChanged(ctx context.Context, change service.BookChange) {
+	tenantID, _ := tenant.FromContext(ctx)
+	h.Publish(tenantID, change.Type, change.Book)
+}
+
+// Publish records an event of a tenant and delivers it to every matching
+// subscriber. It never blocks: subscribers whose queue is full are dropped.
+func (h *Hub) Publish(tenantID string, eventType events.Type, book models.Book) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := Event{
+		ID:         fmt.Sprintf("%s-%d", h.epoch, h.seq),
+		Type:       eventType,
+		Book:       book,
+		OccurredAt: time.Now().UTC(),
+		tenantID:   tenantID,
+		seq:        h.seq,
+	}
+
+	h.buffer = append(h.buffer, event)
+	if len(h.buffer) > h.bufferSize {
+		h.buffer = h.buffer[len(h.buffer)-h.bufferSize:]
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.overflowed = true
+			h.remove(sub)
+		}
+	}
+
+	return event
+}
+
+// Subscribe registers a new subscriber. When lastEventID is not empty the
+// buffered events after it that match filter are returned as backlog.
+// resumed is false when lastEventID can no longer be resumed from, in which
+// case the client has missed events and should reload its state.
+func (h *Hub) Subscribe(lastEventID string, filter Filter) (sub *Subscriber, backlog []Event, resumed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, h.subBuffer)
+	sub = &Subscriber{C: ch, ch: ch, filter: filter}
+	h.subs[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	seq, ok := h.parseID(lastEventID)
+	if !ok || seq > h.seq {
+		return sub, nil, false
+	}
+
+	// Events between lastEventID and the oldest buffered one were evicted
+	if len(h.buffer) > 0 && seq+1 < h.buffer[0].seq {
+		return sub, nil, false
+	}
+
+	for _, event := range h.buffer {
+		if event.seq > seq && filter.Matches(event) {
+			backlog = append(backlog, event)
+		}
+	}
+
+	return sub, backlog, true
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+// remove drops a subscriber; h.mu must be held
+func (h *Hub) remove(sub *Subscriber) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// parseID extracts the sequence from an event ID of this hub's epoch
+func (h *Hub) parseID(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != h.epoch {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
@@ -0,0 +1,187 @@
+package stream_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/stream"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// book returns a book called name by Frank Herbert, published by Chilton
+func book(name string) models.Book {
+	return models.Book{
+		ID:        name,
+		Name:      name,
+		AuthorID:  "herbert",
+		Author:    models.Author{ID: "herbert", Name: "Frank Herbert"},
+		Publisher: "Chilton",
+	}
+}
+
+// receive returns the events queued for sub, failing if C is closed
+func receive(t *testing.T, sub *stream.Subscriber) []string {
+	t.Helper()
+	var names []string
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				t.Fatal("subscriber channel closed")
+			}
+			names = append(names, event.Book.Name)
+		default:
+			return names
+		}
+	}
+}
+
+// names returns the book names of events
+func names(events []stream.Event) []string {
+	var names []string
+	for _, event := range events {
+		names = append(names, event.Book.Name)
+	}
+	return names
+}
+
+func TestHubResumesFromLastEventID(t *testing.T) {
+	hub := stream.NewHub(3, 10)
+	filter := stream.Filter{TenantID: "acme"}
+
+	var published []stream.Event
+	for _, name := range []string{"Dune", "Dune Messiah", "Children of Dune", "God Emperor of Dune", "Heretics of Dune"} {
+		published = append(published, hub.Publish("acme", events.BookCreated, book(name)))
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		backlog     []string
+		resumed     bool
+	}{
+		{"no last event", "", nil, true},
+		{"the last event", published[4].ID, nil, true},
+		{"a buffered event", published[2].ID, []string{"God Emperor of Dune", "Heretics of Dune"}, true},
+		{"just before the buffer", published[1].ID, []string{"Children of Dune", "God Emperor of Dune", "Heretics of Dune"}, true},
+		{"an evicted event", published[0].ID, nil, false},
+		{"a future event", strings.Replace(published[4].ID, "-5", "-6", 1), nil, false},
+		{"another process", "other-3", nil, false},
+		{"garbage", "garbage", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog, resumed := hub.Subscribe(tt.lastEventID, filter)
+			defer hub.Unsubscribe(sub)
+
+			if resumed != tt.resumed {
+				t.Errorf("Subscribe() resumed = %v, want %v", resumed, tt.resumed)
+			}
+			if got := names(backlog); strings.Join(got, ",") != strings.Join(tt.backlog, ",") {
+				t.Errorf("Subscribe() backlog = %v, want %v", got, tt.backlog)
+			}
+		})
+	}
+
+	// Live events follow the backlog
+	sub, backlog, _ := hub.Subscribe(published[3].ID, filter)
+	defer hub.Unsubscribe(sub)
+	hub.Publish("acme", events.BookUpdated, book("Chapterhouse: Dune"))
+	if len(backlog) != 1 || backlog[0].ID != published[4].ID {
+		t.Errorf("backlog = %v, want the last event", names(backlog))
+	}
+	if got := receive(t, sub); len(got) != 1 || got[0] != "Chapterhouse: Dune" {
+		t.Errorf("received %v, want the new event", got)
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := stream.NewHub(10, 2)
+	slow, _, _ := hub.Subscribe("", stream.Filter{TenantID: "acme"})
+	fast, _, _ := hub.Subscribe("", stream.Filter{TenantID: "acme"})
+
+	hub.Publish("acme", events.BookCreated, book("Dune"))
+	hub.Publish("acme", events.BookCreated, book("Dune Messiah"))
+	if got := receive(t, fast); len(got) != 2 {
+		t.Fatalf("fast subscriber received %v, want two events", got)
+	}
+
+	// The slow subscriber's queue is full: publishing does not block, and the
+	// subscriber is dropped after the events it had queued
+	hub.Publish("acme", events.BookCreated, book("Children of Dune"))
+
+	var queued []string
+	for event := range slow.C {
+		queued = append(queued, event.Book.Name)
+	}
+	if len(queued) != 2 || !slow.Overflowed() {
+		t.Errorf("slow subscriber received %v, overflowed = %v, want two events then overflow", queued, slow.Overflowed())
+	}
+	if got := receive(t, fast); len(got) != 1 || fast.Overflowed() {
+		t.Errorf("fast subscriber received %v, want the third event", got)
+	}
+
+	// Unsubscribing a dropped subscriber is harmless; unsubscribing closes C
+	hub.Unsubscribe(slow)
+	hub.Unsubscribe(fast)
+	if _, ok := <-fast.C; ok || fast.Overflowed() {
+		t.Error("Unsubscribe() did not close C, or marked an overflow")
+	}
+}
+
+func TestHubFiltersByTenant(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	first := hub.Publish("acme", events.BookCreated, book("Dune"))
+
+	acme, _, _ := hub.Subscribe("", stream.Filter{TenantID: "acme"})
+	other, _, _ := hub.Subscribe("", stream.Filter{TenantID: "other"})
+	defer hub.Unsubscribe(acme)
+	defer hub.Unsubscribe(other)
+
+	// BookChanged publishes in the tenant of its context
+	hub.BookChanged(tenant.WithID(t.Context(), "other"), service.BookChange{Type: events.BookCreated, Book: book("Solaris")})
+	hub.Publish("acme", events.BookUpdated, book("Dune Messiah"))
+	// Changes without a tenant reach no one
+	hub.BookChanged(t.Context(), service.BookChange{Type: events.BookCreated, Book: book("Nowhere")})
+
+	if got := receive(t, acme); strings.Join(got, ",") != "Dune Messiah" {
+		t.Errorf("acme received %v, want only its event", got)
+	}
+	if got := receive(t, other); strings.Join(got, ",") != "Solaris" {
+		t.Errorf("other received %v, want only its event", got)
+	}
+
+	// Backlogs are filtered too
+	sub, backlog, resumed := hub.Subscribe(first.ID, stream.Filter{TenantID: "other"})
+	defer hub.Unsubscribe(sub)
+	if got := names(backlog); !resumed || strings.Join(got, ",") != "Solaris" {
+		t.Errorf("backlog of other = %v, %v, want only its event", got, resumed)
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	event := stream.NewHub(1, 1).Publish("acme", events.BookCreated, book("Dune"))
+
+	tests := []struct {
+		name   string
+		filter stream.Filter
+		want   bool
+	}{
+		{"tenant", stream.Filter{TenantID: "acme"}, true},
+		{"another tenant", stream.Filter{TenantID: "other"}, false},
+		{"no tenant", stream.Filter{}, false},
+		{"author ID", stream.Filter{TenantID: "acme", Author: "herbert"}, true},
+		{"author name", stream.Filter{TenantID: "acme", Author: "frank herbert"}, true},
+		{"another author", stream.Filter{TenantID: "acme", Author: "Brian Herbert"}, false},
+		{"publisher", stream.Filter{TenantID: "acme", Publisher: "CHILTON"}, true},
+		{"another publisher", stream.Filter{TenantID: "acme", Publisher: "Ace"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Matches(event); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
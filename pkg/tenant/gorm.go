@@ -0,0 +1,133 @@
+package tenant
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// fieldName is the field that marks a model as owned by a tenant
+const fieldName = "TenantID"
+
+// GORMPlugin scopes every operation on a model with a TenantID field to the
+// tenant of the statement's context: queries, updates and deletes only see
+// its rows, and created rows are assigned to it. An operation on such a
+// model without a tenant in its context fails with ErrMissing, unless the
+// context was created by WithAllTenants.
+type GORMPlugin struct{}
+
+// NewGORMPlugin creates a new GORMPlugin
+func NewGORMPlugin() *GORMPlugin {
+	return &GORMPlugin{}
+}
+
+// Name implements gorm.Plugin
+func (p *GORMPlugin) Name() string {
+	return "tenant"
+}
+
+// Initialize implements gorm.Plugin by registering the scoping callbacks
+func (p *GORMPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		fn        func(*gorm.DB)
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, p.assign},
+		{"query", cb.Query().Before("gorm:query").Register, p.filter},
+		{"update", cb.Update().Before("gorm:update").Register, p.update},
+		{"delete", cb.Delete().Before("gorm:delete").Register, p.filter},
+		{"row", cb.Row().Before("gorm:row").Register, p.filter},
+	}
+
+	for _, r := range register {
+		if err := r.before("tenant:"+r.operation, r.fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scoped returns the tenant field of the statement's model and the tenant to
+// scope it to. ok is false when the statement must not be scoped.
+func scoped(db *gorm.DB) (field *schema.Field, id string, ok bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, "", false
+	}
+	field = db.Statement.Schema.LookUpField(fieldName)
+	if field == nil || AllTenants(db.Statement.Context) {
+		return nil, "", false
+	}
+
+	id, ok = FromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(fmt.Errorf("%s: %w", db.Statement.Schema.Table, ErrMissing))
+		return nil, "", false
+	}
+	return field, id, true
+}
+
+// filter restricts the statement to the rows of the tenant
+func (p *GORMPlugin) filter(db *gorm.DB) {
+	field, id, ok := scoped(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id},
+	}})
+}
+
+// assign sets the tenant of the created rows
+func (p *GORMPlugin) assign(db *gorm.DB) {
+	field, id, ok := scoped(db)
+	if !ok {
+		return
+	}
+
+	p.set(db, field, id)
+}
+
+// update restricts the statement to the rows of the tenant, and keeps saved
+// models in it
+func (p *GORMPlugin) update(db *gorm.DB) {
+	field, id, ok := scoped(db)
+	if !ok {
+		return
+	}
+
+	p.set(db, field, id)
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id},
+	}})
+}
+
+// set stores id in the tenant field of the statement's models
+func (p *GORMPlugin) set(db *gorm.DB, field *schema.Field, id string) {
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			p.setOne(db, field, reflect.Indirect(value.Index(i)), id)
+		}
+	case reflect.Struct:
+		p.setOne(db, field, value, id)
+	}
+}
+
+// setOne stores id in the tenant field of a single model
+func (p *GORMPlugin) setOne(db *gorm.DB, field *schema.Field, model reflect.Value, id string) {
+	if model.Kind() != reflect.Struct || model.Type() != field.Schema.ModelType {
+		return
+	}
+	if err := field.Set(db.Statement.Context, model, id); err != nil {
+		db.AddError(fmt.Errorf("failed to set tenant: %w", err))
+	}
+}
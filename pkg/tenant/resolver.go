@@ -0,0 +1,161 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Sources a tenant can be resolved from
+const (
+	// SourceJWT reads a claim of an HS256 bearer token; bearer tokens that
+	// are not JWTs are left to other sources
+	SourceJWT = "jwt"
+	// SourceSubdomain reads the subdomain of Resolver.Domain in the host
+	SourceSubdomain = "subdomain"
+	// SourceHeader reads Resolver.Header
+	SourceHeader = "header"
+)
+
+// DefaultHeader is the request header naming the tenant
+const DefaultHeader = "X-Tenant-ID"
+
+// ErrInvalidToken is returned when a request carries a bearer token that
+// cannot be verified
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// Resolver finds the tenant a request is for
+type Resolver struct {
+	// Sources are tried in order; the first one naming a tenant wins
+	Sources []string
+	// Header is read by SourceHeader
+	Header string
+	// Domain is the parent domain of the tenants' subdomains, e.g.
+	// books.example.com for acme.books.example.com
+	Domain string
+	// Secret verifies the bearer tokens read by SourceJWT
+	Secret []byte
+	// Claim is the token claim naming the tenant
+	Claim string
+	// Default is used when no source names a tenant; empty to require one
+	Default string
+}
+
+// Request is the part of a request a Resolver reads
+type Request struct {
+	// Header returns the value of a request header
+	Header func(name string) string
+	// Host is the requested host, with or without a port
+	Host string
+}
+
+// Resolve returns the ID of the tenant req is for, or "" when it names none
+// and there is no default
+func (r Resolver) Resolve(req Request) (string, error) {
+	for _, source := range r.Sources {
+		var id string
+		switch source {
+		case SourceJWT:
+			authorization := req.Header("Authorization")
+			token, ok := strings.CutPrefix(authorization, "Bearer ")
+			// Other bearer tokens, e.g. the admin token, are not JWTs
+			if !ok || strings.Count(token, ".") != 2 {
+				continue
+			}
+			claim, err := VerifyClaim(token, r.Secret, r.Claim, time.Now())
+			if err != nil {
+				return "", err
+			}
+			id = claim
+		case SourceSubdomain:
+			id = subdomain(req.Host, r.Domain)
+		case SourceHeader:
+			id = strings.TrimSpace(req.Header(r.Header))
+		}
+		if id != "" {
+			return id, nil
+		}
+	}
+	return r.Default, nil
+}
+
+// subdomain returns the label of host directly below domain, if any
+func subdomain(host string, domain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	label, ok := strings.CutSuffix(host, "."+strings.ToLower(domain))
+	if !ok || domain == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// VerifyClaim checks the HS256 signature and expiry of a JWT and returns the
+// string value of one of its claims, "" when it is absent
+func VerifyClaim(token string, secret []byte, claim string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return "", ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", ErrInvalidToken
+	}
+
+	claims := map[string]any{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", ErrInvalidToken
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return "", fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+
+	value, _ := claims[claim].(string)
+	return value, nil
+}
+
+// SignClaims returns an HS256 JWT of claims signed with secret, as checked
+// by VerifyClaim
+func SignClaims(claims map[string]any, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) +
+		"." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
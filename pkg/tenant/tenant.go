@@ -0,0 +1,55 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// DefaultID is the tenant that owns the data created before tenancy
+const DefaultID = "default"
+
+// ErrMissing is returned by queries on tenant-owned models whose context
+// carries no tenant
+var ErrMissing = errors.New("no tenant in context")
+
+// idPattern matches valid tenant IDs, which are also used as subdomains
+var idPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,62})$`)
+
+// ValidID reports whether id can be used as a tenant ID: up to 63 lower
+// case letters, digits and dashes, not starting with a dash
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// scope is the tenant stored in a context
+type scope struct {
+	id  string
+	all bool
+}
+
+type scopeKey struct{}
+
+// WithID returns a copy of ctx scoped to the tenant id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope{id: id})
+}
+
+// WithAllTenants returns a copy of ctx whose queries are not scoped to a
+// tenant. It is meant for background work spanning tenants, such as the
+// outbox relay and migrations, never for requests.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope{all: true})
+}
+
+// FromContext returns the tenant ctx is scoped to, if any
+func FromContext(ctx context.Context) (string, bool) {
+	s, _ := ctx.Value(scopeKey{}).(scope)
+	return s.id, s.id != ""
+}
+
+// AllTenants reports whether ctx was created by WithAllTenants
+func AllTenants(ctx context.Context) bool {
+	s, _ := ctx.Value(scopeKey{}).(scope)
+	return s.all
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey is the statement setting holding the query span
+const spanKey = "tracing:span"
+
+// GORMPlugin creates a client span for every GORM operation, as a child of
+// the span in the statement's context
+type GORMPlugin struct{}
+
+// NewGORMPlugin creates a new GORMPlugin
+func NewGORMPlugin() *GORMPlugin {
+	return &GORMPlugin{}
+}
+
+// Name implements gorm.Plugin
+func (p *GORMPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin by registering before/after callbacks
+func (p *GORMPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, r := range register {
+		if err := r.before("tracing:before_"+r.operation, p.before(r.operation)); err != nil {
+			return err
+		}
+		if err := r.after("tracing:after_"+r.operation, p.after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// before starts the query span
+func (p *GORMPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			// Only trace queries that belong to a traced operation
+			return
+		}
+
+		_, span := Tracer().Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// after records the statement and any error, and ends the span
+func (p *GORMPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
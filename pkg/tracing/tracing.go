@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer used throughout the application
+const instrumentationName = "github.com/dtg-lucifer/go-bookstore"
+
+// Exporters supported by Setup
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// Setup installs the global tracer provider and W3C trace context propagator.
+// exporter selects where spans go: "otlp" (configured through the standard
+// OTEL_EXPORTER_OTLP_* variables), "stdout" or "none". The returned function
+// flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, exporter string, serviceName string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterNone, "":
+		spanExporter = nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporter, err)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if spanExporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := NewProvider(serviceName, sdktrace.WithBatcher(spanExporter))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// NewProvider creates a tracer provider for serviceName with the given
+// options, e.g. sdktrace.WithSyncer(tracetest.NewInMemoryExporter()) in tests
+func NewProvider(serviceName string, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(semconv.ServiceName(serviceName))
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...)
+}
+
+// Tracer returns the application tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
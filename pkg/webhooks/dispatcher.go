@@ -0,0 +1,324 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+)
+
+// maxResponseBody is how much of a receiver's response is kept in the delivery log
+const maxResponseBody = 1024
+
+// disabledError is the error of deliveries to a disabled subscription
+const disabledError = "subscription is disabled"
+
+// Config controls delivery retries and auto-disabling
+type Config struct {
+	// MaxAttempts is the number of attempts before a delivery is marked failed
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry; it doubles on every retry
+	BaseBackoff time.Duration
+	// MaxBackoff caps the retry delay
+	MaxBackoff time.Duration
+	// DisableAfter disables a subscription after this many consecutive failed attempts
+	DisableAfter int
+	// PollInterval is how often due deliveries are looked up
+	PollInterval time.Duration
+	// Timeout bounds a single delivery request
+	Timeout time.Duration
+	// Concurrency is how many subscriptions are delivered to at once. Each
+	// subscription gets its deliveries one at a time.
+	Concurrency int
+	// AllowPrivateNetworks lets deliveries reach loopback, private and
+	// link-local addresses, e.g. for local development
+	AllowPrivateNetworks bool
+}
+
+// DefaultConfig returns the default delivery configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:  8,
+		BaseBackoff:  10 * time.Second,
+		MaxBackoff:   time.Hour,
+		DisableAfter: 20,
+		PollInterval: time.Second,
+		Timeout:      10 * time.Second,
+		Concurrency:  16,
+	}
+}
+
+// Dispatcher fans domain events out to webhook subscriptions.
+// It is an events.Sink that queues one delivery per matching subscription,
+// and a worker that sends due deliveries with retries.
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	config Config
+	client *http.Client
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(repo repository.WebhookRepository, config Config, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		config: config,
+		client: newClient(config.Timeout, config.AllowPrivateNetworks),
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// Publish queues a delivery of event for every active subscription of its
+// tenant that wants it
+func (d *Dispatcher) Publish(ctx context.Context, event events.Event) error {
+	ctx = tenant.WithID(ctx, event.TenantID)
+	subs, err := d.repo.ListActiveSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	now := d.now().UTC()
+	for _, sub := range subs {
+		if !sub.Matches(string(event.Type)) {
+			continue
+		}
+
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      string(event.Type),
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		}
+		if err := d.repo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run sends due deliveries until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Flush(ctx); err != nil {
+			d.logger.Error("Failed to dispatch webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush attempts due deliveries once and returns how many succeeded.
+// Subscriptions are delivered to concurrently, up to Concurrency at once,
+// and each one gets its deliveries in order, one at a time. A failed
+// attempt holds the subscription's other due deliveries back until its
+// retry, so that a receiver that is down costs a flush one Timeout at most.
+func (d *Dispatcher) Flush(ctx context.Context) (int, error) {
+	due, err := d.repo.ListDueDeliveries(ctx, d.now().UTC(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	var order []string
+	bySubscription := map[string][]models.WebhookDelivery{}
+	for _, delivery := range due {
+		if _, ok := bySubscription[delivery.SubscriptionID]; !ok {
+			order = append(order, delivery.SubscriptionID)
+		}
+		bySubscription[delivery.SubscriptionID] = append(bySubscription[delivery.SubscriptionID], delivery)
+	}
+
+	var (
+		succeeded atomic.Int64
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		errs      []error
+	)
+	slots := make(chan struct{}, max(d.config.Concurrency, 1))
+	for _, id := range order {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(deliveries []models.WebhookDelivery) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			n, err := d.deliver(ctx, deliveries)
+			succeeded.Add(int64(n))
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(bySubscription[id])
+	}
+	wg.Wait()
+
+	return int(succeeded.Load()), errors.Join(errs...)
+}
+
+// deliver attempts the due deliveries of a subscription in order and
+// returns how many succeeded. Once one fails, the others wait for its retry.
+func (d *Dispatcher) deliver(ctx context.Context, deliveries []models.WebhookDelivery) (int, error) {
+	succeeded := 0
+	for i := range deliveries {
+		ok, err := d.attempt(ctx, &deliveries[i])
+		if err != nil {
+			return succeeded, err
+		}
+		if ok {
+			succeeded++
+			continue
+		}
+		if deliveries[i].LastError == disabledError {
+			continue
+		}
+
+		retry := d.now().UTC().Add(d.backoff(1))
+		if next := deliveries[i].NextAttemptAt; next != nil && next.After(retry) {
+			retry = *next
+		}
+		for _, held := range deliveries[i+1:] {
+			held.NextAttemptAt = &retry
+			if err := d.repo.UpdateDelivery(ctx, &held); err != nil {
+				return succeeded, err
+			}
+		}
+		return succeeded, nil
+	}
+	return succeeded, nil
+}
+
+// attempt sends a single delivery and records the outcome
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	sub, err := d.repo.GetSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	now := d.now().UTC()
+	delivery.Attempts++
+
+	if !sub.Active {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = disabledError
+		delivery.NextAttemptAt = nil
+		return false, d.repo.UpdateDelivery(ctx, delivery)
+	}
+
+	status, body, sendErr := d.send(ctx, sub, delivery)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+
+	if sendErr == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+
+		if sub.ConsecutiveFailures > 0 {
+			sub.ConsecutiveFailures = 0
+			if err := d.repo.UpdateSubscription(ctx, sub); err != nil {
+				return false, err
+			}
+		}
+		return true, d.repo.UpdateDelivery(ctx, delivery)
+	}
+
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	} else {
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	sub.ConsecutiveFailures++
+	if d.config.DisableAfter > 0 && sub.ConsecutiveFailures >= d.config.DisableAfter {
+		sub.Active = false
+		sub.DisabledAt = &now
+		sub.DisabledReason = fmt.Sprintf("disabled after %d consecutive failed deliveries", sub.ConsecutiveFailures)
+		d.logger.WarnContext(ctx, "Disabling failing webhook", "webhook_id", sub.ID, "url", sub.URL)
+	}
+	if err := d.repo.UpdateSubscription(ctx, sub); err != nil {
+		return false, err
+	}
+
+	return false, d.repo.UpdateDelivery(ctx, delivery)
+}
+
+// send POSTs the signed delivery payload to the subscription URL
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-bookstore-webhooks/1")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, string(respBody), nil
+}
+
+// backoff returns the delay before retry number attempt, with full jitter
+// over the upper half of the exponential window
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.BaseBackoff
+	for i := 1; i < attempt && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
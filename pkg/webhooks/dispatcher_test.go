@@ -0,0 +1,435 @@
+package webhooks_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/events"
+	"github.com/dtg-lucifer/go-bookstore/pkg/logging"
+	"github.com/dtg-lucifer/go-bookstore/pkg/models"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/impl"
+	"github.com/dtg-lucifer/go-bookstore/pkg/repository/repotest"
+	"github.com/dtg-lucifer/go-bookstore/pkg/service"
+	"github.com/dtg-lucifer/go-bookstore/pkg/tenant"
+	"github.com/dtg-lucifer/go-bookstore/pkg/webhooks"
+	"github.com/google/uuid"
+)
+
+// receiver is a webhook endpoint answering with its status, after its delay
+type receiver struct {
+	*httptest.Server
+	status   atomic.Int32
+	delay    time.Duration
+	requests atomic.Int32
+	// inFlight and maxInFlight count concurrent requests
+	inFlight, maxInFlight atomic.Int32
+
+	mu       sync.Mutex
+	received []*http.Request
+	bodies   [][]byte
+}
+
+// newReceiver starts a receiver answering with status after delay
+func newReceiver(t *testing.T, status int, delay time.Duration) *receiver {
+	t.Helper()
+	r := &receiver{delay: delay}
+	r.status.Store(int32(status))
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.requests.Add(1)
+		if n := r.inFlight.Add(1); n > r.maxInFlight.Load() {
+			r.maxInFlight.Store(n)
+		}
+		defer r.inFlight.Add(-1)
+
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.received = append(r.received, req)
+		r.bodies = append(r.bodies, body)
+		r.mu.Unlock()
+
+		select {
+		case <-time.After(r.delay):
+		case <-req.Context().Done():
+			return
+		}
+		w.WriteHeader(int(r.status.Load()))
+		w.Write([]byte("received"))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// testConfig returns a configuration delivering to local receivers
+func testConfig() webhooks.Config {
+	config := webhooks.DefaultConfig()
+	config.AllowPrivateNetworks = true
+	config.Timeout = time.Second
+	return config
+}
+
+// subscribe creates an active subscription of a tenant to every event
+func subscribe(t *testing.T, repo repository.WebhookRepository, tenantID, url string) *models.WebhookSubscription {
+	t.Helper()
+	sub := &models.WebhookSubscription{URL: url, Secret: "whsec_" + tenantID, Active: true}
+	if err := repo.CreateSubscription(tenant.WithID(t.Context(), tenantID), sub); err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
+
+// publish publishes a book.created event of a tenant to the dispatcher
+func publish(t *testing.T, dispatcher *webhooks.Dispatcher, tenantID string) events.Event {
+	t.Helper()
+	event := events.Event{
+		ID:          uuid.NewString(),
+		Type:        events.BookCreated,
+		AggregateID: uuid.NewString(),
+		OccurredAt:  time.Now().UTC(),
+		Payload:     []byte(`{"book":{"name":"Dune"}}`),
+		TenantID:    tenantID,
+	}
+	if err := dispatcher.Publish(t.Context(), event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// deliveries returns the deliveries of a subscription, newest first
+func deliveries(t *testing.T, repo repository.WebhookRepository, sub *models.WebhookSubscription) []models.WebhookDelivery {
+	t.Helper()
+	list, err := repo.ListDeliveries(tenant.WithAllTenants(t.Context()), sub.ID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+// makeDue makes the pending deliveries of a subscription due now, with
+// attempts made already
+func makeDue(t *testing.T, repo repository.WebhookRepository, sub *models.WebhookSubscription, attempts int) {
+	t.Helper()
+	now := time.Now().UTC().Add(-time.Second)
+	for _, delivery := range deliveries(t, repo, sub) {
+		if delivery.Status != models.WebhookDeliveryPending {
+			continue
+		}
+		delivery.NextAttemptAt = &now
+		delivery.Attempts = attempts
+		if err := repo.UpdateDelivery(tenant.WithAllTenants(t.Context()), &delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// flush flushes the dispatcher for every tenant, like the server
+func flush(t *testing.T, dispatcher *webhooks.Dispatcher) int {
+	t.Helper()
+	succeeded, err := dispatcher.Flush(tenant.WithAllTenants(t.Context()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return succeeded
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	dispatcher := webhooks.NewDispatcher(repo, testConfig(), logging.Discard())
+	target := newReceiver(t, http.StatusNoContent, 0)
+	sub := subscribe(t, repo, "north", target.URL)
+
+	// Only the subscriptions of the event's tenant get it
+	other := newReceiver(t, http.StatusNoContent, 0)
+	subscribe(t, repo, "south", other.URL)
+
+	event := publish(t, dispatcher, "north")
+	if got := flush(t, dispatcher); got != 1 {
+		t.Fatalf("Flush() = %d, want 1 delivery", got)
+	}
+	if other.requests.Load() != 0 {
+		t.Errorf("another tenant's subscription got %d requests", other.requests.Load())
+	}
+
+	target.mu.Lock()
+	req, body := target.received[0], target.bodies[0]
+	target.mu.Unlock()
+	if err := webhooks.Verify(sub.Secret, req.Header.Get(webhooks.SignatureHeader), body, time.Minute); err != nil {
+		t.Errorf("delivery signature: %v", err)
+	}
+	if req.Header.Get(webhooks.EventHeader) != string(events.BookCreated) || !strings.Contains(string(body), event.ID) {
+		t.Errorf("delivery of %s = %s %s, want the event", event.ID, req.Header.Get(webhooks.EventHeader), body)
+	}
+
+	delivered := deliveries(t, repo, sub)[0]
+	if delivered.Status != models.WebhookDeliverySucceeded || delivered.Attempts != 1 || delivered.ResponseStatus != http.StatusNoContent || delivered.DeliveredAt == nil {
+		t.Errorf("delivery = %+v, want succeeded on the first attempt", delivered)
+	}
+	if req.Header.Get(webhooks.DeliveryHeader) != delivered.ID {
+		t.Errorf("%s = %q, want the delivery ID %s", webhooks.DeliveryHeader, req.Header.Get(webhooks.DeliveryHeader), delivered.ID)
+	}
+
+	// Delivered events are not sent again
+	if got := flush(t, dispatcher); got != 0 || target.requests.Load() != 1 {
+		t.Errorf("second Flush() = %d with %d requests, want nothing sent", got, target.requests.Load())
+	}
+}
+
+func TestDispatcherBacksOff(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	config := testConfig()
+	config.BaseBackoff = time.Hour
+	config.MaxBackoff = 4 * time.Hour
+	config.MaxAttempts = 10
+	dispatcher := webhooks.NewDispatcher(repo, config, logging.Discard())
+
+	target := newReceiver(t, http.StatusInternalServerError, 0)
+	var subs []*models.WebhookSubscription
+	for range 8 {
+		sub := subscribe(t, repo, "north", target.URL)
+		subs = append(subs, sub)
+	}
+	publish(t, dispatcher, "north")
+
+	// within checks that the next attempt of every delivery is in
+	// [low, high) from start, and returns the distinct delays
+	within := func(start time.Time, low, high time.Duration) map[time.Duration]bool {
+		t.Helper()
+		delays := map[time.Duration]bool{}
+		for _, sub := range subs {
+			delivery := deliveries(t, repo, sub)[0]
+			if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt == nil {
+				t.Fatalf("delivery = %+v, want a pending retry", delivery)
+			}
+			delay := delivery.NextAttemptAt.Sub(start)
+			if delay < low-time.Second || delay >= high+time.Second {
+				t.Errorf("retry after attempt %d in %s, want within [%s, %s)", delivery.Attempts, delay, low, high)
+			}
+			delays[delay] = true
+		}
+		return delays
+	}
+
+	start := time.Now()
+	flush(t, dispatcher)
+	if delays := within(start, 30*time.Minute, time.Hour); len(delays) < 2 {
+		t.Errorf("retries of %d deliveries are all in %v, want jitter", len(subs), delays)
+	}
+
+	// The delay doubles on every attempt, up to MaxBackoff
+	for _, sub := range subs {
+		makeDue(t, repo, sub, 2)
+	}
+	start = time.Now()
+	flush(t, dispatcher)
+	within(start, 2*time.Hour, 4*time.Hour)
+
+	for _, sub := range subs {
+		makeDue(t, repo, sub, 6)
+	}
+	start = time.Now()
+	flush(t, dispatcher)
+	within(start, 2*time.Hour, 4*time.Hour)
+
+	// The last attempt fails the delivery
+	for _, sub := range subs {
+		makeDue(t, repo, sub, config.MaxAttempts-1)
+	}
+	flush(t, dispatcher)
+	for _, sub := range subs {
+		if delivery := deliveries(t, repo, sub)[0]; delivery.Status != models.WebhookDeliveryFailed || delivery.NextAttemptAt != nil {
+			t.Errorf("delivery after %d attempts = %+v, want failed", delivery.Attempts, delivery)
+		}
+	}
+}
+
+func TestDispatcherDisablesFailingSubscriptions(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	config := testConfig()
+	config.DisableAfter = 3
+	dispatcher := webhooks.NewDispatcher(repo, config, logging.Discard())
+
+	target := newReceiver(t, http.StatusBadGateway, 0)
+	sub := subscribe(t, repo, "north", target.URL)
+	for range config.DisableAfter {
+		publish(t, dispatcher, "north")
+	}
+
+	for range config.DisableAfter {
+		makeDue(t, repo, sub, 0)
+		flush(t, dispatcher)
+	}
+	disabled, err := repo.GetSubscriptionByID(tenant.WithID(t.Context(), "north"), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled.Active || disabled.DisabledAt == nil || disabled.DisabledReason == "" || disabled.ConsecutiveFailures != config.DisableAfter {
+		t.Fatalf("subscription after %d failures = %+v, want disabled", config.DisableAfter, disabled)
+	}
+
+	// Deliveries of a disabled subscription fail without being sent
+	requests := target.requests.Load()
+	makeDue(t, repo, sub, 0)
+	flush(t, dispatcher)
+	if target.requests.Load() != requests {
+		t.Errorf("disabled subscription got %d more requests", target.requests.Load()-requests)
+	}
+	for _, delivery := range deliveries(t, repo, sub) {
+		if delivery.Status != models.WebhookDeliveryFailed {
+			t.Errorf("delivery of a disabled subscription = %+v, want failed", delivery)
+		}
+	}
+}
+
+func TestDispatcherResetsFailuresOnSuccess(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	config := testConfig()
+	config.DisableAfter = 2
+	dispatcher := webhooks.NewDispatcher(repo, config, logging.Discard())
+
+	target := newReceiver(t, http.StatusInternalServerError, 0)
+	sub := subscribe(t, repo, "north", target.URL)
+	publish(t, dispatcher, "north")
+	flush(t, dispatcher)
+
+	target.status.Store(http.StatusOK)
+	makeDue(t, repo, sub, 1)
+	flush(t, dispatcher)
+
+	current, err := repo.GetSubscriptionByID(tenant.WithID(t.Context(), "north"), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !current.Active || current.ConsecutiveFailures != 0 {
+		t.Errorf("subscription after a success = %+v, want active without failures", current)
+	}
+}
+
+func TestDispatcherReplay(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	config := testConfig()
+	config.MaxAttempts = 1
+	dispatcher := webhooks.NewDispatcher(repo, config, logging.Discard())
+	webhookService := service.NewWebhookService(repo, logging.Discard())
+
+	target := newReceiver(t, http.StatusServiceUnavailable, 0)
+	sub := subscribe(t, repo, "north", target.URL)
+	publish(t, dispatcher, "north")
+	flush(t, dispatcher)
+	failed := deliveries(t, repo, sub)[0]
+	if failed.Status != models.WebhookDeliveryFailed {
+		t.Fatalf("delivery = %+v, want failed", failed)
+	}
+
+	// Another tenant cannot replay it
+	if _, err := webhookService.ReplayDelivery(tenant.WithID(t.Context(), "south"), sub.ID, failed.ID); err == nil {
+		t.Error("ReplayDelivery() from another tenant succeeded")
+	}
+
+	target.status.Store(http.StatusOK)
+	replayed, err := webhookService.ReplayDelivery(tenant.WithID(t.Context(), "north"), sub.ID, failed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Status != models.WebhookDeliveryPending || replayed.Attempts != 0 {
+		t.Fatalf("replayed delivery = %+v, want pending", replayed)
+	}
+
+	if got := flush(t, dispatcher); got != 1 {
+		t.Fatalf("Flush() after the replay = %d, want 1", got)
+	}
+	if delivery := deliveries(t, repo, sub)[0]; delivery.Status != models.WebhookDeliverySucceeded || delivery.EventID != failed.EventID {
+		t.Errorf("replayed delivery = %+v, want the event delivered", delivery)
+	}
+	if target.requests.Load() != 2 {
+		t.Errorf("receiver got %d requests, want the failed one and the replay", target.requests.Load())
+	}
+}
+
+func TestDispatcherRejectsPrivateAddresses(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	config := testConfig()
+	config.AllowPrivateNetworks = false
+	dispatcher := webhooks.NewDispatcher(repo, config, logging.Discard())
+
+	target := newReceiver(t, http.StatusOK, 0)
+	loopback := subscribe(t, repo, "north", target.URL)
+	localhost := subscribe(t, repo, "north", strings.Replace(target.URL, "127.0.0.1", "localhost", 1))
+	publish(t, dispatcher, "north")
+
+	if got := flush(t, dispatcher); got != 0 {
+		t.Fatalf("Flush() = %d, want no delivery", got)
+	}
+	if target.requests.Load() != 0 {
+		t.Errorf("receiver on a loopback address got %d requests", target.requests.Load())
+	}
+	for _, sub := range []*models.WebhookSubscription{loopback, localhost} {
+		if delivery := deliveries(t, repo, sub)[0]; !strings.Contains(delivery.LastError, webhooks.ErrPrivateAddress.Error()) {
+			t.Errorf("delivery to %s failed with %q, want %q", sub.URL, delivery.LastError, webhooks.ErrPrivateAddress)
+		}
+	}
+}
+
+func TestDispatcherDoesNotFollowRedirects(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	dispatcher := webhooks.NewDispatcher(repo, testConfig(), logging.Discard())
+
+	target := newReceiver(t, http.StatusOK, 0)
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(redirect.Close)
+	sub := subscribe(t, repo, "north", redirect.URL)
+	publish(t, dispatcher, "north")
+
+	flush(t, dispatcher)
+	if target.requests.Load() != 0 {
+		t.Error("delivery followed a redirect")
+	}
+	if delivery := deliveries(t, repo, sub)[0]; delivery.Status != models.WebhookDeliveryPending || delivery.ResponseStatus != http.StatusTemporaryRedirect {
+		t.Errorf("redirected delivery = %+v, want a failed attempt", delivery)
+	}
+}
+
+func TestDispatcherIsolatesSlowReceivers(t *testing.T) {
+	repo := impl.NewWebhookRepository(repotest.SQLite(t))
+	config := testConfig()
+	config.Timeout = 200 * time.Millisecond
+	dispatcher := webhooks.NewDispatcher(repo, config, logging.Discard())
+
+	// A receiver that never answers in time, and one busy with each request
+	stalled := newReceiver(t, http.StatusOK, time.Minute)
+	busy := newReceiver(t, http.StatusOK, 20*time.Millisecond)
+	stalledSub := subscribe(t, repo, "north", stalled.URL)
+	busySubs := []*models.WebhookSubscription{subscribe(t, repo, "south", busy.URL), subscribe(t, repo, "south", busy.URL)}
+	for range 5 {
+		publish(t, dispatcher, "north")
+		publish(t, dispatcher, "south")
+	}
+
+	start := time.Now()
+	if got := flush(t, dispatcher); got != 10 {
+		t.Errorf("Flush() = %d, want the 10 deliveries of the busy receiver", got)
+	}
+	if elapsed := time.Since(start); elapsed > 3*config.Timeout {
+		t.Errorf("Flush() took %s with a stalled receiver, want about one timeout", elapsed)
+	}
+
+	// The stalled receiver was tried once, and its other deliveries wait
+	if stalled.requests.Load() != 1 {
+		t.Errorf("stalled receiver got %d requests, want 1", stalled.requests.Load())
+	}
+	for _, delivery := range deliveries(t, repo, stalledSub) {
+		if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.After(time.Now()) {
+			t.Errorf("delivery to the stalled receiver = %+v, want a later retry", delivery)
+		}
+	}
+
+	// Each subscription got its deliveries one at a time, and both at once
+	if max := busy.maxInFlight.Load(); max != int32(len(busySubs)) {
+		t.Errorf("busy receiver had up to %d requests in flight, want %d", max, len(busySubs))
+	}
+}
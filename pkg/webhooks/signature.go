@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every webhook request
+const (
+	SignatureHeader = "X-Bookstore-Signature"
+	EventHeader     = "X-Bookstore-Event"
+	DeliveryHeader  = "X-Bookstore-Delivery"
+)
+
+// ErrInvalidSignature is returned by Verify when a signature does not match
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// NewSecret generates a random signing secret for a subscription
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign computes the signature header value for body sent at timestamp.
+// The format is "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + computeMAC(secret, t, body)
+}
+
+// Verify checks a signature header produced by Sign. Signatures older than
+// tolerance are rejected to limit replays; a zero tolerance disables the check.
+func Verify(secret string, header string, body []byte, tolerance time.Duration) error {
+	var t, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			mac = value
+		}
+	}
+
+	if t == "" || mac == "" {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		if time.Since(time.Unix(unix, 0)) > tolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+		}
+	}
+
+	if !hmac.Equal([]byte(mac), []byte(computeMAC(secret, t, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// computeMAC returns the hex HMAC-SHA256 of "<t>.<body>"
+func computeMAC(secret string, t string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(t))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
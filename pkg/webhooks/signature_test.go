@@ -0,0 +1,98 @@
+package webhooks_test
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtg-lucifer/go-bookstore/pkg/webhooks"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"type":"book.created"}`)
+	now := time.Now()
+	header := webhooks.Sign("whsec_test", now, body)
+
+	if !strings.HasPrefix(header, "t=") || !strings.Contains(header, ",v1=") {
+		t.Fatalf("Sign() = %q, want t=<unix>,v1=<hex>", header)
+	}
+	if err := webhooks.Verify("whsec_test", header, body, time.Minute); err != nil {
+		t.Fatalf("Verify() of a fresh signature: %v", err)
+	}
+
+	old := webhooks.Sign("whsec_test", now.Add(-time.Hour), body)
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		tolerance time.Duration
+	}{
+		{"another secret", "whsec_other", header, body, time.Minute},
+		{"another body", "whsec_test", header, []byte(`{"type":"book.deleted"}`), time.Minute},
+		{"another timestamp", "whsec_test", strings.Replace(header, "t=", "t=1", 1), body, 0},
+		{"an old timestamp", "whsec_test", old, body, time.Minute},
+		{"no timestamp", "whsec_test", header[strings.Index(header, ",")+1:], body, 0},
+		{"no signature", "whsec_test", header[:strings.Index(header, ",")], body, 0},
+		{"garbage", "whsec_test", "garbage", body, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := webhooks.Verify(tt.secret, tt.header, tt.body, tt.tolerance); !errors.Is(err, webhooks.ErrInvalidSignature) {
+				t.Errorf("Verify() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	// A zero tolerance accepts signatures of any age
+	if err := webhooks.Verify("whsec_test", old, body, 0); err != nil {
+		t.Errorf("Verify() of an old signature without tolerance: %v", err)
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := webhooks.NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := webhooks.NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, "whsec_") || len(first) != len("whsec_")+64 || first == second {
+		t.Errorf("NewSecret() = %q then %q, want distinct whsec_<64 hex digits>", first, second)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"198.18.0.1", false},
+		{"64:ff9b::7f00:1", false},
+	}
+	for _, tt := range tests {
+		if got := webhooks.IsPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}